@@ -3,12 +3,107 @@ package collectors
 import (
 	"context"
 	"log"
+	"runtime"
+	"slices"
+	"time"
 
-	"sakin-go/cmd/sge-agent/communicator"
+	"sakin-go/cmd/sge-agent/collectors/host"
+	"sakin-go/cmd/sge-agent/config"
+	"sakin-go/pkg/messaging"
+	"sakin-go/pkg/models"
 )
 
-// Start starts all OS-specific collectors.
-func Start(ctx context.Context, comm *communicator.Communicator) {
+// Publisher is the part of the communicator collectors need.
+type Publisher interface {
+	Send(subject string, data []byte) error
+}
+
+// Collector describes a data source the manager can run.
+type Collector struct {
+	Name      string
+	Platforms []string // Supported runtime.GOOS values, empty means all
+	Interval  int      // Default interval (seconds)
+	Enabled   bool     // Default state when not configured
+
+	// Run blocks until ctx is done, collecting every interval.
+	Run func(ctx context.Context, pub Publisher, interval time.Duration)
+}
+
+// Start starts all configured collectors available on this platform.
+func Start(ctx context.Context, pub Publisher, cfg *config.AgentConfig) {
 	log.Println("[Collectors] Starting platform specific collectors...")
-	startPlatformCollectors(ctx, comm)
+	available := append([]*Collector{hostCollector(cfg.AgentID)}, platformCollectors()...)
+	startCollectors(ctx, pub, cfg.Collectors, available, runtime.GOOS)
+}
+
+// startCollectors applies the configured overrides and launches the enabled
+// collectors. It returns the names of the collectors that were started.
+func startCollectors(ctx context.Context, pub Publisher, settings map[string]config.CollectorConfig, available []*Collector, goos string) []string {
+	known := make(map[string]bool, len(available))
+	var started []string
+
+	for _, c := range available {
+		known[c.Name] = true
+
+		enabled, interval := c.Enabled, c.Interval
+		if s, ok := settings[c.Name]; ok {
+			enabled = s.Enabled
+			if s.Interval > 0 {
+				interval = s.Interval
+			}
+		}
+
+		if !enabled {
+			log.Printf("[Collectors] %s disabled", c.Name)
+			continue
+		}
+		if len(c.Platforms) > 0 && !slices.Contains(c.Platforms, goos) {
+			log.Printf("[Collectors] %s not supported on %s, skipping", c.Name, goos)
+			continue
+		}
+
+		log.Printf("[Collectors] Starting %s (every %ds)", c.Name, interval)
+		go c.Run(ctx, pub, time.Duration(interval)*time.Second)
+		started = append(started, c.Name)
+	}
+
+	for name := range settings {
+		if !known[name] {
+			log.Printf("[Collectors] Warning: unknown collector %q in config", name)
+		}
+	}
+
+	return started
+}
+
+// hostCollector publishes the periodic host info heartbeat.
+func hostCollector(agentID string) *Collector {
+	return &Collector{
+		Name:     "host",
+		Interval: 60,
+		Enabled:  true,
+		Run: func(ctx context.Context, pub Publisher, interval time.Duration) {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					info, err := host.CollectEnvInfo()
+					if err != nil {
+						log.Printf("[Collectors] Error collecting host info: %v", err)
+						continue
+					}
+					info.AgentID = agentID
+
+					// Topic structure: events.raw.<severity>.<source>
+					if err := pub.Send(messaging.SafeSubject(messaging.TopicEventsRaw, string(models.SeverityInfo), "agent"), info.ToJSON()); err != nil {
+						log.Printf("[Collectors] Error publishing host info: %v", err)
+					}
+				}
+			}
+		},
+	}
 }
@@ -5,13 +5,31 @@ package collectors
 import (
 	"context"
 	"log"
-
-	"sakin-go/cmd/sge-agent/communicator"
+	"time"
 )
 
-func startPlatformCollectors(ctx context.Context, comm *communicator.Communicator) {
-	log.Println("[Collectors] Linux: Starting Auditd and Syslog collectors...")
-	// TODO: Implement Auditd / Syslog runners here
-	_, _ = ctx, comm
-	// go runAuditd(ctx, comm)
+func platformCollectors() []*Collector {
+	linux := []string{"linux"}
+	return []*Collector{
+		{Name: "auditd", Platforms: linux, Interval: 10, Enabled: true, Run: runAuditd},
+		{Name: "syslog", Platforms: linux, Interval: 10, Enabled: true, Run: runSyslog},
+		// FIM is expensive on busy servers, so it is opt-in
+		{Name: "fim", Platforms: linux, Interval: 300, Enabled: false, Run: runFIM},
+	}
+}
+
+// TODO: Implement Auditd / Syslog / FIM runners here
+func runAuditd(ctx context.Context, pub Publisher, interval time.Duration) {
+	log.Println("[Collectors] Linux: Auditd collector not implemented yet")
+	<-ctx.Done()
+}
+
+func runSyslog(ctx context.Context, pub Publisher, interval time.Duration) {
+	log.Println("[Collectors] Linux: Syslog collector not implemented yet")
+	<-ctx.Done()
+}
+
+func runFIM(ctx context.Context, pub Publisher, interval time.Duration) {
+	log.Println("[Collectors] Linux: FIM collector not implemented yet")
+	<-ctx.Done()
 }
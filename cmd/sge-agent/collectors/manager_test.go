@@ -0,0 +1,91 @@
+package collectors
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-agent/config"
+)
+
+type nopPublisher struct{}
+
+func (nopPublisher) Send(string, []byte) error { return nil }
+
+// fakeCollectors returns collectors that record the interval they were started with.
+func fakeCollectors(started map[string]time.Duration, mu *sync.Mutex, wg *sync.WaitGroup) []*Collector {
+	run := func(name string) func(context.Context, Publisher, time.Duration) {
+		return func(ctx context.Context, pub Publisher, interval time.Duration) {
+			mu.Lock()
+			started[name] = interval
+			mu.Unlock()
+			wg.Done()
+		}
+	}
+
+	return []*Collector{
+		{Name: "host", Interval: 60, Enabled: true, Run: run("host")},
+		{Name: "auditd", Platforms: []string{"linux"}, Interval: 10, Enabled: true, Run: run("auditd")},
+		{Name: "fim", Platforms: []string{"linux"}, Interval: 300, Enabled: false, Run: run("fim")},
+		{Name: "etw", Platforms: []string{"windows"}, Interval: 10, Enabled: true, Run: run("etw")},
+	}
+}
+
+func TestStartCollectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]config.CollectorConfig
+		want     map[string]time.Duration
+	}{
+		{
+			name:     "Defaults",
+			settings: nil,
+			want:     map[string]time.Duration{"host": 60 * time.Second, "auditd": 10 * time.Second},
+		},
+		{
+			name: "Disable Auditd And Enable FIM With Interval",
+			settings: map[string]config.CollectorConfig{
+				"auditd": {Enabled: false},
+				"fim":    {Enabled: true, Interval: 900},
+			},
+			want: map[string]time.Duration{"host": 60 * time.Second, "fim": 900 * time.Second},
+		},
+		{
+			name: "Enabled But Wrong Platform",
+			settings: map[string]config.CollectorConfig{
+				"etw":  {Enabled: true, Interval: 5},
+				"host": {Enabled: true, Interval: 15},
+			},
+			want: map[string]time.Duration{"host": 15 * time.Second, "auditd": 10 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			started := make(map[string]time.Duration)
+			wg.Add(len(tt.want))
+
+			names := startCollectors(context.Background(), nopPublisher{}, tt.settings, fakeCollectors(started, &mu, &wg), "linux")
+			wg.Wait()
+
+			if len(names) != len(tt.want) {
+				t.Fatalf("started = %v, want %d collectors", names, len(tt.want))
+			}
+			for name, interval := range tt.want {
+				if !slices.Contains(names, name) {
+					t.Errorf("%s was not started", name)
+				}
+				mu.Lock()
+				got := started[name]
+				mu.Unlock()
+				if got != interval {
+					t.Errorf("%s interval = %v, want %v", name, got, interval)
+				}
+			}
+		})
+	}
+}
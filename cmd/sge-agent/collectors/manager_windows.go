@@ -5,12 +5,24 @@ package collectors
 import (
 	"context"
 	"log"
-
-	"sakin-go/cmd/sge-agent/communicator"
+	"time"
 )
 
-func startPlatformCollectors(ctx context.Context, comm *communicator.Communicator) {
-	log.Println("[Collectors] Windows: Starting ETW and EventLog collectors...")
-	// TODO: Implement ETW / EventLog runners here
-	// go runETW(ctx, comm)
+func platformCollectors() []*Collector {
+	windows := []string{"windows"}
+	return []*Collector{
+		{Name: "etw", Platforms: windows, Interval: 10, Enabled: true, Run: runETW},
+		{Name: "eventlog", Platforms: windows, Interval: 10, Enabled: true, Run: runEventLog},
+	}
+}
+
+// TODO: Implement ETW / EventLog runners here
+func runETW(ctx context.Context, pub Publisher, interval time.Duration) {
+	log.Println("[Collectors] Windows: ETW collector not implemented yet")
+	<-ctx.Done()
+}
+
+func runEventLog(ctx context.Context, pub Publisher, interval time.Duration) {
+	log.Println("[Collectors] Windows: EventLog collector not implemented yet")
+	<-ctx.Done()
 }
@@ -0,0 +1,133 @@
+package communicator
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"sakin-go/pkg/messaging"
+)
+
+// BatchConfig controls client-side batching of published events.
+type BatchConfig struct {
+	MaxItems int           // Flush when a subject has this many pending items
+	MaxBytes int           // Flush when pending payload bytes exceed this
+	Interval time.Duration // Flush everything at least this often
+	Compress bool          // Gzip batches before publishing
+}
+
+// Batcher accumulates payloads per subject and publishes them as a single
+// batch message (see messaging.EncodeBatch) on size or interval.
+type Batcher struct {
+	cfg     BatchConfig
+	publish func(msg *nats.Msg) error
+
+	mu      sync.Mutex
+	pending map[string][][]byte
+	sizes   map[string]int
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewBatcher creates a batcher and starts its interval flush loop.
+func NewBatcher(cfg BatchConfig, publish func(msg *nats.Msg) error) *Batcher {
+	b := &Batcher{
+		cfg:     cfg,
+		publish: publish,
+		pending: make(map[string][][]byte),
+		sizes:   make(map[string]int),
+		done:    make(chan struct{}),
+	}
+
+	if cfg.Interval > 0 {
+		b.wg.Add(1)
+		go b.flushLoop()
+	}
+	return b
+}
+
+// Add queues a payload for the subject, flushing it if a limit is reached.
+func (b *Batcher) Add(subject string, data []byte) error {
+	b.mu.Lock()
+	b.pending[subject] = append(b.pending[subject], data)
+	b.sizes[subject] += len(data)
+
+	var items [][]byte
+	if len(b.pending[subject]) >= b.cfg.MaxItems || (b.cfg.MaxBytes > 0 && b.sizes[subject] >= b.cfg.MaxBytes) {
+		items = b.take(subject)
+	}
+	b.mu.Unlock()
+
+	if items != nil {
+		return b.send(subject, items)
+	}
+	return nil
+}
+
+// Flush publishes all pending batches.
+func (b *Batcher) Flush() error {
+	b.mu.Lock()
+	batches := make(map[string][][]byte, len(b.pending))
+	for subject := range b.pending {
+		batches[subject] = b.take(subject)
+	}
+	b.mu.Unlock()
+
+	var firstErr error
+	for subject, items := range batches {
+		if err := b.send(subject, items); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close stops the flush loop and publishes whatever is still pending. It
+// is safe to call more than once.
+func (b *Batcher) Close() error {
+	b.closeOnce.Do(func() { close(b.done) })
+	b.wg.Wait()
+	return b.Flush()
+}
+
+// take removes and returns pending items for a subject. Caller holds mu.
+func (b *Batcher) take(subject string) [][]byte {
+	items := b.pending[subject]
+	delete(b.pending, subject)
+	delete(b.sizes, subject)
+	return items
+}
+
+func (b *Batcher) send(subject string, items [][]byte) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	h, data, err := messaging.EncodeBatch(items, b.cfg.Compress)
+	if err != nil {
+		return err
+	}
+
+	return b.publish(&nats.Msg{Subject: subject, Header: h, Data: data})
+}
+
+func (b *Batcher) flushLoop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			if err := b.Flush(); err != nil {
+				log.Printf("[Communicator] Batch flush failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package communicator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"sakin-go/pkg/messaging"
+)
+
+// recordingPublisher captures messages instead of sending them to NATS.
+type recordingPublisher struct {
+	mu   sync.Mutex
+	msgs []*nats.Msg
+}
+
+func (r *recordingPublisher) publish(msg *nats.Msg) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func (r *recordingPublisher) messages() []*nats.Msg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*nats.Msg(nil), r.msgs...)
+}
+
+func hostPayload(i int) []byte {
+	return []byte(fmt.Sprintf(`{"agent_id":"agent-test","hostname":"host-%d"}`, i))
+}
+
+func TestBatcher_BatchesIntoFewerPublishes(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{name: "Plain", compress: false},
+		{name: "Gzip", compress: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingPublisher{}
+			b := NewBatcher(BatchConfig{MaxItems: 10, Compress: tt.compress}, rec.publish)
+
+			for i := 0; i < 25; i++ {
+				if err := b.Add("events.raw.info.agent", hostPayload(i)); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+			if err := b.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			msgs := rec.messages()
+			if len(msgs) != 3 {
+				t.Fatalf("publishes = %d, want 3", len(msgs))
+			}
+
+			// Server side: decode every batch and check nothing was lost or reordered
+			var got []string
+			for _, msg := range msgs {
+				if tt.compress && msg.Header.Get(messaging.HeaderContentEncoding) != messaging.EncodingGzip {
+					t.Errorf("Content-Encoding = %q, want gzip", msg.Header.Get(messaging.HeaderContentEncoding))
+				}
+
+				items, err := messaging.DecodeBatch(msg.Header, msg.Data)
+				if err != nil {
+					t.Fatalf("DecodeBatch() error = %v", err)
+				}
+				for _, item := range items {
+					var info struct {
+						Hostname string `json:"hostname"`
+					}
+					if err := json.Unmarshal(item, &info); err != nil {
+						t.Fatalf("item unmarshal error = %v", err)
+					}
+					got = append(got, info.Hostname)
+				}
+			}
+
+			if len(got) != 25 {
+				t.Fatalf("decoded items = %d, want 25", len(got))
+			}
+			for i, h := range got {
+				if want := fmt.Sprintf("host-%d", i); h != want {
+					t.Errorf("item %d hostname = %s, want %s", i, h, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBatcher_FlushOnBytes(t *testing.T) {
+	rec := &recordingPublisher{}
+	payload := hostPayload(0)
+	b := NewBatcher(BatchConfig{MaxItems: 100, MaxBytes: len(payload) * 2}, rec.publish)
+	defer b.Close()
+
+	b.Add("events.raw.info.agent", payload)
+	if n := len(rec.messages()); n != 0 {
+		t.Fatalf("publishes after first item = %d, want 0", n)
+	}
+	b.Add("events.raw.info.agent", payload)
+	if n := len(rec.messages()); n != 1 {
+		t.Fatalf("publishes after byte limit = %d, want 1", n)
+	}
+}
+
+func TestBatcher_FlushOnInterval(t *testing.T) {
+	rec := &recordingPublisher{}
+	b := NewBatcher(BatchConfig{MaxItems: 100, Interval: 20 * time.Millisecond}, rec.publish)
+	defer b.Close()
+
+	b.Add("events.raw.info.agent", hostPayload(1))
+	b.Add("events.raw.info.agent", hostPayload(2))
+
+	deadline := time.Now().Add(time.Second)
+	for len(rec.messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	msgs := rec.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("publishes = %d, want 1", len(msgs))
+	}
+	items, err := messaging.DecodeBatch(msgs[0].Header, msgs[0].Data)
+	if err != nil {
+		t.Fatalf("DecodeBatch() error = %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("items = %d, want 2", len(items))
+	}
+}
+
+func TestBatcher_CloseTwice(t *testing.T) {
+	rec := &recordingPublisher{}
+	b := NewBatcher(BatchConfig{MaxItems: 100, Interval: time.Hour}, rec.publish)
+
+	b.Add("events.raw.info.agent", hostPayload(1))
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+	if n := len(rec.messages()); n != 1 {
+		t.Errorf("publishes = %d, want the pending batch once", n)
+	}
+}
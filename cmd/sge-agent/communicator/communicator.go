@@ -2,6 +2,7 @@ package communicator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -11,11 +12,14 @@ import (
 	"github.com/nats-io/nats.go"
 
 	"sakin-go/cmd/sge-agent/config"
+	"sakin-go/pkg/messaging"
+	"sakin-go/pkg/models"
 )
 
 type Communicator struct {
-	config *config.AgentConfig
-	nc     *nats.Conn
+	config  *config.AgentConfig
+	nc      *nats.Conn
+	batcher *Batcher
 }
 
 func NewCommunicator(cfg *config.AgentConfig) (*Communicator, error) {
@@ -58,24 +62,60 @@ func NewCommunicator(cfg *config.AgentConfig) (*Communicator, error) {
 		return nil, fmt.Errorf("nats connect failed: %w", err)
 	}
 
-	return &Communicator{
+	c := &Communicator{
 		config: cfg,
 		nc:     nc,
-	}, nil
+	}
+
+	// 3. Client-side batching (disabled when batch size <= 1)
+	if cfg.BatchSize > 1 {
+		c.batcher = NewBatcher(BatchConfig{
+			MaxItems: cfg.BatchSize,
+			MaxBytes: cfg.BatchMaxBytes,
+			Interval: time.Duration(cfg.BatchInterval) * time.Second,
+			Compress: cfg.Compress,
+		}, nc.PublishMsg)
+	}
+
+	return c, nil
 }
 
 func (c *Communicator) Close() {
+	if c.batcher != nil {
+		if err := c.batcher.Close(); err != nil {
+			log.Printf("[Communicator] Final batch flush failed: %v", err)
+		}
+	}
 	if c.nc != nil {
 		c.nc.Close()
 	}
 }
 
+// Send queues an event for batched publishing.
+// Falls back to a direct Publish when batching is disabled.
+func (c *Communicator) Send(subject string, data []byte) error {
+	if c.batcher == nil {
+		return c.Publish(subject, data)
+	}
+	return c.batcher.Add(subject, data)
+}
+
 func (c *Communicator) Publish(subject string, data []byte) error {
 	return c.nc.Publish(subject, data)
 }
 
+// PublishResult reports a command outcome to SOAR on results.<agent_id>.
+// Results bypass the batcher so SOAR sees them immediately.
+func (c *Communicator) PublishResult(res *models.CommandResult) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return c.Publish(messaging.SafeSubject(messaging.TopicCommandResults, c.config.AgentID), data)
+}
+
 func (c *Communicator) SubscribeCommands(ctx context.Context, handler func(cmd []byte)) error {
-	topic := "commands." + c.config.AgentID
+	topic := messaging.SafeSubject(messaging.TopicCommands, c.config.AgentID)
 	_, err := c.nc.Subscribe(topic, func(msg *nats.Msg) {
 		handler(msg.Data)
 	})
@@ -2,9 +2,19 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
+// CollectorConfig overrides the defaults of a single collector.
+type CollectorConfig struct {
+	Enabled  bool
+	Interval int // Seconds, 0 keeps the collector default
+}
+
 type AgentConfig struct {
 	AgentID   string
 	ServerURL string // NATS URL (tls://...)
@@ -15,6 +25,24 @@ type AgentConfig struct {
 	// Collection intervals
 	HostInfoInterval int
 	AuditInterval    int
+
+	// Per-collector enable flags and intervals, keyed by collector name.
+	// Collectors not listed run with their own defaults.
+	Collectors map[string]CollectorConfig
+
+	// Publishing
+	BatchSize     int  // Events per batch message (<= 1 disables batching)
+	BatchMaxBytes int  // Flush a batch early once it reaches this many bytes
+	BatchInterval int  // Max seconds an event waits in a batch
+	Compress      bool // Gzip batches before publishing
+
+	// Self-update
+	UpdateURL       string // Release manifest URL (empty disables update checks)
+	UpdatePublicKey string // Hex encoded ed25519 release signing key
+
+	// Seconds an upgraded binary must run before the upgrade is confirmed;
+	// restarting earlier rolls back to the previous binary.
+	UpgradeConfirmDelay int
 }
 
 func LoadConfig() *AgentConfig {
@@ -26,9 +54,28 @@ func LoadConfig() *AgentConfig {
 	flag.StringVar(&cfg.KeyFile, "key", getEnv("SGE_KEY_FILE", "./certs/client.key"), "Client Key")
 	flag.StringVar(&cfg.CAFile, "ca", getEnv("SGE_CA_FILE", "./certs/ca.crt"), "CA Certificate")
 	flag.IntVar(&cfg.HostInfoInterval, "host-interval", 60, "Host info collection interval (seconds)")
+	collectorSpec := flag.String("collectors", getEnv("SGE_COLLECTORS", ""), "Collector overrides, e.g. \"auditd=30,fim=off,syslog=on\"")
+	flag.IntVar(&cfg.BatchSize, "batch-size", getEnvInt("SGE_BATCH_SIZE", 100), "Max events per published batch (1 disables batching)")
+	flag.IntVar(&cfg.BatchMaxBytes, "batch-bytes", getEnvInt("SGE_BATCH_BYTES", 512*1024), "Max batch payload size in bytes")
+	flag.IntVar(&cfg.BatchInterval, "batch-interval", getEnvInt("SGE_BATCH_INTERVAL", 5), "Batch flush interval (seconds)")
+	flag.StringVar(&cfg.UpdateURL, "update-url", getEnv("SGE_UPDATE_URL", ""), "Release manifest URL for self-update")
+	flag.StringVar(&cfg.UpdatePublicKey, "update-pubkey", getEnv("SGE_UPDATE_PUBKEY", ""), "Hex encoded ed25519 release signing key")
+	flag.IntVar(&cfg.UpgradeConfirmDelay, "upgrade-confirm", getEnvInt("SGE_UPGRADE_CONFIRM", 60), "Healthy run time before an upgrade is confirmed (seconds)")
+	flag.BoolVar(&cfg.Compress, "compress", getEnv("SGE_COMPRESS", "false") == "true", "Gzip batches before publishing")
 
 	flag.Parse()
 
+	collectors, err := ParseCollectorSpec(*collectorSpec)
+	if err != nil {
+		log.Fatalf("[Config] Invalid collectors setting: %v", err)
+	}
+	cfg.Collectors = collectors
+
+	// -host-interval remains the default for the host collector
+	if _, ok := cfg.Collectors["host"]; !ok {
+		cfg.Collectors["host"] = CollectorConfig{Enabled: true, Interval: cfg.HostInfoInterval}
+	}
+
 	// Auto-generate ID if needed (could rely on machine-id)
 	if cfg.AgentID == "agent-unknown" {
 		hostname, _ := os.Hostname()
@@ -38,9 +85,54 @@ func LoadConfig() *AgentConfig {
 	return cfg
 }
 
+// ParseCollectorSpec parses a comma separated list of collector overrides.
+// Each entry is "name" or "name=on" (enable), "name=off" (disable) or
+// "name=<seconds>" (enable with interval).
+func ParseCollectorSpec(spec string) (map[string]CollectorConfig, error) {
+	collectors := make(map[string]CollectorConfig)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if name == "" {
+			return nil, fmt.Errorf("empty collector name in %q", entry)
+		}
+
+		switch value {
+		case "", "on", "true":
+			collectors[name] = CollectorConfig{Enabled: true}
+		case "off", "false":
+			collectors[name] = CollectorConfig{Enabled: false}
+		default:
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval <= 0 {
+				return nil, fmt.Errorf("invalid interval %q for collector %s", value, name)
+			}
+			collectors[name] = CollectorConfig{Enabled: true, Interval: interval}
+		}
+	}
+
+	return collectors, nil
+}
+
 func getEnv(key, fallback string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		return val
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		if i, err := strconv.Atoi(val); err == nil {
+			return i
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCollectorSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]CollectorConfig
+		wantErr bool
+	}{
+		{
+			name: "Empty",
+			spec: "",
+			want: map[string]CollectorConfig{},
+		},
+		{
+			name: "Mixed Entries",
+			spec: "auditd=30, FIM=off,syslog,host=on",
+			want: map[string]CollectorConfig{
+				"auditd": {Enabled: true, Interval: 30},
+				"fim":    {Enabled: false},
+				"syslog": {Enabled: true},
+				"host":   {Enabled: true},
+			},
+		},
+		{
+			name:    "Invalid Interval",
+			spec:    "auditd=fast",
+			wantErr: true,
+		},
+		{
+			name:    "Zero Interval",
+			spec:    "auditd=0",
+			wantErr: true,
+		},
+		{
+			name:    "Missing Name",
+			spec:    "=30",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCollectorSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCollectorSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCollectorSpec() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
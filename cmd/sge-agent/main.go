@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/hex"
 	"log"
 	"os"
 	"os/signal"
@@ -9,28 +11,48 @@ import (
 	"time"
 
 	"sakin-go/cmd/sge-agent/collectors"
-	"sakin-go/cmd/sge-agent/collectors/host"
 	"sakin-go/cmd/sge-agent/communicator"
 	"sakin-go/cmd/sge-agent/config"
+	"sakin-go/cmd/sge-agent/responder"
 	"sakin-go/cmd/sge-agent/updater"
 )
 
+// version is the running agent version, overridden at build time:
+// go build -ldflags "-X main.version=1.2.3"
+var version = "0.1.0"
+
 func main() {
 	// 1. Config
 	cfg := config.LoadConfig()
 	log.Printf("[Agent] Starting SGE Agent (%s)...", cfg.AgentID)
 
-	// 1.5 Auto-Update Check
-	// In production, get URLs from config
+	// 1.5 Upgrade Rollback & Auto-Update Check
+	pubKey, err := hex.DecodeString(cfg.UpdatePublicKey)
+	if err != nil {
+		log.Printf("[Agent] Invalid update public key: %v", err)
+	}
 	upd := &updater.Updater{
-		CurrentVersion: "0.1.0",
-		UpdateURL:      "https://update.sge.io/latest", // Mock
-		BinaryURL:      "",
+		CurrentVersion: version,
+		UpdateURL:      cfg.UpdateURL,
+		PublicKey:      ed25519.PublicKey(pubKey),
+	}
+
+	if rolledBack, err := upd.VerifyStartup(); err != nil {
+		log.Printf("[Agent] Upgrade verification failed: %v", err)
+	} else if rolledBack {
+		// Exit so the supervisor restarts the restored binary
+		log.Println("[Agent] Previous version restored, restarting...")
+		os.Exit(1)
 	}
-	if v, ok, _ := upd.CheckUpdate(); ok {
-		log.Printf("[Agent] New version %s found!", v)
-		if err := upd.PerformUpdate(); err != nil {
-			log.Printf("[Agent] Update failed: %v", err)
+
+	if cfg.UpdateURL != "" {
+		if v, ok, err := upd.CheckUpdate(); err != nil {
+			log.Printf("[Agent] Update check failed: %v", err)
+		} else if ok {
+			log.Printf("[Agent] New version %s found!", v)
+			if err := upd.PerformUpdate(); err != nil {
+				log.Printf("[Agent] Update failed: %v", err)
+			}
 		}
 	}
 
@@ -48,44 +70,45 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 4. Start Host Info Heartbeat
-	go func() {
-		ticker := time.NewTicker(time.Duration(cfg.HostInfoInterval) * time.Second)
-		defer ticker.Stop()
+	// 4. Start Collectors (host heartbeat + platform specific)
+	if comm != nil {
+		collectors.Start(ctx, comm, cfg)
+	}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				info, err := host.CollectEnvInfo()
-				if err != nil {
-					log.Printf("[Agent] Error collecting host info: %v", err)
-					continue
-				}
-				info.AgentID = cfg.AgentID
+	// 4.5 Active Response: execute SOAR commands and report the result
+	if comm != nil {
+		resp := responder.NewResponder(cfg.AgentID)
+		err := comm.SubscribeCommands(ctx, func(cmd []byte) {
+			res := resp.Handle(ctx, cmd)
+			if err := comm.PublishResult(res); err != nil {
+				log.Printf("[Agent] Failed to publish command result: %v", err)
+			}
+		})
+		if err != nil {
+			log.Printf("[Agent] Failed to subscribe to commands: %v", err)
+		}
+	}
 
-				// Publish to 'events.raw.info.host' or similar
-				// Assuming topic structure: events.raw.<severity>.<source>
-				if comm != nil {
-					topic := "events.raw.info.agent"
-					comm.Publish(topic, info.ToJSON())
-				}
+	// 5. Confirm a pending upgrade once we have run healthy for a while
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(cfg.UpgradeConfirmDelay) * time.Second):
+			if err := upd.ConfirmUpgrade(); err != nil {
+				log.Printf("[Agent] Upgrade confirmation failed: %v", err)
 			}
 		}
 	}()
 
-	// 5. Start Platform Collectors
-	if comm != nil {
-		collectors.Start(ctx, comm)
-	}
-
 	// 6. Wait for Shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
 	log.Println("[Agent] Shutting down...")
+	if err := upd.RecordCleanShutdown(); err != nil {
+		log.Printf("[Agent] Failed to record clean shutdown: %v", err)
+	}
 	cancel()
 	time.Sleep(1 * time.Second) // Give routines time to stop
 	log.Println("[Agent] Goodbye.")
@@ -0,0 +1,86 @@
+package responder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+// Handler executes a single command action and returns a short detail on success.
+type Handler func(ctx context.Context, params map[string]interface{}) (string, error)
+
+// Responder executes SOAR commands and builds the result sent back to SOAR.
+type Responder struct {
+	AgentID  string
+	Timeout  time.Duration
+	handlers map[string]Handler
+}
+
+// NewResponder creates a responder with the platform's built-in actions.
+func NewResponder(agentID string) *Responder {
+	r := &Responder{
+		AgentID:  agentID,
+		Timeout:  30 * time.Second,
+		handlers: make(map[string]Handler),
+	}
+	for action, h := range platformHandlers() {
+		r.Register(action, h)
+	}
+	return r
+}
+
+// Register adds or replaces the handler for an action.
+func (r *Responder) Register(action string, h Handler) {
+	r.handlers[action] = h
+}
+
+// Handle decodes a raw command, executes it and returns its result.
+// A result is always returned so SOAR never waits on a silent agent.
+func (r *Responder) Handle(ctx context.Context, data []byte) *models.CommandResult {
+	var cmd models.Command
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return r.result("", fmt.Errorf("invalid command: %w", err), "")
+	}
+
+	h, ok := r.handlers[cmd.Action]
+	if !ok {
+		return r.result(cmd.ID, fmt.Errorf("unsupported action %q", cmd.Action), "")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	log.Printf("[Responder] Executing %s (command %s)", cmd.Action, cmd.ID)
+	detail, err := h(ctx, cmd.Params)
+	if err != nil {
+		log.Printf("[Responder] %s failed: %v", cmd.Action, err)
+	}
+	return r.result(cmd.ID, err, detail)
+}
+
+func (r *Responder) result(commandID string, err error, detail string) *models.CommandResult {
+	res := &models.CommandResult{
+		CommandID: commandID,
+		AgentID:   r.AgentID,
+		Success:   err == nil,
+		Detail:    detail,
+		Timestamp: time.Now().UTC(),
+	}
+	if err != nil {
+		res.Detail = err.Error()
+	}
+	return res
+}
+
+// stringParam returns a required string parameter.
+func stringParam(params map[string]interface{}, key string) (string, error) {
+	v, _ := params[key].(string)
+	if v == "" {
+		return "", fmt.Errorf("missing %q parameter", key)
+	}
+	return v, nil
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+package responder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+func platformHandlers() map[string]Handler {
+	return map[string]Handler{
+		"firewall_block": firewallBlock,
+	}
+}
+
+// firewallBlock drops all inbound traffic from params["ip"] using iptables.
+func firewallBlock(ctx context.Context, params map[string]interface{}) (string, error) {
+	ip, err := stringParam(params, "ip")
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("invalid ip %q", ip)
+	}
+
+	out, err := exec.CommandContext(ctx, "iptables", "-I", "INPUT", "-s", ip, "-j", "DROP").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("iptables: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return "blocked " + ip, nil
+}
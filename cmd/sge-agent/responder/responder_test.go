@@ -0,0 +1,50 @@
+package responder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResponderHandle(t *testing.T) {
+	r := &Responder{AgentID: "agent-1", Timeout: time.Second, handlers: make(map[string]Handler)}
+	r.Register("echo", func(ctx context.Context, params map[string]interface{}) (string, error) {
+		return stringParam(params, "msg")
+	})
+	r.Register("fail", func(ctx context.Context, params map[string]interface{}) (string, error) {
+		return "", errors.New("boom")
+	})
+
+	tests := []struct {
+		name        string
+		cmd         string
+		wantID      string
+		wantSuccess bool
+		wantDetail  string
+	}{
+		{"Success", `{"id":"c1","action":"echo","params":{"msg":"done"}}`, "c1", true, "done"},
+		{"Handler Error", `{"id":"c2","action":"fail"}`, "c2", false, "boom"},
+		{"Missing Param", `{"id":"c3","action":"echo"}`, "c3", false, `missing "msg" parameter`},
+		{"Unsupported Action", `{"id":"c4","action":"reboot"}`, "c4", false, `unsupported action "reboot"`},
+		{"Malformed", `{not json`, "", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := r.Handle(context.Background(), []byte(tt.cmd))
+			if res.CommandID != tt.wantID {
+				t.Errorf("CommandID = %q, want %q", res.CommandID, tt.wantID)
+			}
+			if res.AgentID != "agent-1" {
+				t.Errorf("AgentID = %q, want agent-1", res.AgentID)
+			}
+			if res.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", res.Success, tt.wantSuccess)
+			}
+			if tt.wantDetail != "" && res.Detail != tt.wantDetail {
+				t.Errorf("Detail = %q, want %q", res.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
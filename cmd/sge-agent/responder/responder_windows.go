@@ -0,0 +1,35 @@
+//go:build windows
+
+package responder
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+func platformHandlers() map[string]Handler {
+	return map[string]Handler{
+		"firewall_block": firewallBlock,
+	}
+}
+
+// firewallBlock adds an inbound block rule for params["ip"] using netsh.
+func firewallBlock(ctx context.Context, params map[string]interface{}) (string, error) {
+	ip, err := stringParam(params, "ip")
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("invalid ip %q", ip)
+	}
+
+	out, err := exec.CommandContext(ctx, "netsh", "advfirewall", "firewall", "add", "rule",
+		"name=SGE-Block-"+ip, "dir=in", "action=block", "remoteip="+ip).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("netsh: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return "blocked " + ip, nil
+}
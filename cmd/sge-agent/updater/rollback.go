@@ -0,0 +1,175 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// upgradeSentinel is written next to the executable before restarting into a
+// new binary. It is removed once the new binary confirms a healthy start;
+// finding it on a later boot means the upgraded binary never got that far.
+type upgradeSentinel struct {
+	Version         string `json:"version"`
+	PreviousVersion string `json:"previous_version"`
+	Boots           int    `json:"boots"` // Starts of the new binary not ended by a clean shutdown
+}
+
+// failedVersionSuffix names the file next to the executable recording the
+// version last rolled back, so CheckUpdate does not reinstall it.
+const failedVersionSuffix = ".failed-version"
+
+// VerifyStartup must be called early on every start.
+//
+// On the first boot after an upgrade it records the attempt and returns false.
+// If the sentinel shows the new binary already booted without confirming or
+// shutting down cleanly (crash, hang, killed by the supervisor) the previous
+// binary is restored from .old, the failed version is recorded so it is not
+// installed again, and true is returned; the caller should then exit so the
+// supervisor restarts the restored version.
+func (u *Updater) VerifyStartup() (bool, error) {
+	s, err := u.readSentinel()
+	if err != nil {
+		return false, err
+	}
+	if s == nil {
+		return false, nil // No upgrade pending
+	}
+
+	if s.Boots == 0 {
+		s.Boots++
+		log.Printf("[Updater] First start after upgrade %s -> %s, awaiting confirmation", s.PreviousVersion, s.Version)
+		return false, u.writeSentinel(s)
+	}
+
+	log.Printf("[Updater] Upgrade to %s did not confirm a healthy start, rolling back to %s", s.Version, s.PreviousVersion)
+	if err := u.Rollback(); err != nil {
+		return false, err
+	}
+	if err := u.writeFailedVersion(s.Version); err != nil {
+		log.Printf("[Updater] Warning: %v", err)
+	}
+	return true, nil
+}
+
+// RecordCleanShutdown must be called when the agent stops on request. A
+// clean shutdown before ConfirmUpgrade does not count as a failed boot, so
+// a restart within the confirmation delay does not roll back. It is a no-op
+// when no upgrade is pending.
+func (u *Updater) RecordCleanShutdown() error {
+	s, err := u.readSentinel()
+	if err != nil || s == nil {
+		return err
+	}
+	s.Boots = 0
+	return u.writeSentinel(s)
+}
+
+// ConfirmUpgrade marks the running binary as healthy, clearing the sentinel
+// and discarding the previous binary. It is a no-op when no upgrade is pending.
+func (u *Updater) ConfirmUpgrade() error {
+	s, err := u.readSentinel()
+	if err != nil || s == nil {
+		return err
+	}
+
+	exePath, err := u.exePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(exePath + ".upgrade"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove upgrade sentinel: %w", err)
+	}
+	os.Remove(exePath + ".old")
+
+	log.Printf("[Updater] Upgrade to %s confirmed", s.Version)
+	return nil
+}
+
+// Rollback restores the .old binary over the current executable and clears the sentinel.
+func (u *Updater) Rollback() error {
+	exePath, err := u.exePath()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	oldPath := exePath + ".old"
+	if _, err := os.Stat(oldPath); err != nil {
+		return fmt.Errorf("no previous binary to roll back to: %w", err)
+	}
+
+	// Keep the failed binary aside for inspection instead of deleting it
+	failedPath := exePath + ".failed"
+	os.Remove(failedPath)
+	if err := os.Rename(exePath, failedPath); err != nil {
+		return fmt.Errorf("failed to move bad binary aside: %w", err)
+	}
+	if err := os.Rename(oldPath, exePath); err != nil {
+		return fmt.Errorf("failed to restore previous binary: %w", err)
+	}
+
+	if err := os.Remove(exePath + ".upgrade"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove upgrade sentinel: %w", err)
+	}
+	return nil
+}
+
+// failedVersion returns the version last rolled back, or "" if none.
+func (u *Updater) failedVersion() string {
+	exePath, err := u.exePath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(exePath + failedVersionSuffix)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func (u *Updater) writeFailedVersion(version string) error {
+	exePath, err := u.exePath()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if err := os.WriteFile(exePath+failedVersionSuffix, []byte(version+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to record failed version: %w", err)
+	}
+	return nil
+}
+
+func (u *Updater) readSentinel() (*upgradeSentinel, error) {
+	exePath, err := u.exePath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	data, err := os.ReadFile(exePath + ".upgrade")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upgrade sentinel: %w", err)
+	}
+
+	var s upgradeSentinel
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("corrupt upgrade sentinel: %w", err)
+	}
+	return &s, nil
+}
+
+func (u *Updater) writeSentinel(s *upgradeSentinel) error {
+	exePath, err := u.exePath()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	data, _ := json.Marshal(s)
+	if err := os.WriteFile(exePath+".upgrade", data, 0644); err != nil {
+		return fmt.Errorf("failed to write upgrade sentinel: %w", err)
+	}
+	return nil
+}
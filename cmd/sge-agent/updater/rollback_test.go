@@ -0,0 +1,120 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"testing"
+)
+
+func stageTestUpgrade(t *testing.T) (*Updater, string) {
+	t.Helper()
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	rs := newReleaseServer(t, priv, "1.3.0", []byte("new-binary"))
+	u, exe := newTestUpdater(t, pub, rs.URL+"/manifest.json")
+
+	if _, ok, err := u.CheckUpdate(); err != nil || !ok {
+		t.Fatalf("CheckUpdate() = %v, %v; want update available", ok, err)
+	}
+	if err := u.stageUpgrade(); err != nil {
+		t.Fatalf("stageUpgrade() error = %v", err)
+	}
+
+	if got, _ := os.ReadFile(exe); string(got) != "new-binary" {
+		t.Fatalf("executable = %q, want new binary", got)
+	}
+	if got, _ := os.ReadFile(exe + ".old"); string(got) != "old-binary" {
+		t.Fatalf(".old = %q, want previous binary", got)
+	}
+	return u, exe
+}
+
+func TestUpdater_RollbackAfterFailedBoot(t *testing.T) {
+	u, exe := stageTestUpgrade(t)
+
+	// First boot of the new binary: recorded, no rollback yet
+	rolledBack, err := u.VerifyStartup()
+	if err != nil || rolledBack {
+		t.Fatalf("first VerifyStartup() = %v, %v; want false, nil", rolledBack, err)
+	}
+
+	// The new binary crashes before ConfirmUpgrade; the supervisor restarts it
+	rolledBack, err = u.VerifyStartup()
+	if err != nil {
+		t.Fatalf("second VerifyStartup() error = %v", err)
+	}
+	if !rolledBack {
+		t.Fatal("second VerifyStartup() did not roll back")
+	}
+
+	if got, _ := os.ReadFile(exe); string(got) != "old-binary" {
+		t.Errorf("executable after rollback = %q, want old-binary", got)
+	}
+	if got, _ := os.ReadFile(exe + ".failed"); string(got) != "new-binary" {
+		t.Errorf(".failed = %q, want new-binary", got)
+	}
+	if _, err := os.Stat(exe + ".upgrade"); !os.IsNotExist(err) {
+		t.Error("sentinel still present after rollback")
+	}
+
+	// The restored binary starts normally
+	if rolledBack, err := u.VerifyStartup(); err != nil || rolledBack {
+		t.Errorf("VerifyStartup() after rollback = %v, %v; want false, nil", rolledBack, err)
+	}
+
+	// and does not reinstall the release it just rolled back
+	if v, ok, err := u.CheckUpdate(); err != nil || ok {
+		t.Errorf("CheckUpdate() after rollback = %s, %v, %v; want %s skipped", v, ok, err, "1.3.0")
+	}
+}
+
+func TestUpdater_CleanShutdownIsNotAFailedBoot(t *testing.T) {
+	u, exe := stageTestUpgrade(t)
+
+	// The new binary is stopped cleanly (e.g. SIGTERM) before confirming, twice
+	for i := 0; i < 2; i++ {
+		if rolledBack, err := u.VerifyStartup(); err != nil || rolledBack {
+			t.Fatalf("VerifyStartup() #%d = %v, %v; want false, nil", i+1, rolledBack, err)
+		}
+		if err := u.RecordCleanShutdown(); err != nil {
+			t.Fatalf("RecordCleanShutdown() error = %v", err)
+		}
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "new-binary" {
+		t.Errorf("executable = %q, want new-binary kept", got)
+	}
+
+	// A crash after that still rolls back
+	if rolledBack, err := u.VerifyStartup(); err != nil || rolledBack {
+		t.Fatalf("VerifyStartup() = %v, %v; want false, nil", rolledBack, err)
+	}
+	if rolledBack, err := u.VerifyStartup(); err != nil || !rolledBack {
+		t.Errorf("VerifyStartup() after crash = %v, %v; want true, nil", rolledBack, err)
+	}
+}
+
+func TestUpdater_ConfirmedUpgradeIsKept(t *testing.T) {
+	u, exe := stageTestUpgrade(t)
+
+	if rolledBack, err := u.VerifyStartup(); err != nil || rolledBack {
+		t.Fatalf("VerifyStartup() = %v, %v; want false, nil", rolledBack, err)
+	}
+	if err := u.ConfirmUpgrade(); err != nil {
+		t.Fatalf("ConfirmUpgrade() error = %v", err)
+	}
+
+	for _, suffix := range []string{".upgrade", ".old"} {
+		if _, err := os.Stat(exe + suffix); !os.IsNotExist(err) {
+			t.Errorf("%s still present after confirmation", suffix)
+		}
+	}
+
+	// Later restarts must not roll back
+	if rolledBack, err := u.VerifyStartup(); err != nil || rolledBack {
+		t.Errorf("VerifyStartup() after confirm = %v, %v; want false, nil", rolledBack, err)
+	}
+	if got, _ := os.ReadFile(exe); string(got) != "new-binary" {
+		t.Errorf("executable = %q, want confirmed new binary", got)
+	}
+}
@@ -1,43 +1,157 @@
 package updater
 
 import (
+	"bytes"
+	"cmp"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Manifest is the JSON document served at UpdateURL describing the latest release.
+type Manifest struct {
+	Version   string `json:"version"`
+	URL       string `json:"url"`       // Binary download URL
+	SHA256    string `json:"sha256"`    // Hex encoded digest of the binary
+	Signature string `json:"signature"` // Base64 ed25519 signature over the raw SHA256 digest
+}
+
 // Updater manages the self-update process.
 type Updater struct {
 	CurrentVersion string
-	UpdateURL      string // URL to check for updates (returns JSON or plain version)
-	BinaryURL      string // URL to download the new binary
+	UpdateURL      string            // URL of the release manifest (JSON)
+	BinaryURL      string            // URL to download the new binary (filled from manifest)
+	PublicKey      ed25519.PublicKey // Release signing key; updates are refused without it
+
+	// Optional overrides (mainly for tests)
+	HTTPClient *http.Client
+	ExePath    string // Defaults to os.Executable()
+
+	manifest *Manifest
 }
 
-// CheckUpdate checks if a newer version is available.
-// In a real scenario, this would hit an endpoint.
-// For now, it mocks a check.
+// ErrNoManifest is returned when PerformUpdate is called before a successful CheckUpdate.
+var ErrNoManifest = errors.New("no update manifest loaded, call CheckUpdate first")
+
+// CheckUpdate fetches the release manifest and reports whether it is newer
+// than CurrentVersion. A release that was rolled back by VerifyStartup is
+// not offered again; a later release is.
 func (u *Updater) CheckUpdate() (string, bool, error) {
-	// Mock implementation
-	// Real world: GET u.UpdateURL -> parse JSON -> compare semver
-	return "", false, nil
+	resp, err := u.client().Get(u.UpdateURL)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("manifest request failed: %s", resp.Status)
+	}
+
+	var m Manifest
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&m); err != nil {
+		return "", false, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version == "" || m.URL == "" || m.SHA256 == "" {
+		return "", false, fmt.Errorf("invalid manifest: version, url and sha256 are required")
+	}
+
+	cmp, err := CompareVersions(m.Version, u.CurrentVersion)
+	if err != nil {
+		return "", false, err
+	}
+	if cmp <= 0 {
+		return m.Version, false, nil
+	}
+	if failed := u.failedVersion(); failed != "" && failed == m.Version {
+		log.Printf("[Updater] Skipping %s, it was rolled back", m.Version)
+		return m.Version, false, nil
+	}
+
+	u.manifest = &m
+	u.BinaryURL = m.URL
+	return m.Version, true, nil
 }
 
-// PerformUpdate downloads the new binary and replaces the current executable.
+// PerformUpdate installs the checked release and exits so the supervisor restarts us.
 func (u *Updater) PerformUpdate() error {
-	log.Printf("[Updater] Starting self-update process...")
+	if err := u.stageUpgrade(); err != nil {
+		return err
+	}
+
+	log.Printf("[Updater] Update successful! Restarting...")
+
+	// Restart
+	// Simple strategy: Exit and let Systemd/Supervisor restart us.
+	os.Exit(0)
+	return nil
+}
+
+// stageUpgrade installs the new binary and marks the upgrade as pending so
+// the next start can detect a bad binary (see VerifyStartup).
+func (u *Updater) stageUpgrade() error {
+	if err := u.Install(); err != nil {
+		return err
+	}
+
+	if err := u.writeSentinel(&upgradeSentinel{
+		Version:         u.manifest.Version,
+		PreviousVersion: u.CurrentVersion,
+	}); err != nil {
+		log.Printf("[Updater] Warning: rollback unavailable: %v", err)
+	}
+	return nil
+}
+
+// Install downloads the binary from the loaded manifest, verifies its checksum
+// and signature and swaps it in place of the current executable.
+// The current executable is left untouched if any verification fails.
+func (u *Updater) Install() error {
+	if u.manifest == nil {
+		return ErrNoManifest
+	}
+	if len(u.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("no valid update public key configured")
+	}
+
+	log.Printf("[Updater] Starting self-update to %s...", u.manifest.Version)
+
+	wantSum, err := hex.DecodeString(u.manifest.SHA256)
+	if err != nil || len(wantSum) != sha256.Size {
+		return fmt.Errorf("invalid manifest checksum")
+	}
+	sig, err := base64.StdEncoding.DecodeString(u.manifest.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid manifest signature encoding: %w", err)
+	}
+	if !ed25519.Verify(u.PublicKey, wantSum, sig) {
+		return fmt.Errorf("manifest signature verification failed")
+	}
 
 	// 1. Download new binary
-	resp, err := http.Get(u.BinaryURL)
+	resp, err := u.client().Get(u.BinaryURL)
 	if err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("binary download failed: %s", resp.Status)
+	}
+
 	// 2. Prepare temporary file
-	exePath, err := os.Executable()
+	exePath, err := u.exePath()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
@@ -47,45 +161,149 @@ func (u *Updater) PerformUpdate() error {
 	if err != nil {
 		return fmt.Errorf("failed to create temp binary: %w", err)
 	}
-	defer out.Close()
 
-	// 3. Write data
-	if _, err := io.Copy(out, resp.Body); err != nil {
+	// 3. Write data while hashing
+	hasher := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, hasher), resp.Body)
+	out.Close() // Close before verify/move
+	if err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write binary: %w", err)
 	}
 
+	if got := hasher.Sum(nil); !bytes.Equal(got, wantSum) {
+		os.Remove(tmpPath)
+		return fmt.Errorf("checksum mismatch: got %x, want %x", got, wantSum)
+	}
+
 	// 4. Make executable (Linux/Mac)
 	if runtime.GOOS != "windows" {
 		if err := os.Chmod(tmpPath, 0755); err != nil {
+			os.Remove(tmpPath)
 			return fmt.Errorf("failed to chmod: %w", err)
 		}
 	}
 
-	out.Close() // Close before move
-
-	// 5. Replace binary (Atomic move on Linux)
-	// On Windows, you can't rename over a running executable easily.
-	// Windows strategy often involves a wrapper script or renaming the *current* exe to .old first.
-	if runtime.GOOS == "windows" {
-		oldPath := exePath + ".old"
-		os.Remove(oldPath) // Setup
-		if err := os.Rename(exePath, oldPath); err != nil {
-			return fmt.Errorf("windows rename failed: %w", err)
-		}
+	// 5. Replace binary, keeping the previous one as .old for rollback.
+	// Renaming the running executable is fine on Linux/Mac and is also the
+	// only way to replace it on Windows.
+	oldPath := exePath + ".old"
+	os.Remove(oldPath) // Setup
+	if err := os.Rename(exePath, oldPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to keep previous binary: %w", err)
 	}
 
 	if err := os.Rename(tmpPath, exePath); err != nil {
+		// Put the previous binary back so we are never left without one
+		os.Rename(oldPath, exePath)
 		return fmt.Errorf("failed to replace binary: %w", err)
 	}
 
-	log.Printf("[Updater] Update successful! Restarting...")
-
-	// 6. Restart
-	// Simple strategy: Exit and let Systemd/Supervisor restart us.
-	os.Exit(0)
 	return nil
 }
 
+func (u *Updater) client() *http.Client {
+	if u.HTTPClient != nil {
+		return u.HTTPClient
+	}
+	return &http.Client{Timeout: 60 * time.Second}
+}
+
+func (u *Updater) exePath() (string, error) {
+	if u.ExePath != "" {
+		return u.ExePath, nil
+	}
+	return os.Executable()
+}
+
+// CompareVersions compares two semantic versions ("v1.2.3", "1.2.3-rc1").
+// Returns -1 if a < b, 0 if equal and 1 if a > b.
+// A pre-release sorts before the matching release (1.0.0-rc1 < 1.0.0).
+func CompareVersions(a, b string) (int, error) {
+	pa, preA, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, preB, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	switch {
+	case preA == preB:
+		return 0, nil
+	case preA == "":
+		return 1, nil
+	case preB == "":
+		return -1, nil
+	}
+	return comparePreRelease(preA, preB), nil
+}
+
+// comparePreRelease compares dot-separated pre-release tags by semver
+// precedence: numeric identifiers compare numerically (rc.2 < rc.10) and
+// sort before alphanumeric ones, and a shorter tag sorts first when all
+// its identifiers match.
+func comparePreRelease(a, b string) int {
+	ia, ib := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(ia) && i < len(ib); i++ {
+		na, errA := strconv.ParseUint(ia[i], 10, 64)
+		nb, errB := strconv.ParseUint(ib[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return cmp.Compare(na, nb)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(ia[i], ib[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(ia), len(ib))
+}
+
+func parseVersion(v string) ([3]int, string, error) {
+	var parts [3]int
+
+	s := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexByte(s, '+'); i != -1 {
+		s = s[:i] // Build metadata is ignored for precedence
+	}
+	pre := ""
+	if i := strings.IndexByte(s, '-'); i != -1 {
+		s, pre = s[:i], s[i+1:]
+	}
+
+	fields := strings.Split(s, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, "", fmt.Errorf("invalid version %q", v)
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, "", fmt.Errorf("invalid version %q", v)
+		}
+		parts[i] = n
+	}
+
+	return parts, pre, nil
+}
+
 // SelfRestart attempts to restart the process directly (alternative to os.Exit)
 func SelfRestart() error {
 	exe, err := os.Executable()
@@ -0,0 +1,193 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// releaseServer serves a manifest and binary the way the update endpoint would.
+type releaseServer struct {
+	*httptest.Server
+	manifest Manifest
+	binary   []byte
+}
+
+func newReleaseServer(t *testing.T, priv ed25519.PrivateKey, version string, binary []byte) *releaseServer {
+	t.Helper()
+
+	rs := &releaseServer{binary: binary}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(rs.manifest)
+	})
+	mux.HandleFunc("/agent", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(rs.binary)
+	})
+	rs.Server = httptest.NewServer(mux)
+	t.Cleanup(rs.Close)
+
+	sum := sha256.Sum256(binary)
+	rs.manifest = Manifest{
+		Version:   version,
+		URL:       rs.URL + "/agent",
+		SHA256:    hex.EncodeToString(sum[:]),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sum[:])),
+	}
+	return rs
+}
+
+func newTestUpdater(t *testing.T, pub ed25519.PublicKey, manifestURL string) (*Updater, string) {
+	t.Helper()
+
+	exe := filepath.Join(t.TempDir(), "sge-agent")
+	if err := os.WriteFile(exe, []byte("old-binary"), 0755); err != nil {
+		t.Fatalf("failed to write fake executable: %v", err)
+	}
+	return &Updater{
+		CurrentVersion: "1.2.0",
+		UpdateURL:      manifestURL,
+		PublicKey:      pub,
+		ExePath:        exe,
+	}, exe
+}
+
+func TestUpdater_CheckAndInstall(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name       string
+		version    string
+		wantUpdate bool
+	}{
+		{name: "Newer Version", version: "1.3.0", wantUpdate: true},
+		{name: "Newer Patch With Prefix", version: "v1.2.1", wantUpdate: true},
+		{name: "Same Version", version: "1.2.0", wantUpdate: false},
+		{name: "Older Version", version: "1.1.9", wantUpdate: false},
+		{name: "Pre-release Of Current", version: "1.2.0-rc1", wantUpdate: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := newReleaseServer(t, priv, tt.version, []byte("new-binary-"+tt.version))
+			u, exe := newTestUpdater(t, pub, rs.URL+"/manifest.json")
+
+			v, ok, err := u.CheckUpdate()
+			if err != nil {
+				t.Fatalf("CheckUpdate() error = %v", err)
+			}
+			if ok != tt.wantUpdate {
+				t.Fatalf("CheckUpdate() ok = %v, want %v", ok, tt.wantUpdate)
+			}
+			if v != tt.version {
+				t.Errorf("CheckUpdate() version = %s, want %s", v, tt.version)
+			}
+
+			if !ok {
+				if err := u.Install(); err != ErrNoManifest {
+					t.Errorf("Install() without newer release error = %v, want ErrNoManifest", err)
+				}
+				return
+			}
+
+			if err := u.Install(); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			got, _ := os.ReadFile(exe)
+			if string(got) != string(rs.binary) {
+				t.Errorf("executable = %q, want %q", got, rs.binary)
+			}
+		})
+	}
+}
+
+func TestUpdater_InstallRejectsTamperedRelease(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name   string
+		tamper func(rs *releaseServer)
+	}{
+		{
+			name:   "Checksum Mismatch",
+			tamper: func(rs *releaseServer) { rs.binary = []byte("evil-binary") },
+		},
+		{
+			name: "Signature From Unknown Key",
+			tamper: func(rs *releaseServer) {
+				sum, _ := hex.DecodeString(rs.manifest.SHA256)
+				rs.manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(otherPriv, sum))
+			},
+		},
+		{
+			name:   "Missing Signature",
+			tamper: func(rs *releaseServer) { rs.manifest.Signature = "" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := newReleaseServer(t, priv, "2.0.0", []byte("new-binary"))
+			tt.tamper(rs)
+			u, exe := newTestUpdater(t, pub, rs.URL+"/manifest.json")
+
+			if _, ok, err := u.CheckUpdate(); err != nil || !ok {
+				t.Fatalf("CheckUpdate() = %v, %v; want update available", ok, err)
+			}
+			if err := u.Install(); err == nil {
+				t.Fatal("Install() expected error for tampered release")
+			}
+
+			got, _ := os.ReadFile(exe)
+			if string(got) != "old-binary" {
+				t.Errorf("executable was modified: %q", got)
+			}
+			if _, err := os.Stat(exe + ".new"); !os.IsNotExist(err) {
+				t.Errorf("temporary binary left behind")
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "1.0.0", b: "1.0.0", want: 0},
+		{a: "v1.0.1", b: "1.0.0", want: 1},
+		{a: "1.0.0", b: "1.10.0", want: -1},
+		{a: "2.0", b: "1.9.9", want: 1},
+		{a: "1.0.0-rc1", b: "1.0.0", want: -1},
+		{a: "1.0.0-rc2", b: "1.0.0-rc1", want: 1},
+		{a: "1.0.0-rc.2", b: "1.0.0-rc.10", want: -1},
+		{a: "1.0.0-rc.10", b: "1.0.0-rc.2", want: 1},
+		{a: "1.0.0-1", b: "1.0.0-alpha", want: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{a: "1.0.0-alpha.beta", b: "1.0.0-alpha.1", want: 1},
+		{a: "1.0.0+build5", b: "1.0.0", want: 0},
+		{a: "latest", b: "1.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			got, err := CompareVersions(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CompareVersions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CompareVersions() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package baseline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKey holds the baselines of all sources as one JSON document.
+const redisKey = "analytics:baseline"
+
+// Store persists learned baselines across restarts.
+type Store interface {
+	Load(ctx context.Context) (map[string]Stats, error)
+	Save(ctx context.Context, stats map[string]Stats) error
+}
+
+// kv is the Redis operations RedisStore needs (see database.RedisClient).
+type kv interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+}
+
+// RedisStore keeps baselines in Redis. Instances sharing a Redis overwrite
+// each other's baselines (last save wins).
+type RedisStore struct {
+	client kv
+}
+
+// NewRedisStore creates a store (client is usually a *database.RedisClient).
+func NewRedisStore(client kv) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Load returns the saved baselines, or none on the first run.
+func (s *RedisStore) Load(ctx context.Context) (map[string]Stats, error) {
+	data, err := s.client.Get(ctx, redisKey)
+	if errors.Is(err, redis.Nil) {
+		return map[string]Stats{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]Stats)
+	if err := json.Unmarshal([]byte(data), &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (s *RedisStore) Save(ctx context.Context, stats map[string]Stats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKey, data, 0)
+}
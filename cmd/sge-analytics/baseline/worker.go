@@ -1,46 +1,220 @@
 package baseline
 
 import (
+	"context"
 	"log"
+	"math"
 	"sync"
 	"time"
 
 	"sakin-go/pkg/models"
 )
 
+const (
+	highVolume   = 1000 // Events/min flagged regardless of history
+	minHistory   = 10   // Minutes learned before a source is judged against its baseline
+	zThreshold   = 3.0  // Standard deviations above the mean
+	persistEvery = 10   // Minutes between saves, besides the one at Close
+	maxIdle      = 1440 // Silent minutes after which a source's baseline is dropped
+)
+
+// Stats is the learned events-per-minute profile of one source.
+type Stats struct {
+	Minutes int64   `json:"minutes"`
+	Mean    float64 `json:"mean"`
+	M2      float64 `json:"m2"`             // Sum of squared deviations from the mean (Welford)
+	Idle    int64   `json:"idle,omitempty"` // Consecutive silent minutes
+}
+
+// Add learns one minute with count events.
+func (s *Stats) Add(count float64) {
+	s.Minutes++
+	delta := count - s.Mean
+	s.Mean += delta / float64(s.Minutes)
+	s.M2 += delta * (count - s.Mean)
+}
+
+// StdDev returns the standard deviation of the events per minute.
+func (s Stats) StdDev() float64 {
+	if s.Minutes < 2 {
+		return 0
+	}
+	return math.Sqrt(s.M2 / float64(s.Minutes-1))
+}
+
+// Anomaly is a source whose last minute was unusually busy.
+type Anomaly struct {
+	Source string
+	Count  int
+	Mean   float64
+	StdDev float64
+}
+
+// Worker counts events per source and minute, learns a baseline per
+// source and flags minutes far above it. Baselines are kept in a Store
+// (if any) so they survive restarts.
 type Worker struct {
-	counts map[string]int
+	store Store // nil keeps baselines in memory only
+
 	mu     sync.Mutex
+	counts map[string]int    // Current minute
+	stats  map[string]*Stats // Learned baselines
+	ticks  int
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewWorker() *Worker {
+// NewWorker creates a worker and starts its minute ticker. store may be nil.
+func NewWorker(store Store) *Worker {
 	w := &Worker{
+		store:  store,
 		counts: make(map[string]int),
+		stats:  make(map[string]*Stats),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
 	}
 	go w.run()
 	return w
 }
 
+// Load restores the baselines saved by a previous run and returns how many
+// sources were restored. Sources already learned in this run are kept.
+func (w *Worker) Load(ctx context.Context) (int, error) {
+	if w.store == nil {
+		return 0, nil
+	}
+	saved, err := w.store.Load(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for src, s := range saved {
+		if _, ok := w.stats[src]; !ok {
+			s := s
+			w.stats[src] = &s
+			n++
+		}
+	}
+	return n, nil
+}
+
 func (w *Worker) Process(evt *models.Event) {
 	w.mu.Lock()
 	w.counts[evt.Source]++
 	w.mu.Unlock()
 }
 
+// Baseline returns the learned profile of src.
+func (w *Worker) Baseline(src string) (Stats, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s, ok := w.stats[src]
+	if !ok {
+		return Stats{}, false
+	}
+	return *s, true
+}
+
+// Flush saves the learned baselines to the store.
+func (w *Worker) Flush(ctx context.Context) error {
+	if w.store == nil {
+		return nil
+	}
+	return w.store.Save(ctx, w.snapshot())
+}
+
+// Close stops the ticker and saves the baselines. The counts of the
+// current, incomplete minute are not learned.
+func (w *Worker) Close(ctx context.Context) error {
+	w.closeOnce.Do(func() { close(w.stop) })
+	<-w.done
+	return w.Flush(ctx)
+}
+
+func (w *Worker) snapshot() map[string]Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make(map[string]Stats, len(w.stats))
+	for src, s := range w.stats {
+		out[src] = *s
+	}
+	return out
+}
+
 func (w *Worker) run() {
+	defer close(w.done)
 	ticker := time.NewTicker(1 * time.Minute) // Check every minute
 	defer ticker.Stop()
 
-	for range ticker.C {
-		w.mu.Lock()
-		for src, count := range w.counts {
-			// Simple anomaly detection: > 1000 events/min is "high"
-			if count > 1000 {
-				log.Printf("[Analytics] 📈 High Volume Detected: %s (%d events/min)", src, count)
-				// TODO: Publish a 'system.alert' or verify against historical Z-Score
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			for _, a := range w.tick() {
+				log.Printf("[Analytics] 📈 High Volume Detected: %s (%d events/min, baseline %.0f±%.0f)", a.Source, a.Count, a.Mean, a.StdDev)
+				// TODO: Publish a 'system.alert'
+			}
+			if w.store != nil && w.ticks%persistEvery == 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := w.Flush(ctx); err != nil {
+					log.Printf("[Analytics] Baseline save failed: %v", err)
+				}
+				cancel()
 			}
-			delete(w.counts, src) // Reset
 		}
-		w.mu.Unlock()
 	}
 }
+
+// tick closes the current minute: every known source is judged against
+// its baseline, which then learns the minute (silent sources learn 0).
+// Sources silent for maxIdle minutes are forgotten, so retired hosts do
+// not grow the baselines kept in memory and in the store.
+func (w *Worker) tick() []Anomaly {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.ticks++
+	for src := range w.counts {
+		if _, ok := w.stats[src]; !ok {
+			w.stats[src] = &Stats{}
+		}
+	}
+
+	var anomalies []Anomaly
+	for src, s := range w.stats {
+		count := w.counts[src]
+		if isAnomalous(*s, count) {
+			anomalies = append(anomalies, Anomaly{Source: src, Count: count, Mean: s.Mean, StdDev: s.StdDev()})
+		}
+		s.Add(float64(count))
+
+		if count > 0 {
+			s.Idle = 0
+		} else if s.Idle++; s.Idle >= maxIdle {
+			delete(w.stats, src)
+		}
+	}
+	w.counts = make(map[string]int)
+	return anomalies
+}
+
+// isAnomalous reports whether count events in a minute stand out: above
+// the absolute limit, or zThreshold deviations above a learned mean. The
+// deviation is floored at the Poisson one so steady sources are not
+// flagged for small fluctuations.
+func isAnomalous(s Stats, count int) bool {
+	if count > highVolume {
+		return true
+	}
+	if s.Minutes < minHistory {
+		return false
+	}
+	dev := max(s.StdDev(), math.Sqrt(s.Mean), 1)
+	return float64(count) > s.Mean+zThreshold*dev
+}
@@ -0,0 +1,175 @@
+package baseline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"sakin-go/pkg/models"
+)
+
+// fakeRedis mimics GET/SET, returning redis.Nil for missing keys.
+type fakeRedis struct {
+	mu   sync.Mutex
+	keys map[string]string
+	err  error
+}
+
+func (f *fakeRedis) Get(_ context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return "", f.err
+	}
+	v, ok := f.keys[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return v, nil
+}
+
+func (f *fakeRedis) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.keys[key] = string(value.([]byte))
+	return nil
+}
+
+// feed processes n events from src and closes the minute.
+func feed(w *Worker, src string, n int) []Anomaly {
+	for i := 0; i < n; i++ {
+		w.Process(&models.Event{Source: src})
+	}
+	return w.tick()
+}
+
+func TestWorkerLearnsAndFlags(t *testing.T) {
+	w := NewWorker(nil)
+	defer w.Close(context.Background())
+
+	for i := 0; i < minHistory; i++ {
+		if a := feed(w, "firewall", 100+i%3); a != nil {
+			t.Fatalf("minute %d: anomalies = %v, want none while learning", i, a)
+		}
+	}
+	if a := feed(w, "firewall", 110); a != nil {
+		t.Errorf("anomalies = %v, want none for a normal minute", a)
+	}
+	a := feed(w, "firewall", 400)
+	if len(a) != 1 || a[0].Source != "firewall" || a[0].Count != 400 {
+		t.Errorf("anomalies = %v, want firewall at 400", a)
+	}
+
+	// Absolute limit applies without history
+	if a := feed(w, "new-source", highVolume+1); len(a) != 1 || a[0].Source != "new-source" {
+		t.Errorf("anomalies = %v, want new-source above the absolute limit", a)
+	}
+}
+
+func TestWorkerSilentMinutes(t *testing.T) {
+	w := NewWorker(nil)
+	defer w.Close(context.Background())
+
+	feed(w, "linux", 60)
+	w.tick()
+	s, _ := w.Baseline("linux")
+	if s.Minutes != 2 || s.Mean != 30 {
+		t.Errorf("Baseline() = %+v, want 2 minutes with mean 30", s)
+	}
+}
+
+func TestWorkerForgetsSilentSources(t *testing.T) {
+	w := NewWorker(nil)
+	defer w.Close(context.Background())
+
+	feed(w, "retired", 50)
+	for i := 0; i < maxIdle-1; i++ {
+		feed(w, "active", 1)
+	}
+	if s, ok := w.Baseline("retired"); !ok || s.Idle != maxIdle-1 {
+		t.Fatalf("Baseline(retired) = %+v, %v, want kept with %d idle minutes", s, ok, maxIdle-1)
+	}
+
+	feed(w, "active", 1)
+	if _, ok := w.Baseline("retired"); ok {
+		t.Errorf("Baseline(retired) kept after %d silent minutes", maxIdle)
+	}
+	if s, ok := w.Baseline("active"); !ok || s.Idle != 0 {
+		t.Errorf("Baseline(active) = %+v, %v, want kept and not idle", s, ok)
+	}
+	if n := len(w.snapshot()); n != 1 {
+		t.Errorf("snapshot() holds %d sources, want 1", n)
+	}
+
+	// A source that speaks again starts its idle count over
+	feed(w, "bursty", 5)
+	for i := 0; i < maxIdle-1; i++ {
+		w.tick()
+	}
+	feed(w, "bursty", 5)
+	if s, ok := w.Baseline("bursty"); !ok || s.Idle != 0 {
+		t.Errorf("Baseline(bursty) = %+v, %v, want kept with idle reset", s, ok)
+	}
+}
+
+func TestWorkerPersistsAcrossRestart(t *testing.T) {
+	ctx := context.Background()
+	store := NewRedisStore(&fakeRedis{keys: map[string]string{}})
+
+	before := NewWorker(store)
+	if n, err := before.Load(ctx); n != 0 || err != nil {
+		t.Fatalf("Load() on first run = %d, %v, want 0, nil", n, err)
+	}
+	for i := 0; i < minHistory; i++ {
+		feed(before, "firewall", 100)
+	}
+	want, _ := before.Baseline("firewall")
+	if err := before.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Simulated restart: the new worker picks up where the old one stopped
+	after := NewWorker(store)
+	defer after.Close(ctx)
+	if n, err := after.Load(ctx); n != 1 || err != nil {
+		t.Fatalf("Load() = %d, %v, want 1, nil", n, err)
+	}
+	if got, ok := after.Baseline("firewall"); !ok || got != want {
+		t.Errorf("Baseline() = %+v, want %+v", got, want)
+	}
+
+	// Detection continues without relearning
+	if a := feed(after, "firewall", 400); len(a) != 1 {
+		t.Errorf("anomalies after restart = %v, want firewall flagged", a)
+	}
+	fresh := NewWorker(nil)
+	defer fresh.Close(ctx)
+	if a := feed(fresh, "firewall", 400); a != nil {
+		t.Errorf("anomalies without history = %v, want none", a)
+	}
+}
+
+func TestWorkerStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	down := errors.New("connection refused")
+	w := NewWorker(NewRedisStore(&fakeRedis{keys: map[string]string{}, err: down}))
+
+	if _, err := w.Load(ctx); !errors.Is(err, down) {
+		t.Errorf("Load() error = %v, want %v", err, down)
+	}
+	feed(w, "firewall", 10)
+	if err := w.Close(ctx); !errors.Is(err, down) {
+		t.Errorf("Close() error = %v, want %v", err, down)
+	}
+	// Close is idempotent
+	if err := w.Close(ctx); !errors.Is(err, down) {
+		t.Errorf("second Close() error = %v, want %v", err, down)
+	}
+}
@@ -1,42 +1,61 @@
 package config
 
 import (
-	"os"
+	"sakin-go/pkg/settings"
 )
 
 type Config struct {
-	NatsURL      string
-	NatsUser     string
-	NatsPassword string
+	NatsURL       string
+	NatsUser      string
+	NatsPassword  string
+	NatsConsumers int // Concurrent pull subscribers sharing the durable consumer
 
-	ClickHouseAddr     string
+	ClickHouseAddr     settings.Address
 	ClickHouseDB       string
 	ClickHouseUser     string
 	ClickHousePassword string
 
 	BatchSize     int
 	FlushInterval int // Seconds
-}
 
-func LoadConfig() *Config {
-	return &Config{
-		NatsURL:      getEnv("NATS_URL", "nats://localhost:4222"),
-		NatsUser:     getEnv("NATS_USER", "admin"),
-		NatsPassword: getEnv("NATS_PASSWORD", "sakin123"),
+	// ClickHouse circuit breaker & dead-letter spill
+	BreakerThreshold    int    // Consecutive insert failures before opening
+	BreakerResetTimeout int    // Seconds before a trial insert
+	DeadLetterDir       string // Empty disables spilling
 
-		ClickHouseAddr:     getEnv("CLICKHOUSE_ADDR", "localhost:9000"),
-		ClickHouseDB:       getEnv("CLICKHOUSE_DB", "sge_logs"),
-		ClickHouseUser:     getEnv("CLICKHOUSE_USER", "default"),
-		ClickHousePassword: getEnv("CLICKHOUSE_PASSWORD", ""),
+	// Redis keeps the learned volume baselines across restarts
+	RedisAddr     settings.Address
+	RedisPassword string
+}
 
-		BatchSize:     5000,
-		FlushInterval: 5,
+// LoadConfig reads the environment (and SGE_CONFIG_FILE, if set) and
+// reports every invalid value at once.
+func LoadConfig() (*Config, error) {
+	l, err := settings.FromEnv()
+	if err != nil {
+		return nil, err
 	}
-}
 
-func getEnv(key, fallback string) string {
-	if val, ok := os.LookupEnv(key); ok {
-		return val
+	cfg := &Config{
+		NatsURL:       l.String("NATS_URL", "nats://localhost:4222"),
+		NatsUser:      l.String("NATS_USER", "admin"),
+		NatsPassword:  l.String("NATS_PASSWORD", "sakin123"),
+		NatsConsumers: l.Int("NATS_CONSUMERS", 1, 1),
+
+		ClickHouseAddr:     l.Address("CLICKHOUSE_ADDR", "localhost:9000", 9000),
+		ClickHouseDB:       l.String("CLICKHOUSE_DB", "sge_logs"),
+		ClickHouseUser:     l.String("CLICKHOUSE_USER", "default"),
+		ClickHousePassword: l.String("CLICKHOUSE_PASSWORD", ""),
+
+		BatchSize:     l.Int("ANALYTICS_BATCH_SIZE", 5000, 1),
+		FlushInterval: l.Int("ANALYTICS_FLUSH_INTERVAL", 5, 1),
+
+		BreakerThreshold:    l.Int("CLICKHOUSE_BREAKER_THRESHOLD", 5, 1),
+		BreakerResetTimeout: l.Int("CLICKHOUSE_BREAKER_RESET", 30, 1),
+		DeadLetterDir:       l.String("DEAD_LETTER_DIR", "./data/deadletter"),
+
+		RedisAddr:     l.Address("REDIS_ADDR", "localhost:6379", 6379),
+		RedisPassword: l.String("REDIS_PASSWORD", ""),
 	}
-	return fallback
+	return cfg, l.Err()
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"sakin-go/pkg/settings"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+	}{
+		{
+			name: "Defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ClickHouseAddr != (settings.Address{Host: "localhost", Port: 9000}) {
+					t.Errorf("ClickHouseAddr = %v, want localhost:9000", cfg.ClickHouseAddr)
+				}
+				if cfg.BatchSize != 5000 || cfg.FlushInterval != 5 || cfg.BreakerThreshold != 5 {
+					t.Errorf("defaults = %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "Host Without Port",
+			env:  map[string]string{"CLICKHOUSE_ADDR": "ch.internal"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.ClickHouseAddr.String() != "ch.internal:9000" {
+					t.Errorf("ClickHouseAddr = %v, want ch.internal:9000", cfg.ClickHouseAddr)
+				}
+			},
+		},
+		{
+			name:    "Malformed Address",
+			env:     map[string]string{"CLICKHOUSE_ADDR": "ch.internal:nine"},
+			wantErr: "CLICKHOUSE_ADDR: invalid address",
+		},
+		{
+			name:    "Invalid Batch Size",
+			env:     map[string]string{"ANALYTICS_BATCH_SIZE": "0"},
+			wantErr: "ANALYTICS_BATCH_SIZE: 0 is below the minimum 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(settings.FileEnv, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := LoadConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"os"
 	"os/signal"
@@ -20,50 +19,88 @@ import (
 )
 
 func main() {
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("[Analytics] Invalid configuration: %v", err)
+	}
 	log.Println("[Analytics] Starting SGE Analytics & Archival Service...")
 
 	// 1. ClickHouse
 	chCfg := &database.ClickHouseConfig{
-		Host: cfg.ClickHouseAddr, Port: 9000,
+		Host: cfg.ClickHouseAddr.Host, Port: cfg.ClickHouseAddr.Port,
 		Database: cfg.ClickHouseDB, Username: cfg.ClickHouseUser, Password: cfg.ClickHousePassword,
-	} // Need robust error handling here
-	// Assuming NewClickHouseClient handles "Host" parsing correctly or we split it properly.
-	// For now assume config matches library expectation.
+	}
 
 	chClient, err := database.NewClickHouseClient(chCfg)
 	if err != nil {
 		log.Printf("[Analytics] Warning: ClickHouse connect failed: %v", err)
+	} else if err := chClient.InitializeSchema(context.Background()); err != nil {
+		// Missing columns are added automatically; type drift needs manual migration
+		log.Fatalf("[Analytics] ClickHouse schema check failed: %v", err)
 	}
 
 	// 2. NATS
+	slo, err := messaging.SLOFromEnv()
+	if err != nil {
+		log.Fatalf("[Analytics] Invalid consumer SLO config: %v", err)
+	}
 	nc, err := messaging.NewClient(&messaging.NatsConfig{
 		URL: cfg.NatsURL, Username: cfg.NatsUser, Password: cfg.NatsPassword,
 		ReconnectWait: 2 * time.Second,
+		SLO:           slo,
 	})
 	if err != nil {
 		log.Fatalf("[Analytics] NATS Error: %v", err)
 	}
 	defer nc.Close()
+	if slo.HealthAddr != "" {
+		go func() {
+			if err := nc.ServeHealth(slo.HealthAddr); err != nil {
+				log.Printf("[Analytics] Health endpoint stopped: %v", err)
+			}
+		}()
+		log.Printf("[Analytics] Health endpoint (consumer SLO) on %s/health", slo.HealthAddr)
+	}
 
 	// 3. Components
-	var eventSink *sink.ClickHouseSink
+	var eventSink *sink.Sink
 	if chClient != nil {
-		eventSink = sink.NewClickHouseSink(cfg, chClient)
+		eventSink = sink.New(cfg, chClient)
 		defer eventSink.Close()
 	}
 
-	baWorker := baseline.NewWorker()
+	// Baselines survive restarts through Redis; without it they are relearned
+	var store baseline.Store
+	rdb, err := database.NewRedisClient(&database.RedisConfig{Addr: cfg.RedisAddr.String(), Password: cfg.RedisPassword})
+	if err != nil {
+		log.Printf("[Analytics] Warning: Redis connect failed, baselines will not persist: %v", err)
+	} else {
+		defer rdb.Close()
+		store = baseline.NewRedisStore(rdb)
+	}
+	baWorker := baseline.NewWorker(store)
+	if n, err := baWorker.Load(context.Background()); err != nil {
+		log.Printf("[Analytics] Warning: Baselines not restored: %v", err)
+	} else if n > 0 {
+		log.Printf("[Analytics] Restored baselines of %d sources", n)
+	}
 
 	// 4. Consume
 	// We listen to Enriched events to store the final state of the event
 	// 4. Consume
 	// We listen to Enriched events to store the final state of the event
-	_, err = nc.QueueSubscribe(context.Background(), messaging.StreamEvents, messaging.TopicEventsEnriched, messaging.ConsumerArchival, func(msg jetstream.Msg) {
+	_, err = nc.QueueSubscribeN(context.Background(), messaging.StreamEvents, messaging.TopicEventsEnriched, messaging.ConsumerArchival, cfg.NatsConsumers, func(msg jetstream.Msg) {
 		msg.Ack()
 
+		codec, err := messaging.MessageCodec(msg.Headers())
+		if err != nil {
+			log.Printf("[Analytics] Decode error: %v", err)
+			messaging.MarkFailed(msg)
+			return
+		}
 		var evt models.Event
-		if err := json.Unmarshal(msg.Data(), &evt); err != nil {
+		if err := codec.Unmarshal(msg.Data(), &evt); err != nil {
+			messaging.MarkFailed(msg)
 			return
 		}
 
@@ -87,4 +124,10 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 	log.Println("[Analytics] Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := baWorker.Close(ctx); err != nil {
+		log.Printf("[Analytics] Baseline save failed: %v", err)
+	}
 }
@@ -0,0 +1,143 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-analytics/config"
+	"sakin-go/internal/storage"
+	"sakin-go/pkg/database"
+	"sakin-go/pkg/models"
+)
+
+// Sink batches events into an EventStore (ClickHouse by default).
+type Sink struct {
+	store   database.EventStore
+	config  *config.Config
+	breaker *storage.CircuitBreaker
+	spill   *storage.DeadLetter // nil disables spilling
+	buffer  []*models.Event
+	mu      sync.Mutex
+	done    chan struct{}
+}
+
+// New creates a sink writing to store and starts its periodic flush.
+func New(cfg *config.Config, store database.EventStore) *Sink {
+	s := newSink(cfg, store)
+	go s.flushLoop()
+	return s
+}
+
+func newSink(cfg *config.Config, store database.EventStore) *Sink {
+	s := &Sink{
+		store:   store,
+		config:  cfg,
+		breaker: storage.NewCircuitBreaker(cfg.BreakerThreshold, time.Duration(cfg.BreakerResetTimeout)*time.Second),
+		buffer:  make([]*models.Event, 0, cfg.BatchSize),
+		done:    make(chan struct{}),
+	}
+	s.breaker.OnStateChange = func(from, to storage.State) {
+		log.Printf("[Sink] Store circuit %s -> %s", from, to)
+	}
+
+	if cfg.DeadLetterDir != "" {
+		spill, err := storage.NewDeadLetter(cfg.DeadLetterDir, "events")
+		if err != nil {
+			log.Printf("[Sink] Warning: dead-letter spill disabled: %v", err)
+		} else {
+			s.spill = spill
+		}
+	}
+	return s
+}
+
+// Write adds an event to the buffer.
+func (s *Sink) Write(evt *models.Event) {
+	s.mu.Lock()
+	s.buffer = append(s.buffer, evt)
+	shouldFlush := len(s.buffer) >= s.config.BatchSize
+	s.mu.Unlock()
+
+	if shouldFlush {
+		s.Flush() // Async or sync? Sync here to apply backpressure if DB is slow
+	}
+}
+
+// Flush forces a database write.
+// While the circuit is open the batch goes straight to the dead-letter spill.
+func (s *Sink) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buffer) == 0 {
+		return
+	}
+
+	// Copy buffer to free it up for incoming writes while previous batch is writing
+	// For simplicity in this non-concurrent write design (single buffer), we just write directly.
+	// To optimize further, we could swap buffers.
+
+	err := s.breaker.Execute(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return s.store.InsertEvents(ctx, s.buffer)
+	})
+	if err != nil {
+		if !errors.Is(err, storage.ErrCircuitOpen) {
+			log.Printf("[Sink] Insert Error: %v", err)
+		}
+		s.spillBuffer()
+	}
+
+	// efficient clear
+	// s.buffer = s.buffer[:0] // keep capacity
+	// But we passed slice reference to InsertEvents, if it's async inside client it's dangerous.
+	// database.InsertEvents copies or streams? It iterates.
+	// To be safe and let GC reclaim references:
+	clear(s.buffer) // Go 1.21+
+	s.buffer = s.buffer[:0]
+}
+
+// spillBuffer writes the current batch to the dead-letter spill. Caller holds s.mu.
+func (s *Sink) spillBuffer() {
+	if s.spill == nil {
+		log.Printf("[Sink] Dropping %d events (no dead-letter spill)", len(s.buffer))
+		return
+	}
+
+	records := make([]interface{}, len(s.buffer))
+	for i, evt := range s.buffer {
+		records[i] = evt
+	}
+	if err := s.spill.Spill(records); err != nil {
+		log.Printf("[Sink] Dead-letter spill failed, dropping %d events: %v", len(s.buffer), err)
+	}
+}
+
+func (s *Sink) flushLoop() {
+	ticker := time.NewTicker(time.Duration(s.config.FlushInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			s.Flush()
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+func (s *Sink) Close() {
+	close(s.done)
+}
+
+func clear(s []*models.Event) {
+	for i := range s {
+		s[i] = nil
+	}
+}
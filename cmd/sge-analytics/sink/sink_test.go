@@ -0,0 +1,148 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-analytics/config"
+	"sakin-go/internal/storage"
+	"sakin-go/pkg/models"
+)
+
+// fakeStore records the IDs of each inserted batch and fails inserts
+// while down is set.
+type fakeStore struct {
+	down     bool
+	calls    int
+	inserted int
+	batches  [][]string
+}
+
+func (f *fakeStore) InsertEvents(ctx context.Context, events []*models.Event) error {
+	f.calls++
+	if f.down {
+		return errors.New("connection refused")
+	}
+	f.inserted += len(events)
+	ids := make([]string, len(events))
+	for i, evt := range events {
+		ids[i] = evt.ID
+	}
+	f.batches = append(f.batches, ids)
+	return nil
+}
+
+func (f *fakeStore) InsertFlows(ctx context.Context, flows []map[string]interface{}) error {
+	return errors.New("not used by the sink")
+}
+
+func (f *fakeStore) CountEvents(ctx context.Context, since time.Time) (uint64, error) {
+	return uint64(f.inserted), nil
+}
+
+func countLines(t *testing.T, dir string) int {
+	t.Helper()
+	files, _ := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	n := 0
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("open %s: %v", path, err)
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			n++
+		}
+		f.Close()
+	}
+	return n
+}
+
+func TestSinkWritesBatches(t *testing.T) {
+	store := &fakeStore{}
+	s := newSink(&config.Config{BatchSize: 3, FlushInterval: 60}, store)
+
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		s.Write(&models.Event{ID: id})
+	}
+	s.Flush()
+	s.Flush() // Empty buffer, no insert
+
+	want := [][]string{{"a", "b", "c"}, {"d", "e", "f"}, {"g"}}
+	if !reflect.DeepEqual(store.batches, want) {
+		t.Errorf("batches = %v, want %v", store.batches, want)
+	}
+}
+
+func TestSinkCircuitBreaker(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		BatchSize:           2,
+		FlushInterval:       60,
+		BreakerThreshold:    3,
+		BreakerResetTimeout: 0, // Trial immediately on the next flush
+		DeadLetterDir:       dir,
+	}
+	ch := &fakeStore{down: true}
+	s := newSink(cfg, ch)
+
+	writeBatch := func() {
+		s.Write(&models.Event{ID: "a"})
+		s.Write(&models.Event{ID: "b"})
+	}
+
+	// 1. Breaker opens after 3 failed inserts, each batch is spilled
+	for i := 0; i < 3; i++ {
+		writeBatch()
+	}
+	if s.breaker.State() != storage.StateOpen {
+		t.Fatalf("breaker = %v after 3 failures, want open", s.breaker.State())
+	}
+	if got := countLines(t, dir); got != 6 {
+		t.Errorf("spilled = %d events, want 6", got)
+	}
+
+	// 2. Recovers once ClickHouse is back
+	ch.down = false
+	writeBatch()
+	if s.breaker.State() != storage.StateClosed {
+		t.Errorf("breaker = %v after recovery, want closed", s.breaker.State())
+	}
+	if ch.inserted != 2 {
+		t.Errorf("inserted = %d, want 2", ch.inserted)
+	}
+	if got := countLines(t, dir); got != 6 {
+		t.Errorf("spilled = %d events after recovery, want 6", got)
+	}
+}
+
+func TestSinkFastFailsWhileOpen(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		BatchSize:           1,
+		FlushInterval:       60,
+		BreakerThreshold:    2,
+		BreakerResetTimeout: 3600,
+		DeadLetterDir:       dir,
+	}
+	ch := &fakeStore{down: true}
+	s := newSink(cfg, ch)
+
+	for i := 0; i < 5; i++ {
+		s.Write(&models.Event{ID: "x"})
+	}
+
+	// Only the first 2 flushes reach ClickHouse, the rest go straight to spill
+	if ch.calls != 2 {
+		t.Errorf("InsertEvents calls = %d, want 2", ch.calls)
+	}
+	if got := countLines(t, dir); got != 5 {
+		t.Errorf("spilled = %d events, want 5", got)
+	}
+}
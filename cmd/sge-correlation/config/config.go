@@ -1,42 +1,45 @@
 package config
 
 import (
-	"os"
+	"sakin-go/pkg/settings"
 )
 
 type Config struct {
-	NatsURL      string
-	NatsUser     string
-	NatsPassword string
+	NatsURL       string
+	NatsUser      string
+	NatsPassword  string
+	NatsConsumers int // Concurrent pull subscribers sharing the durable consumer
 
-	RedisAddr     string
+	RedisAddr     settings.Address
 	RedisPassword string
 
-	PostgresAddr     string
+	PostgresAddr     settings.Address
 	PostgresUser     string
 	PostgresPassword string
 	PostgresDB       string
 }
 
-func LoadConfig() *Config {
-	return &Config{
-		NatsURL:      getEnv("NATS_URL", "nats://localhost:4222"),
-		NatsUser:     getEnv("NATS_USER", "admin"),
-		NatsPassword: getEnv("NATS_PASSWORD", "sakin123"),
+// LoadConfig reads the environment (and SGE_CONFIG_FILE, if set) and
+// reports every invalid value at once.
+func LoadConfig() (*Config, error) {
+	l, err := settings.FromEnv()
+	if err != nil {
+		return nil, err
+	}
 
-		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+	cfg := &Config{
+		NatsURL:       l.String("NATS_URL", "nats://localhost:4222"),
+		NatsUser:      l.String("NATS_USER", "admin"),
+		NatsPassword:  l.String("NATS_PASSWORD", "sakin123"),
+		NatsConsumers: l.Int("NATS_CONSUMERS", 1, 1),
 
-		PostgresAddr:     getEnv("POSTGRES_ADDR", "localhost:5432"),
-		PostgresUser:     getEnv("POSTGRES_USER", "postgres"),
-		PostgresPassword: getEnv("POSTGRES_PASSWORD", "sakin123"),
-		PostgresDB:       getEnv("POSTGRES_DB", "sge_db"),
-	}
-}
+		RedisAddr:     l.Address("REDIS_ADDR", "localhost:6379", 6379),
+		RedisPassword: l.String("REDIS_PASSWORD", ""),
 
-func getEnv(key, fallback string) string {
-	if val, ok := os.LookupEnv(key); ok {
-		return val
+		PostgresAddr:     l.Address("POSTGRES_ADDR", "localhost:5432", 5432),
+		PostgresUser:     l.String("POSTGRES_USER", "postgres"),
+		PostgresPassword: l.String("POSTGRES_PASSWORD", "sakin123"),
+		PostgresDB:       l.String("POSTGRES_DB", "sge_db"),
 	}
-	return fallback
+	return cfg, l.Err()
 }
@@ -21,13 +21,16 @@ import (
 
 func main() {
 	// 1. Config
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("[Correlation] Invalid configuration: %v", err)
+	}
 	log.Println("[Correlation] Starting SGE Correlation Engine...")
 
 	// 2. Database Clients
 	pgCfg := &database.PostgresConfig{
-		Host:     cfg.PostgresAddr, // Needs port splitting logic
-		Port:     5432,
+		Host:     cfg.PostgresAddr.Host,
+		Port:     cfg.PostgresAddr.Port,
 		Username: cfg.PostgresUser,
 		Password: cfg.PostgresPassword,
 		Database: cfg.PostgresDB,
@@ -37,17 +40,35 @@ func main() {
 	// assuming connected for now
 
 	// 3. NATS
+	retention, err := messaging.RetentionFromEnv()
+	if err != nil {
+		log.Fatalf("[Correlation] Invalid NATS retention config: %v", err)
+	}
+	slo, err := messaging.SLOFromEnv()
+	if err != nil {
+		log.Fatalf("[Correlation] Invalid consumer SLO config: %v", err)
+	}
 	natsConfig := &messaging.NatsConfig{
 		URL:           cfg.NatsURL,
 		Username:      cfg.NatsUser,
 		Password:      cfg.NatsPassword,
 		ReconnectWait: 2 * time.Second,
+		Retention:     retention,
+		SLO:           slo,
 	}
 	nc, err := messaging.NewClient(natsConfig)
 	if err != nil {
 		log.Fatalf("[Correlation] NATS Error: %v", err)
 	}
 	defer nc.Close()
+	if slo.HealthAddr != "" {
+		go func() {
+			if err := nc.ServeHealth(slo.HealthAddr); err != nil {
+				log.Printf("[Correlation] Health endpoint stopped: %v", err)
+			}
+		}()
+		log.Printf("[Correlation] Health endpoint (consumer SLO) on %s/health", slo.HealthAddr)
+	}
 
 	// 4. Rule Engine
 	eng := engine.NewEngine()
@@ -63,42 +84,59 @@ func main() {
 
 	// 5. Consumption Loop
 	// Queue Subscribe ensures load balancing if multiple correlation instances run
-	_, err = nc.QueueSubscribe(context.Background(), messaging.StreamEvents, messaging.TopicEventsRaw, messaging.ConsumerCorrelation, func(msg jetstream.Msg) {
+	_, err = nc.QueueSubscribeN(context.Background(), messaging.StreamEvents, messaging.TopicEventsRaw, messaging.ConsumerCorrelation, cfg.NatsConsumers, func(msg jetstream.Msg) {
 		// Ack immediately or manual? Manual is safer.
 		msg.Ack()
 
-		var evt models.Event
-		if err := json.Unmarshal(msg.Data(), &evt); err != nil {
-			log.Printf("[Correlation] Unmarshal error: %v", err)
+		// Agents may publish batched (and gzip compressed) messages
+		payloads, err := messaging.DecodeBatch(msg.Headers(), msg.Data())
+		if err != nil {
+			log.Printf("[Correlation] Decode error: %v", err)
+			messaging.MarkFailed(msg)
+			return
+		}
+		codec, err := messaging.MessageCodec(msg.Headers())
+		if err != nil {
+			log.Printf("[Correlation] Decode error: %v", err)
+			messaging.MarkFailed(msg)
 			return
 		}
 
-		// Evaluate
-		matchedRules := eng.Evaluate(&evt)
-		if len(matchedRules) > 0 {
-			for _, r := range matchedRules {
-				// Raise Alert
-				alert := models.Alert{
-					ID:        utils.GenerateID(),
-					RuleID:    r.ID,
-					Title:     r.Name,
-					Severity:  r.Severity,
-					Status:    models.AlertStatusNew,
-					CreatedAt: time.Now().UTC(),
-					EventIDs:  []string{evt.ID},
-				}
-
-				// Publish Alert
-				alertBytes, _ := json.Marshal(alert)
-				subject := messaging.TopicAlerts + string(alert.Severity) + "." + r.ID
-				nc.PublishAsync(context.Background(), subject, alertBytes)
-
-				// Save to DB (Async optimized)
-				go func(a models.Alert) {
-					// pg.CreateAlert(context.Background(), &a) // Implement in pkg/database
-				}(alert)
+		for _, data := range payloads {
+			var evt models.Event
+			if err := codec.Unmarshal(data, &evt); err != nil {
+				log.Printf("[Correlation] Unmarshal error: %v", err)
+				messaging.MarkFailed(msg)
+				continue
+			}
 
-				log.Printf("[Correlation] 🚨 ALERT Generated: %s (Rule: %s)", alert.Title, r.Name)
+			// Evaluate
+			matchedRules := eng.Evaluate(&evt)
+			if len(matchedRules) > 0 {
+				for _, r := range matchedRules {
+					// Raise Alert
+					alert := models.Alert{
+						ID:        utils.GenerateID(),
+						RuleID:    r.ID,
+						Title:     r.Name,
+						Severity:  r.Severity,
+						Status:    models.AlertStatusNew,
+						CreatedAt: time.Now().UTC(),
+						EventIDs:  []string{evt.ID},
+					}
+
+					// Publish Alert
+					alertBytes, _ := json.Marshal(alert)
+					subject := messaging.SafeSubject(messaging.TopicAlerts, string(alert.Severity), r.ID)
+					nc.PublishAsync(context.Background(), subject, alertBytes)
+
+					// Save to DB (Async optimized)
+					go func(a models.Alert) {
+						// pg.CreateAlert(context.Background(), &a) // Implement in pkg/database
+					}(alert)
+
+					log.Printf("[Correlation] 🚨 ALERT Generated: %s (Rule: %s)", alert.Title, r.Name)
+				}
 			}
 		}
 
@@ -0,0 +1,154 @@
+// Package backfill gives threat events the context analysts triage them
+// with: the geolocation of the source and the asset under attack. Regular
+// events get geo from the main enrichment path when available; threats
+// are completed here whatever that path skipped.
+package backfill
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-enrichment/criticality"
+	"sakin-go/cmd/sge-enrichment/geoip"
+	"sakin-go/pkg/models"
+)
+
+// Enrichment fields set by Apply.
+const (
+	FieldSrcGeoCountry = "src_geo_country"
+	FieldSrcGeoCity    = "src_geo_city"
+	FieldSrcGeoISO     = "src_geo_iso"
+	FieldSrcGeoLat     = "src_geo_lat"
+	FieldSrcGeoLon     = "src_geo_lon"
+
+	FieldDestAssetID          = "dest_asset_id"
+	FieldDestAssetName        = "dest_asset_name"
+	FieldDestAssetCriticality = criticality.FieldCriticality
+)
+
+// TagThreat marks an event as a threat whatever its type.
+const TagThreat = "threat"
+
+// Geo resolves IP locations (see geoip.Provider).
+type Geo interface {
+	Lookup(ip string) *geoip.Location
+}
+
+// Source lists assets by IP (see database.PostgresClient.Assets).
+type Source interface {
+	Assets(ctx context.Context) (map[string]models.Asset, error)
+}
+
+// Backfiller completes threat events. The asset list is held in memory
+// and refreshed periodically, like criticality.Booster. It is safe for
+// concurrent use.
+type Backfiller struct {
+	geo    Geo    // nil skips geolocation
+	source Source // nil skips assets
+	types  map[string]bool
+
+	mu     sync.RWMutex
+	assets map[string]models.Asset // IP -> asset
+}
+
+// New creates a backfiller for events whose type is in types or that are
+// tagged TagThreat.
+func New(geo Geo, source Source, types []string) *Backfiller {
+	b := &Backfiller{geo: geo, source: source, types: make(map[string]bool, len(types)), assets: make(map[string]models.Asset)}
+	for _, t := range types {
+		b.types[strings.TrimSpace(t)] = true
+	}
+	return b
+}
+
+// IsThreat reports whether evt is backfilled.
+func (b *Backfiller) IsThreat(evt *models.Event) bool {
+	if b.types[evt.EventType] {
+		return true
+	}
+	for _, tag := range evt.Tags {
+		if tag == TagThreat {
+			return true
+		}
+	}
+	return false
+}
+
+// Refresh reloads the asset list from the source.
+func (b *Backfiller) Refresh(ctx context.Context) error {
+	if b.source == nil {
+		return nil
+	}
+	assets, err := b.source.Assets(ctx)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.assets = assets
+	b.mu.Unlock()
+	return nil
+}
+
+// Watch refreshes the asset list every interval until ctx is done. On
+// failure the previous list stays in use.
+func (b *Backfiller) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Refresh(ctx); err != nil {
+				log.Printf("[Backfill] Asset refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// Apply adds the source location and destination asset to a threat
+// event, keeping fields that are already set. It reports whether
+// anything was added; non-threat events are left alone.
+func (b *Backfiller) Apply(evt *models.Event) bool {
+	if !b.IsThreat(evt) {
+		return false
+	}
+	added := false
+	set := func(field string, v interface{}) {
+		if evt.Enrichment == nil {
+			evt.Enrichment = make(map[string]interface{})
+		}
+		if _, ok := evt.Enrichment[field]; !ok {
+			evt.Enrichment[field] = v
+			added = true
+		}
+	}
+
+	if b.geo != nil && evt.SourceIP != "" {
+		if loc := b.geo.Lookup(evt.SourceIP); loc != nil {
+			set(FieldSrcGeoCountry, loc.Country)
+			set(FieldSrcGeoCity, loc.City)
+			set(FieldSrcGeoISO, loc.ISO)
+			set(FieldSrcGeoLat, loc.Lat)
+			set(FieldSrcGeoLon, loc.Lon)
+		}
+	}
+
+	if evt.DestIP != "" {
+		b.mu.RLock()
+		asset, ok := b.assets[evt.DestIP]
+		b.mu.RUnlock()
+		if ok {
+			set(FieldDestAssetID, asset.ID)
+			set(FieldDestAssetName, asset.Name)
+			if asset.Criticality != "" {
+				set(FieldDestAssetCriticality, strings.ToLower(asset.Criticality))
+			}
+		}
+	}
+	return added
+}
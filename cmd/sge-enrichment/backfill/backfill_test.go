@@ -0,0 +1,161 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"sakin-go/cmd/sge-enrichment/geoip"
+	"sakin-go/pkg/models"
+)
+
+type fakeGeo map[string]*geoip.Location
+
+func (f fakeGeo) Lookup(ip string) *geoip.Location { return f[ip] }
+
+type fakeAssets struct {
+	assets map[string]models.Asset
+	err    error
+}
+
+func (f *fakeAssets) Assets(context.Context) (map[string]models.Asset, error) {
+	return f.assets, f.err
+}
+
+func newTestBackfiller(t *testing.T) *Backfiller {
+	t.Helper()
+	geo := fakeGeo{"203.0.113.50": {Country: "Netherlands", City: "Amsterdam", ISO: "NL", Lat: 52.37, Lon: 4.89}}
+	src := &fakeAssets{assets: map[string]models.Asset{
+		"10.0.0.10": {ID: "42", Name: "dc01", IPAddress: "10.0.0.10", Criticality: "Critical"},
+	}}
+	b := New(geo, src, []string{models.EventTypeNetworkThreat})
+	if err := b.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	return b
+}
+
+// portScanThreat is a port scan as a sensor reports it.
+func portScanThreat() *models.Event {
+	return &models.Event{
+		Source:    "network-sensor",
+		EventType: models.EventTypeNetworkThreat,
+		SourceIP:  "203.0.113.50",
+		DestIP:    "10.0.0.10",
+		Severity:  models.SeverityMedium,
+		Metadata:  map[string]interface{}{"threat_type": "port_scan", "ports": 25},
+	}
+}
+
+func TestApplyThreat(t *testing.T) {
+	b := newTestBackfiller(t)
+	evt := portScanThreat()
+	if !b.Apply(evt) {
+		t.Fatal("Apply() = false, want threat backfilled")
+	}
+
+	want := map[string]interface{}{
+		FieldSrcGeoCountry:        "Netherlands",
+		FieldSrcGeoCity:           "Amsterdam",
+		FieldSrcGeoISO:            "NL",
+		FieldSrcGeoLat:            52.37,
+		FieldSrcGeoLon:            4.89,
+		FieldDestAssetID:          "42",
+		FieldDestAssetName:        "dc01",
+		FieldDestAssetCriticality: "critical",
+	}
+	for field, v := range want {
+		if got := evt.Enrichment[field]; got != v {
+			t.Errorf("Enrichment[%s] = %v, want %v", field, got, v)
+		}
+	}
+	if evt.Severity != models.SeverityMedium {
+		t.Errorf("Severity = %s, backfill must not change it", evt.Severity)
+	}
+}
+
+// TestApplySensorThreat backfills a network.threat event as the network
+// sensor publishes it (testdata/network_threat.json, a live port scan).
+func TestApplySensorThreat(t *testing.T) {
+	data, err := os.ReadFile("testdata/network_threat.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evt models.Event
+	if err := json.Unmarshal(data, &evt); err != nil {
+		t.Fatalf("decode sensor threat: %v", err)
+	}
+
+	b := newTestBackfiller(t)
+	if !b.Apply(&evt) {
+		t.Fatal("Apply() = false, want the sensor threat backfilled")
+	}
+	if evt.Enrichment[FieldSrcGeoCountry] != "Netherlands" || evt.Enrichment[FieldDestAssetName] != "dc01" || evt.Enrichment[FieldDestAssetCriticality] != "critical" {
+		t.Errorf("Enrichment = %v, want source geo and target asset", evt.Enrichment)
+	}
+}
+
+func TestApplyKeepsExisting(t *testing.T) {
+	b := newTestBackfiller(t)
+	evt := portScanThreat()
+	// The main path already resolved the country (e.g. with a newer DB)
+	evt.Enrichment = map[string]interface{}{FieldSrcGeoCountry: "Kingdom of the Netherlands"}
+	b.Apply(evt)
+
+	if got := evt.Enrichment[FieldSrcGeoCountry]; got != "Kingdom of the Netherlands" {
+		t.Errorf("Enrichment[%s] = %v, want the existing value", FieldSrcGeoCountry, got)
+	}
+	if evt.Enrichment[FieldSrcGeoLat] != 52.37 || evt.Enrichment[FieldDestAssetName] != "dc01" {
+		t.Errorf("Enrichment = %v, want missing fields added", evt.Enrichment)
+	}
+	if b.Apply(evt) {
+		t.Error("second Apply() = true, want nothing left to add")
+	}
+}
+
+func TestApplySelectsThreats(t *testing.T) {
+	b := newTestBackfiller(t)
+
+	tests := []struct {
+		name string
+		evt  *models.Event
+		want bool
+	}{
+		{"Threat Type", portScanThreat(), true},
+		{"Threat Tag", &models.Event{EventType: "auth.login", SourceIP: "203.0.113.50", Tags: []string{"auth", TagThreat}}, true},
+		{"Regular Event", &models.Event{EventType: "auth.login", SourceIP: "203.0.113.50", DestIP: "10.0.0.10"}, false},
+		{"Unknown Endpoints", &models.Event{EventType: models.EventTypeNetworkThreat, SourceIP: "10.0.0.5", DestIP: "10.0.0.99"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Apply(tt.evt); got != tt.want {
+				t.Errorf("Apply() = %v, want %v (enrichment %v)", got, tt.want, tt.evt.Enrichment)
+			}
+		})
+	}
+}
+
+func TestApplyWithoutSources(t *testing.T) {
+	b := New(nil, nil, []string{models.EventTypeNetworkThreat})
+	if err := b.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if b.Apply(portScanThreat()) {
+		t.Error("Apply() = true without geo or assets")
+	}
+}
+
+func TestRefreshFailureKeepsAssets(t *testing.T) {
+	b := newTestBackfiller(t)
+	b.source = &fakeAssets{err: errors.New("connection refused")}
+	if err := b.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh() expected error")
+	}
+	evt := portScanThreat()
+	b.Apply(evt)
+	if evt.Enrichment[FieldDestAssetName] != "dc01" {
+		t.Errorf("Enrichment[%s] = %v, want previous asset list kept", FieldDestAssetName, evt.Enrichment[FieldDestAssetName])
+	}
+}
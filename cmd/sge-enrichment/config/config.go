@@ -1,40 +1,101 @@
 package config
 
 import (
-	"os"
+	"strings"
+	"time"
+
+	"sakin-go/pkg/models"
+	"sakin-go/pkg/settings"
 )
 
 type Config struct {
-	NatsURL      string
-	NatsUser     string
-	NatsPassword string
+	NatsURL       string
+	NatsUser      string
+	NatsPassword  string
+	NatsConsumers int // Concurrent pull subscribers sharing the durable consumer
 
-	RedisAddr     string
+	RedisAddr     settings.Address
 	RedisPassword string
 
 	AbuseIPDBKey string
 	OTXKey       string
 	MaxMindPath  string
+
+	// How often the GeoIP DB file is checked for updates (seconds, 0 = never)
+	MaxMindReloadInterval int
+
+	// Reverse DNS (PTR) hostnames, opt-in: every uncached IP is a query
+	RDNSEnabled       bool
+	RDNSCacheSize     int
+	RDNSCacheTTL      time.Duration
+	RDNSNegativeTTL   time.Duration // How long failed lookups are remembered
+	RDNSMaxConcurrent int
+	RDNSTimeout       time.Duration
+
+	// Source geo and destination asset backfilled onto threat events
+	ThreatBackfill   bool
+	ThreatEventTypes []string // Event types treated as threats (besides the "threat" tag)
+
+	// Severity boost for events targeting critical assets (assets table)
+	CriticalityEnabled bool
+	CriticalityBoost   string        // "level=tiers,..." e.g. "critical=1"
+	CriticalityRefresh time.Duration // How often the asset list is reloaded
+	PostgresAddr       settings.Address
+	PostgresUser       string
+	PostgresPassword   string
+	PostgresDB         string
 }
 
-func LoadConfig() *Config {
-	return &Config{
-		NatsURL:      getEnv("NATS_URL", "nats://localhost:4222"),
-		NatsUser:     getEnv("NATS_USER", "admin"),
-		NatsPassword: getEnv("NATS_PASSWORD", "sakin123"),
+// LoadConfig reads the environment (and SGE_CONFIG_FILE, if set) and
+// reports every invalid value at once.
+func LoadConfig() (*Config, error) {
+	l, err := settings.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		NatsURL:       l.String("NATS_URL", "nats://localhost:4222"),
+		NatsUser:      l.String("NATS_USER", "admin"),
+		NatsPassword:  l.String("NATS_PASSWORD", "sakin123"),
+		NatsConsumers: l.Int("NATS_CONSUMERS", 1, 1),
+
+		RedisAddr:     l.Address("REDIS_ADDR", "localhost:6379", 6379),
+		RedisPassword: l.String("REDIS_PASSWORD", ""),
+
+		AbuseIPDBKey: l.String("ABUSEIPDB_KEY", ""),
+		OTXKey:       l.String("OTX_KEY", ""),
+		MaxMindPath:  l.String("MAXMIND_DB_PATH", "./GeoLite2-City.mmdb"),
+
+		MaxMindReloadInterval: l.Int("MAXMIND_RELOAD_INTERVAL", 300, 0),
+
+		RDNSEnabled:       l.Bool("RDNS_ENABLED", false),
+		RDNSCacheSize:     l.Int("RDNS_CACHE_SIZE", 10000, 1),
+		RDNSCacheTTL:      l.Duration("RDNS_CACHE_TTL", time.Hour, time.Second),
+		RDNSNegativeTTL:   l.Duration("RDNS_NEGATIVE_TTL", 5*time.Minute, time.Second),
+		RDNSMaxConcurrent: l.Int("RDNS_MAX_CONCURRENT", 8, 1),
+		RDNSTimeout:       l.Duration("RDNS_TIMEOUT", 500*time.Millisecond, time.Millisecond),
 
-		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+		ThreatBackfill:   l.Bool("THREAT_BACKFILL_ENABLED", true),
+		ThreatEventTypes: splitList(l.String("THREAT_EVENT_TYPES", models.EventTypeNetworkThreat)),
 
-		AbuseIPDBKey: getEnv("ABUSEIPDB_KEY", ""),
-		OTXKey:       getEnv("OTX_KEY", ""),
-		MaxMindPath:  getEnv("MAXMIND_DB_PATH", "./GeoLite2-City.mmdb"),
+		CriticalityEnabled: l.Bool("ASSET_CRITICALITY_ENABLED", false),
+		CriticalityBoost:   l.String("ASSET_CRITICALITY_BOOST", "critical=1"),
+		CriticalityRefresh: l.Duration("ASSET_CRITICALITY_REFRESH", 5*time.Minute, time.Second),
+		PostgresAddr:       l.Address("POSTGRES_ADDR", "localhost:5432", 5432),
+		PostgresUser:       l.String("POSTGRES_USER", "postgres"),
+		PostgresPassword:   l.String("POSTGRES_PASSWORD", "sakin123"),
+		PostgresDB:         l.String("POSTGRES_DB", "sge_db"),
 	}
+	return cfg, l.Err()
 }
 
-func getEnv(key, fallback string) string {
-	if val, ok := os.LookupEnv(key); ok {
-		return val
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
 	}
-	return fallback
+	return out
 }
@@ -0,0 +1,148 @@
+// Package criticality escalates the severity of events that target
+// high-value assets, using the criticality recorded in the assets table.
+package criticality
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+// Enrichment fields set by Apply.
+const (
+	FieldCriticality   = "dest_asset_criticality"
+	FieldBoostedFrom   = "severity_boosted_from"
+	defaultCriticality = "normal"
+)
+
+// severities in ascending order; boosting moves up this list.
+var severities = []models.Severity{
+	models.SeverityInfo,
+	models.SeverityLow,
+	models.SeverityMedium,
+	models.SeverityHigh,
+	models.SeverityCritical,
+}
+
+// Source lists asset criticality by IP (see
+// database.PostgresClient.AssetCriticality).
+type Source interface {
+	AssetCriticality(ctx context.Context) (map[string]string, error)
+}
+
+// Booster raises event severity by a number of tiers per destination
+// asset criticality. The asset list is held in memory and refreshed
+// periodically, so events never wait on the database. It is safe for
+// concurrent use.
+type Booster struct {
+	source Source
+	tiers  map[string]int // Criticality -> severity tiers added
+
+	mu     sync.RWMutex
+	assets map[string]string // IP -> criticality
+}
+
+// NewBooster creates a booster; tiers maps criticality levels to the
+// number of severity tiers added (see ParseTiers).
+func NewBooster(source Source, tiers map[string]int) *Booster {
+	return &Booster{source: source, tiers: tiers, assets: make(map[string]string)}
+}
+
+// ParseTiers parses "critical=1,high=1" style boosts.
+func ParseTiers(s string) (map[string]int, error) {
+	tiers := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		level, n, ok := strings.Cut(part, "=")
+		tier, err := strconv.Atoi(strings.TrimSpace(n))
+		if !ok || err != nil || tier < 0 {
+			return nil, fmt.Errorf("criticality boost %q: want level=tiers", part)
+		}
+		tiers[strings.ToLower(strings.TrimSpace(level))] = tier
+	}
+	return tiers, nil
+}
+
+// Refresh reloads the asset list from the source.
+func (b *Booster) Refresh(ctx context.Context) error {
+	assets, err := b.source.AssetCriticality(ctx)
+	if err != nil {
+		return err
+	}
+	for ip, c := range assets {
+		assets[ip] = strings.ToLower(c)
+	}
+	b.mu.Lock()
+	b.assets = assets
+	b.mu.Unlock()
+	return nil
+}
+
+// Watch refreshes the asset list every interval until ctx is done. On
+// failure the previous list stays in use.
+func (b *Booster) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Refresh(ctx); err != nil {
+				log.Printf("[Criticality] Asset refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// Apply records the destination asset's criticality on evt and raises its
+// severity by the configured tiers, capped at critical. It reports whether
+// the severity changed.
+func (b *Booster) Apply(evt *models.Event) bool {
+	if evt.DestIP == "" {
+		return false
+	}
+	b.mu.RLock()
+	criticality, ok := b.assets[evt.DestIP]
+	b.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	if criticality == "" {
+		criticality = defaultCriticality
+	}
+
+	if evt.Enrichment == nil {
+		evt.Enrichment = make(map[string]interface{})
+	}
+	evt.Enrichment[FieldCriticality] = criticality
+
+	boosted := Boost(evt.Severity, b.tiers[criticality])
+	if boosted == evt.Severity {
+		return false
+	}
+	evt.Enrichment[FieldBoostedFrom] = string(evt.Severity)
+	evt.Severity = boosted
+	return true
+}
+
+// Boost returns sev raised by tiers, capped at critical. Unknown
+// severities are returned unchanged.
+func Boost(sev models.Severity, tiers int) models.Severity {
+	for i, s := range severities {
+		if s == sev {
+			return severities[min(i+tiers, len(severities)-1)]
+		}
+	}
+	return sev
+}
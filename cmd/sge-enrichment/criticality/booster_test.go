@@ -0,0 +1,120 @@
+package criticality
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sakin-go/pkg/models"
+)
+
+type fakeAssets struct {
+	assets map[string]string
+	err    error
+}
+
+func (f *fakeAssets) AssetCriticality(context.Context) (map[string]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := make(map[string]string, len(f.assets))
+	for ip, c := range f.assets {
+		out[ip] = c
+	}
+	return out, nil
+}
+
+func newTestBooster(t *testing.T, src *fakeAssets) *Booster {
+	t.Helper()
+	tiers, err := ParseTiers("critical=1")
+	if err != nil {
+		t.Fatalf("ParseTiers() error = %v", err)
+	}
+	b := NewBooster(src, tiers)
+	if err := b.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	return b
+}
+
+func TestApply(t *testing.T) {
+	b := newTestBooster(t, &fakeAssets{assets: map[string]string{
+		"10.0.0.10": "critical",
+		"10.0.0.20": "normal",
+		"10.0.0.30": "Critical",
+	}})
+
+	tests := []struct {
+		name        string
+		dst         string
+		severity    models.Severity
+		want        models.Severity
+		criticality interface{}
+	}{
+		{"Medium Against Critical", "10.0.0.10", models.SeverityMedium, models.SeverityHigh, "critical"},
+		{"Medium Against Normal", "10.0.0.20", models.SeverityMedium, models.SeverityMedium, "normal"},
+		{"Capped At Critical", "10.0.0.10", models.SeverityCritical, models.SeverityCritical, "critical"},
+		{"Case Insensitive", "10.0.0.30", models.SeverityLow, models.SeverityMedium, "critical"},
+		{"Unknown Asset", "10.0.0.99", models.SeverityMedium, models.SeverityMedium, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt := &models.Event{DestIP: tt.dst, Severity: tt.severity}
+			changed := b.Apply(evt)
+			if evt.Severity != tt.want || changed != (tt.want != tt.severity) {
+				t.Errorf("Apply() = %v (changed %v), want %s", evt.Severity, changed, tt.want)
+			}
+			if got := evt.Enrichment[FieldCriticality]; got != tt.criticality {
+				t.Errorf("Enrichment[%s] = %v, want %v", FieldCriticality, got, tt.criticality)
+			}
+			if changed && evt.Enrichment[FieldBoostedFrom] != string(tt.severity) {
+				t.Errorf("Enrichment[%s] = %v, want %s", FieldBoostedFrom, evt.Enrichment[FieldBoostedFrom], tt.severity)
+			}
+		})
+	}
+}
+
+func TestRefreshFailureKeepsAssets(t *testing.T) {
+	src := &fakeAssets{assets: map[string]string{"10.0.0.10": "critical"}}
+	b := newTestBooster(t, src)
+
+	src.err = errors.New("connection refused")
+	if err := b.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh() error = nil")
+	}
+	evt := &models.Event{DestIP: "10.0.0.10", Severity: models.SeverityMedium}
+	if !b.Apply(evt) || evt.Severity != models.SeverityHigh {
+		t.Errorf("Apply() = %s, want high from the previous asset list", evt.Severity)
+	}
+}
+
+func TestBoost(t *testing.T) {
+	tests := []struct {
+		sev   models.Severity
+		tiers int
+		want  models.Severity
+	}{
+		{models.SeverityInfo, 1, models.SeverityLow},
+		{models.SeverityMedium, 2, models.SeverityCritical},
+		{models.SeverityHigh, 5, models.SeverityCritical},
+		{models.SeverityMedium, 0, models.SeverityMedium},
+		{"unknown", 1, "unknown"},
+	}
+	for _, tt := range tests {
+		if got := Boost(tt.sev, tt.tiers); got != tt.want {
+			t.Errorf("Boost(%s, %d) = %s, want %s", tt.sev, tt.tiers, got, tt.want)
+		}
+	}
+}
+
+func TestParseTiers(t *testing.T) {
+	got, err := ParseTiers("critical=2, High=1")
+	if err != nil || got["critical"] != 2 || got["high"] != 1 {
+		t.Errorf("ParseTiers() = %v, %v", got, err)
+	}
+	for _, bad := range []string{"critical", "critical=x", "high=-1"} {
+		if _, err := ParseTiers(bad); err == nil {
+			t.Errorf("ParseTiers(%q) error = nil", bad)
+		}
+	}
+}
@@ -1,12 +1,19 @@
 package geoip
 
 import (
+	"context"
 	"log"
 	"net"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/oschwald/geoip2-golang"
 )
 
+// maxCacheEntries bounds the lookup cache; it is reset once full.
+const maxCacheEntries = 100_000
+
 type Location struct {
 	Country string
 	City    string
@@ -16,23 +23,122 @@ type Location struct {
 }
 
 type Provider struct {
-	db *geoip2.Reader
+	path string
+
+	// mu guards db and its file identity. Lookups hold the read lock for
+	// their whole duration so a reload never closes a reader in use.
+	mu      sync.RWMutex
+	db      *geoip2.Reader
+	modTime time.Time
+	size    int64
+
+	// cache belongs to the current db and is dropped whenever it is swapped.
+	cacheMu sync.Mutex
+	cache   map[string]*Location
 }
 
 func NewProvider(path string) (*Provider, error) {
-	db, err := geoip2.Open(path)
-	if err != nil {
+	p := &Provider{path: path, cache: make(map[string]*Location)}
+	if _, err := p.Reload(); err != nil {
 		log.Printf("[GeoIP] Warning: DB not found at %s. Geo enrichment disabled.", path)
-		return &Provider{db: nil}, nil // Return nil db but no error to allow start
+		return p, nil // Return nil db but no error to allow start
+	}
+	return p, nil
+}
+
+// Reload reopens the DB if the file changed since it was last opened
+// (different mtime or size). The new reader replaces the old one atomically
+// and the lookup cache is invalidated. It reports whether a swap happened.
+func (p *Provider) Reload() (bool, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.RLock()
+	unchanged := p.db != nil && info.ModTime().Equal(p.modTime) && info.Size() == p.size
+	p.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	// Read into memory instead of mmap: an updater rewriting the file in
+	// place must not corrupt the reader that is still serving lookups.
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return false, err
+	}
+	db, err := geoip2.FromBytes(data)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	old := p.db
+	p.db, p.modTime, p.size = db, info.ModTime(), info.Size()
+	p.cacheMu.Lock()
+	p.cache = make(map[string]*Location)
+	p.cacheMu.Unlock()
+	p.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return true, nil
+}
+
+// Watch checks the DB file every interval and reloads it on change until
+// ctx is done.
+func (p *Provider) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := p.Reload()
+			if err != nil {
+				// Keep serving from the current reader (e.g. file mid-update)
+				log.Printf("[GeoIP] Reload failed: %v", err)
+				continue
+			}
+			if reloaded {
+				log.Printf("[GeoIP] Reloaded DB from %s", p.path)
+			}
+		}
 	}
-	return &Provider{db: db}, nil
 }
 
 func (p *Provider) Lookup(ipStr string) *Location {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	if p.db == nil {
 		return nil
 	}
 
+	p.cacheMu.Lock()
+	loc, ok := p.cache[ipStr]
+	p.cacheMu.Unlock()
+	if ok {
+		return loc
+	}
+
+	loc = p.lookup(ipStr)
+
+	p.cacheMu.Lock()
+	if len(p.cache) >= maxCacheEntries {
+		p.cache = make(map[string]*Location)
+	}
+	p.cache[ipStr] = loc
+	p.cacheMu.Unlock()
+
+	return loc
+}
+
+func (p *Provider) lookup(ipStr string) *Location {
 	ip := net.ParseIP(ipStr)
 	if ip == nil {
 		return nil
@@ -53,7 +159,10 @@ func (p *Provider) Lookup(ipStr string) *Location {
 }
 
 func (p *Provider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.db != nil {
 		p.db.Close()
+		p.db = nil
 	}
 }
@@ -0,0 +1,150 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// mmdbValue encodes v in the MaxMind DB data section format. Only the types
+// needed for a minimal City record and the metadata are supported.
+func mmdbValue(buf *bytes.Buffer, v any) {
+	switch val := v.(type) {
+	case string:
+		buf.WriteByte(2<<5 | byte(len(val)))
+		buf.WriteString(val)
+	case uint16:
+		buf.WriteByte(5<<5 | 2)
+		binary.Write(buf, binary.BigEndian, val)
+	case uint32:
+		buf.WriteByte(6<<5 | 4)
+		binary.Write(buf, binary.BigEndian, val)
+	case uint64:
+		buf.WriteByte(8) // extended type, 8 byte payload
+		buf.WriteByte(9 - 7)
+		binary.Write(buf, binary.BigEndian, val)
+	case map[string]any:
+		buf.WriteByte(7<<5 | byte(len(val)))
+		for k, item := range val {
+			mmdbValue(buf, k)
+			mmdbValue(buf, item)
+		}
+	}
+}
+
+// writeTestDB writes an IPv4 City DB mapping every address to one record.
+// Like geoipupdate, it replaces the file via rename.
+func writeTestDB(t *testing.T, path, country, iso, city string, mtime time.Time) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	// Search tree: a single node whose both records point to data offset 0
+	// (pointer = node_count + 16 + offset).
+	buf.Write([]byte{0, 0, 17, 0, 0, 17})
+	buf.Write(make([]byte, 16))
+	mmdbValue(&buf, map[string]any{
+		"country": map[string]any{"iso_code": iso, "names": map[string]any{"en": country}},
+		"city":    map[string]any{"names": map[string]any{"en": city}},
+	})
+	buf.WriteString("\xAB\xCD\xEFMaxMind.com")
+	mmdbValue(&buf, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(mtime.Unix()),
+		"database_type":               "GeoLite2-City",
+		"ip_version":                  uint16(4),
+		"node_count":                  uint32(1),
+		"record_size":                 uint16(24),
+	})
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(tmp, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProviderReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+	start := time.Now().Add(-time.Hour)
+	writeTestDB(t, path, "Turkey", "TR", "Istanbul", start)
+
+	p, err := NewProvider(path)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	defer p.Close()
+
+	loc := p.Lookup("8.8.8.8")
+	if loc == nil || loc.ISO != "TR" || loc.City != "Istanbul" {
+		t.Fatalf("Lookup() = %+v, want TR/Istanbul", loc)
+	}
+
+	if reloaded, err := p.Reload(); err != nil || reloaded {
+		t.Fatalf("Reload() on unchanged file = %v, %v, want false, nil", reloaded, err)
+	}
+
+	writeTestDB(t, path, "Germany", "DE", "Berlin", start.Add(time.Minute))
+
+	reloaded, err := p.Reload()
+	if err != nil || !reloaded {
+		t.Fatalf("Reload() after update = %v, %v, want true, nil", reloaded, err)
+	}
+
+	// The cached entry from the old DB must not survive the swap
+	loc = p.Lookup("8.8.8.8")
+	if loc == nil || loc.ISO != "DE" || loc.City != "Berlin" {
+		t.Errorf("Lookup() after reload = %+v, want DE/Berlin", loc)
+	}
+}
+
+func TestProviderReloadKeepsReaderOnBadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+	start := time.Now().Add(-time.Hour)
+	writeTestDB(t, path, "Turkey", "TR", "Istanbul", start)
+
+	p, _ := NewProvider(path)
+	defer p.Close()
+
+	if err := os.WriteFile(path, []byte("truncated"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Reload(); err == nil {
+		t.Fatal("Reload() on corrupt file error = nil, want error")
+	}
+
+	if loc := p.Lookup("1.1.1.1"); loc == nil || loc.ISO != "TR" {
+		t.Errorf("Lookup() after failed reload = %+v, want TR", loc)
+	}
+}
+
+func TestProviderMissingDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "GeoLite2-City.mmdb")
+
+	p, err := NewProvider(path)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	defer p.Close()
+
+	if loc := p.Lookup("8.8.8.8"); loc != nil {
+		t.Fatalf("Lookup() without DB = %+v, want nil", loc)
+	}
+
+	// A DB that appears later is picked up by the next reload
+	writeTestDB(t, path, "Turkey", "TR", "Ankara", time.Now())
+	if reloaded, err := p.Reload(); err != nil || !reloaded {
+		t.Fatalf("Reload() = %v, %v, want true, nil", reloaded, err)
+	}
+	if loc := p.Lookup("8.8.8.8"); loc == nil || loc.City != "Ankara" {
+		t.Errorf("Lookup() = %+v, want Ankara", loc)
+	}
+}
@@ -2,42 +2,72 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 
+	"sakin-go/cmd/sge-enrichment/backfill"
 	"sakin-go/cmd/sge-enrichment/config"
+	"sakin-go/cmd/sge-enrichment/criticality"
 	"sakin-go/cmd/sge-enrichment/geoip"
 	"sakin-go/cmd/sge-enrichment/intel"
+	"sakin-go/cmd/sge-enrichment/rdns"
 	"sakin-go/pkg/database"
 	"sakin-go/pkg/messaging"
 	"sakin-go/pkg/models"
 )
 
 func main() {
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("[Enrichment] Invalid configuration: %v", err)
+	}
 	log.Println("[Enrichment] Starting SGE Enrichment Service...")
 
 	// 1. Infrastructure
 	// NATS
+	retention, err := messaging.RetentionFromEnv()
+	if err != nil {
+		log.Fatalf("[Enrichment] Invalid NATS retention config: %v", err)
+	}
+	codec, err := messaging.CodecFromEnv()
+	if err != nil {
+		log.Fatalf("[Enrichment] Invalid NATS codec: %v", err)
+	}
+	slo, err := messaging.SLOFromEnv()
+	if err != nil {
+		log.Fatalf("[Enrichment] Invalid consumer SLO config: %v", err)
+	}
 	natsCfg := &messaging.NatsConfig{
 		URL: cfg.NatsURL, Username: cfg.NatsUser, Password: cfg.NatsPassword,
 		ReconnectWait: 2 * time.Second,
+		Retention:     retention,
+		SLO:           slo,
 	}
 	nc, err := messaging.NewClient(natsCfg)
 	if err != nil {
 		log.Fatalf("[Enrichment] NATS Error: %v", err)
 	}
 	defer nc.Close()
+	if slo.HealthAddr != "" {
+		go func() {
+			if err := nc.ServeHealth(slo.HealthAddr); err != nil {
+				log.Printf("[Enrichment] Health endpoint stopped: %v", err)
+			}
+		}()
+		log.Printf("[Enrichment] Health endpoint (consumer SLO) on %s/health", slo.HealthAddr)
+	}
 
 	// Redis
 	rdb, _ := database.NewRedisClient(&database.RedisConfig{
-		Addr: cfg.RedisAddr, Password: cfg.RedisPassword,
+		Addr: cfg.RedisAddr.String(), Password: cfg.RedisPassword,
 	})
 	if rdb != nil {
 		defer rdb.Close()
@@ -48,55 +78,158 @@ func main() {
 	geoProvider, _ := geoip.NewProvider(cfg.MaxMindPath)
 	defer geoProvider.Close()
 
+	var ptr *rdns.Enricher
+	if cfg.RDNSEnabled {
+		ptr = rdns.NewEnricher(net.DefaultResolver, rdns.Options{
+			CacheSize:     cfg.RDNSCacheSize,
+			TTL:           cfg.RDNSCacheTTL,
+			NegativeTTL:   cfg.RDNSNegativeTTL,
+			MaxConcurrent: cfg.RDNSMaxConcurrent,
+			Timeout:       cfg.RDNSTimeout,
+		})
+		log.Printf("[Enrichment] Reverse DNS enabled (%d concurrent lookups, %s timeout)", cfg.RDNSMaxConcurrent, cfg.RDNSTimeout)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Pick up MaxMind DB updates without a restart
+	if cfg.MaxMindReloadInterval > 0 {
+		go geoProvider.Watch(ctx, time.Duration(cfg.MaxMindReloadInterval)*time.Second)
+	}
+
+	// Assets table, for the criticality boost and threat backfill
+	var pg *database.PostgresClient
+	if cfg.CriticalityEnabled || cfg.ThreatBackfill {
+		pg, err = database.NewPostgresClient(&database.PostgresConfig{
+			Host: cfg.PostgresAddr.Host, Port: cfg.PostgresAddr.Port,
+			Username: cfg.PostgresUser, Password: cfg.PostgresPassword, Database: cfg.PostgresDB,
+			SSLMode: "disable",
+		})
+		if err != nil {
+			log.Printf("[Enrichment] Warning: Postgres unavailable, asset context disabled: %v", err)
+			pg = nil
+		} else {
+			defer pg.Close()
+		}
+	}
+
+	// Severity boost by destination asset criticality
+	var booster *criticality.Booster
+	if cfg.CriticalityEnabled && pg != nil {
+		tiers, err := criticality.ParseTiers(cfg.CriticalityBoost)
+		if err != nil {
+			log.Fatalf("[Enrichment] Invalid ASSET_CRITICALITY_BOOST: %v", err)
+		}
+		booster = criticality.NewBooster(pg, tiers)
+		if err := booster.Refresh(ctx); err != nil {
+			log.Printf("[Enrichment] Warning: Asset criticality not loaded: %v", err)
+		}
+		go booster.Watch(ctx, cfg.CriticalityRefresh)
+		log.Printf("[Enrichment] Criticality boost enabled (%s)", cfg.CriticalityBoost)
+	}
+
+	// Source geo and destination asset on threat events
+	var backfiller *backfill.Backfiller
+	if cfg.ThreatBackfill {
+		var assets backfill.Source
+		if pg != nil {
+			assets = pg
+		}
+		backfiller = backfill.New(geoProvider, assets, cfg.ThreatEventTypes)
+		if err := backfiller.Refresh(ctx); err != nil {
+			log.Printf("[Enrichment] Warning: Threat backfill assets not loaded: %v", err)
+		}
+		go backfiller.Watch(ctx, cfg.CriticalityRefresh)
+		log.Printf("[Enrichment] Threat backfill enabled for %s", strings.Join(cfg.ThreatEventTypes, ", "))
+	}
+
 	// 3. Process Loop
 	// Subscribe to RAW events
 	// Subscribe to RAW events
 	// Stream name is messaging.StreamEvents ("EVENTS")
-	_, err = nc.QueueSubscribe(context.Background(), messaging.StreamEvents, messaging.TopicEventsRaw, messaging.ConsumerEnrichment, func(msg jetstream.Msg) {
+	_, err = nc.QueueSubscribeN(context.Background(), messaging.StreamEvents, messaging.TopicEventsRaw, messaging.ConsumerEnrichment, cfg.NatsConsumers, func(msg jetstream.Msg) {
 		msg.Ack()
 
-		var evt models.Event
-		if err := json.Unmarshal(msg.Data(), &evt); err != nil {
+		// Agents may publish batched (and gzip compressed) messages
+		payloads, err := messaging.DecodeBatch(msg.Headers(), msg.Data())
+		if err != nil {
+			log.Printf("[Enrichment] Decode error: %v", err)
+			messaging.MarkFailed(msg)
+			return
+		}
+		// Publishers may use different codecs mid-rollout
+		in, err := messaging.MessageCodec(msg.Headers())
+		if err != nil {
+			log.Printf("[Enrichment] Decode error: %v", err)
+			messaging.MarkFailed(msg)
 			return
 		}
 
-		// ENRICHMENT LOGIC
+		for _, data := range payloads {
+			var evt models.Event
+			if err := in.Unmarshal(data, &evt); err != nil {
+				continue
+			}
+
+			// ENRICHMENT LOGIC
+
+			// 3.1 Host Enrichment (GeoIP)
+			if evt.SourceIP != "" {
+				if loc := geoProvider.Lookup(evt.SourceIP); loc != nil {
+					if evt.Enrichment == nil {
+						evt.Enrichment = make(map[string]interface{})
+					}
+					evt.Enrichment["src_geo_country"] = loc.Country
+					evt.Enrichment["src_geo_city"] = loc.City
+					evt.Enrichment["src_geo_iso"] = loc.ISO
 
-		// 3.1 Host Enrichment (GeoIP)
-		if evt.SourceIP != "" {
-			if loc := geoProvider.Lookup(evt.SourceIP); loc != nil {
-				if evt.Enrichment == nil {
-					evt.Enrichment = make(map[string]interface{})
 				}
-				evt.Enrichment["src_geo_country"] = loc.Country
-				evt.Enrichment["src_geo_city"] = loc.City
-				evt.Enrichment["src_geo_iso"] = loc.ISO
 
-			}
+				// 3.2 Intel Enrichment
+				rep, _ := intelProvider.CheckIP(context.Background(), evt.SourceIP)
+				if rep != nil && rep.IsMalicious {
+					if evt.Enrichment == nil {
+						evt.Enrichment = make(map[string]interface{})
+					}
+					evt.Enrichment["threat_intel_score"] = rep.Score
+					evt.Enrichment["threat_intel_source"] = rep.Source
+
+					// Escalate severity if malicious
+					evt.Severity = models.SeverityCritical
+					evt.Tags = append(evt.Tags, "malicious_ip")
 
-			// 3.2 Intel Enrichment
-			rep, _ := intelProvider.CheckIP(context.Background(), evt.SourceIP)
-			if rep != nil && rep.IsMalicious {
-				if evt.Enrichment == nil {
-					evt.Enrichment = make(map[string]interface{})
 				}
-				evt.Enrichment["threat_intel_score"] = rep.Score
-				evt.Enrichment["threat_intel_source"] = rep.Source
+			}
+
+			// 3.3 Hostnames (PTR)
+			if ptr != nil {
+				ptr.Enrich(ctx, &evt)
+			}
 
-				// Escalate severity if malicious
-				evt.Severity = models.SeverityCritical
-				evt.Tags = append(evt.Tags, "malicious_ip")
+			// 3.4 Asset criticality (after intel, so it can raise an escalated severity further)
+			if booster != nil {
+				booster.Apply(&evt)
+			}
 
+			// 3.5 Threats always carry source geo and target asset
+			if backfiller != nil {
+				backfiller.Apply(&evt)
 			}
-		}
 
-		// 4. Republish if enriched (or simply passthrough all to enriched stream?
-		// Usually passthrough is better for unified downstream)
-		// Subject: events.enriched.<severity>.<source>
-		subject := messaging.TopicEventsEnriched + string(evt.Severity) + "." + evt.Source
+			// 4. Republish if enriched (or simply passthrough all to enriched stream?
+			// Usually passthrough is better for unified downstream)
+			// Subject: events.enriched.<severity>.<source>
+			subject := messaging.SafeSubject(messaging.TopicEventsEnriched, string(evt.Severity), evt.Source)
 
-		outBytes, _ := json.Marshal(evt)
-		nc.PublishAsync(context.Background(), subject, outBytes)
+			h, outBytes, err := messaging.EncodeMessage(codec, &evt)
+			if err != nil {
+				log.Printf("[Enrichment] Encode error: %v", err)
+				messaging.MarkFailed(msg)
+				continue
+			}
+			nc.PublishMsgAsync(context.Background(), &nats.Msg{Subject: subject, Header: h, Data: outBytes})
+		}
 
 	})
 
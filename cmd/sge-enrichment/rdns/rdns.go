@@ -0,0 +1,178 @@
+// Package rdns resolves event IPs to hostnames with reverse DNS (PTR)
+// lookups, caching answers and failures so repeated IPs cost no queries.
+package rdns
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+// Enrichment fields set by Enrich.
+const (
+	FieldSourceHostname = "source_hostname"
+	FieldDestHostname   = "dest_hostname"
+)
+
+// Resolver performs PTR lookups; *net.Resolver satisfies it.
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// Options configures an Enricher. Zero values select the defaults below.
+type Options struct {
+	CacheSize     int           // Cached IPs (default 10000)
+	TTL           time.Duration // Lifetime of a resolved name (default 1h)
+	NegativeTTL   time.Duration // Lifetime of a failed lookup (default 5m)
+	MaxConcurrent int           // Lookups in flight at once (default 8)
+	Timeout       time.Duration // Per lookup, including the wait for a slot (default 500ms)
+}
+
+func (o Options) withDefaults() Options {
+	if o.CacheSize <= 0 {
+		o.CacheSize = 10000
+	}
+	if o.TTL <= 0 {
+		o.TTL = time.Hour
+	}
+	if o.NegativeTTL <= 0 {
+		o.NegativeTTL = 5 * time.Minute
+	}
+	if o.MaxConcurrent <= 0 {
+		o.MaxConcurrent = 8
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 500 * time.Millisecond
+	}
+	return o
+}
+
+type cacheEntry struct {
+	ip     string
+	name   string // Empty for a negative entry
+	expiry time.Time
+}
+
+// Enricher adds hostnames to events. It is safe for concurrent use.
+type Enricher struct {
+	resolver Resolver
+	opts     Options
+	slots    chan struct{} // Semaphore bounding lookups in flight
+
+	mu    sync.Mutex
+	cache map[string]*list.Element // Values are *cacheEntry
+	lru   *list.List               // Front is most recently used
+	now   func() time.Time
+}
+
+// NewEnricher creates an enricher using resolver (net.DefaultResolver for
+// the system resolver).
+func NewEnricher(resolver Resolver, opts Options) *Enricher {
+	opts = opts.withDefaults()
+	return &Enricher{
+		resolver: resolver,
+		opts:     opts,
+		slots:    make(chan struct{}, opts.MaxConcurrent),
+		cache:    make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+// Lookup returns the hostname of ip, or "" when it has none, the lookup
+// failed or no slot freed up within the timeout. Only completed lookups
+// are cached: a timeout waiting for a slot says nothing about the IP.
+func (e *Enricher) Lookup(ctx context.Context, ip string) string {
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	if name, ok := e.cached(ip); ok {
+		return name
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.opts.Timeout)
+	defer cancel()
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ""
+	}
+	names, err := e.resolver.LookupAddr(ctx, ip)
+	<-e.slots
+
+	name := ""
+	if err == nil && len(names) > 0 {
+		name = strings.TrimSuffix(names[0], ".")
+	}
+	e.store(ip, name)
+	return name
+}
+
+// Enrich sets source_hostname and dest_hostname for the IPs that resolve.
+func (e *Enricher) Enrich(ctx context.Context, evt *models.Event) {
+	for _, f := range []struct{ ip, field string }{
+		{evt.SourceIP, FieldSourceHostname},
+		{evt.DestIP, FieldDestHostname},
+	} {
+		if f.ip == "" {
+			continue
+		}
+		if name := e.Lookup(ctx, f.ip); name != "" {
+			if evt.Enrichment == nil {
+				evt.Enrichment = make(map[string]interface{})
+			}
+			evt.Enrichment[f.field] = name
+		}
+	}
+}
+
+// Len returns the number of cached IPs, negative entries included.
+func (e *Enricher) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.cache)
+}
+
+func (e *Enricher) cached(ip string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	el, ok := e.cache[ip]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*cacheEntry)
+	if e.now().After(entry.expiry) {
+		e.lru.Remove(el)
+		delete(e.cache, ip)
+		return "", false
+	}
+	e.lru.MoveToFront(el)
+	return entry.name, true
+}
+
+func (e *Enricher) store(ip, name string) {
+	ttl := e.opts.TTL
+	if name == "" {
+		ttl = e.opts.NegativeTTL
+	}
+	entry := &cacheEntry{ip: ip, name: name, expiry: e.now().Add(ttl)}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if el, ok := e.cache[ip]; ok {
+		el.Value = entry
+		e.lru.MoveToFront(el)
+		return
+	}
+	if len(e.cache) >= e.opts.CacheSize {
+		oldest := e.lru.Back()
+		e.lru.Remove(oldest)
+		delete(e.cache, oldest.Value.(*cacheEntry).ip)
+	}
+	e.cache[ip] = e.lru.PushFront(entry)
+}
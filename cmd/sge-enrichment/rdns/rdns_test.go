@@ -0,0 +1,192 @@
+package rdns
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+type fakeResolver struct {
+	names map[string]string
+	calls atomic.Int32
+
+	// When set, lookups block until release is closed
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (r *fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	r.calls.Add(1)
+	n := r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+	for {
+		p := r.peak.Load()
+		if n <= p || r.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	if r.release != nil {
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if name, ok := r.names[addr]; ok {
+		return []string{name + "."}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestLookupCache(t *testing.T) {
+	r := &fakeResolver{names: map[string]string{"10.0.0.7": "ws07.corp.local"}}
+	e := NewEnricher(r, Options{})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if got := e.Lookup(ctx, "10.0.0.7"); got != "ws07.corp.local" {
+			t.Fatalf("Lookup() = %q, want ws07.corp.local", got)
+		}
+	}
+	if got := r.calls.Load(); got != 1 {
+		t.Errorf("resolver calls = %d, want 1 (cache hits)", got)
+	}
+
+	now = now.Add(time.Hour + time.Second)
+	e.Lookup(ctx, "10.0.0.7")
+	if got := r.calls.Load(); got != 2 {
+		t.Errorf("resolver calls after TTL = %d, want 2", got)
+	}
+}
+
+func TestLookupNegativeCache(t *testing.T) {
+	r := &fakeResolver{}
+	e := NewEnricher(r, Options{NegativeTTL: time.Minute})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if got := e.Lookup(ctx, "203.0.113.9"); got != "" {
+			t.Fatalf("Lookup() = %q, want empty", got)
+		}
+	}
+	if got := r.calls.Load(); got != 1 {
+		t.Errorf("resolver calls = %d, want 1 (negative cache)", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	e.Lookup(ctx, "203.0.113.9")
+	if got := r.calls.Load(); got != 2 {
+		t.Errorf("resolver calls after negative TTL = %d, want 2", got)
+	}
+}
+
+func TestLookupEvictsLeastRecentlyUsed(t *testing.T) {
+	r := &fakeResolver{names: map[string]string{"10.0.0.1": "a", "10.0.0.2": "b", "10.0.0.3": "c"}}
+	e := NewEnricher(r, Options{CacheSize: 2})
+	ctx := context.Background()
+
+	e.Lookup(ctx, "10.0.0.1")
+	e.Lookup(ctx, "10.0.0.2")
+	e.Lookup(ctx, "10.0.0.1") // 10.0.0.2 is now the oldest
+	e.Lookup(ctx, "10.0.0.3")
+	if got := e.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+
+	calls := r.calls.Load()
+	e.Lookup(ctx, "10.0.0.1")
+	if got := r.calls.Load(); got != calls {
+		t.Error("10.0.0.1 was evicted, want 10.0.0.2")
+	}
+	e.Lookup(ctx, "10.0.0.2")
+	if got := r.calls.Load(); got != calls+1 {
+		t.Error("10.0.0.2 still cached, want evicted")
+	}
+}
+
+func TestLookupBounded(t *testing.T) {
+	r := &fakeResolver{release: make(chan struct{})}
+	e := NewEnricher(r, Options{MaxConcurrent: 2, Timeout: 5 * time.Second})
+	ips := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"}
+
+	var wg sync.WaitGroup
+	for _, ip := range ips {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Lookup(context.Background(), ip)
+		}()
+	}
+	for r.calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // Give the others a chance to exceed the bound
+	close(r.release)
+	wg.Wait()
+
+	if got := r.peak.Load(); got != 2 {
+		t.Errorf("peak lookups in flight = %d, want 2", got)
+	}
+	if got := r.calls.Load(); got != int32(len(ips)) {
+		t.Errorf("resolver calls = %d, want %d", got, len(ips))
+	}
+}
+
+func TestLookupSlotTimeout(t *testing.T) {
+	r := &fakeResolver{names: map[string]string{"10.0.0.7": "ws07.corp.local"}}
+	e := NewEnricher(r, Options{MaxConcurrent: 1, Timeout: 10 * time.Millisecond})
+
+	e.slots <- struct{}{} // Occupy the only slot
+	if got := e.Lookup(context.Background(), "10.0.0.7"); got != "" {
+		t.Errorf("Lookup() = %q, want empty while saturated", got)
+	}
+	if r.calls.Load() != 0 || e.Len() != 0 {
+		t.Errorf("calls = %d, Len() = %d, want 0 and 0 (not cached)", r.calls.Load(), e.Len())
+	}
+
+	<-e.slots
+	if got := e.Lookup(context.Background(), "10.0.0.7"); got != "ws07.corp.local" {
+		t.Errorf("Lookup() = %q, want ws07.corp.local", got)
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	r := &fakeResolver{names: map[string]string{"10.0.0.7": "ws07.corp.local"}}
+	e := NewEnricher(r, Options{})
+
+	tests := []struct {
+		name string
+		evt  models.Event
+		want map[string]interface{}
+	}{
+		{"both", models.Event{SourceIP: "10.0.0.7", DestIP: "10.0.0.7"},
+			map[string]interface{}{FieldSourceHostname: "ws07.corp.local", FieldDestHostname: "ws07.corp.local"}},
+		{"source only", models.Event{SourceIP: "10.0.0.7", DestIP: "198.51.100.1"},
+			map[string]interface{}{FieldSourceHostname: "ws07.corp.local"}},
+		{"unresolved", models.Event{SourceIP: "198.51.100.1"}, nil},
+		{"not an ip", models.Event{SourceIP: "host-a"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.Enrich(context.Background(), &tt.evt)
+			if len(tt.evt.Enrichment) != len(tt.want) {
+				t.Fatalf("Enrichment = %v, want %v", tt.evt.Enrichment, tt.want)
+			}
+			for k, v := range tt.want {
+				if tt.evt.Enrichment[k] != v {
+					t.Errorf("Enrichment[%s] = %v, want %v", k, tt.evt.Enrichment[k], v)
+				}
+			}
+		})
+	}
+}
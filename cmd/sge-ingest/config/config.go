@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 )
 
 type IngestConfig struct {
@@ -12,6 +14,22 @@ type IngestConfig struct {
 	NatsURL      string
 	NatsUser     string
 	NatsPassword string
+
+	// Noisy source tagging (0 threshold disables)
+	NoisySourceThreshold int // Events per source per window
+	NoisySourceWindow    int // Seconds
+
+	// Normalization stages in order
+	PipelineStages []string
+
+	// Metadata limits per event (0 disables a limit)
+	MetadataMaxKeys      int // Entries per object or array
+	MetadataMaxDepth     int // Nesting levels
+	MetadataMaxStringLen int // Bytes per string value
+
+	// Publish confirmation: attempts per event and ack wait per attempt
+	PublishAttempts     int
+	PublishAckTimeoutMs int
 }
 
 func LoadConfig() *IngestConfig {
@@ -23,12 +41,43 @@ func LoadConfig() *IngestConfig {
 		NatsURL:      getEnv("NATS_URL", "nats://localhost:4222"),
 		NatsUser:     getEnv("NATS_USER", "admin"),
 		NatsPassword: getEnv("NATS_PASSWORD", "sakin123"),
+
+		NoisySourceThreshold: getEnvInt("INGEST_NOISY_THRESHOLD", 1000),
+		NoisySourceWindow:    getEnvInt("INGEST_NOISY_WINDOW", 60),
+
+		PipelineStages: splitList(getEnv("INGEST_PIPELINE", "id,timestamp,fields,metadata,noisy")),
+
+		MetadataMaxKeys:      getEnvInt("INGEST_METADATA_MAX_KEYS", 64),
+		MetadataMaxDepth:     getEnvInt("INGEST_METADATA_MAX_DEPTH", 4),
+		MetadataMaxStringLen: getEnvInt("INGEST_METADATA_MAX_STRING", 1024),
+
+		PublishAttempts:     getEnvInt("INGEST_PUBLISH_ATTEMPTS", 3),
+		PublishAckTimeoutMs: getEnvInt("INGEST_PUBLISH_ACK_TIMEOUT_MS", 2000),
 	}
 }
 
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func getEnv(key, fallback string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		return val
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		if i, err := strconv.Atoi(val); err == nil {
+			return i
+		}
+	}
+	return fallback
+}
@@ -1,48 +1,169 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/nats-io/nats.go/jetstream"
 
 	"sakin-go/cmd/sge-ingest/normalizer"
 	"sakin-go/pkg/messaging"
+	"sakin-go/pkg/models"
 )
 
+// Publisher is the part of the NATS client the handler needs.
+type Publisher interface {
+	PublishAsync(ctx context.Context, subject string, data []byte, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error)
+}
+
+// RetryConfig controls how long a request waits for JetStream to confirm
+// its events before answering 503.
+type RetryConfig struct {
+	Attempts   int           // Publish attempts per event (>= 1)
+	AckTimeout time.Duration // Max wait for one ack
+	Backoff    time.Duration // Pause between attempts
+}
+
+// DefaultRetryConfig returns the retry settings used in production.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{Attempts: 3, AckTimeout: 2 * time.Second, Backoff: 100 * time.Millisecond}
+}
+
+var errAckTimeout = errors.New("ack timeout")
+
 type EventHandler struct {
-	natsClient *messaging.Client
+	natsClient Publisher
+	pipeline   *normalizer.Pipeline
+	retry      RetryConfig
 }
 
-func NewEventHandler(nc *messaging.Client) *EventHandler {
-	return &EventHandler{natsClient: nc}
+// NewEventHandler creates the HTTP event handler. A nil pipeline uses the
+// default normalization stages.
+func NewEventHandler(nc Publisher, pipeline *normalizer.Pipeline, retry RetryConfig) *EventHandler {
+	if retry.Attempts < 1 {
+		retry.Attempts = 1
+	}
+	if pipeline == nil {
+		pipeline, _ = normalizer.BuildPipeline(normalizer.DefaultStageNames, normalizer.Stages(nil, normalizer.DefaultMetadataLimits()))
+	}
+	return &EventHandler{natsClient: nc, pipeline: pipeline, retry: retry}
+}
+
+// pending is one event waiting for its JetStream ack.
+type pending struct {
+	id      string
+	subject string
+	data    []byte
+	future  jetstream.PubAckFuture
+	err     error
 }
 
-// HandleHTTPEvent receives events via HTTP POST.
+// HandleHTTPEvent receives events via HTTP POST. The body is a single event
+// object or a JSON array of them. It answers 202 with the assigned event
+// IDs only after JetStream has acked every event, and 503 if any of them
+// could not be enqueued, so clients know to retry.
 func (h *EventHandler) HandleHTTPEvent(c *fiber.Ctx) error {
 	// 1. Get Raw Body (Zero Allocation in Fiber)
 	body := c.Body()
 
-	// 2. Normalize
-	evt, err := normalizer.NormalizeAgentEvent(body)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid event format"})
+	raws := []json.RawMessage{body}
+	trimmed := bytes.TrimSpace(body)
+	batch := len(trimmed) > 0 && trimmed[0] == '['
+	if batch {
+		if err := json.Unmarshal(body, &raws); err != nil || len(raws) == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid event format"})
+		}
 	}
 
-	// 3. Serialize for Bus
-	// Can optimize by using same buffer if normalization supports it
-	data, _ := json.Marshal(evt) // In real world use custom serializer
+	// 2. Normalize & Serialize for Bus
+	events := make([]*pending, 0, len(raws))
+	for _, raw := range raws {
+		evt, err := h.pipeline.NormalizeFrom(raw, c.IP())
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid event format"})
+		}
 
-	// 4. Publish to NATS (Async)
-	// Topic: events.raw.<severity>.<source>
-	subject := messaging.TopicEventsRaw + string(evt.Severity) + "." + evt.Source
+		data, _ := json.Marshal(evt) // In real world use custom serializer
+		events = append(events, &pending{id: evt.ID, subject: eventSubject(evt), data: data})
+	}
 
-	_, err = h.natsClient.PublishAsync(context.Background(), subject, data)
-	if err != nil {
+	// 3. Publish to NATS and wait for the acks
+	if err := h.publish(c.UserContext(), events); err != nil {
 		log.Printf("[Ingest] NATS Publish Error: %v", err)
-		return c.Status(500).SendString("Internal Bus Error")
+		return c.Status(503).JSON(fiber.Map{"error": "Event bus unavailable, retry later"})
+	}
+
+	if !batch {
+		return c.Status(202).JSON(fiber.Map{"id": events[0].id})
+	}
+	ids := make([]string, len(events))
+	for i, p := range events {
+		ids[i] = p.id
 	}
+	return c.Status(202).JSON(fiber.Map{"ids": ids})
+}
+
+// publish sends all events asynchronously so one request's events share the
+// round trip, then awaits each ack. Events that failed are republished, up
+// to retry.Attempts. The event ID is used as the JetStream message ID, so a
+// retry of an event whose ack was merely lost is deduplicated server side.
+func (h *EventHandler) publish(ctx context.Context, events []*pending) error {
+	todo := events
+	for attempt := 1; ; attempt++ {
+		for _, p := range todo {
+			p.future, p.err = h.natsClient.PublishAsync(ctx, p.subject, p.data, jetstream.WithMsgID(p.id))
+		}
+
+		var failed []*pending
+		for _, p := range todo {
+			if p.err == nil {
+				p.err = h.awaitAck(ctx, p.future)
+			}
+			if p.err != nil {
+				failed = append(failed, p)
+			}
+		}
+
+		if len(failed) == 0 {
+			return nil
+		}
+		if attempt >= h.retry.Attempts {
+			return fmt.Errorf("%d of %d events not acked after %d attempts: %w", len(failed), len(events), attempt, failed[0].err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(h.retry.Backoff):
+		}
+		todo = failed
+	}
+}
+
+func (h *EventHandler) awaitAck(ctx context.Context, f jetstream.PubAckFuture) error {
+	timer := time.NewTimer(h.retry.AckTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-f.Ok():
+		return nil
+	case err := <-f.Err():
+		return err
+	case <-timer.C:
+		return errAckTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	return c.SendStatus(202) // Accepted
+// eventSubject builds the raw event subject.
+// Topic: events.raw.<severity>.<source>
+func eventSubject(evt *models.Event) string {
+	return messaging.SafeSubject(messaging.TopicEventsRaw, string(evt.Severity), evt.Source)
 }
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ackFuture is a PubAckFuture that is already resolved.
+type ackFuture struct {
+	ok  chan *jetstream.PubAck
+	err chan error
+}
+
+func newAckFuture(err error) *ackFuture {
+	f := &ackFuture{ok: make(chan *jetstream.PubAck, 1), err: make(chan error, 1)}
+	if err != nil {
+		f.err <- err
+	} else {
+		f.ok <- &jetstream.PubAck{Stream: "EVENTS"}
+	}
+	return f
+}
+
+func (f *ackFuture) Ok() <-chan *jetstream.PubAck { return f.ok }
+func (f *ackFuture) Err() <-chan error            { return f.err }
+func (f *ackFuture) Msg() *nats.Msg               { return nil }
+
+// fakePublisher fails its first `failures` publish calls (all of them if negative).
+type fakePublisher struct {
+	mu        sync.Mutex
+	failures  int
+	ackErr    bool // Fail via the ack future instead of the publish call
+	calls     int
+	published []string
+}
+
+func (p *fakePublisher) PublishAsync(_ context.Context, subject string, _ []byte, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	if p.failures < 0 || p.calls <= p.failures {
+		if p.ackErr {
+			return newAckFuture(errors.New("no responders")), nil
+		}
+		return nil, errors.New("connection closed")
+	}
+	p.published = append(p.published, subject)
+	return newAckFuture(nil), nil
+}
+
+func newTestApp(pub Publisher) *fiber.App {
+	h := NewEventHandler(pub, nil, RetryConfig{Attempts: 3, AckTimeout: time.Second, Backoff: time.Millisecond})
+	app := fiber.New()
+	app.Post("/events", h.HandleHTTPEvent)
+	return app
+}
+
+func TestHandleHTTPEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		pub       *fakePublisher
+		body      string
+		wantCode  int
+		wantIDs   int
+		wantCalls int
+	}{
+		{
+			name:      "Success",
+			pub:       &fakePublisher{},
+			body:      `{"source":"syslog","severity":"info"}`,
+			wantCode:  202,
+			wantIDs:   1,
+			wantCalls: 1,
+		},
+		{
+			name:      "Recovers After Retry",
+			pub:       &fakePublisher{failures: 2},
+			body:      `{"source":"syslog","severity":"info"}`,
+			wantCode:  202,
+			wantIDs:   1,
+			wantCalls: 3,
+		},
+		{
+			name:      "Publish Failure",
+			pub:       &fakePublisher{failures: -1},
+			body:      `{"source":"syslog","severity":"info"}`,
+			wantCode:  503,
+			wantCalls: 3,
+		},
+		{
+			name:      "Ack Failure",
+			pub:       &fakePublisher{failures: -1, ackErr: true},
+			body:      `{"source":"syslog","severity":"info"}`,
+			wantCode:  503,
+			wantCalls: 3,
+		},
+		{
+			name:      "Batch Retries Only Failed Events",
+			pub:       &fakePublisher{failures: 1},
+			body:      `[{"source":"a"},{"source":"b"},{"source":"c"}]`,
+			wantCode:  202,
+			wantIDs:   3,
+			wantCalls: 4,
+		},
+		{
+			name:     "Invalid Body",
+			pub:      &fakePublisher{},
+			body:     `not json`,
+			wantCode: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/events", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := newTestApp(tt.pub).Test(req)
+			if err != nil {
+				t.Fatalf("Test() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			if tt.pub.calls != tt.wantCalls {
+				t.Errorf("publish calls = %d, want %d", tt.pub.calls, tt.wantCalls)
+			}
+			if tt.wantCode != 202 {
+				return
+			}
+
+			raw, _ := io.ReadAll(resp.Body)
+			var out struct {
+				ID  string   `json:"id"`
+				IDs []string `json:"ids"`
+			}
+			if err := json.Unmarshal(raw, &out); err != nil {
+				t.Fatalf("response %q: %v", raw, err)
+			}
+			ids := out.IDs
+			if out.ID != "" {
+				ids = append(ids, out.ID)
+			}
+			if len(ids) != tt.wantIDs {
+				t.Errorf("ids = %v, want %d", ids, tt.wantIDs)
+			}
+			for _, id := range ids {
+				if id == "" {
+					t.Errorf("empty event ID in %s", raw)
+				}
+			}
+			if len(tt.pub.published) != tt.wantIDs {
+				t.Errorf("published = %v, want %d events", tt.pub.published, tt.wantIDs)
+			}
+		})
+	}
+}
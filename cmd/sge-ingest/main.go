@@ -12,6 +12,7 @@ import (
 
 	"sakin-go/cmd/sge-ingest/config"
 	"sakin-go/cmd/sge-ingest/handlers"
+	"sakin-go/cmd/sge-ingest/normalizer"
 	"sakin-go/pkg/messaging"
 )
 
@@ -21,12 +22,17 @@ func main() {
 	log.Println("[Ingest] Starting SGE Ingest Service...")
 
 	// 2. NATS Connection
+	retention, err := messaging.RetentionFromEnv()
+	if err != nil {
+		log.Fatalf("[Ingest] Invalid NATS retention config: %v", err)
+	}
 	natsConfig := &messaging.NatsConfig{
 		URL:           cfg.NatsURL,
 		Username:      cfg.NatsUser,
 		Password:      cfg.NatsPassword,
 		MaxReconnects: 10,
 		ReconnectWait: 2 * time.Second,
+		Retention:     retention,
 	}
 	nc, err := messaging.NewClient(natsConfig)
 	if err != nil {
@@ -47,7 +53,24 @@ func main() {
 	})
 
 	// Handlers
-	eventHandler := handlers.NewEventHandler(nc)
+	var noisy *normalizer.NoisySourceTagger
+	if cfg.NoisySourceThreshold > 0 {
+		noisy = normalizer.NewNoisySourceTagger(cfg.NoisySourceThreshold, time.Duration(cfg.NoisySourceWindow)*time.Second)
+	}
+	pipeline, err := normalizer.BuildPipeline(cfg.PipelineStages, normalizer.Stages(noisy, normalizer.MetadataLimits{
+		MaxKeys:      cfg.MetadataMaxKeys,
+		MaxDepth:     cfg.MetadataMaxDepth,
+		MaxStringLen: cfg.MetadataMaxStringLen,
+	}))
+	if err != nil {
+		log.Fatalf("[Ingest] Invalid INGEST_PIPELINE: %v", err)
+	}
+	log.Printf("[Ingest] Normalization stages: %v", pipeline.Stages())
+
+	retry := handlers.DefaultRetryConfig()
+	retry.Attempts = cfg.PublishAttempts
+	retry.AckTimeout = time.Duration(cfg.PublishAckTimeoutMs) * time.Millisecond
+	eventHandler := handlers.NewEventHandler(nc, pipeline, retry)
 
 	// Routes
 	api := app.Group("/api/v1")
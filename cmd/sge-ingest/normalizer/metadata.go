@@ -0,0 +1,129 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+)
+
+// TagMetadataTruncated marks events whose metadata exceeded a limit.
+const TagMetadataTruncated = "metadata_truncated"
+
+// MetadataLimits bounds the free-form metadata agents send (HTTP headers,
+// DNS answers, ...), so one event cannot bloat the JSON passed downstream
+// or hit parser limits. Zero disables a limit.
+type MetadataLimits struct {
+	MaxKeys      int // Entries kept per object or array
+	MaxDepth     int // Nesting levels kept, deeper values become JSON strings
+	MaxStringLen int // Bytes kept per string value
+}
+
+// DefaultMetadataLimits returns the limits used when none are configured.
+func DefaultMetadataLimits() MetadataLimits {
+	return MetadataLimits{MaxKeys: 64, MaxDepth: 4, MaxStringLen: 1024}
+}
+
+// SanitizeMetadata returns a copy of m within limits and whether anything
+// was cut. Objects keep their first MaxKeys keys in sorted order, so the
+// result does not depend on map iteration order.
+func SanitizeMetadata(m map[string]interface{}, limits MetadataLimits) (map[string]interface{}, bool) {
+	s := sanitizer{limits: limits}
+	out, _ := s.value(m, 1).(map[string]interface{})
+	return out, s.truncated
+}
+
+type sanitizer struct {
+	limits    MetadataLimits
+	truncated bool
+}
+
+// value sanitizes v found at nesting level depth (1 for m's entries).
+func (s *sanitizer) value(v interface{}, depth int) interface{} {
+	switch x := v.(type) {
+	case string:
+		return s.string(x)
+	case map[string]interface{}:
+		if s.tooDeep(depth) {
+			return s.flatten(x)
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		keys = s.cap(keys)
+		out := make(map[string]interface{}, len(keys))
+		for _, k := range keys {
+			out[s.string(k)] = s.value(x[k], depth+1)
+		}
+		return out
+	case []interface{}:
+		if s.tooDeep(depth) {
+			return s.flatten(x)
+		}
+		n := len(x)
+		if s.limits.MaxKeys > 0 && n > s.limits.MaxKeys {
+			n = s.limits.MaxKeys
+			s.truncated = true
+		}
+		out := make([]interface{}, n)
+		for i := range out {
+			out[i] = s.value(x[i], depth+1)
+		}
+		return out
+	}
+	return v
+}
+
+func (s *sanitizer) tooDeep(depth int) bool {
+	return s.limits.MaxDepth > 0 && depth > s.limits.MaxDepth
+}
+
+func (s *sanitizer) cap(keys []string) []string {
+	if s.limits.MaxKeys > 0 && len(keys) > s.limits.MaxKeys {
+		s.truncated = true
+		return keys[:s.limits.MaxKeys]
+	}
+	return keys
+}
+
+// flatten replaces a container nested too deeply with its (length
+// limited) JSON encoding.
+func (s *sanitizer) flatten(v interface{}) interface{} {
+	s.truncated = true
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return s.string(string(data))
+}
+
+// string cuts v to MaxStringLen bytes without splitting a UTF-8 sequence.
+func (s *sanitizer) string(v string) string {
+	n := s.limits.MaxStringLen
+	if n <= 0 || len(v) <= n {
+		return v
+	}
+	s.truncated = true
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return v[:n]
+}
+
+// mapMetadata copies the payload's metadata object onto the event within
+// limits, tagging the event when something was cut.
+func mapMetadata(limits MetadataLimits) func(rec *Record) error {
+	return func(rec *Record) error {
+		m, ok := rec.Raw["metadata"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		out, truncated := SanitizeMetadata(m, limits)
+		rec.Event.Metadata = out
+		if truncated {
+			rec.Event.Tags = append(rec.Event.Tags, TagMetadataTruncated)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// oversizedMetadata resembles an agent dumping a whole HTTP exchange.
+func oversizedMetadata() map[string]interface{} {
+	headers := make(map[string]interface{})
+	for i := 0; i < 100; i++ {
+		headers[fmt.Sprintf("x-header-%03d", i)] = "v"
+	}
+	answers := make([]interface{}, 50)
+	for i := range answers {
+		answers[i] = fmt.Sprintf("192.0.2.%d", i)
+	}
+	return map[string]interface{}{
+		"headers": headers,
+		"answers": answers,
+		"body":    strings.Repeat("A", 5000),
+		"nested":  map[string]interface{}{"l2": map[string]interface{}{"l3": map[string]interface{}{"l4": "deep"}}},
+		"status":  float64(200),
+	}
+}
+
+func TestSanitizeMetadata(t *testing.T) {
+	limits := MetadataLimits{MaxKeys: 10, MaxDepth: 2, MaxStringLen: 100}
+	got, truncated := SanitizeMetadata(oversizedMetadata(), limits)
+	if !truncated {
+		t.Fatal("SanitizeMetadata() truncated = false, want true")
+	}
+
+	if headers := got["headers"].(map[string]interface{}); len(headers) != 10 {
+		t.Errorf("headers = %d keys, want 10", len(headers))
+	} else if _, ok := headers["x-header-000"]; !ok {
+		t.Errorf("headers = %v, want the first keys in sorted order", headers)
+	}
+	if answers := got["answers"].([]interface{}); len(answers) != 10 || answers[0] != "192.0.2.0" {
+		t.Errorf("answers = %v, want the first 10", answers)
+	}
+	if body := got["body"].(string); len(body) != 100 {
+		t.Errorf("body = %d bytes, want 100", len(body))
+	}
+	// Level 3 is beyond MaxDepth and kept as JSON text
+	l2 := got["nested"].(map[string]interface{})["l2"]
+	if s, ok := l2.(string); !ok || s != `{"l3":{"l4":"deep"}}` {
+		t.Errorf("nested.l2 = %#v, want flattened JSON", l2)
+	}
+	if got["status"] != float64(200) {
+		t.Errorf("status = %v, want numbers untouched", got["status"])
+	}
+}
+
+func TestSanitizeMetadataWithinLimits(t *testing.T) {
+	m := map[string]interface{}{"method": "GET", "path": "/login", "tags": []interface{}{"a", "b"}, "ok": true}
+	got, truncated := SanitizeMetadata(m, DefaultMetadataLimits())
+	if truncated {
+		t.Error("SanitizeMetadata() truncated = true for small metadata")
+	}
+	a, _ := json.Marshal(m)
+	b, _ := json.Marshal(got)
+	if string(a) != string(b) {
+		t.Errorf("SanitizeMetadata() = %s, want %s", b, a)
+	}
+
+	// Zero limits disable capping
+	if _, truncated := SanitizeMetadata(oversizedMetadata(), MetadataLimits{}); truncated {
+		t.Error("SanitizeMetadata() with zero limits truncated = true")
+	}
+}
+
+func TestSanitizeMetadataUTF8(t *testing.T) {
+	got, _ := SanitizeMetadata(map[string]interface{}{"c": "aİb"}, MetadataLimits{MaxStringLen: 2})
+	if s := got["c"].(string); s != "a" {
+		t.Errorf("city = %q, want a multi-byte rune dropped rather than split", s)
+	}
+}
+
+func TestMetadataStage(t *testing.T) {
+	p, err := BuildPipeline(DefaultStageNames, Stages(nil, MetadataLimits{MaxKeys: 20, MaxDepth: 3, MaxStringLen: 256}))
+	if err != nil {
+		t.Fatalf("BuildPipeline() error = %v", err)
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{"event_type": "http.request", "metadata": oversizedMetadata()})
+	evt, err := p.Normalize(payload)
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if !slices.Contains(evt.Tags, TagMetadataTruncated) {
+		t.Errorf("Tags = %v, want %s", evt.Tags, TagMetadataTruncated)
+	}
+	if len(evt.Metadata["headers"].(map[string]interface{})) != 20 || len(evt.Metadata["body"].(string)) != 256 {
+		t.Errorf("Metadata not capped: %d headers, %d byte body", len(evt.Metadata["headers"].(map[string]interface{})), len(evt.Metadata["body"].(string)))
+	}
+	if out, _ := json.Marshal(evt); len(out) > 4096 {
+		t.Errorf("event encodes to %d bytes, want the caps to keep it small", len(out))
+	}
+
+	evt, err = p.Normalize([]byte(`{"metadata":{"user":"alice"}}`))
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if evt.Metadata["user"] != "alice" || slices.Contains(evt.Tags, TagMetadataTruncated) {
+		t.Errorf("Normalize() = %+v, want metadata copied untagged", evt)
+	}
+}
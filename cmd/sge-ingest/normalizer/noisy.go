@@ -0,0 +1,124 @@
+package normalizer
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+// TagNoisySource marks events from sources exceeding their event budget.
+const TagNoisySource = "noisy-source"
+
+// maxTrackedSources bounds the per-source counter map.
+const maxTrackedSources = 100000
+
+// noisyBuckets is the number of slices the rolling window is divided into.
+const noisyBuckets = 10
+
+// sourceCount holds one source's event counts per window slice.
+type sourceCount struct {
+	slots  [noisyBuckets]int64 // Window slice each bucket holds, older slices are stale
+	counts [noisyBuckets]int
+}
+
+// add counts one event in slot and returns the total since oldest.
+func (c *sourceCount) add(slot, oldest int64) int {
+	i := slot % noisyBuckets
+	if c.slots[i] != slot {
+		c.slots[i], c.counts[i] = slot, 0
+	}
+	c.counts[i]++
+
+	n := 0
+	for i, s := range c.slots {
+		if s >= oldest {
+			n += c.counts[i]
+		}
+	}
+	return n
+}
+
+// latest returns the newest slot counted.
+func (c *sourceCount) latest() int64 {
+	return slices.Max(c.slots[:])
+}
+
+// NoisySourceTagger counts events per source over a rolling window and tags
+// events from sources that exceed the configured budget, so dashboards can
+// filter them and correlation can decide on suppression.
+type NoisySourceTagger struct {
+	threshold int
+	width     time.Duration // Duration of one bucket
+
+	mu        sync.Mutex
+	counts    map[string]*sourceCount
+	lastSweep int64 // Slot of the last stale source sweep
+	now       func() time.Time
+}
+
+// NewNoisySourceTagger creates a tagger allowing threshold events per source
+// within any window.
+func NewNoisySourceTagger(threshold int, window time.Duration) *NoisySourceTagger {
+	width := window / noisyBuckets
+	if width <= 0 {
+		width = time.Second
+	}
+	return &NoisySourceTagger{
+		threshold: threshold,
+		width:     width,
+		counts:    make(map[string]*sourceCount),
+		now:       time.Now,
+	}
+}
+
+// Tag counts evt against its source and adds TagNoisySource once the source
+// is over budget for the last window. It reports whether the tag was added.
+func (t *NoisySourceTagger) Tag(evt *models.Event) bool {
+	key := sourceKey(evt)
+	if key == "" {
+		return false
+	}
+
+	slot := t.now().UnixNano() / int64(t.width)
+	oldest := slot - noisyBuckets + 1
+
+	t.mu.Lock()
+	if slot-t.lastSweep >= noisyBuckets {
+		t.lastSweep = slot
+		for k, c := range t.counts {
+			if c.latest() < oldest {
+				delete(t.counts, k)
+			}
+		}
+	}
+
+	c, tracked := t.counts[key]
+	if !tracked {
+		if len(t.counts) >= maxTrackedSources {
+			t.mu.Unlock()
+			return false
+		}
+		c = &sourceCount{}
+		t.counts[key] = c
+	}
+	n := c.add(slot, oldest)
+	t.mu.Unlock()
+
+	if n <= t.threshold {
+		return false
+	}
+	if !slices.Contains(evt.Tags, TagNoisySource) {
+		evt.Tags = append(evt.Tags, TagNoisySource)
+	}
+	return true
+}
+
+// sourceKey identifies the emitting device: its IP if known, otherwise the source name.
+func sourceKey(evt *models.Event) string {
+	if evt.SourceIP != "" {
+		return evt.SourceIP
+	}
+	return evt.Source
+}
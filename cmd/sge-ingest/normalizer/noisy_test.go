@@ -0,0 +1,62 @@
+package normalizer
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+func TestNoisySourceTagger(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tagger := NewNoisySourceTagger(5, time.Minute)
+	tagger.now = func() time.Time { return now }
+
+	send := func(ip string, n int) (tagged int) {
+		for i := 0; i < n; i++ {
+			evt := &models.Event{Source: "syslog", SourceIP: ip}
+			if tagger.Tag(evt) {
+				tagged++
+				if !slices.Contains(evt.Tags, TagNoisySource) {
+					t.Fatalf("Tag() = true but tags = %v", evt.Tags)
+				}
+			}
+		}
+		return tagged
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		ip      string
+		events  int
+		want    int
+	}{
+		{"High Rate Source", 0, "10.0.0.1", 8, 3},
+		{"Normal Source", 0, "10.0.0.2", 5, 0},
+		{"Still Over Budget", 30 * time.Second, "10.0.0.1", 1, 1},
+		{"Old Events Expire", 31 * time.Second, "10.0.0.1", 4, 0},
+		{"Window Rolls", time.Second, "10.0.0.1", 1, 1},
+		{"Window Drains", time.Minute, "10.0.0.1", 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if got := send(tt.ip, tt.events); got != tt.want {
+				t.Errorf("tagged %d of %d events, want %d", got, tt.events, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoisySourceTaggerKeepsTagsUnique(t *testing.T) {
+	tagger := NewNoisySourceTagger(0, time.Minute)
+	evt := &models.Event{Source: "agent", Tags: []string{TagNoisySource}}
+
+	tagger.Tag(evt)
+	if len(evt.Tags) != 1 {
+		t.Errorf("Tags = %v, want a single %s", evt.Tags, TagNoisySource)
+	}
+}
@@ -0,0 +1,156 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sakin-go/pkg/models"
+	"sakin-go/pkg/utils"
+)
+
+// Names of the built-in stages, usable in INGEST_PIPELINE.
+const (
+	StageID        = "id"
+	StageTimestamp = "timestamp"
+	StageFields    = "fields"
+	StageMetadata  = "metadata"
+	StageNoisy     = "noisy"
+)
+
+// DefaultStageNames is the built-in stage order.
+var DefaultStageNames = []string{StageID, StageTimestamp, StageFields, StageMetadata, StageNoisy}
+
+// Record is what the stages work on: the decoded payload and the event
+// being built from it.
+type Record struct {
+	Raw      map[string]interface{}
+	Event    *models.Event
+	RemoteIP string // Address the payload was received from, if known
+}
+
+// Stage is one step of event normalization. Returning an error stops the
+// pipeline and rejects the event.
+type Stage interface {
+	Name() string
+	Process(rec *Record) error
+}
+
+type stageFunc struct {
+	name string
+	fn   func(rec *Record) error
+}
+
+func (s stageFunc) Name() string              { return s.name }
+func (s stageFunc) Process(rec *Record) error { return s.fn(rec) }
+
+// NewStage wraps fn as a Stage.
+func NewStage(name string, fn func(rec *Record) error) Stage {
+	return stageFunc{name: name, fn: fn}
+}
+
+// Pipeline runs its stages in order on every event.
+type Pipeline struct {
+	stages []Stage
+}
+
+// NewPipeline creates a pipeline running stages in the given order.
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// BuildPipeline creates a pipeline from stage names, looked up in available.
+func BuildPipeline(names []string, available map[string]Stage) (*Pipeline, error) {
+	stages := make([]Stage, 0, len(names))
+	for _, name := range names {
+		s, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown pipeline stage %q", name)
+		}
+		stages = append(stages, s)
+	}
+	return NewPipeline(stages...), nil
+}
+
+// Stages returns the stage names in execution order.
+func (p *Pipeline) Stages() []string {
+	names := make([]string, len(p.stages))
+	for i, s := range p.stages {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// Normalize decodes an agent payload and runs the stages on it. The first
+// failing stage aborts the pipeline.
+func (p *Pipeline) Normalize(data []byte) (*models.Event, error) {
+	return p.NormalizeFrom(data, "")
+}
+
+// NormalizeFrom is Normalize for a payload received from remoteIP, which
+// becomes the event's source IP when the payload carries none.
+func (p *Pipeline) NormalizeFrom(data []byte, remoteIP string) (*models.Event, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	rec := &Record{Raw: raw, Event: &models.Event{}, RemoteIP: remoteIP}
+	for _, s := range p.stages {
+		if err := s.Process(rec); err != nil {
+			return nil, fmt.Errorf("stage %s: %w", s.Name(), err)
+		}
+	}
+	return rec.Event, nil
+}
+
+// Stages returns the built-in stages by name. noisy may be nil, in which
+// case the noisy stage does nothing; limits bound the metadata stage.
+func Stages(noisy *NoisySourceTagger, limits MetadataLimits) map[string]Stage {
+	return map[string]Stage{
+		StageID:        NewStage(StageID, assignID),
+		StageTimestamp: NewStage(StageTimestamp, stampReceived),
+		StageFields:    NewStage(StageFields, mapFields),
+		StageMetadata:  NewStage(StageMetadata, mapMetadata(limits)),
+		StageNoisy: NewStage(StageNoisy, func(rec *Record) error {
+			if noisy != nil {
+				noisy.Tag(rec.Event)
+			}
+			return nil
+		}),
+	}
+}
+
+func assignID(rec *Record) error {
+	rec.Event.ID = utils.GenerateID()
+	return nil
+}
+
+func stampReceived(rec *Record) error {
+	rec.Event.Timestamp = time.Now().UTC()
+	return nil
+}
+
+// mapFields copies the known agent fields, with defaults for missing ones.
+func mapFields(rec *Record) error {
+	evt := rec.Event
+	evt.Source = "agent" // Default
+	evt.Status = models.EventStatusNew
+
+	if val, ok := rec.Raw["source"].(string); ok {
+		evt.Source = val
+	}
+	if val, ok := rec.Raw["event_type"].(string); ok {
+		evt.EventType = val
+	}
+	if val, ok := rec.Raw["severity"].(string); ok {
+		evt.Severity = models.Severity(val)
+	}
+	evt.SourceIP = rec.RemoteIP
+	if val, ok := rec.Raw["source_ip"].(string); ok && val != "" {
+		evt.SourceIP = val
+	}
+
+	// ... Map other fields ...
+	return nil
+}
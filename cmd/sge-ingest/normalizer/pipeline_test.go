@@ -0,0 +1,140 @@
+package normalizer
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+// recordStage appends its name to order when run.
+func recordStage(name string, order *[]string, err error) Stage {
+	return NewStage(name, func(*Record) error {
+		*order = append(*order, name)
+		return err
+	})
+}
+
+func TestPipelineOrder(t *testing.T) {
+	var order []string
+	p := NewPipeline(recordStage("a", &order, nil), recordStage("b", &order, nil), recordStage("c", &order, nil))
+
+	if _, err := p.Normalize([]byte(`{}`)); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if !slices.Equal(order, []string{"a", "b", "c"}) {
+		t.Errorf("stages ran as %v, want [a b c]", order)
+	}
+	if !slices.Equal(p.Stages(), []string{"a", "b", "c"}) {
+		t.Errorf("Stages() = %v, want [a b c]", p.Stages())
+	}
+}
+
+func TestPipelineShortCircuit(t *testing.T) {
+	errReject := errors.New("rejected")
+	var order []string
+	p := NewPipeline(recordStage("a", &order, nil), recordStage("b", &order, errReject), recordStage("c", &order, nil))
+
+	evt, err := p.Normalize([]byte(`{}`))
+	if !errors.Is(err, errReject) || evt != nil {
+		t.Fatalf("Normalize() = %v, %v, want nil, %v", evt, err, errReject)
+	}
+	if !slices.Equal(order, []string{"a", "b"}) {
+		t.Errorf("stages ran as %v, want [a b] (c skipped)", order)
+	}
+
+	if _, err := p.Normalize([]byte(`not json`)); err == nil {
+		t.Error("Normalize() on invalid JSON error = nil")
+	}
+}
+
+func TestPipelineCustomStage(t *testing.T) {
+	// A redaction stage injected after the built-in field mapping
+	redact := NewStage("redact", func(rec *Record) error {
+		if _, ok := rec.Raw["password"]; ok {
+			rec.Event.Tags = append(rec.Event.Tags, "redacted")
+		}
+		return nil
+	})
+	available := Stages(nil, DefaultMetadataLimits())
+	available["redact"] = redact
+
+	p, err := BuildPipeline([]string{StageID, StageFields, "redact"}, available)
+	if err != nil {
+		t.Fatalf("BuildPipeline() error = %v", err)
+	}
+	evt, err := p.Normalize([]byte(`{"source":"vpn","password":"x"}`))
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if evt.Source != "vpn" || !slices.Contains(evt.Tags, "redacted") || evt.ID == "" {
+		t.Errorf("Normalize() = %+v, want source vpn, ID and redacted tag", evt)
+	}
+	if !evt.Timestamp.IsZero() {
+		t.Errorf("Timestamp = %v, want zero (timestamp stage not configured)", evt.Timestamp)
+	}
+
+	if _, err := BuildPipeline([]string{"id", "hash"}, available); err == nil {
+		t.Error("BuildPipeline() with unknown stage error = nil")
+	}
+}
+
+func TestDefaultPipeline(t *testing.T) {
+	tagger := NewNoisySourceTagger(0, time.Minute)
+	p, err := BuildPipeline(DefaultStageNames, Stages(tagger, DefaultMetadataLimits()))
+	if err != nil {
+		t.Fatalf("BuildPipeline() error = %v", err)
+	}
+
+	evt, err := p.Normalize([]byte(`{"event_type":"login","severity":"high"}`))
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if evt.ID == "" || evt.Timestamp.IsZero() || evt.Source != "agent" || evt.Status != models.EventStatusNew {
+		t.Errorf("Normalize() = %+v, want ID, timestamp and defaults", evt)
+	}
+	if evt.EventType != "login" || evt.Severity != models.SeverityHigh {
+		t.Errorf("EventType/Severity = %s/%s, want login/high", evt.EventType, evt.Severity)
+	}
+	if !slices.Contains(evt.Tags, TagNoisySource) {
+		t.Errorf("Tags = %v, want %s from the noisy stage", evt.Tags, TagNoisySource)
+	}
+}
+
+func TestNormalizeFromSourceIP(t *testing.T) {
+	tagger := NewNoisySourceTagger(1, time.Minute)
+	p, err := BuildPipeline(DefaultStageNames, Stages(tagger, DefaultMetadataLimits()))
+	if err != nil {
+		t.Fatalf("BuildPipeline() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		payload  string
+		remoteIP string
+		wantIP   string
+		noisy    bool
+	}{
+		{"Payload Source IP", `{"source_ip":"10.0.0.1"}`, "192.0.2.1", "10.0.0.1", false},
+		{"Remote IP Fallback", `{}`, "10.0.0.2", "10.0.0.2", false},
+		{"Empty Source IP Falls Back", `{"source_ip":""}`, "10.0.0.3", "10.0.0.3", false},
+		{"Same Agent Over Budget", `{}`, "10.0.0.2", "10.0.0.2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt, err := p.NormalizeFrom([]byte(tt.payload), tt.remoteIP)
+			if err != nil {
+				t.Fatalf("NormalizeFrom() error = %v", err)
+			}
+			if evt.SourceIP != tt.wantIP {
+				t.Errorf("SourceIP = %q, want %q", evt.SourceIP, tt.wantIP)
+			}
+			if got := slices.Contains(evt.Tags, TagNoisySource); got != tt.noisy {
+				t.Errorf("noisy = %v, want %v (agents must not share a budget)", got, tt.noisy)
+			}
+		})
+	}
+}
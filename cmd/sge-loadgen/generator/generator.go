@@ -0,0 +1,140 @@
+// Package generator produces synthetic events and paces them at a target
+// rate, independent of how they are delivered.
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+// Weighted is a value drawn with probability Weight / sum of weights.
+type Weighted[T any] struct {
+	Value  T
+	Weight int
+}
+
+// Mix describes the distribution of generated events.
+type Mix struct {
+	Severities []Weighted[models.Severity]
+	Sources    []Weighted[string]
+	EventTypes []string
+}
+
+// DefaultMix resembles a typical SIEM feed: mostly informational events,
+// a thin tail of high and critical ones.
+func DefaultMix() Mix {
+	return Mix{
+		Severities: []Weighted[models.Severity]{
+			{models.SeverityInfo, 60},
+			{models.SeverityLow, 20},
+			{models.SeverityMedium, 12},
+			{models.SeverityHigh, 6},
+			{models.SeverityCritical, 2},
+		},
+		Sources: []Weighted[string]{
+			{"firewall", 40},
+			{"linux", 25},
+			{"windows", 20},
+			{"web", 10},
+			{"dns", 5},
+		},
+		EventTypes: []string{"network.connection", "auth.login", "process.start", "http.request", "dns.query"},
+	}
+}
+
+// Generator creates synthetic events. The sequence is fully determined by
+// the seed. It is not safe for concurrent use.
+type Generator struct {
+	mix Mix
+	rng *rand.Rand
+	seq uint64
+	now func() time.Time
+}
+
+// New creates a generator drawing from mix.
+func New(mix Mix, seed int64) *Generator {
+	return &Generator{mix: mix, rng: rand.New(rand.NewSource(seed)), now: time.Now}
+}
+
+// Next returns a new event in the shape agents send to ingest.
+func (g *Generator) Next() *models.Event {
+	g.seq++
+	severity := pick(g.rng, g.mix.Severities)
+	source := pick(g.rng, g.mix.Sources)
+	eventType := g.mix.EventTypes[g.rng.Intn(len(g.mix.EventTypes))]
+	src := fmt.Sprintf("10.%d.%d.%d", g.rng.Intn(4), g.rng.Intn(256), 1+g.rng.Intn(254))
+	dst := fmt.Sprintf("203.0.113.%d", 1+g.rng.Intn(254))
+
+	return &models.Event{
+		Timestamp:   g.now().UTC(),
+		Source:      source,
+		SourceIP:    src,
+		DestIP:      dst,
+		EventType:   eventType,
+		Severity:    severity,
+		Status:      models.EventStatusNew,
+		Description: fmt.Sprintf("loadgen %s event #%d", eventType, g.seq),
+		Metadata: map[string]interface{}{
+			"loadgen_seq": g.seq,
+			"dst_port":    []int{22, 53, 80, 443, 3389}[g.rng.Intn(5)],
+		},
+		Tags: []string{"loadgen"},
+	}
+}
+
+// Batch returns n new events.
+func (g *Generator) Batch(n int) []*models.Event {
+	out := make([]*models.Event, n)
+	for i := range out {
+		out[i] = g.Next()
+	}
+	return out
+}
+
+func pick[T any](rng *rand.Rand, items []Weighted[T]) T {
+	total := 0
+	for _, it := range items {
+		total += it.Weight
+	}
+	n := rng.Intn(total)
+	for _, it := range items {
+		if n < it.Weight {
+			return it.Value
+		}
+		n -= it.Weight
+	}
+	return items[len(items)-1].Value
+}
+
+// Pacer spreads events evenly over time at a fixed rate, without drift:
+// it always computes what should have been sent since the start rather
+// than sleeping a fixed amount per event.
+type Pacer struct {
+	rate float64 // Events per second
+	sent uint64
+}
+
+// NewPacer creates a pacer for rate events per second.
+func NewPacer(rate float64) *Pacer {
+	return &Pacer{rate: rate}
+}
+
+// Due returns how many events to send now, elapsed after the start, to
+// stay on schedule, and counts them as sent.
+func (p *Pacer) Due(elapsed time.Duration) int {
+	target := uint64(p.rate * elapsed.Seconds())
+	if target <= p.sent {
+		return 0
+	}
+	n := target - p.sent
+	p.sent = target
+	return int(n)
+}
+
+// Next returns how long after the start the next event is due.
+func (p *Pacer) Next() time.Duration {
+	return time.Duration(float64(p.sent+1) / p.rate * float64(time.Second))
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+func TestGeneratorDistribution(t *testing.T) {
+	const n = 50000
+	mix := DefaultMix()
+	g := New(mix, 42)
+
+	severities := make(map[models.Severity]int)
+	sources := make(map[string]int)
+	for _, evt := range g.Batch(n) {
+		severities[evt.Severity]++
+		sources[evt.Source]++
+		if evt.SourceIP == "" || evt.EventType == "" || evt.Status != models.EventStatusNew {
+			t.Fatalf("incomplete event %+v", evt)
+		}
+	}
+
+	check := func(name string, got int, weight, total int) {
+		t.Helper()
+		want := float64(weight) / float64(total)
+		if frac := float64(got) / n; math.Abs(frac-want) > 0.01 {
+			t.Errorf("%s fraction = %.3f, want %.3f", name, frac, want)
+		}
+	}
+	total := 0
+	for _, w := range mix.Severities {
+		total += w.Weight
+	}
+	for _, w := range mix.Severities {
+		check(string(w.Value), severities[w.Value], w.Weight, total)
+	}
+	total = 0
+	for _, w := range mix.Sources {
+		total += w.Weight
+	}
+	for _, w := range mix.Sources {
+		check(w.Value, sources[w.Value], w.Weight, total)
+	}
+}
+
+func TestGeneratorDeterministic(t *testing.T) {
+	a, b := New(DefaultMix(), 7), New(DefaultMix(), 7)
+	for i := 0; i < 100; i++ {
+		x, y := a.Next(), b.Next()
+		if x.Severity != y.Severity || x.Source != y.Source || x.SourceIP != y.SourceIP || x.Description != y.Description {
+			t.Fatalf("event %d differs for the same seed: %+v vs %+v", i, x, y)
+		}
+	}
+}
+
+func TestPacerRate(t *testing.T) {
+	tests := []struct {
+		name string
+		rate float64
+		step time.Duration
+		run  time.Duration
+		want int
+	}{
+		{"1k/s in 1ms steps", 1000, time.Millisecond, 2 * time.Second, 2000},
+		{"Coarse steps", 1000, 250 * time.Millisecond, 2 * time.Second, 2000},
+		{"Slower than steps", 3, 10 * time.Millisecond, 10 * time.Second, 30},
+		{"Fractional", 2.5, 100 * time.Millisecond, 4 * time.Second, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPacer(tt.rate)
+			sent := 0
+			for at := time.Duration(0); at <= tt.run; at += tt.step {
+				sent += p.Due(at)
+			}
+			if sent != tt.want {
+				t.Errorf("sent %d events, want %d", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestPacerNext(t *testing.T) {
+	p := NewPacer(4)
+	if got := p.Next(); got != 250*time.Millisecond {
+		t.Errorf("Next() = %s, want 250ms", got)
+	}
+	p.Due(time.Second)
+	if got := p.Next(); got != 1250*time.Millisecond {
+		t.Errorf("Next() after 4 events = %s, want 1.25s", got)
+	}
+}
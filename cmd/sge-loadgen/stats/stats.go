@@ -0,0 +1,88 @@
+// Package stats collects per-request latencies and errors of a load run.
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder accumulates request outcomes. It is safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration // Successful requests only
+	events    int             // Events in successful requests
+	errors    int
+	started   time.Time
+}
+
+// NewRecorder creates a recorder whose throughput is measured from now.
+func NewRecorder(now time.Time) *Recorder {
+	return &Recorder{started: now}
+}
+
+// Record adds one request carrying events that took latency.
+func (r *Recorder) Record(events int, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.errors++
+		return
+	}
+	r.events += events
+	r.latencies = append(r.latencies, latency)
+}
+
+// Summary is the result of a run.
+type Summary struct {
+	Requests   int
+	Events     int // Delivered events
+	Errors     int // Failed requests
+	ErrorRate  float64
+	Throughput float64 // Delivered events per second
+	Duration   time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+// Summary computes the run statistics at now.
+func (r *Recorder) Summary(now time.Time) Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lat := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+
+	s := Summary{
+		Requests: len(lat) + r.errors,
+		Events:   r.events,
+		Errors:   r.errors,
+		Duration: now.Sub(r.started),
+	}
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.Errors) / float64(s.Requests)
+	}
+	if s.Duration > 0 {
+		s.Throughput = float64(s.Events) / s.Duration.Seconds()
+	}
+	if len(lat) > 0 {
+		s.P50, s.P90, s.P99 = percentile(lat, 50), percentile(lat, 90), percentile(lat, 99)
+		s.Max = lat[len(lat)-1]
+	}
+	return s
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	return sorted[max(rank, 1)-1]
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d events in %s (%.0f events/s), %d requests, %d errors (%.2f%%), latency p50=%s p90=%s p99=%s max=%s",
+		s.Events, s.Duration.Round(time.Millisecond), s.Throughput, s.Requests, s.Errors, s.ErrorRate*100,
+		s.P50, s.P90, s.P99, s.Max)
+}
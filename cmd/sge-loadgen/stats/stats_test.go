@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecorderSummary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRecorder(start)
+	for i := 1; i <= 100; i++ {
+		r.Record(10, time.Duration(i)*time.Millisecond, nil)
+	}
+	for i := 0; i < 25; i++ {
+		r.Record(10, 0, errors.New("503"))
+	}
+
+	s := r.Summary(start.Add(2 * time.Second))
+	if s.Requests != 125 || s.Events != 1000 || s.Errors != 25 {
+		t.Errorf("Summary() = %+v, want 125 requests, 1000 events, 25 errors", s)
+	}
+	if s.ErrorRate != 0.2 || s.Throughput != 500 {
+		t.Errorf("ErrorRate = %v, Throughput = %v, want 0.2, 500", s.ErrorRate, s.Throughput)
+	}
+	if s.P50 != 50*time.Millisecond || s.P90 != 90*time.Millisecond || s.P99 != 99*time.Millisecond || s.Max != 100*time.Millisecond {
+		t.Errorf("percentiles = %s/%s/%s/%s, want 50ms/90ms/99ms/100ms", s.P50, s.P90, s.P99, s.Max)
+	}
+}
+
+func TestRecorderEmpty(t *testing.T) {
+	start := time.Now()
+	if s := NewRecorder(start).Summary(start); s.Requests != 0 || s.ErrorRate != 0 || s.Throughput != 0 || s.P99 != 0 {
+		t.Errorf("Summary() = %+v, want zero", s)
+	}
+}
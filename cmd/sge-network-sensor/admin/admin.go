@@ -0,0 +1,98 @@
+// Package admin serves the sensor's local control API: pipeline stats,
+// pausing/resuming capture and, when a detector runs, its tracker state.
+package admin
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+// Capture is the part of the inspector the API controls.
+type Capture interface {
+	Pause()
+	Resume()
+	Paused() bool
+	Stats() inspector.Stats
+}
+
+// Detections is the part of the detector the API reports (see
+// detector.Detector).
+type Detections interface {
+	GetPortScanRanking() []detector.RankedPortScan
+	GetBeaconRanking() []detector.RankedBeacon
+}
+
+// Server is the admin API.
+type Server struct {
+	app        *fiber.App
+	capture    Capture
+	detections Detections // nil when no detector runs
+}
+
+// NewServer creates the admin API for capture.
+func NewServer(capture Capture) *Server {
+	s := &Server{
+		app:     fiber.New(fiber.Config{DisableStartupMessage: true}),
+		capture: capture,
+	}
+	s.app.Get("/health", func(c *fiber.Ctx) error {
+		return c.SendString("OK")
+	})
+	s.app.Get("/stats", s.handleStats)
+	s.app.Get("/capture", s.handleCapture)
+	s.app.Post("/capture/pause", s.handlePause)
+	s.app.Post("/capture/resume", s.handleResume)
+	s.app.Get("/detections/portscan", s.handlePortScan)
+	s.app.Get("/detections/beacons", s.handleBeacons)
+	return s
+}
+
+// WithDetections makes the detector's tracker state available under
+// /detections; without it those endpoints answer 404.
+func (s *Server) WithDetections(d Detections) *Server {
+	s.detections = d
+	return s
+}
+
+// App returns the underlying fiber app (for tests).
+func (s *Server) App() *fiber.App { return s.app }
+
+// Listen serves the API on addr until Shutdown.
+func (s *Server) Listen(addr string) error { return s.app.Listen(addr) }
+
+// Shutdown stops the server.
+func (s *Server) Shutdown() error { return s.app.Shutdown() }
+
+func (s *Server) handleStats(c *fiber.Ctx) error {
+	return c.JSON(s.capture.Stats())
+}
+
+func (s *Server) handleCapture(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"paused": s.capture.Paused()})
+}
+
+func (s *Server) handlePause(c *fiber.Ctx) error {
+	s.capture.Pause()
+	return c.JSON(fiber.Map{"paused": true})
+}
+
+func (s *Server) handleResume(c *fiber.Ctx) error {
+	s.capture.Resume()
+	return c.JSON(fiber.Map{"paused": false})
+}
+
+func (s *Server) handlePortScan(c *fiber.Ctx) error {
+	if s.detections == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "no detector running"})
+	}
+	return c.JSON(s.detections.GetPortScanRanking())
+}
+
+func (s *Server) handleBeacons(c *fiber.Ctx) error {
+	if s.detections == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "no detector running"})
+	}
+	return c.JSON(s.detections.GetBeaconRanking())
+}
@@ -0,0 +1,129 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+type fakeCapture struct {
+	paused bool
+}
+
+func (f *fakeCapture) Pause()       { f.paused = true }
+func (f *fakeCapture) Resume()      { f.paused = false }
+func (f *fakeCapture) Paused() bool { return f.paused }
+func (f *fakeCapture) Stats() inspector.Stats {
+	return inspector.Stats{Packets: 42, Paused: f.paused}
+}
+
+func TestCaptureControl(t *testing.T) {
+	capture := &fakeCapture{}
+	app := NewServer(capture).App()
+
+	steps := []struct {
+		method, path string
+		wantCode     int
+		wantPaused   bool
+	}{
+		{"GET", "/capture", 200, false},
+		{"POST", "/capture/pause", 200, true},
+		{"GET", "/capture", 200, true},
+		{"POST", "/capture/pause", 200, true}, // Idempotent
+		{"POST", "/capture/resume", 200, false},
+		{"GET", "/capture/pause", 405, false},
+	}
+	for _, st := range steps {
+		resp, err := app.Test(httptest.NewRequest(st.method, st.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: %v", st.method, st.path, err)
+		}
+		if resp.StatusCode != st.wantCode {
+			t.Errorf("%s %s status = %d, want %d", st.method, st.path, resp.StatusCode, st.wantCode)
+		}
+		if capture.paused != st.wantPaused {
+			t.Errorf("after %s %s paused = %v, want %v", st.method, st.path, capture.paused, st.wantPaused)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestStats(t *testing.T) {
+	capture := &fakeCapture{paused: true}
+	resp, err := NewServer(capture).App().Test(httptest.NewRequest("GET", "/stats", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got inspector.Stats
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Packets != 42 || !got.Paused {
+		t.Errorf("stats = %+v, want 42 packets, paused", got)
+	}
+}
+
+type fakeDetections struct{}
+
+func (fakeDetections) GetPortScanRanking() []detector.RankedPortScan {
+	return []detector.RankedPortScan{{Source: "10.0.0.3", PortScanStats: detector.PortScanStats{Ports: 9}}, {Source: "10.0.0.1", PortScanStats: detector.PortScanStats{Ports: 2}}}
+}
+
+func (fakeDetections) GetBeaconRanking() []detector.RankedBeacon {
+	return []detector.RankedBeacon{{Key: "10.0.0.8->198.51.100.1:443", BeaconStats: detector.BeaconStats{Connections: 6, Score: 1}}}
+}
+
+func TestDetections(t *testing.T) {
+	t.Run("Without Detector", func(t *testing.T) {
+		app := NewServer(&fakeCapture{}).App()
+		for _, path := range []string{"/detections/portscan", "/detections/beacons"} {
+			resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != 404 {
+				t.Errorf("GET %s status = %d, want 404", path, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("Port Scan Order", func(t *testing.T) {
+		app := NewServer(&fakeCapture{}).WithDetections(fakeDetections{}).App()
+		resp, err := app.Test(httptest.NewRequest("GET", "/detections/portscan", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var got []detector.RankedPortScan
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(got) != 2 || got[0].Source != "10.0.0.3" || got[0].Ports != 9 || got[1].Source != "10.0.0.1" {
+			t.Errorf("portscan = %+v, want ranking order kept", got)
+		}
+	})
+
+	t.Run("Beacons", func(t *testing.T) {
+		app := NewServer(&fakeCapture{}).WithDetections(fakeDetections{}).App()
+		resp, err := app.Test(httptest.NewRequest("GET", "/detections/beacons", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		var got []map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(got) != 1 || got[0]["key"] != "10.0.0.8->198.51.100.1:443" || got[0]["score"] != 1.0 {
+			t.Errorf("beacons = %v, want flat key/score fields", got)
+		}
+	})
+}
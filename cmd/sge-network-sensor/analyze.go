@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"sakin-go/cmd/sge-network-sensor/analyzer"
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/dpi"
+)
+
+// runAnalyze implements `sge-network-sensor analyze [flags] <file.pcap>`.
+// It replays a capture through DPI and the threat detector and writes a
+// JSON report, without needing NATS or any database.
+func runAnalyze(args []string) int {
+	opts := analyzer.DefaultOptions()
+
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	out := fs.String("o", "", "Write the report to this file instead of stdout")
+	fs.IntVar(&opts.TopN, "top", opts.TopN, "Number of top talkers to report")
+	fs.IntVar(&opts.MaxEvents, "max-events", opts.MaxEvents, "Maximum events included in the report (0 for none)")
+	fs.IntVar(&opts.Detector.PortScanThreshold, "portscan-threshold", opts.Detector.PortScanThreshold, "Distinct ports per source to flag a port scan")
+	fs.Uint64Var(&opts.Detector.ExfilThresholdBytes, "exfil-bytes", opts.Detector.ExfilThresholdBytes, "Outbound bytes per pair to flag exfiltration")
+	exfilRules := fs.String("exfil-rules", "", "Per port/destination exfiltration thresholds, e.g. 443=1073741824,10.20.0.0/16:22=0 (0 never flags)")
+	minTLS := fs.String("min-tls", "1.2", "Flag TLS clients offering only versions below this (1.0-1.3, empty disables)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sge-network-sensor analyze [flags] <file.pcap>")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *exfilRules != "" {
+		rules, err := detector.ParseExfilThresholds(*exfilRules)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Analyze] -exfil-rules: %v\n", err)
+			return 2
+		}
+		opts.Detector.ExfilThresholds = rules
+	}
+	opts.Detector.MinTLSVersion = 0
+	if *minTLS != "" {
+		v, err := dpi.ParseTLSVersion(*minTLS)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Analyze] -min-tls: %v\n", err)
+			return 2
+		}
+		opts.Detector.MinTLSVersion = v
+	}
+
+	report, err := analyzer.AnalyzeFile(fs.Arg(0), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[Analyze] %v\n", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Analyze] %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "[Analyze] Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
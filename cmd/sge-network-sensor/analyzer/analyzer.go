@@ -0,0 +1,195 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket"
+
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// Options controls an offline analysis run.
+type Options struct {
+	Detector  detector.Config
+	TopN      int // Number of top talkers to report
+	MaxEvents int // Events included verbatim in the report, 0 for none
+}
+
+// DefaultOptions returns the options used by the analyze subcommand.
+func DefaultOptions() Options {
+	return Options{
+		Detector:  detector.DefaultConfig(),
+		TopN:      10,
+		MaxEvents: 1000,
+	}
+}
+
+// Report is the JSON summary of an analyzed capture.
+type Report struct {
+	File        string    `json:"file,omitempty"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	PacketCount int       `json:"packet_count"` // Packets read from the capture
+	EventCount  int       `json:"event_count"`  // Packets decoded into IP events
+	Bytes       uint64    `json:"bytes"`        // Total L4 payload bytes
+
+	Protocols    map[string]int `json:"protocols"`    // L4 protocol breakdown
+	Applications map[string]int `json:"applications"` // DPI detected L7 protocols
+	Categories   map[string]int `json:"categories"`   // Events per app_category
+
+	TopTalkers []Talker      `json:"top_talkers"`
+	Threats    ThreatSummary `json:"threats"`
+
+	Events          []inspector.NetworkEvent `json:"events"`
+	EventsTruncated bool                     `json:"events_truncated,omitempty"`
+}
+
+// Talker aggregates the traffic sent by one source address.
+type Talker struct {
+	IP      string `json:"ip"`
+	Packets int    `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+// ThreatSummary groups detected threats by type and severity.
+type ThreatSummary struct {
+	Total      int                         `json:"total"`
+	ByType     map[detector.ThreatType]int `json:"by_type"`
+	BySeverity map[models.Severity]int     `json:"by_severity"`
+	Items      []detector.Threat           `json:"items"`
+}
+
+// AnalyzeFile replays a pcap or pcapng file through the DPI decoder and
+// threat detector.
+func AnalyzeFile(path string, opts Options) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	report, err := Analyze(f, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	report.File = path
+	return report, nil
+}
+
+// Analyze replays a capture read from r.
+func Analyze(r io.Reader, opts Options) (*Report, error) {
+	src, err := inspector.NewCaptureReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return run(src, opts)
+}
+
+func run(src gopacket.PacketDataSource, opts Options) (*Report, error) {
+	report := &Report{
+		Protocols:    make(map[string]int),
+		Applications: make(map[string]int),
+		Categories:   make(map[string]int),
+		Threats: ThreatSummary{
+			ByType:     make(map[detector.ThreatType]int),
+			BySeverity: make(map[models.Severity]int),
+			Items:      []detector.Threat{},
+		},
+		Events: []inspector.NetworkEvent{},
+	}
+
+	decoder := inspector.NewDecoder()
+	det := detector.NewDetector(opts.Detector)
+	talkers := make(map[string]*Talker)
+
+	for {
+		data, ci, err := src.ReadPacketData()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read packet %d: %w", report.PacketCount+1, err)
+		}
+		report.PacketCount++
+
+		if report.StartTime.IsZero() {
+			report.StartTime = ci.Timestamp
+		}
+		report.EndTime = ci.Timestamp
+
+		evt, ok := decoder.Decode(data, ci.Timestamp)
+		if !ok {
+			continue
+		}
+		report.record(&evt, talkers, opts.MaxEvents)
+
+		for _, t := range det.Analyze(&evt) {
+			report.Threats.add(t)
+		}
+	}
+
+	report.TopTalkers = topTalkers(talkers, opts.TopN)
+	return report, nil
+}
+
+func (r *Report) record(evt *inspector.NetworkEvent, talkers map[string]*Talker, maxEvents int) {
+	r.EventCount++
+	r.Bytes += uint64(evt.PayloadSize)
+	r.Protocols[evt.Protocol]++
+	r.Categories[evt.AppCategory]++
+
+	if evt.AppProtocol != "" {
+		r.Applications[strings.ToUpper(evt.AppProtocol)]++
+	}
+
+	t, ok := talkers[evt.SrcIP]
+	if !ok {
+		t = &Talker{IP: evt.SrcIP}
+		talkers[evt.SrcIP] = t
+	}
+	t.Packets++
+	t.Bytes += uint64(evt.PayloadSize)
+
+	if len(r.Events) < maxEvents {
+		r.Events = append(r.Events, *evt)
+	} else {
+		r.EventsTruncated = true
+	}
+}
+
+func (s *ThreatSummary) add(t detector.Threat) {
+	s.Total++
+	s.ByType[t.Type]++
+	s.BySeverity[t.Severity]++
+	s.Items = append(s.Items, t)
+}
+
+// topTalkers returns the n sources that sent the most bytes.
+func topTalkers(talkers map[string]*Talker, n int) []Talker {
+	list := make([]Talker, 0, len(talkers))
+	for _, t := range talkers {
+		list = append(list, *t)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bytes != list[j].Bytes {
+			return list[i].Bytes > list[j].Bytes
+		}
+		if list[i].Packets != list[j].Packets {
+			return list[i].Packets > list[j].Packets
+		}
+		return list[i].IP < list[j].IP
+	})
+
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
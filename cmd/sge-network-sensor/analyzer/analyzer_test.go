@@ -0,0 +1,133 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/pkg/models"
+)
+
+// testdata/sample.pcap contains:
+//   - 10.0.0.5 SYN scanning 25 ports on 10.0.0.9
+//   - 10.0.0.7 beaconing to 203.0.113.10:443 (SNI c2.example.net) every ~60s, 8 times
+//   - 10.0.0.12 making 3 HTTP requests to example.com and 3 DNS queries (example.com, www. and api.)
+//   - 10.0.0.8 uploading 200 x 1400 bytes to 198.51.100.20:8443
+func analyzeSample(t *testing.T) *Report {
+	t.Helper()
+
+	opts := DefaultOptions()
+	opts.Detector.ExfilThresholdBytes = 256 * 1024
+
+	report, err := AnalyzeFile("testdata/sample.pcap", opts)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	return report
+}
+
+func TestAnalyzeFileCounts(t *testing.T) {
+	r := analyzeSample(t)
+
+	const packets = 25 + 8*2 + 3 + 3 + 200
+	if r.PacketCount != packets || r.EventCount != packets {
+		t.Errorf("PacketCount/EventCount = %d/%d, want %d", r.PacketCount, r.EventCount, packets)
+	}
+	if r.Protocols["TCP"] != packets-3 || r.Protocols["UDP"] != 3 {
+		t.Errorf("Protocols = %v, want TCP:%d UDP:3", r.Protocols, packets-3)
+	}
+	if r.Applications["TLS"] != 8 || r.Applications["HTTP"] != 3 || r.Applications["DNS"] != 3 {
+		t.Errorf("Applications = %v, want TLS:8 HTTP:3 DNS:3", r.Applications)
+	}
+	// Beacon (443), HTTP (80) and upload (8443) are web; DNS is other
+	if r.Categories["web"] < 8*2+3+200 || r.Categories["other"] < 3 {
+		t.Errorf("Categories = %v, want web >= %d and other >= 3", r.Categories, 8*2+3+200)
+	}
+	if len(r.TopTalkers) == 0 || r.TopTalkers[0].IP != "10.0.0.8" || r.TopTalkers[0].Bytes != 200*1400 {
+		t.Errorf("TopTalkers[0] = %+v, want 10.0.0.8 with %d bytes", r.TopTalkers, 200*1400)
+	}
+	if len(r.TopTalkers) != 4 {
+		t.Errorf("TopTalkers = %d entries, want 4", len(r.TopTalkers))
+	}
+	if !r.EndTime.After(r.StartTime) {
+		t.Errorf("EndTime %v not after StartTime %v", r.EndTime, r.StartTime)
+	}
+}
+
+func TestAnalyzeFileThreats(t *testing.T) {
+	r := analyzeSample(t)
+
+	tests := []struct {
+		typ      detector.ThreatType
+		srcIP    string
+		severity models.Severity
+	}{
+		{detector.ThreatPortScan, "10.0.0.5", models.SeverityMedium},
+		{detector.ThreatBeaconing, "10.0.0.7", models.SeverityHigh},
+		{detector.ThreatExfiltration, "10.0.0.8", models.SeverityHigh},
+	}
+
+	if r.Threats.Total != len(tests) {
+		t.Fatalf("Threats.Total = %d, want %d: %+v", r.Threats.Total, len(tests), r.Threats.Items)
+	}
+	if r.Threats.BySeverity[models.SeverityHigh] != 2 || r.Threats.BySeverity[models.SeverityMedium] != 1 {
+		t.Errorf("BySeverity = %v, want high:2 medium:1", r.Threats.BySeverity)
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.typ), func(t *testing.T) {
+			if r.Threats.ByType[tt.typ] != 1 {
+				t.Errorf("ByType[%s] = %d, want 1", tt.typ, r.Threats.ByType[tt.typ])
+			}
+			for _, item := range r.Threats.Items {
+				if item.Type == tt.typ {
+					if item.SrcIP != tt.srcIP || item.Severity != tt.severity {
+						t.Errorf("threat = %s/%s, want %s/%s", item.SrcIP, item.Severity, tt.srcIP, tt.severity)
+					}
+					return
+				}
+			}
+			t.Errorf("no %s threat in report", tt.typ)
+		})
+	}
+}
+
+func TestAnalyzeEventLimit(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxEvents = 10
+
+	r, err := AnalyzeFile("testdata/sample.pcap", opts)
+	if err != nil {
+		t.Fatalf("AnalyzeFile() error = %v", err)
+	}
+	if len(r.Events) != 10 || !r.EventsTruncated {
+		t.Errorf("Events = %d (truncated %v), want 10 (true)", len(r.Events), r.EventsTruncated)
+	}
+	// Default exfil threshold is far above the sample volume
+	if r.Threats.ByType[detector.ThreatExfiltration] != 0 {
+		t.Errorf("exfiltration flagged with default threshold")
+	}
+
+	if _, err := json.Marshal(r); err != nil {
+		t.Errorf("json.Marshal(report) error = %v", err)
+	}
+}
+
+func TestAnalyzeInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"Empty", nil},
+		{"Not A Capture", []byte("this is not a pcap file at all")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Analyze(bytes.NewReader(tt.data), DefaultOptions()); err == nil {
+				t.Error("Analyze() error = nil, want error")
+			}
+		})
+	}
+}
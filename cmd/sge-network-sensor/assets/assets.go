@@ -0,0 +1,232 @@
+// Package assets reports internal hosts (IP and MAC addresses) the first
+// time they appear in traffic, feeding the asset inventory.
+package assets
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+	"sakin-go/pkg/utils"
+)
+
+// Source is the event source of discovery events.
+const Source = "network-sensor"
+
+// redisKeyPrefix namespaces the shared seen-set in Redis.
+const redisKeyPrefix = "sensor:asset:"
+
+// SeenSet remembers assets for a TTL.
+type SeenSet interface {
+	// FirstSeen records key and reports whether it was not already present.
+	FirstSeen(ctx context.Context, key string) (bool, error)
+}
+
+type seenEntry struct {
+	key    string
+	expiry time.Time
+}
+
+// MemorySeen is a SeenSet holding at most capacity keys; the least
+// recently seen key is forgotten first. Seeing a key again extends its TTL.
+// It is safe for concurrent use.
+type MemorySeen struct {
+	ttl      time.Duration
+	capacity int
+
+	mu   sync.Mutex
+	keys map[string]*list.Element // Values are *seenEntry
+	lru  *list.List               // Front is most recently seen
+	now  func() time.Time
+}
+
+// NewMemorySeen creates a local seen-set.
+func NewMemorySeen(ttl time.Duration, capacity int) *MemorySeen {
+	return &MemorySeen{
+		ttl:      ttl,
+		capacity: capacity,
+		keys:     make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+func (m *MemorySeen) FirstSeen(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if el, ok := m.keys[key]; ok {
+		e := el.Value.(*seenEntry)
+		fresh := now.After(e.expiry)
+		e.expiry = now.Add(m.ttl)
+		m.lru.MoveToFront(el)
+		return fresh, nil
+	}
+	if m.capacity > 0 && len(m.keys) >= m.capacity {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.keys, oldest.Value.(*seenEntry).key)
+	}
+	m.keys[key] = m.lru.PushFront(&seenEntry{key: key, expiry: now.Add(m.ttl)})
+	return true, nil
+}
+
+// Len returns the number of remembered keys.
+func (m *MemorySeen) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.keys)
+}
+
+// setter is the Redis operation RedisSeen needs (see
+// database.RedisClient.SetIfAbsent).
+type setter interface {
+	SetIfAbsent(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+}
+
+// RedisSeen is a SeenSet shared by all sensors using the same Redis, so an
+// asset is reported once across the fleet per TTL.
+type RedisSeen struct {
+	client setter
+	ttl    time.Duration
+}
+
+// NewRedisSeen creates a shared seen-set (client is usually a
+// *database.RedisClient).
+func NewRedisSeen(client setter, ttl time.Duration) *RedisSeen {
+	return &RedisSeen{client: client, ttl: ttl}
+}
+
+func (r *RedisSeen) FirstSeen(ctx context.Context, key string) (bool, error) {
+	return r.client.SetIfAbsent(ctx, redisKeyPrefix+key, 1, r.ttl)
+}
+
+// Writer delivers encoded discovery events.
+type Writer interface {
+	Write(data []byte) error
+}
+
+// Discoverer turns the first appearance of an internal IP or MAC into an
+// asset.discovered event. A local seen-set filters repeats so the shared
+// one (if any) is only asked about assets new to this sensor.
+type Discoverer struct {
+	sensor string
+	local  SeenSet
+	shared SeenSet // nil when not shared
+}
+
+// NewDiscoverer creates a discoverer. shared may be nil.
+func NewDiscoverer(sensor string, local, shared SeenSet) *Discoverer {
+	return &Discoverer{sensor: sensor, local: local, shared: shared}
+}
+
+// Observe returns the discovery events for the assets first seen in evt.
+func (d *Discoverer) Observe(ctx context.Context, evt *inspector.NetworkEvent) []*models.Event {
+	ip := net.ParseIP(evt.SrcIP)
+	if ip == nil || !isInternal(ip) {
+		return nil
+	}
+
+	var out []*models.Event
+	if d.first(ctx, "ip:"+evt.SrcIP) {
+		out = append(out, d.event(evt, "ip"))
+	}
+	if evt.SrcMAC != "" && isUnicastMAC(evt.SrcMAC) && d.first(ctx, "mac:"+evt.SrcMAC) {
+		out = append(out, d.event(evt, "mac"))
+	}
+	return out
+}
+
+func (d *Discoverer) first(ctx context.Context, key string) bool {
+	if ok, _ := d.local.FirstSeen(ctx, key); !ok || d.shared == nil {
+		return ok
+	}
+	ok, err := d.shared.FirstSeen(ctx, key)
+	if err != nil {
+		// Better a duplicate report than a missed asset
+		log.Printf("[Assets] Shared seen-set unavailable: %v", err)
+		return true
+	}
+	return ok
+}
+
+func (d *Discoverer) event(evt *inspector.NetworkEvent, kind string) *models.Event {
+	desc := "New asset discovered: " + evt.SrcIP
+	if kind == "mac" {
+		desc = "New MAC address discovered: " + evt.SrcMAC + " (" + evt.SrcIP + ")"
+	}
+	meta := map[string]interface{}{
+		"sensor":     d.sensor,
+		"asset_type": kind,
+		"ip":         evt.SrcIP,
+		"first_seen": evt.Timestamp.UTC(),
+		"interface":  evt.Interface,
+	}
+	if evt.SrcMAC != "" {
+		meta["mac"] = evt.SrcMAC
+	}
+	return &models.Event{
+		ID:          utils.GenerateID(),
+		Timestamp:   evt.Timestamp.UTC(),
+		Source:      Source,
+		SourceIP:    evt.SrcIP,
+		EventType:   models.EventTypeAssetDiscovered,
+		Severity:    models.SeverityLow,
+		Status:      models.EventStatusNew,
+		Description: desc,
+		Metadata:    meta,
+	}
+}
+
+// Run observes the network events from in until ctx is done or in is
+// closed, writing discovery events to w. Other values are ignored.
+func (d *Discoverer) Run(ctx context.Context, in <-chan interface{}, w Writer) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-in:
+			if !ok {
+				return
+			}
+			evt, ok := v.(inspector.NetworkEvent)
+			if !ok {
+				continue
+			}
+			for _, e := range d.Observe(ctx, &evt) {
+				data, err := json.Marshal(e)
+				if err == nil {
+					err = w.Write(data)
+				}
+				if err != nil {
+					log.Printf("[Assets] Publish failed: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func isInternal(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLinkLocalUnicast()
+}
+
+// isUnicastMAC rejects broadcast, multicast and all-zero addresses.
+func isUnicastMAC(s string) bool {
+	mac, err := net.ParseMAC(s)
+	if err != nil || len(mac) == 0 || mac[0]&1 == 1 {
+		return false
+	}
+	for _, b := range mac {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
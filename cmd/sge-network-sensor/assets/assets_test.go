@@ -0,0 +1,135 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+var t0 = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func packet(ip, mac string) *inspector.NetworkEvent {
+	return &inspector.NetworkEvent{Timestamp: t0, SrcIP: ip, SrcMAC: mac, DstIP: "203.0.113.10", Protocol: "TCP"}
+}
+
+// fakeRedis mimics SET NX with a shared key space.
+type fakeRedis struct {
+	mu   sync.Mutex
+	keys map[string]bool
+	err  error
+}
+
+func (f *fakeRedis) SetIfAbsent(_ context.Context, key string, _ interface{}, _ time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.keys[key] {
+		return false, nil
+	}
+	f.keys[key] = true
+	return true, nil
+}
+
+func TestDiscovererFirstSeen(t *testing.T) {
+	ctx := context.Background()
+	d := NewDiscoverer("sensor-a", NewMemorySeen(time.Hour, 100), nil)
+
+	got := d.Observe(ctx, packet("10.0.0.5", "00:11:22:33:44:55"))
+	if len(got) != 2 {
+		t.Fatalf("Observe() = %d events, want 2 (ip and mac)", len(got))
+	}
+	for _, e := range got {
+		if e.EventType != models.EventTypeAssetDiscovered || e.Severity != models.SeverityLow || e.SourceIP != "10.0.0.5" {
+			t.Errorf("Observe() event = %+v", e)
+		}
+	}
+	if got[0].Metadata["asset_type"] != "ip" || got[1].Metadata["asset_type"] != "mac" {
+		t.Errorf("asset types = %v, %v, want ip, mac", got[0].Metadata["asset_type"], got[1].Metadata["asset_type"])
+	}
+
+	for n := 0; n < 5; n++ {
+		if got := d.Observe(ctx, packet("10.0.0.5", "00:11:22:33:44:55")); len(got) != 0 {
+			t.Errorf("repeat #%d: Observe() = %d events, want 0", n, len(got))
+		}
+	}
+
+	tests := []struct {
+		name string
+		ip   string
+		mac  string
+		want int
+	}{
+		{"New IP, known MAC", "10.0.0.6", "00:11:22:33:44:55", 1},
+		{"External IP", "8.8.8.8", "00:aa:bb:cc:dd:ee", 0},
+		{"Broadcast MAC", "10.0.0.7", "ff:ff:ff:ff:ff:ff", 1},
+		{"No MAC", "10.0.0.8", "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.Observe(ctx, packet(tt.ip, tt.mac)); len(got) != tt.want {
+				t.Errorf("Observe() = %d events, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySeenWindow(t *testing.T) {
+	m := NewMemorySeen(time.Hour, 3)
+	now := t0
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if ok, _ := m.FirstSeen(ctx, "a"); !ok {
+		t.Error("FirstSeen(a) = false, want true")
+	}
+	now = now.Add(30 * time.Minute)
+	if ok, _ := m.FirstSeen(ctx, "a"); ok {
+		t.Error("FirstSeen(a) within window = true, want false")
+	}
+	// Seeing it refreshed the TTL
+	now = now.Add(45 * time.Minute)
+	if ok, _ := m.FirstSeen(ctx, "a"); ok {
+		t.Error("FirstSeen(a) after refresh = true, want false")
+	}
+	now = now.Add(2 * time.Hour)
+	if ok, _ := m.FirstSeen(ctx, "a"); !ok {
+		t.Error("FirstSeen(a) after window = false, want true")
+	}
+
+	for n := 0; n < 10; n++ {
+		m.FirstSeen(ctx, fmt.Sprint(n))
+	}
+	if m.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", m.Len())
+	}
+}
+
+func TestDiscovererShared(t *testing.T) {
+	ctx := context.Background()
+	redis := &fakeRedis{keys: make(map[string]bool)}
+	a := NewDiscoverer("sensor-a", NewMemorySeen(time.Hour, 100), NewRedisSeen(redis, time.Hour))
+	b := NewDiscoverer("sensor-b", NewMemorySeen(time.Hour, 100), NewRedisSeen(redis, time.Hour))
+
+	if got := a.Observe(ctx, packet("10.0.0.5", "")); len(got) != 1 {
+		t.Errorf("sensor-a Observe() = %d events, want 1", len(got))
+	}
+	if got := b.Observe(ctx, packet("10.0.0.5", "")); len(got) != 0 {
+		t.Errorf("sensor-b Observe() = %d events, want 0 (seen by sensor-a)", len(got))
+	}
+	if !redis.keys[redisKeyPrefix+"ip:10.0.0.5"] {
+		t.Errorf("redis keys = %v, want %s", redis.keys, redisKeyPrefix+"ip:10.0.0.5")
+	}
+
+	redis.err = errors.New("connection refused")
+	if got := b.Observe(ctx, packet("10.0.0.9", "")); len(got) != 1 {
+		t.Errorf("Observe() with Redis down = %d events, want 1", len(got))
+	}
+}
@@ -0,0 +1,135 @@
+package assets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotVersion is bumped when the snapshot layout changes.
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version int             `json:"version"`
+	Entries []snapshotEntry `json:"entries"` // Least recently seen first
+}
+
+type snapshotEntry struct {
+	Key    string    `json:"key"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// Snapshot writes the remembered keys and their expiry to w as JSON.
+func (m *MemorySeen) Snapshot(w io.Writer) error {
+	m.mu.Lock()
+	snap := snapshot{Version: snapshotVersion, Entries: make([]snapshotEntry, 0, len(m.keys))}
+	for el := m.lru.Back(); el != nil; el = el.Prev() {
+		e := el.Value.(*seenEntry)
+		snap.Entries = append(snap.Entries, snapshotEntry{Key: e.key, Expiry: e.expiry})
+	}
+	m.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(snap)
+}
+
+// Restore adds the keys of a snapshot written by Snapshot, keeping their
+// expiry and recency order. Expired keys are skipped and capacity is
+// enforced as usual. It returns the number of keys restored and still held.
+func (m *MemorySeen) Restore(r io.Reader) (int, error) {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return 0, err
+	}
+	if snap.Version != snapshotVersion {
+		return 0, fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	n := 0
+	for _, s := range snap.Entries {
+		if !now.Before(s.Expiry) {
+			continue
+		}
+		if el, ok := m.keys[s.Key]; ok {
+			el.Value.(*seenEntry).expiry = s.Expiry
+			m.lru.MoveToFront(el)
+			continue
+		}
+		if m.capacity > 0 && len(m.keys) >= m.capacity {
+			oldest := m.lru.Back()
+			m.lru.Remove(oldest)
+			delete(m.keys, oldest.Value.(*seenEntry).key)
+		}
+		m.keys[s.Key] = m.lru.PushFront(&seenEntry{key: s.Key, expiry: s.Expiry})
+		n++
+	}
+	return min(n, len(m.keys)), nil
+}
+
+// SaveFile writes a snapshot to path, replacing it atomically so a crash
+// mid-write keeps the previous snapshot.
+func (m *MemorySeen) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after the rename
+
+	if err := m.Snapshot(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFile restores the snapshot at path. A missing file is not an error,
+// it is the first start.
+func (m *MemorySeen) LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return m.Restore(f)
+}
+
+// RunSnapshots saves the set to path every interval and once more when ctx
+// is done, then returns.
+func (m *MemorySeen) RunSnapshots(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := m.SaveFile(path); err != nil {
+				log.Printf("[Assets] Final snapshot failed: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := m.SaveFile(path); err != nil {
+				log.Printf("[Assets] Snapshot failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.json")
+	ctx := context.Background()
+
+	before := NewMemorySeen(time.Hour, 10)
+	before.now = func() time.Time { return t0 }
+	for _, key := range []string{"ip:10.0.0.1", "ip:10.0.0.2", "mac:00:11:22:33:44:55"} {
+		before.FirstSeen(ctx, key)
+	}
+	if err := before.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	// Simulated restart: a fresh set loads the snapshot
+	after := NewMemorySeen(time.Hour, 10)
+	after.now = func() time.Time { return t0.Add(10 * time.Minute) }
+	n, err := after.LoadFile(path)
+	if err != nil || n != 3 {
+		t.Fatalf("LoadFile() = %d, %v, want 3, nil", n, err)
+	}
+	for _, key := range []string{"ip:10.0.0.1", "ip:10.0.0.2", "mac:00:11:22:33:44:55"} {
+		if first, _ := after.FirstSeen(ctx, key); first {
+			t.Errorf("FirstSeen(%s) = true after restore, want remembered", key)
+		}
+	}
+	if first, _ := after.FirstSeen(ctx, "ip:10.0.0.3"); !first {
+		t.Error("FirstSeen(new key) = false, want true")
+	}
+}
+
+func TestRestoreKeepsExpiryAndOrder(t *testing.T) {
+	ctx := context.Background()
+	src := NewMemorySeen(time.Hour, 10)
+	now := t0
+	src.now = func() time.Time { return now }
+	src.FirstSeen(ctx, "old")
+	now = now.Add(30 * time.Minute)
+	src.FirstSeen(ctx, "mid")
+	src.FirstSeen(ctx, "new")
+
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	// 45 minutes later "old" has expired; capacity 1 keeps the most recent
+	dst := NewMemorySeen(time.Hour, 1)
+	dst.now = func() time.Time { return t0.Add(75 * time.Minute) }
+	n, err := dst.Restore(&buf)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if n != 1 || dst.Len() != 1 {
+		t.Fatalf("Restore() = %d, Len() = %d, want 1 and 1", n, dst.Len())
+	}
+	if first, _ := dst.FirstSeen(ctx, "new"); first {
+		t.Error("FirstSeen(new) = true, want the most recent key kept")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	m := NewMemorySeen(time.Hour, 10)
+
+	if n, err := m.LoadFile(filepath.Join(dir, "missing.json")); n != 0 || err != nil {
+		t.Errorf("LoadFile(missing) = %d, %v, want 0, nil", n, err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"version":99,"entries":[]}`), 0o600)
+	if _, err := m.LoadFile(bad); err == nil {
+		t.Error("LoadFile(unknown version) error = nil")
+	}
+}
+
+func TestRunSnapshotsSavesOnStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.json")
+	m := NewMemorySeen(time.Hour, 10)
+	m.FirstSeen(context.Background(), "ip:10.0.0.1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.RunSnapshots(ctx, path, time.Hour)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	restored := NewMemorySeen(time.Hour, 10)
+	if n, err := restored.LoadFile(path); n != 1 || err != nil {
+		t.Errorf("LoadFile() = %d, %v, want 1, nil", n, err)
+	}
+	if matches, _ := filepath.Glob(path + ".*.tmp"); len(matches) != 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BPFProfiles are named capture filters for common deployments, so
+// operators do not have to hand-write BPF.
+var BPFProfiles = map[string]string{
+	"web-only":     "tcp port 80 or tcp port 443 or tcp port 8080 or tcp port 8443",
+	"dns-and-tls":  "port 53 or tcp port 443 or udp port 443 or tcp port 853",
+	"email":        "tcp port 25 or tcp port 465 or tcp port 587 or tcp port 110 or tcp port 995 or tcp port 143 or tcp port 993",
+	"no-broadcast": "not broadcast and not multicast",
+	"no-ssh":       "not tcp port 22", // Skip the sensor's own management sessions
+	"tcp-control":  "tcp[tcpflags] & (tcp-syn|tcp-fin|tcp-rst) != 0",
+}
+
+// bpfProfileEnvPrefix selects profiles for one interface, e.g.
+// SENSOR_BPF_PROFILE_ETH0 (see bpfEnvName).
+const bpfProfileEnvPrefix = "SENSOR_BPF_PROFILE_"
+
+// ProfileNames returns the BPF profile names in order.
+func ProfileNames() []string {
+	names := make([]string, 0, len(BPFProfiles))
+	for name := range BPFProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandBPF combines the named profiles and a user filter into one BPF
+// expression. All parts must match (AND); each is parenthesized so "or"
+// inside a profile does not leak into the combination. An empty result
+// captures everything.
+func ExpandBPF(profiles []string, filter string) (string, error) {
+	var parts []string
+	for _, name := range profiles {
+		expr, ok := BPFProfiles[name]
+		if !ok {
+			return "", fmt.Errorf("unknown BPF profile %q (known: %s)", name, strings.Join(ProfileNames(), ", "))
+		}
+		parts = append(parts, expr)
+	}
+	if filter = strings.TrimSpace(filter); filter != "" {
+		parts = append(parts, filter)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	for i, p := range parts {
+		parts[i] = "(" + p + ")"
+	}
+	return strings.Join(parts, " and "), nil
+}
+
+// CaptureFilter returns the BPF expression for iface: its own profiles if
+// configured, otherwise the global ones, ANDed with BPFFilter.
+func (c *AppConfig) CaptureFilter(iface string) (string, error) {
+	profiles := c.BPFProfiles
+	if p, ok := c.InterfaceBPFProfiles[bpfEnvName(iface)]; ok {
+		profiles = p
+	}
+	return ExpandBPF(profiles, c.BPFFilter)
+}
+
+// bpfEnvName maps an interface name to its environment variable suffix:
+// uppercase, with everything but letters and digits replaced by '_'
+// (eth0.100 -> ETH0_100).
+func bpfEnvName(iface string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, iface)
+}
+
+// loadInterfaceBPFProfiles reads every SENSOR_BPF_PROFILE_<IFACE> variable.
+func loadInterfaceBPFProfiles() map[string][]string {
+	out := make(map[string][]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(key, bpfProfileEnvPrefix); ok && name != "" {
+			out[name] = splitList(value)
+		}
+	}
+	return out
+}
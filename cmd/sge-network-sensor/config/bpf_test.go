@@ -0,0 +1,109 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// balanced reports whether the parentheses of expr nest correctly and no
+// group is empty.
+func balanced(expr string) bool {
+	depth := 0
+	for i, r := range expr {
+		switch r {
+		case '(':
+			depth++
+			if strings.HasPrefix(strings.TrimSpace(expr[i+1:]), ")") {
+				return false
+			}
+		case ')':
+			if depth--; depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func TestBPFProfiles(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"web-only", "tcp port 80 or tcp port 443 or tcp port 8080 or tcp port 8443"},
+		{"dns-and-tls", "port 53 or tcp port 443 or udp port 443 or tcp port 853"},
+		{"email", "tcp port 25 or tcp port 465 or tcp port 587 or tcp port 110 or tcp port 995 or tcp port 143 or tcp port 993"},
+		{"no-broadcast", "not broadcast and not multicast"},
+		{"no-ssh", "not tcp port 22"},
+		{"tcp-control", "tcp[tcpflags] & (tcp-syn|tcp-fin|tcp-rst) != 0"},
+	}
+	if len(tests) != len(BPFProfiles) {
+		t.Errorf("%d profiles tested, %d defined", len(tests), len(BPFProfiles))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandBPF([]string{tt.name}, "")
+			if err != nil || got != tt.want {
+				t.Errorf("ExpandBPF(%s) = %q, %v, want %q", tt.name, got, err, tt.want)
+			}
+			if !balanced(got) {
+				t.Errorf("ExpandBPF(%s) = %q, unbalanced", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestExpandBPF(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []string
+		filter   string
+		want     string
+		wantErr  bool
+	}{
+		{"Nothing", nil, "", "", false},
+		{"Filter Only", nil, " host 10.0.0.1 ", "host 10.0.0.1", false},
+		{"Profile And Filter", []string{"web-only"}, "net 10.0.0.0/8", "(tcp port 80 or tcp port 443 or tcp port 8080 or tcp port 8443) and (net 10.0.0.0/8)", false},
+		{"Two Profiles", []string{"no-broadcast", "dns-and-tls"}, "", "(not broadcast and not multicast) and (port 53 or tcp port 443 or udp port 443 or tcp port 853)", false},
+		{"Filter With Or", []string{"no-ssh"}, "host a or host b", "(not tcp port 22) and (host a or host b)", false},
+		{"Unknown Profile", []string{"web-only", "everything"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandBPF(tt.profiles, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandBPF() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandBPF() = %q, want %q", got, tt.want)
+			}
+			if !balanced(got) {
+				t.Errorf("ExpandBPF() = %q, unbalanced", got)
+			}
+		})
+	}
+}
+
+func TestCaptureFilter(t *testing.T) {
+	t.Setenv("SENSOR_BPF_PROFILE", "no-broadcast")
+	t.Setenv("SENSOR_BPF_PROFILE_ETH0_100", "web-only")
+	t.Setenv("SENSOR_BPF", "not host 10.0.0.1")
+	cfg := LoadConfig()
+
+	tests := []struct {
+		iface string
+		want  string
+	}{
+		{"eth0.100", "(tcp port 80 or tcp port 443 or tcp port 8080 or tcp port 8443) and (not host 10.0.0.1)"},
+		{"eth1", "(not broadcast and not multicast) and (not host 10.0.0.1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.iface, func(t *testing.T) {
+			got, err := cfg.CaptureFilter(tt.iface)
+			if err != nil || got != tt.want {
+				t.Errorf("CaptureFilter(%s) = %q, %v, want %q", tt.iface, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +16,59 @@ type AppConfig struct {
 	ReadTimeout     time.Duration // pcap read timeout
 	BPFFilter       string
 
+	// Capture file replayed through the live pipeline instead of capturing
+	// on interfaces (empty means live capture)
+	ReplayFile string
+
+	// Named BPF profiles (see BPFProfiles) ANDed with BPFFilter, globally
+	// or per interface (keyed by bpfEnvName). Use CaptureFilter.
+	BPFProfiles          []string
+	InterfaceBPFProfiles map[string][]string
+
+	// Capture scheduling
+	MaxCaptureInterfaces int  // Capture goroutines, each reading its share of the interfaces in turn; 0 means one per interface
+	Workers              int  // Decode workers, 0 means one per CPU
+	QueueSize            int  // Packets queued per interface before dropping
+	PinThreads           bool // Lock capture/worker goroutines to OS threads
+
+	// Flow tracking (0 size disables)
+	FlowTableSize   int           // Max concurrently tracked flows
+	FlowIdleTimeout time.Duration // Flows idle this long are expired
+
+	// Flow sampling: keep this fraction (0..1) of flows, with all of their
+	// packets (0 or 1 disables). The seed selects which subset is kept.
+	FlowSampleRate float64
+	FlowSampleSeed int
+
+	// Identical DNS queries (source, name, type) within this window become
+	// one event with a repeat_count, 0 disables
+	DNSDedupWindow time.Duration
+
+	// Run the threat detectors on the live stream and publish their threats
+	// as network.threat events
+	Detection bool
+
+	// Emit one event per TCP/UDP connection, from its first packet with
+	// payload, and ignore the connection's other packets
+	FirstPacketOnly bool
+
+	// Application protocol parsers (off by default)
+	FTPParsing  bool // FTP control channel commands/replies
+	SMTPParsing bool // SMTP envelope, headers & attachment names
+
+	// Asset discovery: emit an event the first time an internal IP/MAC is
+	// seen. The seen-set is shared through Redis when RedisAddr is set.
+	AssetDiscovery bool
+	AssetTTL       time.Duration // Assets are reported again after this long unseen
+	AssetMaxLocal  int           // Assets remembered locally
+	RedisAddr      string
+	RedisPassword  string
+
+	// Local seen-set snapshot, so sensors without Redis keep what they
+	// learned across restarts (empty path disables)
+	AssetSnapshotPath     string
+	AssetSnapshotInterval time.Duration
+
 	NatsURL      string
 	NatsUser     string
 	NatsPassword string
@@ -24,9 +78,66 @@ type AppConfig struct {
 	ClickHouseUser     string
 	ClickHousePassword string
 
+	// Self-telemetry on system.sensors.<sensor_name> (seconds, 0 disables)
+	TelemetryInterval int
+
+	// Stats deltas and rates written to the log (seconds, 0 disables)
+	StatsLogInterval int
+
+	// Local admin API (stats, pause/resume capture), empty disables
+	AdminAddr string
+
+	// Events failing to publish are stored here and replayed on startup
+	// (empty disables). With a key (hex/base64 AES-128/192/256) the files
+	// are AES-GCM encrypted.
+	FallbackDir string
+	FallbackKey string
+
+	// Outputs are additional destinations, each with its own projection
+	Outputs []OutputConfig
+
+	// Routes send matching events to specific outputs or subjects instead
+	// of all outputs
+	Routes []RouteConfig
+
 	DebugMode bool
 }
 
+// OutputConfig describes one named output.
+//
+//	SENSOR_OUTPUTS=siem,archive
+//	SENSOR_OUTPUT_SIEM_TYPE=nats            (nats | file)
+//	SENSOR_OUTPUT_SIEM_TARGET=events.raw.info.sensor
+//	SENSOR_OUTPUT_SIEM_FIELDS=timestamp,src_ip,dst_ip,dst_port,sni
+//	SENSOR_OUTPUT_SIEM_CATEGORIES=admin,file-transfer
+//	SENSOR_OUTPUT_SIEM_FORMAT=ecs           (native | ecs)
+type OutputConfig struct {
+	Name       string
+	Type       string
+	Format     string   // Serialization, empty is native
+	Target     string   // NATS subject or file path
+	Fields     []string // JSON fields to keep, empty keeps all
+	Categories []string // App categories routed here, empty routes all
+}
+
+// RouteConfig describes one edge routing rule. Empty criteria match all
+// events; an event matching several routes goes to all of their targets.
+//
+//	SENSOR_ROUTES=dns,alerts
+//	SENSOR_ROUTE_DNS_PROTOCOLS=dns                (tcp, udp or app_protocol)
+//	SENSOR_ROUTE_DNS_SUBJECT=events.raw.info.dns
+//	SENSOR_ROUTE_ALERTS_SEVERITIES=high,critical
+//	SENSOR_ROUTE_ALERTS_TAGS=malicious_ip,port_scan
+//	SENSOR_ROUTE_ALERTS_OUTPUTS=siem,archive      (configured output names)
+type RouteConfig struct {
+	Name       string
+	Protocols  []string
+	Severities []string
+	Tags       []string
+	Outputs    []string // Output names
+	Subject    string   // NATS subject, published in native format
+}
+
 // LoadConfig loads configuration from environment variables (or defaults).
 // In a real app, this might use viper or similar, but keeping it zero-alloc/simple here.
 func LoadConfig() *AppConfig {
@@ -39,6 +150,40 @@ func LoadConfig() *AppConfig {
 		ReadTimeout:     time.Duration(getEnvInt("SENSOR_TIMEOUT_MS", 100)) * time.Millisecond,
 		BPFFilter:       getEnv("SENSOR_BPF", ""), // Empty defaults to capturing everything
 
+		ReplayFile: getEnv("SENSOR_REPLAY_FILE", ""),
+
+		BPFProfiles:          splitList(getEnv("SENSOR_BPF_PROFILE", "")),
+		InterfaceBPFProfiles: loadInterfaceBPFProfiles(),
+
+		MaxCaptureInterfaces: getEnvInt("SENSOR_MAX_INTERFACES", 0),
+		Workers:              getEnvInt("SENSOR_WORKERS", 0),
+		QueueSize:            getEnvInt("SENSOR_QUEUE_SIZE", 4096),
+		PinThreads:           getEnv("SENSOR_PIN_THREADS", "false") == "true",
+
+		FlowTableSize:   getEnvInt("SENSOR_FLOW_TABLE_SIZE", 100000),
+		FlowIdleTimeout: time.Duration(getEnvInt("SENSOR_FLOW_IDLE_TIMEOUT", 60)) * time.Second,
+
+		FlowSampleRate: getEnvFloat("SENSOR_FLOW_SAMPLE_RATE", 1),
+		FlowSampleSeed: getEnvInt("SENSOR_FLOW_SAMPLE_SEED", 0),
+
+		DNSDedupWindow: time.Duration(getEnvInt("SENSOR_DNS_DEDUP_MS", 0)) * time.Millisecond,
+
+		Detection: getEnv("SENSOR_DETECTION", "true") == "true",
+
+		FirstPacketOnly: getEnv("SENSOR_DPI_FIRST_PACKET", "false") == "true",
+
+		FTPParsing:  getEnv("SENSOR_DPI_FTP", "false") == "true",
+		SMTPParsing: getEnv("SENSOR_DPI_SMTP", "false") == "true",
+
+		AssetDiscovery: getEnv("SENSOR_ASSET_DISCOVERY", "false") == "true",
+		AssetTTL:       time.Duration(getEnvInt("SENSOR_ASSET_TTL_HOURS", 24)) * time.Hour,
+		AssetMaxLocal:  getEnvInt("SENSOR_ASSET_MAX", 100000),
+		RedisAddr:      getEnv("REDIS_ADDR", ""),
+		RedisPassword:  getEnv("REDIS_PASSWORD", ""),
+
+		AssetSnapshotPath:     getEnv("SENSOR_ASSET_SNAPSHOT_PATH", ""),
+		AssetSnapshotInterval: time.Duration(getEnvInt("SENSOR_ASSET_SNAPSHOT_INTERVAL", 300)) * time.Second,
+
 		NatsURL:      getEnv("NATS_URL", "nats://localhost:4222"),
 		NatsUser:     getEnv("NATS_USER", "admin"),
 		NatsPassword: getEnv("NATS_PASSWORD", "sakin123"),
@@ -48,10 +193,65 @@ func LoadConfig() *AppConfig {
 		ClickHouseUser:     getEnv("CLICKHOUSE_USER", "default"),
 		ClickHousePassword: getEnv("CLICKHOUSE_PASSWORD", ""),
 
+		TelemetryInterval: getEnvInt("SENSOR_TELEMETRY_INTERVAL", 30),
+		StatsLogInterval:  getEnvInt("SENSOR_STATS_LOG_INTERVAL", 30),
+
+		AdminAddr: getEnv("SENSOR_ADMIN_ADDR", ""),
+
+		FallbackDir: getEnv("SENSOR_FALLBACK_DIR", ""),
+		FallbackKey: getEnv("SENSOR_FALLBACK_KEY", ""),
+
+		Outputs: loadOutputs(getEnv("SENSOR_OUTPUTS", "")),
+		Routes:  loadRoutes(getEnv("SENSOR_ROUTES", "")),
+
 		DebugMode: getEnv("DEBUG_MODE", "false") == "true",
 	}
 }
 
+// loadOutputs reads the per-output settings for each name in names.
+func loadOutputs(names string) []OutputConfig {
+	var outputs []OutputConfig
+	for _, name := range splitList(names) {
+		prefix := "SENSOR_OUTPUT_" + strings.ToUpper(name) + "_"
+		outputs = append(outputs, OutputConfig{
+			Name:       name,
+			Type:       getEnv(prefix+"TYPE", "nats"),
+			Format:     getEnv(prefix+"FORMAT", ""),
+			Target:     getEnv(prefix+"TARGET", ""),
+			Fields:     splitList(getEnv(prefix+"FIELDS", "")),
+			Categories: splitList(getEnv(prefix+"CATEGORIES", "")),
+		})
+	}
+	return outputs
+}
+
+// loadRoutes reads the per-route settings for each name in names.
+func loadRoutes(names string) []RouteConfig {
+	var routes []RouteConfig
+	for _, name := range splitList(names) {
+		prefix := "SENSOR_ROUTE_" + strings.ToUpper(name) + "_"
+		routes = append(routes, RouteConfig{
+			Name:       name,
+			Protocols:  splitList(getEnv(prefix+"PROTOCOLS", "")),
+			Severities: splitList(getEnv(prefix+"SEVERITIES", "")),
+			Tags:       splitList(getEnv(prefix+"TAGS", "")),
+			Outputs:    splitList(getEnv(prefix+"OUTPUTS", "")),
+			Subject:    getEnv(prefix+"SUBJECT", ""),
+		})
+	}
+	return routes
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -67,3 +267,12 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,314 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// maxBeaconSamples bounds the connection history kept per pair.
+const maxBeaconSamples = 32
+
+// maxBeaconIPs bounds the distinct destination IPs remembered per domain.
+const maxBeaconIPs = 32
+
+// Domain kinds a beacon can be keyed on.
+const (
+	beaconSNI  = "sni"
+	beaconHTTP = "http_host"
+	beaconDNS  = "dns"
+)
+
+// BeaconStats describes the connection rhythm of a source->destination pair.
+type BeaconStats struct {
+	Connections  int           `json:"connections"`
+	MeanInterval time.Duration `json:"mean_interval"`
+	Jitter       float64       `json:"jitter"` // Coefficient of variation of intervals
+	Score        float64       `json:"score"`  // How close the pair is to being judged a beacon (0..1)
+}
+
+// RankedBeacon is one pair in BeaconTracker.Ranked.
+type RankedBeacon struct {
+	Key string `json:"key"`
+	BeaconStats
+}
+
+type beaconEntry struct {
+	srcIP, dstIP string
+	dstPort      uint16
+	times        []time.Time
+	connections  int
+	alerted      bool
+
+	// Domain keyed entries only
+	kind, domain string
+	ips          map[string]struct{}
+}
+
+// BeaconTracker flags pairs that connect at suspiciously regular intervals,
+// the typical pattern of C2 implants checking in. Besides IP:port pairs
+// (keyed on SYNs) it can key on the contacted domain (TLS SNI, HTTP Host or
+// DNS query), catching C2 behind CDNs or fast-flux DNS where the
+// destination IP rotates but the name stays the same.
+type BeaconTracker struct {
+	minConnections int
+	maxJitter      float64
+	minInterval    time.Duration
+	idleTimeout    time.Duration
+	byDomain       bool
+
+	mu    sync.Mutex
+	pairs map[string]*beaconEntry
+}
+
+// NewBeaconTracker creates a beacon tracker.
+func NewBeaconTracker(minConnections int, maxJitter float64, minInterval, idleTimeout time.Duration) *BeaconTracker {
+	return &BeaconTracker{
+		minConnections: minConnections,
+		maxJitter:      maxJitter,
+		minInterval:    minInterval,
+		idleTimeout:    idleTimeout,
+		pairs:          make(map[string]*beaconEntry),
+	}
+}
+
+func beaconKey(evt *inspector.NetworkEvent) string {
+	return evt.SrcIP + "->" + evt.DstIP + ":" + strconv.Itoa(int(evt.DstPort))
+}
+
+// TrackDomains enables domain keyed beacon detection.
+func (t *BeaconTracker) TrackDomains(enabled bool) *BeaconTracker {
+	t.byDomain = enabled
+	return t
+}
+
+// beaconDomain returns the domain kind and name evt contacts, if any.
+func beaconDomain(evt *inspector.NetworkEvent) (string, string) {
+	switch {
+	case evt.SNI != "":
+		return beaconSNI, strings.ToLower(evt.SNI)
+	case evt.HTTPHost != "":
+		host := strings.ToLower(evt.HTTPHost)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		return beaconHTTP, host
+	case evt.DNSQuery != "":
+		return beaconDNS, strings.ToLower(strings.TrimSuffix(evt.DNSQuery, "."))
+	}
+	return "", ""
+}
+
+// Track records a connection attempt (or, with domain tracking, a TLS,
+// HTTP or DNS event) and returns a threat the first time the pair's
+// intervals look periodic.
+func (t *BeaconTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if !evt.IsConnectionAttempt() {
+		if t.byDomain {
+			if kind, domain := beaconDomain(evt); domain != "" {
+				return t.trackDomain(evt, kind, domain)
+			}
+		}
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := beaconKey(evt)
+	e, ok := t.pairs[key]
+	if !ok {
+		e = &beaconEntry{srcIP: evt.SrcIP, dstIP: evt.DstIP, dstPort: evt.DstPort}
+		t.pairs[key] = e
+	}
+	e.times = append(e.times, evt.Timestamp)
+	if len(e.times) > maxBeaconSamples {
+		e.times = e.times[len(e.times)-maxBeaconSamples:]
+	}
+	e.connections++
+
+	if e.alerted || len(e.times) < t.minConnections {
+		return nil
+	}
+
+	mean, jitter := intervalStats(e.times)
+	if mean < t.minInterval || jitter > t.maxJitter {
+		return nil
+	}
+	e.alerted = true
+
+	return &Threat{
+		Type:        ThreatBeaconing,
+		Severity:    models.SeverityHigh,
+		SrcIP:       e.srcIP,
+		DstIP:       e.dstIP,
+		DstPort:     e.dstPort,
+		Description: fmt.Sprintf("%s connects to %s:%d every %s (jitter %.2f)", e.srcIP, e.dstIP, e.dstPort, mean.Round(time.Second), jitter),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"connections":   e.connections,
+			"mean_interval": mean.Seconds(),
+			"jitter":        jitter,
+		},
+	}
+}
+
+// trackDomain records a contact of domain. TLS and HTTP beacons are only
+// reported once the domain resolved to more than one IP, otherwise the
+// IP:port tracker already covers them.
+func (t *BeaconTracker) trackDomain(evt *inspector.NetworkEvent, kind, domain string) *Threat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := evt.SrcIP + "->" + kind + ":" + domain
+	e, ok := t.pairs[key]
+	if !ok {
+		e = &beaconEntry{srcIP: evt.SrcIP, kind: kind, domain: domain, ips: make(map[string]struct{})}
+		t.pairs[key] = e
+	}
+	e.dstIP, e.dstPort = evt.DstIP, evt.DstPort
+	if len(e.ips) < maxBeaconIPs {
+		e.ips[evt.DstIP] = struct{}{}
+	}
+	e.times = append(e.times, evt.Timestamp)
+	if len(e.times) > maxBeaconSamples {
+		e.times = e.times[len(e.times)-maxBeaconSamples:]
+	}
+	e.connections++
+
+	if e.alerted || len(e.times) < t.minConnections || (kind != beaconDNS && len(e.ips) < 2) {
+		return nil
+	}
+
+	mean, jitter := intervalStats(e.times)
+	if mean < t.minInterval || jitter > t.maxJitter {
+		return nil
+	}
+	e.alerted = true
+
+	return &Threat{
+		Type:        ThreatBeaconing,
+		Severity:    models.SeverityHigh,
+		SrcIP:       e.srcIP,
+		DstIP:       e.dstIP,
+		DstPort:     e.dstPort,
+		Description: fmt.Sprintf("%s contacts %s every %s across %d IPs (jitter %.2f)", e.srcIP, domain, mean.Round(time.Second), len(e.ips), jitter),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"connections":   e.connections,
+			"mean_interval": mean.Seconds(),
+			"jitter":        jitter,
+			"domain":        domain,
+			"domain_source": kind,
+			"distinct_ips":  len(e.ips),
+		},
+	}
+}
+
+// intervalStats returns the mean interval between consecutive timestamps
+// and its coefficient of variation.
+func intervalStats(times []time.Time) (time.Duration, float64) {
+	if len(times) < 2 {
+		return 0, 0
+	}
+
+	n := float64(len(times) - 1)
+	var sum float64
+	for i := 1; i < len(times); i++ {
+		sum += times[i].Sub(times[i-1]).Seconds()
+	}
+	mean := sum / n
+	if mean == 0 {
+		return 0, 0
+	}
+
+	var variance float64
+	for i := 1; i < len(times); i++ {
+		d := times[i].Sub(times[i-1]).Seconds() - mean
+		variance += d * d
+	}
+	stddev := math.Sqrt(variance / n)
+
+	return time.Duration(mean * float64(time.Second)), stddev / mean
+}
+
+// strength returns how close the pair is to being judged a beacon (0..1):
+// the share of the required connections seen, as long as the intervals so
+// far are regular.
+func (t *BeaconTracker) strength(key string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.pairs[key]
+	if !ok {
+		return 0
+	}
+	mean, jitter := intervalStats(e.times)
+	return t.entryStrength(e, mean, jitter)
+}
+
+// entryStrength is strength for e with its interval stats. Caller holds mu.
+func (t *BeaconTracker) entryStrength(e *beaconEntry, mean time.Duration, jitter float64) float64 {
+	if len(e.times) < 3 || t.minConnections <= 0 {
+		return 0
+	}
+	if mean < t.minInterval || jitter > t.maxJitter {
+		return 0
+	}
+	return min(float64(len(e.times))/float64(t.minConnections), 1)
+}
+
+// Cleanup drops pairs that have been idle longer than the idle timeout.
+func (t *BeaconTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, e := range t.pairs {
+		if now.Sub(e.times[len(e.times)-1]) > t.idleTimeout {
+			delete(t.pairs, key)
+		}
+	}
+}
+
+// Stats returns a snapshot keyed by "src->dst:port" (or
+// "src->kind:domain" for domain keyed entries).
+func (t *BeaconTracker) Stats() map[string]BeaconStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]BeaconStats, len(t.pairs))
+	for key, e := range t.pairs {
+		mean, jitter := intervalStats(e.times)
+		stats[key] = BeaconStats{Connections: e.connections, MeanInterval: mean, Jitter: jitter, Score: t.entryStrength(e, mean, jitter)}
+	}
+	return stats
+}
+
+// Ranked returns Stats as a slice ordered by score, highest first, then by
+// connections and key, so repeated calls on the same data agree.
+func (t *BeaconTracker) Ranked() []RankedBeacon {
+	stats := t.Stats()
+	ranked := make([]RankedBeacon, 0, len(stats))
+	for key, s := range stats {
+		ranked = append(ranked, RankedBeacon{Key: key, BeaconStats: s})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Connections != b.Connections {
+			return a.Connections > b.Connections
+		}
+		return a.Key < b.Key
+	})
+	return ranked
+}
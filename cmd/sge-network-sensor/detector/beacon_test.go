@@ -0,0 +1,152 @@
+package detector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+// hello is a connection to dst followed by a TLS ClientHello for sni.
+func hello(src, dst, sni string, at time.Time) []*inspector.NetworkEvent {
+	ch := data(src, dst, 443, 300, at.Add(50*time.Millisecond))
+	ch.SNI = sni
+	return []*inspector.NetworkEvent{syn(src, dst, 443, at), ch}
+}
+
+func TestDomainBeacon(t *testing.T) {
+	tests := []struct {
+		name     string
+		byDomain bool
+		events   func() []*inspector.NetworkEvent
+		want     int
+	}{
+		{
+			name:     "Constant SNI Rotating IPs",
+			byDomain: true,
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					dst := fmt.Sprintf("203.0.113.%d", 10+i)
+					evts = append(evts, hello("10.0.0.7", dst, "cdn.example.com", t0.Add(time.Duration(i)*time.Minute))...)
+				}
+				return
+			},
+			want: 1,
+		},
+		{
+			name:     "IP Only Tracker Misses Rotation",
+			byDomain: false,
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					dst := fmt.Sprintf("203.0.113.%d", 10+i)
+					evts = append(evts, hello("10.0.0.7", dst, "cdn.example.com", t0.Add(time.Duration(i)*time.Minute))...)
+				}
+				return
+			},
+			want: 0,
+		},
+		{
+			name:     "Constant IP Reported Once",
+			byDomain: true,
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					evts = append(evts, hello("10.0.0.7", "203.0.113.10", "c2.example.com", t0.Add(time.Duration(i)*time.Minute))...)
+				}
+				return
+			},
+			want: 1,
+		},
+		{
+			name:     "Periodic HTTP Host",
+			byDomain: true,
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					evt := data("10.0.0.7", fmt.Sprintf("198.51.100.%d", i%3+1), 80, 200, t0.Add(time.Duration(i)*30*time.Second))
+					evt.HTTPHost = "Update.Example.com:80"
+					evts = append(evts, evt)
+				}
+				return
+			},
+			want: 1,
+		},
+		{
+			name:     "Periodic DNS Lookup",
+			byDomain: true,
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					evts = append(evts, &inspector.NetworkEvent{
+						Timestamp: t0.Add(time.Duration(i) * time.Minute),
+						SrcIP:     "10.0.0.7",
+						DstIP:     "10.0.0.53",
+						DstPort:   53,
+						Protocol:  "UDP",
+						DNSQuery:  "c2.example.net.",
+					})
+				}
+				return
+			},
+			want: 1,
+		},
+		{
+			name:     "Irregular SNI",
+			byDomain: true,
+			events: func() (evts []*inspector.NetworkEvent) {
+				offsets := []int{0, 7, 90, 95, 300, 302, 900, 1000}
+				for i, s := range offsets {
+					dst := fmt.Sprintf("203.0.113.%d", 10+i)
+					evts = append(evts, hello("10.0.0.7", dst, "cdn.example.com", t0.Add(time.Duration(s)*time.Second))...)
+				}
+				return
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewBeaconTracker(5, 0.1, 5*time.Second, time.Hour).TrackDomains(tt.byDomain)
+			var got []*Threat
+			for _, evt := range tt.events() {
+				if th := tracker.Track(evt); th != nil {
+					got = append(got, th)
+				}
+			}
+			if len(got) != tt.want {
+				t.Fatalf("beacons = %d, want %d", len(got), tt.want)
+			}
+			for _, th := range got {
+				if th.Type != ThreatBeaconing {
+					t.Errorf("Type = %q, want %q", th.Type, ThreatBeaconing)
+				}
+			}
+		})
+	}
+}
+
+func TestDomainBeaconDetails(t *testing.T) {
+	tracker := NewBeaconTracker(5, 0.1, 5*time.Second, time.Hour).TrackDomains(true)
+	var th *Threat
+	for i := 0; i < 8 && th == nil; i++ {
+		for _, evt := range hello("10.0.0.7", fmt.Sprintf("203.0.113.%d", 10+i%4), "CDN.Example.com", t0.Add(time.Duration(i)*time.Minute)) {
+			if got := tracker.Track(evt); got != nil {
+				th = got
+			}
+		}
+	}
+	if th == nil {
+		t.Fatal("no beacon detected")
+	}
+	if got := th.Details["domain"]; got != "cdn.example.com" {
+		t.Errorf("domain = %v, want cdn.example.com", got)
+	}
+	if got := th.Details["domain_source"]; got != beaconSNI {
+		t.Errorf("domain_source = %v, want %s", got, beaconSNI)
+	}
+	if got := th.Details["distinct_ips"]; got != 4 {
+		t.Errorf("distinct_ips = %v, want 4", got)
+	}
+	if _, ok := tracker.Stats()["10.0.0.7->sni:cdn.example.com"]; !ok {
+		t.Errorf("Stats() missing domain entry: %v", tracker.Stats())
+	}
+}
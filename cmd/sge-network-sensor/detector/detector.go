@@ -0,0 +1,266 @@
+package detector
+
+import (
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// ThreatType identifies the kind of behaviour a tracker detected.
+type ThreatType string
+
+const (
+	ThreatPortScan     ThreatType = "port_scan"
+	ThreatBeaconing    ThreatType = "beaconing"
+	ThreatExfiltration ThreatType = "exfiltration"
+)
+
+// Threat is a detection raised from observed traffic.
+type Threat struct {
+	Type        ThreatType             `json:"type"`
+	Severity    models.Severity        `json:"severity"`
+	SrcIP       string                 `json:"src_ip"`
+	DstIP       string                 `json:"dst_ip,omitempty"`
+	DstPort     uint16                 `json:"dst_port,omitempty"`
+	Description string                 `json:"description"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+// Config holds detection thresholds. All windows are measured in packet
+// time, so live capture and pcap replay behave the same.
+type Config struct {
+	PortScanThreshold int           // Distinct destination ports per source
+	PortScanWindow    time.Duration // Observation window per source
+
+	BeaconMinConnections int           // Connections needed before judging periodicity
+	BeaconMaxJitter      float64       // Max coefficient of variation of intervals
+	BeaconMinInterval    time.Duration // Ignore faster (bursty) connection patterns
+	BeaconIdleTimeout    time.Duration // Forget pairs not seen for this long
+	BeaconByDomain       bool          // Also key beacons on SNI / HTTP Host / DNS name
+
+	ExfilThresholdBytes uint64           // Outbound bytes per internal->external pair
+	ExfilWindow         time.Duration    // Observation window per pair
+	ExfilThresholds     []ExfilThreshold // Per port / destination overrides, first match wins
+
+	RSTThreshold       int           // TCP resets sent per source
+	RSTWindow          time.Duration // Observation window per source
+	RetransMinSegments int           // Payload segments before judging a flow
+	RetransRatio       float64       // Share of retransmitted segments per flow
+	RetransWindow      time.Duration // Observation window per flow
+	TCPHealthMaxFlows  int           // Flows tracked for retransmissions
+
+	SYNFloodSources int           // Distinct sources with half-open connections per destination
+	SYNFloodWindow  time.Duration // Observation window per destination
+
+	MinTLSVersion   uint16        // Flag ClientHellos offering less (dpi.VersionTLS*), 0 disables
+	WeakTLSWindow   time.Duration // Report each client/server pair once per window
+	WeakTLSMaxPairs int           // Reported pairs remembered
+
+	ExpectedProtocols    map[uint16][]string // L7 protocols per port, nil uses the defaults, empty disables
+	PortMismatchWindow   time.Duration       // Report each pair and protocol once per window
+	PortMismatchMaxPairs int                 // Reported pairs remembered
+
+	RemoteAdminAttempts   int           // RDP/WinRM logon attempts per source and server, 0 disables
+	RemoteAdminHosts      int           // Internal servers per internal source, 0 disables
+	RemoteAdminWindow     time.Duration // Observation window per source
+	RemoteAdminMaxSources int           // Sources tracked
+
+	// Composite per-source risk (0 window disables)
+	RiskWindow     time.Duration          // Signals older than this stop counting
+	RiskMaxSources int                    // Sources tracked before LRU eviction
+	RiskWeights    map[ThreatType]float64 // Weight per signal type
+	RiskTiers      []RiskTier             // Scores raising a composite threat
+}
+
+// DefaultConfig returns thresholds suitable for a typical enterprise network.
+func DefaultConfig() Config {
+	return Config{
+		PortScanThreshold: 20,
+		PortScanWindow:    60 * time.Second,
+
+		BeaconMinConnections: 6,
+		BeaconMaxJitter:      0.1,
+		BeaconMinInterval:    5 * time.Second,
+		BeaconIdleTimeout:    time.Hour,
+		BeaconByDomain:       true,
+
+		ExfilThresholdBytes: 100 * 1024 * 1024, // 100MB
+		ExfilWindow:         10 * time.Minute,
+
+		RSTThreshold:       200,
+		RSTWindow:          60 * time.Second,
+		RetransMinSegments: 50,
+		RetransRatio:       0.2,
+		RetransWindow:      60 * time.Second,
+		TCPHealthMaxFlows:  50000,
+
+		SYNFloodSources: 200,
+		SYNFloodWindow:  10 * time.Second,
+
+		MinTLSVersion:   dpi.VersionTLS12,
+		WeakTLSWindow:   time.Hour,
+		WeakTLSMaxPairs: 10000,
+
+		PortMismatchWindow:   time.Hour,
+		PortMismatchMaxPairs: 10000,
+
+		RemoteAdminAttempts:   10,
+		RemoteAdminHosts:      5,
+		RemoteAdminWindow:     10 * time.Minute,
+		RemoteAdminMaxSources: 10000,
+
+		RiskWindow:     10 * time.Minute,
+		RiskMaxSources: 10000,
+		RiskWeights:    DefaultRiskWeights(),
+		RiskTiers:      DefaultRiskTiers(),
+	}
+}
+
+// cleanupInterval is how often (in packet time) stale tracker state is pruned.
+const cleanupInterval = time.Minute
+
+// Detector runs all threat trackers over a stream of network events.
+// Tracking methods must be called from a single goroutine; the Get*Stats
+// accessors are safe to call concurrently.
+type Detector struct {
+	PortScan *PortScanTracker
+	Beacon   *BeaconTracker
+	Exfil    *ExfiltrationTracker
+	TCP      *TCPHealthTracker
+	SYNFlood *SYNFloodTracker
+	WeakTLS  *WeakTLSTracker
+	Ports    *PortMismatchTracker
+	Admin    *RemoteAdminTracker
+	Risk     *RiskAggregator // nil when composite risk is disabled
+
+	lastCleanup time.Time
+}
+
+// NewDetector creates a detector with the given thresholds.
+func NewDetector(cfg Config) *Detector {
+	d := &Detector{
+		PortScan: NewPortScanTracker(cfg.PortScanThreshold, cfg.PortScanWindow),
+		Beacon:   NewBeaconTracker(cfg.BeaconMinConnections, cfg.BeaconMaxJitter, cfg.BeaconMinInterval, cfg.BeaconIdleTimeout).TrackDomains(cfg.BeaconByDomain),
+		Exfil:    NewExfiltrationTracker(cfg.ExfilThresholdBytes, cfg.ExfilWindow).WithThresholds(cfg.ExfilThresholds),
+		TCP:      NewTCPHealthTracker(cfg.RSTThreshold, cfg.RSTWindow, cfg.RetransMinSegments, cfg.RetransRatio, cfg.RetransWindow, cfg.TCPHealthMaxFlows),
+		SYNFlood: NewSYNFloodTracker(cfg.SYNFloodSources, cfg.SYNFloodWindow),
+		WeakTLS:  NewWeakTLSTracker(cfg.MinTLSVersion, cfg.WeakTLSWindow, cfg.WeakTLSMaxPairs),
+		Admin:    NewRemoteAdminTracker(cfg.RemoteAdminAttempts, cfg.RemoteAdminHosts, cfg.RemoteAdminWindow, cfg.RemoteAdminMaxSources),
+	}
+	expected := cfg.ExpectedProtocols
+	if expected == nil {
+		expected = DefaultExpectedProtocols()
+	}
+	d.Ports = NewPortMismatchTracker(expected, cfg.PortMismatchWindow, cfg.PortMismatchMaxPairs)
+	if cfg.RiskWindow > 0 {
+		weights, tiers := cfg.RiskWeights, cfg.RiskTiers
+		if weights == nil {
+			weights = DefaultRiskWeights()
+		}
+		if tiers == nil {
+			tiers = DefaultRiskTiers()
+		}
+		d.Risk = NewRiskAggregator(cfg.RiskWindow, cfg.RiskMaxSources, weights, tiers)
+	}
+	return d
+}
+
+// Analyze feeds an event to every tracker and returns any threats raised.
+func (d *Detector) Analyze(evt *inspector.NetworkEvent) []Threat {
+	var threats []Threat
+
+	if t := d.PortScan.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+	if t := d.Beacon.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+	if t := d.Exfil.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+	if t := d.TCP.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+	if t := d.SYNFlood.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+	if t := d.WeakTLS.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+	if t := d.Ports.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+	if t := d.Admin.Track(evt); t != nil {
+		threats = append(threats, *t)
+	}
+
+	if d.Risk != nil {
+		threats = d.trackRisk(evt, threats)
+	}
+
+	if evt.Timestamp.Sub(d.lastCleanup) >= cleanupInterval {
+		d.PortScan.Cleanup(evt.Timestamp)
+		d.Beacon.Cleanup(evt.Timestamp)
+		d.Exfil.Cleanup(evt.Timestamp)
+		d.TCP.Cleanup(evt.Timestamp)
+		d.SYNFlood.Cleanup(evt.Timestamp)
+		d.WeakTLS.Cleanup(evt.Timestamp)
+		d.Ports.Cleanup(evt.Timestamp)
+		d.Admin.Cleanup(evt.Timestamp)
+		if d.Risk != nil {
+			d.Risk.Cleanup(evt.Timestamp)
+		}
+		d.lastCleanup = evt.Timestamp
+	}
+
+	return threats
+}
+
+// trackRisk feeds every threat raised for evt at full strength, and the
+// graded strengths of the threshold trackers for evt's source, into the
+// risk aggregator, appending any composite threat to threats.
+func (d *Detector) trackRisk(evt *inspector.NetworkEvent, threats []Threat) []Threat {
+	var composite []Threat
+	add := func(src string, typ ThreatType, strength float64) {
+		if t := d.Risk.Add(src, typ, strength, evt.Timestamp); t != nil {
+			composite = append(composite, *t)
+		}
+	}
+
+	for _, t := range threats {
+		add(t.SrcIP, t.Type, 1)
+	}
+	if evt.IsConnectionAttempt() {
+		add(evt.SrcIP, ThreatPortScan, d.PortScan.strength(evt.SrcIP))
+		add(evt.SrcIP, ThreatBeaconing, d.Beacon.strength(beaconKey(evt)))
+	}
+	if evt.PayloadSize > 0 {
+		add(evt.SrcIP, ThreatExfiltration, d.Exfil.strength(evt))
+	}
+	return append(threats, composite...)
+}
+
+// GetPortScanStats returns per-source port scan counters.
+func (d *Detector) GetPortScanStats() map[string]PortScanStats {
+	return d.PortScan.Stats()
+}
+
+// GetBeaconStats returns per-pair beacon statistics.
+func (d *Detector) GetBeaconStats() map[string]BeaconStats {
+	return d.Beacon.Stats()
+}
+
+// GetPortScanRanking returns GetPortScanStats in a stable order, most
+// ports first.
+func (d *Detector) GetPortScanRanking() []RankedPortScan {
+	return d.PortScan.Ranked()
+}
+
+// GetBeaconRanking returns GetBeaconStats in a stable order, highest
+// score first.
+func (d *Detector) GetBeaconRanking() []RankedBeacon {
+	return d.Beacon.Ranked()
+}
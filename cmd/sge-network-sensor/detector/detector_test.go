@@ -0,0 +1,195 @@
+package detector
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+var t0 = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func syn(src, dst string, port uint16, at time.Time) *inspector.NetworkEvent {
+	return &inspector.NetworkEvent{
+		Timestamp: at,
+		SrcIP:     src,
+		DstIP:     dst,
+		SrcPort:   40000,
+		DstPort:   port,
+		Protocol:  "TCP",
+		TCPFlags:  inspector.TCPFlagSYN,
+	}
+}
+
+func data(src, dst string, port uint16, size int, at time.Time) *inspector.NetworkEvent {
+	return &inspector.NetworkEvent{
+		Timestamp:   at,
+		SrcIP:       src,
+		DstIP:       dst,
+		SrcPort:     40000,
+		DstPort:     port,
+		Protocol:    "TCP",
+		PayloadSize: size,
+		TCPFlags:    inspector.TCPFlagACK | inspector.TCPFlagPSH,
+	}
+}
+
+func countThreats(d *Detector, events []*inspector.NetworkEvent) map[ThreatType]int {
+	counts := make(map[ThreatType]int)
+	for _, evt := range events {
+		for _, t := range d.Analyze(evt) {
+			counts[t.Type]++
+		}
+	}
+	return counts
+}
+
+func TestDetector(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PortScanThreshold = 10
+	cfg.BeaconMinConnections = 5
+	cfg.ExfilThresholdBytes = 10000
+
+	tests := []struct {
+		name   string
+		events func() []*inspector.NetworkEvent
+		want   map[ThreatType]int
+	}{
+		{
+			name: "Port Scan",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for p := uint16(1); p <= 30; p++ {
+					evts = append(evts, syn("10.0.0.5", "10.0.0.9", p, t0.Add(time.Duration(p)*10*time.Millisecond)))
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatPortScan: 1},
+		},
+		{
+			name: "Slow Scan Outside Window",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for p := uint16(1); p <= 30; p++ {
+					evts = append(evts, syn("10.0.0.5", "10.0.0.9", p, t0.Add(time.Duration(p)*10*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+		{
+			name: "Periodic Beacon",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					jitter := time.Duration(i%2) * 500 * time.Millisecond
+					evts = append(evts, syn("10.0.0.7", "203.0.113.10", 443, t0.Add(time.Duration(i)*time.Minute+jitter)))
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatBeaconing: 1},
+		},
+		{
+			name: "Irregular Connections",
+			events: func() (evts []*inspector.NetworkEvent) {
+				offsets := []int{0, 7, 90, 95, 300, 302, 900, 1000}
+				for _, s := range offsets {
+					evts = append(evts, syn("10.0.0.7", "203.0.113.10", 443, t0.Add(time.Duration(s)*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+		{
+			name: "Outbound Exfiltration",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 20; i++ {
+					evts = append(evts, data("10.0.0.8", "198.51.100.20", 8443, 1000, t0.Add(time.Duration(i)*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatExfiltration: 1},
+		},
+		{
+			name: "Internal Bulk Transfer",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 20; i++ {
+					evts = append(evts, data("10.0.0.8", "10.0.0.20", 445, 1000, t0.Add(time.Duration(i)*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := countThreats(NewDetector(cfg), tt.events())
+			if len(got) != len(tt.want) {
+				t.Fatalf("threats = %v, want %v", got, tt.want)
+			}
+			for typ, n := range tt.want {
+				if got[typ] != n {
+					t.Errorf("%s threats = %d, want %d", typ, got[typ], n)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectorStats(t *testing.T) {
+	d := NewDetector(DefaultConfig())
+	for i := 0; i < 3; i++ {
+		d.Analyze(syn("10.0.0.5", "10.0.0.9", uint16(100+i), t0.Add(time.Duration(i)*time.Minute)))
+	}
+
+	ps := d.GetPortScanStats()
+	// Each SYN is a minute apart, so the 60s window restarts every time
+	if got := ps["10.0.0.5"].Ports; got != 1 {
+		t.Errorf("GetPortScanStats() ports = %d, want 1", got)
+	}
+
+	bs := d.GetBeaconStats()
+	if len(bs) != 3 {
+		t.Errorf("GetBeaconStats() = %d pairs, want 3", len(bs))
+	}
+	if got := bs["10.0.0.5->10.0.0.9:100"].Connections; got != 1 {
+		t.Errorf("connections = %d, want 1", got)
+	}
+}
+
+func TestDetectorRanking(t *testing.T) {
+	d := NewDetector(DefaultConfig())
+	for i, src := range []string{"10.0.0.2", "10.0.0.3", "10.0.0.1", "10.0.0.3", "10.0.0.2", "10.0.0.3", "10.0.0.1"} {
+		d.Analyze(syn(src, "10.0.0.9", uint16(100+i), t0.Add(time.Duration(i)*time.Second)))
+	}
+	for i := 0; i < 4; i++ {
+		d.Analyze(syn("10.0.0.8", "198.51.100.1", 443, t0.Add(time.Duration(i)*30*time.Second)))
+	}
+
+	ps := d.GetPortScanRanking()
+	var sources []string
+	for _, s := range ps {
+		sources = append(sources, s.Source)
+	}
+	want := []string{"10.0.0.3", "10.0.0.1", "10.0.0.2", "10.0.0.8"}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("GetPortScanRanking() order = %v, want %v", sources, want)
+	}
+
+	bs := d.GetBeaconRanking()
+	if len(bs) != 8 || bs[0].Key != "10.0.0.8->198.51.100.1:443" || bs[0].Score <= 0 {
+		t.Fatalf("GetBeaconRanking()[0] = %+v, want the regular pair first", bs[0])
+	}
+	if !sort.SliceIsSorted(bs[1:], func(i, j int) bool { return bs[1+i].Key < bs[1+j].Key }) {
+		t.Errorf("GetBeaconRanking() ties not ordered by key: %v", bs[1:])
+	}
+
+	for i := 0; i < 20; i++ {
+		if got := d.GetPortScanRanking(); !reflect.DeepEqual(got, ps) {
+			t.Fatalf("GetPortScanRanking() call %d = %v, want %v", i, got, ps)
+		}
+		if got := d.GetBeaconRanking(); !reflect.DeepEqual(got, bs) {
+			t.Fatalf("GetBeaconRanking() call %d = %v, want %v", i, got, bs)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package detector
+
+import (
+	"sakin-go/pkg/models"
+	"sakin-go/pkg/utils"
+)
+
+const (
+	// EventSource is the source of threat events, shared with the sensor's
+	// other events.
+	EventSource = "network-sensor"
+
+	// TagThreat marks threat events, so routes and downstream services can
+	// tell them apart whatever their type.
+	TagThreat = "threat"
+)
+
+// Event converts the threat to a network.threat event. Tags carry
+// TagThreat and the threat type; Details and the destination port go to
+// the metadata.
+func (t Threat) Event() *models.Event {
+	meta := make(map[string]interface{}, len(t.Details)+2)
+	for k, v := range t.Details {
+		meta[k] = v
+	}
+	meta["threat_type"] = string(t.Type)
+	if t.DstPort != 0 {
+		meta["dest_port"] = t.DstPort
+	}
+	return &models.Event{
+		ID:          utils.GenerateID(),
+		Timestamp:   t.Timestamp.UTC(),
+		Source:      EventSource,
+		SourceIP:    t.SrcIP,
+		DestIP:      t.DstIP,
+		EventType:   models.EventTypeNetworkThreat,
+		Severity:    t.Severity,
+		Status:      models.EventStatusNew,
+		Description: t.Description,
+		Tags:        []string{TagThreat, string(t.Type)},
+		Metadata:    meta,
+	}
+}
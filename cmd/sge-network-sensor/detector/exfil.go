@@ -0,0 +1,221 @@
+package detector
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+type exfilEntry struct {
+	bytes     uint64
+	firstSeen time.Time
+	alerted   bool
+}
+
+// ExfilThreshold overrides the volume threshold for traffic to a port, a
+// destination network, or both. Bytes 0 never flags, for expected bulk
+// transfers such as backups.
+type ExfilThreshold struct {
+	Port  uint16     // 0 matches any port
+	Dst   *net.IPNet // nil matches any destination
+	Bytes uint64
+}
+
+func (r *ExfilThreshold) matches(dst net.IP, port uint16) bool {
+	return (r.Port == 0 || r.Port == port) && (r.Dst == nil || r.Dst.Contains(dst))
+}
+
+func (r *ExfilThreshold) String() string {
+	target := ""
+	if r.Dst != nil {
+		target = r.Dst.String()
+	}
+	if r.Port != 0 {
+		if target != "" {
+			target += ":"
+		}
+		target += strconv.Itoa(int(r.Port))
+	}
+	return target
+}
+
+// ParseExfilThresholds parses comma separated "target=bytes" rules where
+// target is a port, a CIDR or "cidr:port", e.g.
+// "443=1073741824,10.20.0.0/16:22=0,4444=1048576".
+func ParseExfilThresholds(s string) ([]ExfilThreshold, error) {
+	var rules []ExfilThreshold
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		target, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("exfil threshold %q: want target=bytes", part)
+		}
+		bytes, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("exfil threshold %q: invalid bytes", part)
+		}
+		r := ExfilThreshold{Bytes: bytes}
+
+		target = strings.TrimSpace(target)
+		port := target
+		if slash := strings.Index(target, "/"); slash >= 0 {
+			cidr := target
+			port = ""
+			if colon := strings.LastIndex(target, ":"); colon > slash {
+				cidr, port = target[:colon], target[colon+1:]
+			}
+			if _, r.Dst, err = net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("exfil threshold %q: %w", part, err)
+			}
+		}
+		if port != "" {
+			p, err := strconv.ParseUint(port, 10, 16)
+			if err != nil || p == 0 {
+				return nil, fmt.Errorf("exfil threshold %q: invalid port", part)
+			}
+			r.Port = uint16(p)
+		}
+		if r.Port == 0 && r.Dst == nil {
+			return nil, fmt.Errorf("exfil threshold %q: missing port or CIDR", part)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// ExfiltrationTracker flags internal hosts sending unusually large volumes
+// to a single external destination within a window. Traffic matching a
+// threshold rule is accounted per pair and rule, everything else per pair
+// against the global threshold.
+type ExfiltrationTracker struct {
+	threshold uint64
+	window    time.Duration
+	rules     []ExfilThreshold // First match wins
+
+	mu    sync.Mutex
+	pairs map[string]*exfilEntry
+}
+
+// NewExfiltrationTracker creates a tracker alerting at threshold bytes per window.
+func NewExfiltrationTracker(threshold uint64, window time.Duration) *ExfiltrationTracker {
+	return &ExfiltrationTracker{
+		threshold: threshold,
+		window:    window,
+		pairs:     make(map[string]*exfilEntry),
+	}
+}
+
+// WithThresholds sets per port / destination threshold rules, checked in
+// order before the global threshold.
+func (t *ExfiltrationTracker) WithThresholds(rules []ExfilThreshold) *ExfiltrationTracker {
+	t.rules = rules
+	return t
+}
+
+// classify returns the accounting key and threshold of evt, and the rule
+// that matched (nil for the global threshold).
+func (t *ExfiltrationTracker) classify(evt *inspector.NetworkEvent) (string, uint64, *ExfilThreshold) {
+	key := evt.SrcIP + "->" + evt.DstIP
+	if len(t.rules) == 0 {
+		return key, t.threshold, nil
+	}
+	dst := net.ParseIP(evt.DstIP)
+	for i := range t.rules {
+		if r := &t.rules[i]; r.matches(dst, evt.DstPort) {
+			return key + "#" + strconv.Itoa(i), r.Bytes, r
+		}
+	}
+	return key, t.threshold, nil
+}
+
+// Track accounts outbound payload bytes and returns a threat once per
+// window when the pair crosses the threshold.
+func (t *ExfiltrationTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if evt.PayloadSize == 0 || !isOutbound(evt.SrcIP, evt.DstIP) {
+		return nil
+	}
+
+	key, threshold, rule := t.classify(evt)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.pairs[key]
+	if !ok || evt.Timestamp.Sub(e.firstSeen) > t.window {
+		e = &exfilEntry{firstSeen: evt.Timestamp}
+		t.pairs[key] = e
+	}
+	e.bytes += uint64(evt.PayloadSize)
+
+	if e.alerted || threshold == 0 || e.bytes < threshold {
+		return nil
+	}
+	e.alerted = true
+
+	details := map[string]interface{}{
+		"bytes":      e.bytes,
+		"first_seen": e.firstSeen,
+		"threshold":  threshold,
+	}
+	if rule != nil {
+		details["threshold_rule"] = rule.String()
+	}
+	return &Threat{
+		Type:        ThreatExfiltration,
+		Severity:    models.SeverityHigh,
+		SrcIP:       evt.SrcIP,
+		DstIP:       evt.DstIP,
+		DstPort:     evt.DstPort,
+		Description: fmt.Sprintf("%s sent %d bytes to %s within %s", evt.SrcIP, e.bytes, evt.DstIP, t.window),
+		Timestamp:   evt.Timestamp,
+		Details:     details,
+	}
+}
+
+// strength returns how close the pair of evt is to the threshold (0..1).
+func (t *ExfiltrationTracker) strength(evt *inspector.NetworkEvent) float64 {
+	key, threshold, _ := t.classify(evt)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.pairs[key]
+	if !ok || threshold == 0 {
+		return 0
+	}
+	return min(float64(e.bytes)/float64(threshold), 1)
+}
+
+// Cleanup drops pairs whose window has expired.
+func (t *ExfiltrationTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, e := range t.pairs {
+		if now.Sub(e.firstSeen) > t.window {
+			delete(t.pairs, key)
+		}
+	}
+}
+
+// isOutbound reports whether traffic goes from an internal to an external address.
+func isOutbound(src, dst string) bool {
+	s, d := net.ParseIP(src), net.ParseIP(dst)
+	if s == nil || d == nil {
+		return false
+	}
+	return isInternal(s) && !isInternal(d) && !d.IsMulticast() && !d.IsUnspecified()
+}
+
+func isInternal(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()
+}
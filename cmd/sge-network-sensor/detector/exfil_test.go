@@ -0,0 +1,103 @@
+package detector
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// upload sends total bytes from src to dst:port in 10KB segments.
+func upload(tracker *ExfiltrationTracker, dst string, port uint16, total int) []*Threat {
+	var threats []*Threat
+	for sent := 0; sent < total; sent += 10000 {
+		if th := tracker.Track(data("10.0.0.5", dst, port, 10000, t0.Add(time.Duration(sent/10000)*time.Millisecond))); th != nil {
+			threats = append(threats, th)
+		}
+	}
+	return threats
+}
+
+func TestExfilThresholdRules(t *testing.T) {
+	rules, err := ParseExfilThresholds("198.51.100.0/24:443=0,4444=100000")
+	if err != nil {
+		t.Fatalf("ParseExfilThresholds() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		dst   string
+		port  uint16
+		total int
+		want  int
+	}{
+		{"Backup Server On 443", "198.51.100.20", 443, 2000000, 0},
+		{"Same Volume To 4444", "203.0.113.10", 4444, 2000000, 1},
+		{"Other Host On 443 Uses Global", "203.0.113.10", 443, 2000000, 1},
+		{"Small Transfer To 4444", "203.0.113.10", 4444, 50000, 0},
+		{"Below Global", "203.0.113.10", 8443, 500000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewExfiltrationTracker(1000000, 10*time.Minute).WithThresholds(rules)
+			if got := upload(tracker, tt.dst, tt.port, tt.total); len(got) != tt.want {
+				t.Fatalf("threats = %d, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestExfilRuleThreatDetails(t *testing.T) {
+	tracker := NewExfiltrationTracker(1000000, 10*time.Minute).WithThresholds([]ExfilThreshold{{Port: 4444, Bytes: 50000}})
+	threats := upload(tracker, "203.0.113.10", 4444, 60000)
+	if len(threats) != 1 {
+		t.Fatalf("threats = %d, want 1", len(threats))
+	}
+	if d := threats[0].Details; d["threshold"] != uint64(50000) || d["threshold_rule"] != "4444" {
+		t.Errorf("Details = %v, want threshold 50000 from rule 4444", d)
+	}
+
+	// Other ports to the same destination are accounted separately
+	if got := tracker.Track(data("10.0.0.5", "203.0.113.10", 443, 10000, t0)); got != nil {
+		t.Errorf("Track(443) = %v, want nil", got)
+	}
+	if s := tracker.strength(data("10.0.0.5", "203.0.113.10", 4444, 0, t0)); s != 1 {
+		t.Errorf("strength(4444) = %v, want 1", s)
+	}
+}
+
+func TestParseExfilThresholds(t *testing.T) {
+	_, backup, _ := net.ParseCIDR("10.20.0.0/16")
+	_, v6, _ := net.ParseCIDR("2001:db8::/32")
+	tests := []struct {
+		spec    string
+		want    []ExfilThreshold
+		wantErr bool
+	}{
+		{"443=1073741824", []ExfilThreshold{{Port: 443, Bytes: 1073741824}}, false},
+		{"10.20.0.0/16=0", []ExfilThreshold{{Dst: backup}}, false},
+		{"10.20.0.0/16:22=5, 4444=1", []ExfilThreshold{{Port: 22, Dst: backup, Bytes: 5}, {Port: 4444, Bytes: 1}}, false},
+		{"2001:db8::/32:873=0", []ExfilThreshold{{Port: 873, Dst: v6}}, false},
+		{"", nil, false},
+		{"443", nil, true},
+		{"443=lots", nil, true},
+		{"70000=1", nil, true},
+		{"10.0.0.0/33=1", nil, true},
+		{"=1", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseExfilThresholds(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseExfilThresholds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseExfilThresholds() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Port != tt.want[i].Port || got[i].Bytes != tt.want[i].Bytes || got[i].String() != tt.want[i].String() {
+					t.Errorf("rule %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
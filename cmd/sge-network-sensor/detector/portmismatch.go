@@ -0,0 +1,131 @@
+package detector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// ThreatPortMismatch is a service speaking a different protocol than its
+// port is assigned to, e.g. SSH on 443: tunneling or a misconfiguration.
+const ThreatPortMismatch ThreatType = "port_protocol_mismatch"
+
+// DefaultExpectedProtocols maps well-known ports to the L7 protocols
+// (dpi.Proto*) legitimately seen on them. TLS is accepted where STARTTLS or
+// an embedded TLS handshake (RDP) is normal.
+func DefaultExpectedProtocols() map[uint16][]string {
+	return map[uint16][]string{
+		21:   {dpi.ProtoFTP, dpi.ProtoTLS},
+		22:   {dpi.ProtoSSH},
+		25:   {dpi.ProtoTLS}, // SMTP itself is not classified by payload
+		53:   {dpi.ProtoDNS},
+		80:   {dpi.ProtoHTTP},
+		443:  {dpi.ProtoTLS},
+		465:  {dpi.ProtoTLS},
+		587:  {dpi.ProtoTLS},
+		853:  {dpi.ProtoTLS},
+		993:  {dpi.ProtoTLS},
+		995:  {dpi.ProtoTLS},
+		3389: {dpi.ProtoRDP, dpi.ProtoTLS},
+		5985: {dpi.ProtoWinRM, dpi.ProtoHTTP},
+		5986: {dpi.ProtoTLS},
+		8080: {dpi.ProtoHTTP},
+		8443: {dpi.ProtoTLS},
+	}
+}
+
+// PortMismatchTracker compares the protocol recognized by DPI with the
+// protocols expected on the destination port. Only ports in the table are
+// judged, and each client/server pair is reported once per window.
+type PortMismatchTracker struct {
+	expected map[uint16]map[string]bool
+	window   time.Duration
+	maxPairs int
+
+	mu       sync.Mutex
+	reported map[string]time.Time // "src->dst:port/proto" -> last report
+}
+
+// NewPortMismatchTracker creates a tracker for the expected protocols per
+// port (see DefaultExpectedProtocols), remembering up to maxPairs reports.
+// An empty table disables it.
+func NewPortMismatchTracker(expected map[uint16][]string, window time.Duration, maxPairs int) *PortMismatchTracker {
+	t := &PortMismatchTracker{
+		expected: make(map[uint16]map[string]bool, len(expected)),
+		window:   window,
+		maxPairs: maxPairs,
+		reported: make(map[string]time.Time),
+	}
+	for port, protos := range expected {
+		set := make(map[string]bool, len(protos))
+		for _, p := range protos {
+			set[p] = true
+		}
+		t.expected[port] = set
+	}
+	return t
+}
+
+// Track returns a threat when evt carries a protocol unexpected on its
+// destination port. Server responses are not judged: SSH servers send a
+// banner too, but from the service port.
+func (t *PortMismatchTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if evt.AppProtocol == "" {
+		return nil
+	}
+	allowed, ok := t.expected[evt.DstPort]
+	if !ok || allowed[evt.AppProtocol] {
+		return nil
+	}
+
+	key := evt.SrcIP + "->" + evt.DstIP + ":" + strconv.Itoa(int(evt.DstPort)) + "/" + evt.AppProtocol
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.reported[key]; ok && evt.Timestamp.Sub(last) < t.window {
+		return nil
+	}
+	if t.maxPairs > 0 && len(t.reported) >= t.maxPairs {
+		// Better a repeated finding than unbounded state
+		t.reported = make(map[string]time.Time)
+	}
+	t.reported[key] = evt.Timestamp
+
+	expected := make([]string, 0, len(allowed))
+	for p := range allowed {
+		expected = append(expected, p)
+	}
+	sort.Strings(expected)
+	return &Threat{
+		Type:        ThreatPortMismatch,
+		Severity:    models.SeverityMedium,
+		SrcIP:       evt.SrcIP,
+		DstIP:       evt.DstIP,
+		DstPort:     evt.DstPort,
+		Description: fmt.Sprintf("%s speaks %s to %s:%d, a port expected to carry %v", evt.SrcIP, evt.AppProtocol, evt.DstIP, evt.DstPort, expected),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"protocol":           evt.AppProtocol,
+			"expected_protocols": expected,
+		},
+	}
+}
+
+// Cleanup forgets reports older than the window.
+func (t *PortMismatchTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, last := range t.reported {
+		if now.Sub(last) >= t.window {
+			delete(t.reported, key)
+		}
+	}
+}
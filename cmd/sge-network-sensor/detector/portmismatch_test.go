@@ -0,0 +1,76 @@
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+func speaks(proto string, port uint16, at time.Time) *inspector.NetworkEvent {
+	evt := data("10.0.0.5", "203.0.113.10", port, 64, at)
+	evt.AppProtocol = proto
+	return evt
+}
+
+func TestPortMismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		proto string
+		port  uint16
+		want  bool
+	}{
+		{"HTTP On 22", dpi.ProtoHTTP, 22, true},
+		{"SSH On 443", dpi.ProtoSSH, 443, true},
+		{"SSH On 8443", dpi.ProtoSSH, 8443, true},
+		{"TLS On 80", dpi.ProtoTLS, 80, true},
+		{"SSH On 22", dpi.ProtoSSH, 22, false},
+		{"HTTP On 80", dpi.ProtoHTTP, 80, false},
+		{"TLS On 443", dpi.ProtoTLS, 443, false},
+		{"STARTTLS On 587", dpi.ProtoTLS, 587, false},
+		{"TLS Inside RDP", dpi.ProtoTLS, 3389, false},
+		{"Unlisted Port", dpi.ProtoSSH, 2222, false},
+		{"Not Classified", "", 443, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := NewPortMismatchTracker(DefaultExpectedProtocols(), time.Hour, 100).Track(speaks(tt.proto, tt.port, t0))
+			if (th != nil) != tt.want {
+				t.Fatalf("Track() = %v, want threat %v", th, tt.want)
+			}
+			if th == nil {
+				return
+			}
+			if th.Type != ThreatPortMismatch || th.Details["protocol"] != tt.proto || th.DstPort != tt.port {
+				t.Errorf("Track() = %+v", th)
+			}
+		})
+	}
+}
+
+func TestPortMismatchOncePerWindow(t *testing.T) {
+	tracker := NewPortMismatchTracker(DefaultExpectedProtocols(), time.Hour, 100)
+	var n int
+	for _, at := range []time.Duration{0, time.Minute, 30 * time.Minute, 61 * time.Minute} {
+		if tracker.Track(speaks(dpi.ProtoSSH, 443, t0.Add(at))) != nil {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("threats = %d, want 2 (first and after the window)", n)
+	}
+
+	tracker.Cleanup(t0.Add(3 * time.Hour))
+	if len(tracker.reported) != 0 {
+		t.Errorf("reported = %d pairs after cleanup, want 0", len(tracker.reported))
+	}
+}
+
+func TestPortMismatchDisabled(t *testing.T) {
+	d := NewDetector(Config{ExpectedProtocols: map[uint16][]string{}, PortMismatchWindow: time.Hour})
+	if th := d.Ports.Track(speaks(dpi.ProtoSSH, 443, t0)); th != nil {
+		t.Errorf("Track() = %v, want nil with an empty table", th)
+	}
+}
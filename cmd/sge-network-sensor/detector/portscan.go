@@ -0,0 +1,140 @@
+package detector
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// PortScanStats summarizes what a source has probed in its current window.
+type PortScanStats struct {
+	Ports     int       `json:"ports"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// RankedPortScan is one source in PortScanTracker.Ranked.
+type RankedPortScan struct {
+	Source string `json:"source"`
+	PortScanStats
+}
+
+type portScanEntry struct {
+	ports     map[uint16]struct{}
+	firstSeen time.Time
+	lastSeen  time.Time
+	alerted   bool
+}
+
+// PortScanTracker flags sources that open connections to many distinct
+// destination ports within a window.
+type PortScanTracker struct {
+	threshold int
+	window    time.Duration
+
+	mu      sync.Mutex
+	sources map[string]*portScanEntry
+}
+
+// NewPortScanTracker creates a tracker alerting at threshold distinct ports per window.
+func NewPortScanTracker(threshold int, window time.Duration) *PortScanTracker {
+	return &PortScanTracker{
+		threshold: threshold,
+		window:    window,
+		sources:   make(map[string]*portScanEntry),
+	}
+}
+
+// Track records a connection attempt and returns a threat once per window
+// when the source crosses the threshold.
+func (t *PortScanTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if !evt.IsConnectionAttempt() {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.sources[evt.SrcIP]
+	if !ok || evt.Timestamp.Sub(e.firstSeen) > t.window {
+		e = &portScanEntry{ports: make(map[uint16]struct{}), firstSeen: evt.Timestamp}
+		t.sources[evt.SrcIP] = e
+	}
+	e.ports[evt.DstPort] = struct{}{}
+	e.lastSeen = evt.Timestamp
+
+	if e.alerted || len(e.ports) < t.threshold {
+		return nil
+	}
+	e.alerted = true
+
+	return &Threat{
+		Type:        ThreatPortScan,
+		Severity:    models.SeverityMedium,
+		SrcIP:       evt.SrcIP,
+		DstIP:       evt.DstIP,
+		Description: fmt.Sprintf("%s probed %d distinct ports within %s", evt.SrcIP, len(e.ports), t.window),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"ports":      len(e.ports),
+			"first_seen": e.firstSeen,
+		},
+	}
+}
+
+// strength returns how close src is to the threshold (0..1) in its window.
+func (t *PortScanTracker) strength(src string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.sources[src]
+	if !ok || t.threshold <= 0 {
+		return 0
+	}
+	return min(float64(len(e.ports))/float64(t.threshold), 1)
+}
+
+// Cleanup drops sources whose window has expired.
+func (t *PortScanTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for ip, e := range t.sources {
+		if now.Sub(e.firstSeen) > t.window {
+			delete(t.sources, ip)
+		}
+	}
+}
+
+// Stats returns a snapshot keyed by source IP.
+func (t *PortScanTracker) Stats() map[string]PortScanStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]PortScanStats, len(t.sources))
+	for ip, e := range t.sources {
+		stats[ip] = PortScanStats{Ports: len(e.ports), FirstSeen: e.firstSeen, LastSeen: e.lastSeen}
+	}
+	return stats
+}
+
+// Ranked returns Stats as a slice ordered by ports probed, most first, with
+// ties broken by source IP so repeated calls on the same data agree.
+func (t *PortScanTracker) Ranked() []RankedPortScan {
+	stats := t.Stats()
+	ranked := make([]RankedPortScan, 0, len(stats))
+	for src, s := range stats {
+		ranked = append(ranked, RankedPortScan{Source: src, PortScanStats: s})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Ports != ranked[j].Ports {
+			return ranked[i].Ports > ranked[j].Ports
+		}
+		return ranked[i].Source < ranked[j].Source
+	})
+	return ranked
+}
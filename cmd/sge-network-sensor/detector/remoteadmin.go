@@ -0,0 +1,173 @@
+package detector
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+const (
+	// ThreatRemoteAdminBruteForce is a burst of RDP/WinRM logon attempts
+	// from one source against one server: password guessing.
+	ThreatRemoteAdminBruteForce ThreatType = "remote_admin_brute_force"
+	// ThreatLateralMovement is an internal host opening remote admin
+	// sessions to many internal servers.
+	ThreatLateralMovement ThreatType = "lateral_movement"
+)
+
+// maxRemoteAdminUsers caps the user names remembered per source.
+const maxRemoteAdminUsers = 20
+
+type remoteAdminEntry struct {
+	firstSeen time.Time
+	attempts  map[string]int      // "dst:port/proto" -> logon attempts
+	hosts     map[string]struct{} // Internal servers reached
+	users     map[string]struct{} // User names offered
+	alerted   map[string]bool     // Brute force reported per "dst:port/proto"
+	lateral   bool                // Lateral movement reported
+}
+
+// RemoteAdminTracker follows RDP connection requests and WinRM requests
+// carrying credentials. Each source has its own window, restarted when it
+// expires, like PortScanTracker.
+type RemoteAdminTracker struct {
+	attempts   int // Logon attempts per server to flag brute force, 0 disables
+	hosts      int // Internal servers per internal source to flag lateral movement, 0 disables
+	window     time.Duration
+	maxSources int
+
+	mu      sync.Mutex
+	sources map[string]*remoteAdminEntry
+}
+
+// NewRemoteAdminTracker creates a tracker remembering up to maxSources
+// sources.
+func NewRemoteAdminTracker(attempts, hosts int, window time.Duration, maxSources int) *RemoteAdminTracker {
+	return &RemoteAdminTracker{
+		attempts:   attempts,
+		hosts:      hosts,
+		window:     window,
+		maxSources: maxSources,
+		sources:    make(map[string]*remoteAdminEntry),
+	}
+}
+
+// isLogonAttempt reports whether evt opens an RDP session or sends WinRM
+// credentials. WinRM requests without credentials (e.g. the NTLM
+// negotiate leg) are not attempts.
+func isLogonAttempt(evt *inspector.NetworkEvent) bool {
+	return evt.AppProtocol == dpi.ProtoRDP || (evt.AppProtocol == dpi.ProtoWinRM && evt.AdminAuth != "")
+}
+
+// Track returns a brute force or lateral movement threat once per source
+// and window (brute force once per server).
+func (t *RemoteAdminTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if (t.attempts <= 0 && t.hosts <= 0) || !isLogonAttempt(evt) {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.sources[evt.SrcIP]
+	if !ok || evt.Timestamp.Sub(e.firstSeen) > t.window {
+		if !ok && t.maxSources > 0 && len(t.sources) >= t.maxSources {
+			// Better a missed burst than unbounded state
+			t.sources = make(map[string]*remoteAdminEntry)
+		}
+		e = &remoteAdminEntry{
+			firstSeen: evt.Timestamp,
+			attempts:  make(map[string]int),
+			hosts:     make(map[string]struct{}),
+			users:     make(map[string]struct{}),
+			alerted:   make(map[string]bool),
+		}
+		t.sources[evt.SrcIP] = e
+	}
+
+	if evt.AdminUser != "" && len(e.users) < maxRemoteAdminUsers {
+		e.users[evt.AdminUser] = struct{}{}
+	}
+	server := evt.DstIP + ":" + strconv.Itoa(int(evt.DstPort)) + "/" + evt.AppProtocol
+	e.attempts[server]++
+	if internalPair(evt) {
+		e.hosts[evt.DstIP] = struct{}{}
+	}
+
+	if t.attempts > 0 && e.attempts[server] >= t.attempts && !e.alerted[server] {
+		e.alerted[server] = true
+		return &Threat{
+			Type:        ThreatRemoteAdminBruteForce,
+			Severity:    models.SeverityHigh,
+			SrcIP:       evt.SrcIP,
+			DstIP:       evt.DstIP,
+			DstPort:     evt.DstPort,
+			Description: fmt.Sprintf("%s made %d %s logon attempts to %s:%d within %s", evt.SrcIP, e.attempts[server], evt.AppProtocol, evt.DstIP, evt.DstPort, t.window),
+			Timestamp:   evt.Timestamp,
+			Details: map[string]interface{}{
+				"protocol":   evt.AppProtocol,
+				"attempts":   e.attempts[server],
+				"users":      e.userList(),
+				"first_seen": e.firstSeen,
+			},
+		}
+	}
+
+	if t.hosts > 0 && len(e.hosts) >= t.hosts && !e.lateral {
+		e.lateral = true
+		hosts := make([]string, 0, len(e.hosts))
+		for h := range e.hosts {
+			hosts = append(hosts, h)
+		}
+		sort.Strings(hosts)
+		return &Threat{
+			Type:        ThreatLateralMovement,
+			Severity:    models.SeverityHigh,
+			SrcIP:       evt.SrcIP,
+			DstIP:       evt.DstIP,
+			DstPort:     evt.DstPort,
+			Description: fmt.Sprintf("%s opened remote admin sessions to %d internal hosts within %s", evt.SrcIP, len(hosts), t.window),
+			Timestamp:   evt.Timestamp,
+			Details: map[string]interface{}{
+				"hosts":      hosts,
+				"users":      e.userList(),
+				"first_seen": e.firstSeen,
+			},
+		}
+	}
+	return nil
+}
+
+func (e *remoteAdminEntry) userList() []string {
+	users := make([]string, 0, len(e.users))
+	for u := range e.users {
+		users = append(users, u)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// internalPair reports whether both ends of evt are internal addresses.
+func internalPair(evt *inspector.NetworkEvent) bool {
+	src, dst := net.ParseIP(evt.SrcIP), net.ParseIP(evt.DstIP)
+	return src != nil && dst != nil && isInternal(src) && isInternal(dst)
+}
+
+// Cleanup drops sources whose window has expired.
+func (t *RemoteAdminTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for ip, e := range t.sources {
+		if now.Sub(e.firstSeen) > t.window {
+			delete(t.sources, ip)
+		}
+	}
+}
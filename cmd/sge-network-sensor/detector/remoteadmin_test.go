@@ -0,0 +1,124 @@
+package detector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+func rdpLogon(src, dst, user string, at time.Time) *inspector.NetworkEvent {
+	evt := data(src, dst, 3389, 40, at)
+	evt.AppProtocol = dpi.ProtoRDP
+	evt.AdminUser = user
+	return evt
+}
+
+func winrmLogon(src, dst, auth, user string, at time.Time) *inspector.NetworkEvent {
+	evt := data(src, dst, 5985, 600, at)
+	evt.AppProtocol = dpi.ProtoWinRM
+	evt.AdminAuth = auth
+	evt.AdminUser = user
+	return evt
+}
+
+func TestRemoteAdmin(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RiskWindow = 0
+
+	tests := []struct {
+		name   string
+		events func() []*inspector.NetworkEvent
+		want   map[ThreatType]int
+	}{
+		{
+			name: "RDP Password Guessing",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 30; i++ {
+					evts = append(evts, rdpLogon("203.0.113.50", "10.0.0.10", fmt.Sprintf("user%d", i%3), t0.Add(time.Duration(i)*2*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatRemoteAdminBruteForce: 1},
+		},
+		{
+			name: "Slow Logons",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 30; i++ {
+					evts = append(evts, rdpLogon("10.0.0.5", "10.0.0.10", "admin", t0.Add(time.Duration(i)*time.Hour)))
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+		{
+			name: "WinRM Without Credentials",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 30; i++ {
+					evts = append(evts, winrmLogon("10.0.0.5", "10.0.0.10", "", "", t0.Add(time.Duration(i)*2*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+		{
+			name: "Lateral Movement",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					at := t0.Add(time.Duration(i) * 30 * time.Second)
+					if i%2 == 0 {
+						evts = append(evts, rdpLogon("10.0.0.5", fmt.Sprintf("10.0.1.%d", i), "corp\\svc", at))
+					} else {
+						evts = append(evts, winrmLogon("10.0.0.5", fmt.Sprintf("10.0.1.%d", i), dpi.WinRMAuthNTLM, "svc", at))
+					}
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatLateralMovement: 1},
+		},
+		{
+			name: "External Servers",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for i := 0; i < 8; i++ {
+					evts = append(evts, rdpLogon("10.0.0.5", fmt.Sprintf("198.51.100.%d", i), "admin", t0.Add(time.Duration(i)*30*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := countThreats(NewDetector(cfg), tt.events())
+			if len(got) != len(tt.want) {
+				t.Fatalf("threats = %v, want %v", got, tt.want)
+			}
+			for typ, n := range tt.want {
+				if got[typ] != n {
+					t.Errorf("threats[%s] = %d, want %d", typ, got[typ], n)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoteAdminDetails(t *testing.T) {
+	tr := NewRemoteAdminTracker(3, 0, time.Minute, 100)
+	var threat *Threat
+	for i, user := range []string{"administrator", "admin", "administrator"} {
+		threat = tr.Track(rdpLogon("203.0.113.50", "10.0.0.10", user, t0.Add(time.Duration(i)*time.Second)))
+	}
+	if threat == nil {
+		t.Fatal("Track() = nil, want brute force threat")
+	}
+	users, _ := threat.Details["users"].([]string)
+	if len(users) != 2 || users[0] != "admin" || users[1] != "administrator" {
+		t.Errorf("users = %v, want [admin administrator]", users)
+	}
+	if threat.Details["protocol"] != dpi.ProtoRDP || threat.Details["attempts"] != 3 {
+		t.Errorf("Details = %v", threat.Details)
+	}
+}
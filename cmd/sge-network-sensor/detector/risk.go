@@ -0,0 +1,206 @@
+package detector
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+const (
+	// ThreatCompositeRisk is raised when the combined signals of one source
+	// cross a risk tier.
+	ThreatCompositeRisk ThreatType = "composite_risk"
+)
+
+// RiskTier is a composite score threshold and the severity raised at it.
+type RiskTier struct {
+	Score    float64
+	Severity models.Severity
+}
+
+// DefaultRiskTiers start above 1.0, the score of a single signal at full
+// strength, so only activity spanning several detectors escalates.
+func DefaultRiskTiers() []RiskTier {
+	return []RiskTier{
+		{Score: 1.2, Severity: models.SeverityMedium},
+		{Score: 2.0, Severity: models.SeverityHigh},
+		{Score: 3.0, Severity: models.SeverityCritical},
+	}
+}
+
+// DefaultRiskWeights weights the threats of every tracker equally.
+func DefaultRiskWeights() map[ThreatType]float64 {
+	return map[ThreatType]float64{
+		ThreatPortScan:              1.0,
+		ThreatBeaconing:             1.0,
+		ThreatExfiltration:          1.0,
+		ThreatRSTStorm:              1.0,
+		ThreatRetransmissionStorm:   1.0,
+		ThreatSYNFlood:              1.0,
+		ThreatWeakTLS:               1.0,
+		ThreatPortMismatch:          1.0,
+		ThreatRemoteAdminBruteForce: 1.0,
+		ThreatLateralMovement:       1.0,
+	}
+}
+
+type riskSignal struct {
+	strength float64
+	at       time.Time
+}
+
+type riskEntry struct {
+	src     string
+	signals map[ThreatType]riskSignal
+	tier    int // Index+1 of the highest tier raised, 0 for none
+}
+
+// RiskAggregator combines the signals of all detectors into a composite
+// per-source score. Each signal type contributes weight x strength, where
+// strength (0..1) is how close the source came to that detector's threshold
+// within the window, so broad but individually mild activity adds up.
+// At most capacity sources are tracked; the least recently active source is
+// evicted first. It is safe for concurrent use.
+type RiskAggregator struct {
+	window   time.Duration
+	capacity int
+	weights  map[ThreatType]float64
+	tiers    []RiskTier // Ascending by score
+
+	mu      sync.Mutex
+	sources map[string]*list.Element // Values are *riskEntry
+	lru     *list.List               // Front is most recently active
+}
+
+// NewRiskAggregator creates an aggregator. Signal types without a weight
+// are ignored.
+func NewRiskAggregator(window time.Duration, capacity int, weights map[ThreatType]float64, tiers []RiskTier) *RiskAggregator {
+	sorted := append([]RiskTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Score < sorted[j].Score })
+	return &RiskAggregator{
+		window:   window,
+		capacity: capacity,
+		weights:  weights,
+		tiers:    sorted,
+		sources:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Add records a signal of strength (capped at 1) for src at time at, and
+// returns a composite threat when the source's score crosses a tier it had
+// not reached yet in the window.
+func (r *RiskAggregator) Add(src string, typ ThreatType, strength float64, at time.Time) *Threat {
+	if _, ok := r.weights[typ]; !ok || strength <= 0 || src == "" {
+		return nil
+	}
+	strength = min(strength, 1)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.sources[src]
+	if ok {
+		r.lru.MoveToFront(el)
+	} else {
+		if r.capacity > 0 && len(r.sources) >= r.capacity {
+			oldest := r.lru.Back()
+			r.lru.Remove(oldest)
+			delete(r.sources, oldest.Value.(*riskEntry).src)
+		}
+		el = r.lru.PushFront(&riskEntry{src: src, signals: make(map[ThreatType]riskSignal)})
+		r.sources[src] = el
+	}
+	e := el.Value.(*riskEntry)
+
+	// Keep the strongest signal of each type within the window
+	if prev, ok := e.signals[typ]; !ok || at.Sub(prev.at) > r.window || strength >= prev.strength {
+		e.signals[typ] = riskSignal{strength: strength, at: at}
+	}
+
+	score := r.score(e, at)
+	tier := 0
+	for i, t := range r.tiers {
+		if score >= t.Score {
+			tier = i + 1
+		}
+	}
+	if tier <= e.tier {
+		if tier == 0 {
+			e.tier = 0 // Signals aged out, allow a new escalation
+		}
+		return nil
+	}
+	e.tier = tier
+
+	t := r.tiers[tier-1]
+	contributions := make(map[string]interface{}, len(e.signals))
+	for typ, s := range e.signals {
+		contributions[string(typ)] = s.strength * r.weights[typ]
+	}
+	return &Threat{
+		Type:        ThreatCompositeRisk,
+		Severity:    t.Severity,
+		SrcIP:       src,
+		Description: fmt.Sprintf("%s reached composite risk %.2f from %d detectors within %s", src, score, len(contributions), r.window),
+		Timestamp:   at,
+		Details: map[string]interface{}{
+			"score":   score,
+			"tier":    tier,
+			"signals": contributions,
+		},
+	}
+}
+
+// score sums the weighted signals still inside the window at now, dropping
+// expired ones.
+func (r *RiskAggregator) score(e *riskEntry, now time.Time) float64 {
+	var score float64
+	for typ, s := range e.signals {
+		if now.Sub(s.at) > r.window {
+			delete(e.signals, typ)
+			continue
+		}
+		score += s.strength * r.weights[typ]
+	}
+	return score
+}
+
+// Score returns the current composite score of src.
+func (r *RiskAggregator) Score(src string, now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.sources[src]
+	if !ok {
+		return 0
+	}
+	return r.score(el.Value.(*riskEntry), now)
+}
+
+// Cleanup drops sources without signals inside the window.
+func (r *RiskAggregator) Cleanup(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for el := r.lru.Back(); el != nil; {
+		prev := el.Prev()
+		e := el.Value.(*riskEntry)
+		if r.score(e, now); len(e.signals) == 0 {
+			r.lru.Remove(el)
+			delete(r.sources, e.src)
+		}
+		el = prev
+	}
+}
+
+// Len returns the number of tracked sources.
+func (r *RiskAggregator) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sources)
+}
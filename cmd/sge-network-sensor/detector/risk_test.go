@@ -0,0 +1,153 @@
+package detector
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// mildScan probes ports 1..n of dst within a second.
+func mildScan(src string, n int, start time.Time) (evts []*inspector.NetworkEvent) {
+	for p := 1; p <= n; p++ {
+		evts = append(evts, syn(src, "10.0.0.9", uint16(p), start.Add(time.Duration(p)*10*time.Millisecond)))
+	}
+	return
+}
+
+// mildBeacon connects to a C2 address n times a minute apart.
+func mildBeacon(src string, n int, start time.Time) (evts []*inspector.NetworkEvent) {
+	for i := 0; i < n; i++ {
+		evts = append(evts, syn(src, "203.0.113.10", 443, start.Add(time.Duration(i)*time.Minute)))
+	}
+	return
+}
+
+func composites(d *Detector, events []*inspector.NetworkEvent) []Threat {
+	var out []Threat
+	for _, evt := range events {
+		for _, t := range d.Analyze(evt) {
+			if t.Type == ThreatCompositeRisk {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+func TestRiskCompositeEscalation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PortScanThreshold = 10
+	cfg.BeaconMinConnections = 5
+
+	// 7 of 10 ports and 4 of 5 beacon connections: below both thresholds
+	scan := mildScan("10.0.0.7", 7, t0)
+	beacon := mildBeacon("10.0.0.7", 4, t0.Add(2*time.Minute))
+
+	for name, events := range map[string][]*inspector.NetworkEvent{"Scan Only": scan, "Beacon Only": beacon} {
+		t.Run(name, func(t *testing.T) {
+			counts := countThreats(NewDetector(cfg), events)
+			if len(counts) != 0 {
+				t.Errorf("threats = %v, want none", counts)
+			}
+		})
+	}
+
+	t.Run("Scan And Beacon", func(t *testing.T) {
+		d := NewDetector(cfg)
+		got := composites(d, append(append([]*inspector.NetworkEvent{}, scan...), beacon...))
+		if len(got) != 1 {
+			t.Fatalf("composite threats = %d, want 1", len(got))
+		}
+		c := got[0]
+		if c.SrcIP != "10.0.0.7" || c.Severity != models.SeverityMedium {
+			t.Errorf("composite = %s %s, want 10.0.0.7 medium", c.SrcIP, c.Severity)
+		}
+		signals := c.Details["signals"].(map[string]interface{})
+		// Escalates at the third regular check-in: 0.7 + 3/5
+		if signals[string(ThreatPortScan)] != 0.7 || signals[string(ThreatBeaconing)] != 0.6 {
+			t.Errorf("signals = %v, want port_scan 0.7, beaconing 0.6", signals)
+		}
+	})
+}
+
+func TestRiskAggregatorTiers(t *testing.T) {
+	r := NewRiskAggregator(10*time.Minute, 100, DefaultRiskWeights(), DefaultRiskTiers())
+	src := "10.0.0.7"
+
+	steps := []struct {
+		typ      ThreatType
+		strength float64
+		at       time.Duration
+		want     models.Severity // Empty for no threat
+	}{
+		{ThreatPortScan, 0.6, 0, ""},
+		{ThreatPortScan, 0.4, time.Minute, ""}, // Weaker signal keeps the stronger one
+		{ThreatBeaconing, 0.7, 2 * time.Minute, models.SeverityMedium},
+		{ThreatBeaconing, 0.8, 3 * time.Minute, ""}, // Same tier is not raised twice
+		{ThreatSYNFlood, 1, 4 * time.Minute, models.SeverityHigh},
+		{ThreatExfiltration, 1, 5 * time.Minute, models.SeverityCritical},
+		{"unknown", 1, 6 * time.Minute, ""},
+	}
+	for n, s := range steps {
+		got := r.Add(src, s.typ, s.strength, t0.Add(s.at))
+		switch {
+		case s.want == "" && got != nil:
+			t.Errorf("#%d Add(%s) = %s threat, want none", n, s.typ, got.Severity)
+		case s.want != "" && (got == nil || got.Severity != s.want):
+			t.Errorf("#%d Add(%s) = %v, want %s", n, s.typ, got, s.want)
+		}
+	}
+
+	if got := r.Score(src, t0.Add(6*time.Minute)); math.Abs(got-3.4) > 1e-9 {
+		t.Errorf("Score() = %v, want 3.4", got)
+	}
+	// Old signals age out of the window
+	if got := r.Score(src, t0.Add(14*time.Minute)); math.Abs(got-2) > 1e-9 {
+		t.Errorf("Score() after window = %v, want 2", got)
+	}
+	r.Cleanup(t0.Add(time.Hour))
+	if r.Len() != 0 {
+		t.Errorf("Len() after Cleanup = %d, want 0", r.Len())
+	}
+}
+
+func TestRiskAggregatorBounded(t *testing.T) {
+	r := NewRiskAggregator(time.Hour, 3, DefaultRiskWeights(), DefaultRiskTiers())
+	for _, src := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		r.Add(src, ThreatPortScan, 0.5, t0)
+	}
+	r.Add("10.0.0.1", ThreatBeaconing, 0.1, t0) // Touch, so .2 is the oldest
+	r.Add("10.0.0.4", ThreatPortScan, 0.5, t0)
+
+	if r.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", r.Len())
+	}
+	if r.Score("10.0.0.2", t0) != 0 || r.Score("10.0.0.1", t0) != 0.6 {
+		t.Errorf("scores = %v / %v, want 10.0.0.2 evicted and 10.0.0.1 kept", r.Score("10.0.0.2", t0), r.Score("10.0.0.1", t0))
+	}
+}
+
+func TestRiskCountsEveryTracker(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RemoteAdminAttempts = 3
+	d := NewDetector(cfg)
+
+	// A weak TLS client that then brute forces RDP: one full signal each
+	events := []*inspector.NetworkEvent{clientHello("10.0.0.7", dpi.VersionTLS10, t0)}
+	for i := 0; i < 3; i++ {
+		events = append(events, rdpLogon("10.0.0.7", "10.0.0.20", "admin", t0.Add(time.Duration(i+1)*time.Second)))
+	}
+
+	got := composites(d, events)
+	if len(got) != 1 || got[0].Severity != models.SeverityHigh {
+		t.Fatalf("composite threats = %v, want one high", got)
+	}
+	signals := got[0].Details["signals"].(map[string]interface{})
+	if signals[string(ThreatWeakTLS)] != 1.0 || signals[string(ThreatRemoteAdminBruteForce)] != 1.0 {
+		t.Errorf("signals = %v, want weak_tls and remote_admin_brute_force at 1", signals)
+	}
+}
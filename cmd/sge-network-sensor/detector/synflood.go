@@ -0,0 +1,118 @@
+package detector
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// ThreatSYNFlood is raised when a destination accumulates half-open
+// connections from many sources, typical of a (spoofed) SYN flood.
+const ThreatSYNFlood ThreatType = "syn_flood"
+
+type synFloodEntry struct {
+	halfOpen  map[string]string // "src:port" -> src, for unanswered SYNs
+	sources   map[string]int    // Half-open connections per source
+	firstSeen time.Time
+	alerted   bool
+}
+
+// SYNFloodTracker counts half-open connections per destination service.
+// A connection stays half-open from its SYN until the source completes the
+// handshake with an ACK. The state per destination is bounded: beyond
+// 4 x threshold half-open connections new SYNs are no longer recorded, so a
+// flood cannot grow it without limit.
+type SYNFloodTracker struct {
+	threshold int // Distinct sources with half-open connections
+	window    time.Duration
+
+	mu    sync.Mutex
+	dests map[string]*synFloodEntry // Keyed by "dst:port"
+}
+
+// NewSYNFloodTracker creates a tracker alerting when threshold distinct
+// sources hold half-open connections to one destination within window.
+func NewSYNFloodTracker(threshold int, window time.Duration) *SYNFloodTracker {
+	return &SYNFloodTracker{
+		threshold: threshold,
+		window:    window,
+		dests:     make(map[string]*synFloodEntry),
+	}
+}
+
+// Track records SYNs and handshake completions and returns a threat once
+// per window when a destination crosses the threshold.
+func (t *SYNFloodTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if evt.Protocol != "TCP" || t.threshold <= 0 {
+		return nil
+	}
+	syn := evt.IsConnectionAttempt()
+	ack := evt.TCPFlags&(inspector.TCPFlagACK|inspector.TCPFlagSYN) == inspector.TCPFlagACK
+	if !syn && !ack {
+		return nil
+	}
+
+	dst := evt.DstIP + ":" + strconv.Itoa(int(evt.DstPort))
+	conn := evt.SrcIP + ":" + strconv.Itoa(int(evt.SrcPort))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.dests[dst]
+	if ack {
+		if ok {
+			if src, open := e.halfOpen[conn]; open {
+				delete(e.halfOpen, conn)
+				if e.sources[src]--; e.sources[src] <= 0 {
+					delete(e.sources, src)
+				}
+			}
+		}
+		return nil
+	}
+
+	if !ok || evt.Timestamp.Sub(e.firstSeen) > t.window {
+		e = &synFloodEntry{halfOpen: make(map[string]string), sources: make(map[string]int), firstSeen: evt.Timestamp}
+		t.dests[dst] = e
+	}
+	if _, dup := e.halfOpen[conn]; !dup && len(e.halfOpen) < 4*t.threshold {
+		e.halfOpen[conn] = evt.SrcIP
+		e.sources[evt.SrcIP]++
+	}
+
+	if e.alerted || len(e.sources) < t.threshold {
+		return nil
+	}
+	e.alerted = true
+
+	return &Threat{
+		Type:        ThreatSYNFlood,
+		Severity:    models.SeverityHigh,
+		SrcIP:       evt.SrcIP,
+		DstIP:       evt.DstIP,
+		DstPort:     evt.DstPort,
+		Description: fmt.Sprintf("%s has %d half-open connections from %d sources within %s", dst, len(e.halfOpen), len(e.sources), t.window),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"half_open":  len(e.halfOpen),
+			"sources":    len(e.sources),
+			"first_seen": e.firstSeen,
+		},
+	}
+}
+
+// Cleanup drops destinations whose window has expired.
+func (t *SYNFloodTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for dst, e := range t.dests {
+		if now.Sub(e.firstSeen) > t.window {
+			delete(t.dests, dst)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package detector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+func spoofedSYN(n int, at time.Time) *inspector.NetworkEvent {
+	evt := syn(fmt.Sprintf("198.51.%d.%d", n/256, n%256), "10.0.0.80", 443, at)
+	evt.SrcPort = uint16(1024 + n%60000)
+	return evt
+}
+
+func TestSYNFlood(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SYNFloodSources = 100
+	cfg.RiskWindow = 0
+
+	tests := []struct {
+		name   string
+		events func() []*inspector.NetworkEvent
+		want   map[ThreatType]int
+	}{
+		{
+			name: "Spoofed Flood",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for n := 0; n < 5000; n++ {
+					evts = append(evts, spoofedSYN(n, t0.Add(time.Duration(n)*time.Millisecond)))
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatSYNFlood: 1},
+		},
+		{
+			name: "Completed Handshakes",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for n := 0; n < 5000; n++ {
+					at := t0.Add(time.Duration(n) * time.Millisecond)
+					s := spoofedSYN(n, at)
+					ack := *s
+					ack.TCPFlags = inspector.TCPFlagACK
+					evts = append(evts, s, &ack)
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := countThreats(NewDetector(cfg), tt.events())
+			if len(got) != len(tt.want) {
+				t.Fatalf("threats = %v, want %v", got, tt.want)
+			}
+			for typ, n := range tt.want {
+				if got[typ] != n {
+					t.Errorf("threats[%s] = %d, want %d", typ, got[typ], n)
+				}
+			}
+		})
+	}
+}
+
+func TestSYNFloodBounded(t *testing.T) {
+	tr := NewSYNFloodTracker(10, time.Minute)
+	for n := 0; n < 1000; n++ {
+		tr.Track(spoofedSYN(n, t0))
+	}
+	e := tr.dests["10.0.0.80:443"]
+	if len(e.halfOpen) != 40 || len(e.sources) != 40 {
+		t.Errorf("half-open = %d, sources = %d, want 40 (4 x threshold)", len(e.halfOpen), len(e.sources))
+	}
+}
@@ -0,0 +1,193 @@
+package detector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+const (
+	// ThreatRSTStorm is raised when a source sends abnormally many TCP
+	// resets (scan responses, reset/teardown attacks).
+	ThreatRSTStorm ThreatType = "rst_storm"
+
+	// ThreatRetransmissionStorm is raised when a flow retransmits a large
+	// share of its segments (network problems, stalled or hijacked sessions).
+	ThreatRetransmissionStorm ThreatType = "retransmission_storm"
+)
+
+// recentSeqs is how many sequence numbers per flow are remembered to
+// recognize retransmitted segments.
+const recentSeqs = 32
+
+type rstEntry struct {
+	count     int
+	peers     map[string]struct{}
+	firstSeen time.Time
+	alerted   bool
+}
+
+type retransEntry struct {
+	seqs      [recentSeqs]uint32
+	n         int // Valid entries in seqs
+	next      int // Ring position of the next write
+	segments  int // Payload segments in the window
+	retrans   int // Segments whose sequence number was already seen
+	firstSeen time.Time
+	alerted   bool
+}
+
+// TCPHealthTracker watches TCP flags and sequence numbers for reset storms
+// per source and retransmission storms per flow direction. At most maxFlows
+// flows are tracked for retransmissions; new flows are ignored once full
+// until Cleanup runs.
+type TCPHealthTracker struct {
+	rstThreshold  int
+	rstWindow     time.Duration
+	retransMin    int     // Payload segments needed before judging a flow
+	retransRatio  float64 // Share of retransmitted segments that alerts
+	retransWindow time.Duration
+	maxFlows      int
+
+	mu     sync.Mutex
+	resets map[string]*rstEntry     // Keyed by source IP
+	flows  map[string]*retransEntry // Keyed by directional flow
+}
+
+// NewTCPHealthTracker creates a tracker alerting at rstThreshold resets per
+// source within rstWindow, and at retransRatio retransmitted segments on
+// flows with at least retransMin payload segments within retransWindow.
+func NewTCPHealthTracker(rstThreshold int, rstWindow time.Duration, retransMin int, retransRatio float64, retransWindow time.Duration, maxFlows int) *TCPHealthTracker {
+	return &TCPHealthTracker{
+		rstThreshold:  rstThreshold,
+		rstWindow:     rstWindow,
+		retransMin:    retransMin,
+		retransRatio:  retransRatio,
+		retransWindow: retransWindow,
+		maxFlows:      maxFlows,
+		resets:        make(map[string]*rstEntry),
+		flows:         make(map[string]*retransEntry),
+	}
+}
+
+// Track records a TCP segment and returns a threat once per window when a
+// source's resets or a flow's retransmissions become abnormal.
+func (t *TCPHealthTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if evt.Protocol != "TCP" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if evt.TCPFlags&inspector.TCPFlagRST != 0 {
+		return t.trackReset(evt)
+	}
+	// Seq 0 means the event carries no sequence number
+	if evt.PayloadSize > 0 && evt.TCPSeq != 0 {
+		return t.trackRetransmission(evt)
+	}
+	return nil
+}
+
+func (t *TCPHealthTracker) trackReset(evt *inspector.NetworkEvent) *Threat {
+	e, ok := t.resets[evt.SrcIP]
+	if !ok || evt.Timestamp.Sub(e.firstSeen) > t.rstWindow {
+		e = &rstEntry{peers: make(map[string]struct{}), firstSeen: evt.Timestamp}
+		t.resets[evt.SrcIP] = e
+	}
+	e.count++
+	if len(e.peers) < t.rstThreshold {
+		e.peers[evt.DstIP] = struct{}{}
+	}
+
+	if e.alerted || e.count < t.rstThreshold {
+		return nil
+	}
+	e.alerted = true
+
+	return &Threat{
+		Type:        ThreatRSTStorm,
+		Severity:    models.SeverityMedium,
+		SrcIP:       evt.SrcIP,
+		DstIP:       evt.DstIP,
+		Description: fmt.Sprintf("%s sent %d TCP resets to %d hosts within %s", evt.SrcIP, e.count, len(e.peers), t.rstWindow),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"resets":     e.count,
+			"peers":      len(e.peers),
+			"first_seen": e.firstSeen,
+		},
+	}
+}
+
+func (t *TCPHealthTracker) trackRetransmission(evt *inspector.NetworkEvent) *Threat {
+	key := fmt.Sprintf("%s:%d->%s:%d", evt.SrcIP, evt.SrcPort, evt.DstIP, evt.DstPort)
+	e, ok := t.flows[key]
+	if !ok || evt.Timestamp.Sub(e.firstSeen) > t.retransWindow {
+		if !ok && t.maxFlows > 0 && len(t.flows) >= t.maxFlows {
+			return nil
+		}
+		e = &retransEntry{firstSeen: evt.Timestamp}
+		t.flows[key] = e
+	}
+	e.segments++
+
+	seen := false
+	for i := 0; i < e.n; i++ {
+		if e.seqs[i] == evt.TCPSeq {
+			seen = true
+			break
+		}
+	}
+	if seen {
+		e.retrans++
+	} else {
+		e.seqs[e.next] = evt.TCPSeq
+		e.next = (e.next + 1) % recentSeqs
+		e.n = min(e.n+1, recentSeqs)
+	}
+
+	ratio := float64(e.retrans) / float64(e.segments)
+	if e.alerted || e.segments < t.retransMin || ratio < t.retransRatio {
+		return nil
+	}
+	e.alerted = true
+
+	return &Threat{
+		Type:        ThreatRetransmissionStorm,
+		Severity:    models.SeverityLow,
+		SrcIP:       evt.SrcIP,
+		DstIP:       evt.DstIP,
+		DstPort:     evt.DstPort,
+		Description: fmt.Sprintf("%s retransmitted %d of %d segments (%.0f%%) to %s:%d", evt.SrcIP, e.retrans, e.segments, ratio*100, evt.DstIP, evt.DstPort),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"segments":        e.segments,
+			"retransmissions": e.retrans,
+			"ratio":           ratio,
+			"src_port":        evt.SrcPort,
+			"first_seen":      e.firstSeen,
+		},
+	}
+}
+
+// Cleanup drops sources and flows whose window has expired.
+func (t *TCPHealthTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for ip, e := range t.resets {
+		if now.Sub(e.firstSeen) > t.rstWindow {
+			delete(t.resets, ip)
+		}
+	}
+	for key, e := range t.flows {
+		if now.Sub(e.firstSeen) > t.retransWindow {
+			delete(t.flows, key)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package detector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+func rst(src, dst string, at time.Time) *inspector.NetworkEvent {
+	return &inspector.NetworkEvent{
+		Timestamp: at,
+		SrcIP:     src,
+		DstIP:     dst,
+		SrcPort:   80,
+		DstPort:   40000,
+		Protocol:  "TCP",
+		TCPFlags:  inspector.TCPFlagRST | inspector.TCPFlagACK,
+	}
+}
+
+func segment(seq uint32, at time.Time) *inspector.NetworkEvent {
+	evt := data("10.0.0.5", "10.0.0.9", 443, 1400, at)
+	evt.TCPSeq = seq
+	return evt
+}
+
+func TestTCPHealth(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RSTThreshold = 50
+	cfg.RetransMinSegments = 20
+
+	tests := []struct {
+		name   string
+		events func() []*inspector.NetworkEvent
+		want   map[ThreatType]int
+	}{
+		{
+			name: "RST Storm",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for n := 0; n < 100; n++ {
+					evts = append(evts, rst("10.0.0.9", fmt.Sprintf("10.0.1.%d", n), t0.Add(time.Duration(n)*100*time.Millisecond)))
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatRSTStorm: 1},
+		},
+		{
+			name: "Sporadic Resets",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for n := 0; n < 100; n++ {
+					evts = append(evts, rst("10.0.0.9", "10.0.1.1", t0.Add(time.Duration(n)*5*time.Second)))
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+		{
+			name: "Retransmission Heavy Flow",
+			events: func() (evts []*inspector.NetworkEvent) {
+				// Every segment is sent twice
+				for n := 0; n < 30; n++ {
+					at := t0.Add(time.Duration(n) * 100 * time.Millisecond)
+					seq := 1000 + uint32(n)*1400
+					evts = append(evts, segment(seq, at), segment(seq, at.Add(50*time.Millisecond)))
+				}
+				return
+			},
+			want: map[ThreatType]int{ThreatRetransmissionStorm: 1},
+		},
+		{
+			name: "Healthy Flow",
+			events: func() (evts []*inspector.NetworkEvent) {
+				for n := 0; n < 100; n++ {
+					seq := 1000 + uint32(n)*1400
+					evts = append(evts, segment(seq, t0.Add(time.Duration(n)*10*time.Millisecond)))
+					if n%20 == 0 {
+						evts = append(evts, segment(seq, t0.Add(time.Duration(n)*10*time.Millisecond+time.Millisecond)))
+					}
+				}
+				return
+			},
+			want: map[ThreatType]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			cfg.RiskWindow = 0
+			got := countThreats(NewDetector(cfg), tt.events())
+			if len(got) != len(tt.want) {
+				t.Fatalf("threats = %v, want %v", got, tt.want)
+			}
+			for typ, n := range tt.want {
+				if got[typ] != n {
+					t.Errorf("threats[%s] = %d, want %d", typ, got[typ], n)
+				}
+			}
+		})
+	}
+}
+
+func TestTCPHealthMaxFlows(t *testing.T) {
+	tr := NewTCPHealthTracker(10, time.Minute, 1, 0.1, time.Minute, 2)
+	for port := uint16(1); port <= 5; port++ {
+		evt := segment(1, t0)
+		evt.SrcPort = port
+		tr.Track(evt)
+	}
+	if n := len(tr.flows); n != 2 {
+		t.Errorf("tracked flows = %d, want 2", n)
+	}
+	tr.Cleanup(t0.Add(2 * time.Minute))
+	if n := len(tr.flows); n != 0 {
+		t.Errorf("tracked flows after Cleanup = %d, want 0", n)
+	}
+}
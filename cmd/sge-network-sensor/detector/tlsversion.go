@@ -0,0 +1,94 @@
+package detector
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// ThreatWeakTLS is a compliance finding: a client offered at most a TLS
+// version below the configured minimum.
+const ThreatWeakTLS ThreatType = "weak_tls"
+
+// WeakTLSTracker flags TLS handshakes whose highest offered version is
+// below a minimum. Each client/server pair is reported once per window.
+// The version comes from the ClientHello; negotiated versions and weak
+// cipher suites need ServerHello parsing.
+type WeakTLSTracker struct {
+	minVersion uint16 // 0 disables
+	window     time.Duration
+	maxPairs   int
+
+	mu       sync.Mutex
+	reported map[string]time.Time // "src->dst:port" -> last report
+}
+
+// NewWeakTLSTracker creates a tracker flagging handshakes below
+// minVersion (dpi.VersionTLS*), remembering up to maxPairs reports.
+func NewWeakTLSTracker(minVersion uint16, window time.Duration, maxPairs int) *WeakTLSTracker {
+	return &WeakTLSTracker{
+		minVersion: minVersion,
+		window:     window,
+		maxPairs:   maxPairs,
+		reported:   make(map[string]time.Time),
+	}
+}
+
+// Track returns a threat for a ClientHello below the minimum version.
+func (t *WeakTLSTracker) Track(evt *inspector.NetworkEvent) *Threat {
+	if t.minVersion == 0 || evt.TLSVersion == 0 || evt.TLSVersion >= t.minVersion {
+		return nil
+	}
+
+	key := evt.SrcIP + "->" + evt.DstIP + ":" + strconv.Itoa(int(evt.DstPort))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.reported[key]; ok && evt.Timestamp.Sub(last) < t.window {
+		return nil
+	}
+	if t.maxPairs > 0 && len(t.reported) >= t.maxPairs {
+		// Better a repeated finding than unbounded state
+		t.reported = make(map[string]time.Time)
+	}
+	t.reported[key] = evt.Timestamp
+
+	version, floor := dpi.TLSVersionName(evt.TLSVersion), dpi.TLSVersionName(t.minVersion)
+	server := evt.SNI
+	if server == "" {
+		server = evt.DstIP
+	}
+	return &Threat{
+		Type:        ThreatWeakTLS,
+		Severity:    models.SeverityMedium,
+		SrcIP:       evt.SrcIP,
+		DstIP:       evt.DstIP,
+		DstPort:     evt.DstPort,
+		Description: fmt.Sprintf("%s offers at most %s to %s:%d, below the required %s", evt.SrcIP, version, server, evt.DstPort, floor),
+		Timestamp:   evt.Timestamp,
+		Details: map[string]interface{}{
+			"compliance":  "tls_min_version",
+			"tls_version": version,
+			"min_version": floor,
+			"sni":         evt.SNI,
+		},
+	}
+}
+
+// Cleanup forgets reports older than the window.
+func (t *WeakTLSTracker) Cleanup(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, last := range t.reported {
+		if now.Sub(last) >= t.window {
+			delete(t.reported, key)
+		}
+	}
+}
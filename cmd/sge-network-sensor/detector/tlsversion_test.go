@@ -0,0 +1,82 @@
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+func clientHello(src string, version uint16, at time.Time) *inspector.NetworkEvent {
+	evt := data(src, "203.0.113.10", 443, 517, at)
+	evt.SNI = "legacy.example.com"
+	evt.TLSVersion = version
+	return evt
+}
+
+func TestWeakTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		floor   uint16
+		version uint16
+		want    bool
+	}{
+		{"TLS 1.0 Below 1.2", dpi.VersionTLS12, dpi.VersionTLS10, true},
+		{"TLS 1.1 Below 1.2", dpi.VersionTLS12, dpi.VersionTLS11, true},
+		{"TLS 1.3 Above 1.2", dpi.VersionTLS12, dpi.VersionTLS13, false},
+		{"TLS 1.2 At Floor", dpi.VersionTLS12, dpi.VersionTLS12, false},
+		{"TLS 1.2 Below 1.3", dpi.VersionTLS13, dpi.VersionTLS12, true},
+		{"Disabled", 0, dpi.VersionTLS10, false},
+		{"Not TLS", dpi.VersionTLS12, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := NewWeakTLSTracker(tt.floor, time.Hour, 100).Track(clientHello("10.0.0.5", tt.version, t0))
+			if (th != nil) != tt.want {
+				t.Fatalf("Track() = %v, want threat %v", th, tt.want)
+			}
+			if th == nil {
+				return
+			}
+			if th.Type != ThreatWeakTLS || th.Details["tls_version"] != dpi.TLSVersionName(tt.version) || th.Details["min_version"] != dpi.TLSVersionName(tt.floor) {
+				t.Errorf("Track() = %+v", th)
+			}
+		})
+	}
+}
+
+func TestWeakTLSOncePerWindow(t *testing.T) {
+	tracker := NewWeakTLSTracker(dpi.VersionTLS12, time.Hour, 100)
+	var n int
+	for _, at := range []time.Duration{0, time.Minute, 30 * time.Minute, 61 * time.Minute} {
+		if tracker.Track(clientHello("10.0.0.5", dpi.VersionTLS10, t0.Add(at))) != nil {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("threats = %d, want 2 (first and after the window)", n)
+	}
+
+	// Another client is reported independently
+	if tracker.Track(clientHello("10.0.0.6", dpi.VersionTLS10, t0.Add(62*time.Minute))) == nil {
+		t.Error("second client not reported")
+	}
+
+	tracker.Cleanup(t0.Add(3 * time.Hour))
+	if len(tracker.reported) != 0 {
+		t.Errorf("reported = %d after Cleanup, want 0", len(tracker.reported))
+	}
+}
+
+func TestDetectorWeakTLS(t *testing.T) {
+	cfg := DefaultConfig()
+	got := countThreats(NewDetector(cfg), []*inspector.NetworkEvent{
+		clientHello("10.0.0.5", dpi.VersionTLS10, t0),
+		clientHello("10.0.0.7", dpi.VersionTLS13, t0),
+	})
+	if got[ThreatWeakTLS] != 1 {
+		t.Errorf("weak_tls threats = %d, want 1", got[ThreatWeakTLS])
+	}
+}
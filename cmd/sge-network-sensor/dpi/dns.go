@@ -0,0 +1,91 @@
+package dpi
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+// DNS parsing safety limits
+const (
+	MaxDNSPayloadSize = 512 // Classic UDP DNS message size
+	dnsHeaderSize     = 12
+	maxDNSLabels      = 127
+)
+
+var dnsTypeNames = map[uint16]string{
+	1: "A", 2: "NS", 5: "CNAME", 6: "SOA", 12: "PTR", 15: "MX",
+	16: "TXT", 28: "AAAA", 33: "SRV", 65: "HTTPS", 255: "ANY",
+}
+
+// DNSQuery represents the first question of a DNS query message.
+type DNSQuery struct {
+	Name string // Lowercase, without trailing dot
+	Type string // e.g. "A", "AAAA", "TYPE64" for unknown types
+}
+
+// ParseDNSQuery extracts the question from a UDP DNS query payload.
+// Responses and non-standard opcodes are not applicable; a question whose
+// type is cut off is returned as a partial parse.
+func ParseDNSQuery(payload []byte) (*DNSQuery, error) {
+	if len(payload) < dnsHeaderSize {
+		return nil, truncated(ProtoDNS, len(payload), "short header")
+	}
+	if len(payload) > MaxDNSPayloadSize {
+		payload = payload[:MaxDNSPayloadSize]
+	}
+
+	// Flags: QR bit set means response, opcode must be standard query (0)
+	flags := binary.BigEndian.Uint16(payload[2:4])
+	if flags&0x8000 != 0 || (flags>>11)&0xF != 0 {
+		return nil, nil
+	}
+	if binary.BigEndian.Uint16(payload[4:6]) == 0 { // QDCOUNT
+		return nil, malformed(ProtoDNS, 4, "no question")
+	}
+
+	// QNAME: length-prefixed labels ending with a zero byte.
+	// Queries never use compression pointers, so they are rejected.
+	var sb strings.Builder
+	pos := dnsHeaderSize
+	for labels := 0; ; labels++ {
+		if pos >= len(payload) {
+			return nil, truncated(ProtoDNS, pos, "name")
+		}
+		if labels > maxDNSLabels {
+			return nil, malformed(ProtoDNS, pos, "too many labels")
+		}
+		l := int(payload[pos])
+		pos++
+		if l == 0 {
+			break
+		}
+		if l&0xC0 != 0 {
+			return nil, malformed(ProtoDNS, pos-1, "compression pointer in question")
+		}
+		if pos+l > len(payload) {
+			return nil, truncated(ProtoDNS, pos, "label")
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.Write(payload[pos : pos+l])
+		pos += l
+		if sb.Len() > MaxSNILength {
+			return nil, malformed(ProtoDNS, pos, "name too long")
+		}
+	}
+
+	q := &DNSQuery{Name: strings.ToLower(sb.String())}
+	if pos+4 > len(payload) {
+		return q, truncated(ProtoDNS, pos, "type and class")
+	}
+	qtype := binary.BigEndian.Uint16(payload[pos : pos+2])
+
+	typeName, ok := dnsTypeNames[qtype]
+	if !ok {
+		typeName = "TYPE" + strconv.Itoa(int(qtype))
+	}
+	q.Type = typeName
+	return q, nil
+}
@@ -0,0 +1,92 @@
+package dpi
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseDNSQuery(t *testing.T) {
+	// Header: ID 0x1234, flags 0x0100 (standard query, RD), QDCOUNT 1
+	header := "123401000001000000000000"
+	exampleA := "076578616d706c6503636f6d00" + "0001" + "0001"     // example.com A IN
+	mixedAAAA := "03575757076578616d706c6503636f6d00" + "001c0001" // WWW.example.com AAAA IN
+
+	tests := []struct {
+		name       string
+		payloadHex string
+		wantName   string
+		wantType   string
+		want       string
+	}{
+		{
+			name:       "A Query",
+			payloadHex: header + exampleA,
+			wantName:   "example.com",
+			wantType:   "A",
+			want:       "ok",
+		},
+		{
+			name:       "AAAA Query Lowercased",
+			payloadHex: header + mixedAAAA,
+			wantName:   "www.example.com",
+			wantType:   "AAAA",
+			want:       "ok",
+		},
+		{
+			name:       "Unknown Type",
+			payloadHex: header + "076578616d706c6503636f6d00" + "00400001",
+			wantName:   "example.com",
+			wantType:   "TYPE64",
+			want:       "ok",
+		},
+		{
+			name:       "Response Rejected",
+			payloadHex: "123481800001000100000000" + exampleA,
+			want:       "n/a",
+		},
+		{
+			name:       "No Questions",
+			payloadHex: "123401000000000000000000" + exampleA,
+			want:       "malformed",
+		},
+		{
+			name:       "Truncated Label",
+			payloadHex: header + "0f6578616d706c65",
+			want:       "truncated",
+		},
+		{
+			name:       "Compression Pointer",
+			payloadHex: header + "c00c00010001",
+			want:       "malformed",
+		},
+		{
+			name:       "Opcode Not Query",
+			payloadHex: "123428000001000000000000" + exampleA,
+			want:       "n/a",
+		},
+		{
+			name:       "Missing Type",
+			payloadHex: header + "076578616d706c6503636f6d00" + "00",
+			wantName:   "example.com",
+			want:       "partial",
+		},
+		{
+			name:       "Short Payload",
+			payloadHex: "1234",
+			want:       "truncated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := hex.DecodeString(tt.payloadHex)
+			got, err := ParseDNSQuery(payload)
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseDNSQuery() = %s (%v), want %s", o, err, tt.want)
+			}
+			if got != nil && (got.Name != tt.wantName || got.Type != tt.wantType) {
+				t.Errorf("ParseDNSQuery() = %s/%s, want %s/%s", got.Name, got.Type, tt.wantName, tt.wantType)
+			}
+		})
+	}
+}
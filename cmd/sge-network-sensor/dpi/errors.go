@@ -0,0 +1,56 @@
+package dpi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Parsers return (packet, error) with three outcomes besides success:
+//
+//	nil, nil     the payload is not this protocol
+//	nil, err     it is this protocol but malformed or truncated
+//	packet, err  partial parse: packet holds what could be extracted
+//
+// Errors are *ParseError wrapping ErrMalformed or ErrTruncated.
+
+// Protocol names reported in ParseError.
+const (
+	ProtoTLS   = "tls"
+	ProtoHTTP  = "http"
+	ProtoDNS   = "dns"
+	ProtoFTP   = "ftp"
+	ProtoSSH   = "ssh"
+	ProtoRDP   = "rdp"
+	ProtoWinRM = "winrm"
+)
+
+var (
+	// ErrMalformed means the payload violates the protocol.
+	ErrMalformed = errors.New("malformed")
+	// ErrTruncated means the payload ends before the message does, e.g. a
+	// message spanning several segments.
+	ErrTruncated = errors.New("truncated")
+)
+
+// ParseError describes why a payload of a recognized protocol could not be
+// (fully) parsed.
+type ParseError struct {
+	Protocol string // Proto* constant
+	Offset   int    // Payload offset where parsing stopped
+	Reason   string
+	Err      error // ErrMalformed or ErrTruncated
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("dpi: %s %s at offset %d: %s", e.Err, e.Protocol, e.Offset, e.Reason)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+func malformed(proto string, offset int, reason string) error {
+	return &ParseError{Protocol: proto, Offset: offset, Reason: reason, Err: ErrMalformed}
+}
+
+func truncated(proto string, offset int, reason string) error {
+	return &ParseError{Protocol: proto, Offset: offset, Reason: reason, Err: ErrTruncated}
+}
@@ -0,0 +1,40 @@
+package dpi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// outcome classifies a parser result as "ok", "n/a" (not this protocol),
+// "malformed", "truncated" or "partial".
+func outcome(pkt interface{}, err error) string {
+	hasPkt := pkt != nil && !reflect.ValueOf(pkt).IsNil()
+	switch {
+	case err == nil && hasPkt:
+		return "ok"
+	case err == nil:
+		return "n/a"
+	case hasPkt:
+		return "partial"
+	case errors.Is(err, ErrTruncated):
+		return "truncated"
+	case errors.Is(err, ErrMalformed):
+		return "malformed"
+	}
+	return "unexpected error " + err.Error()
+}
+
+func TestParseError(t *testing.T) {
+	err := malformed(ProtoDNS, 12, "compression pointer in question")
+	if got, want := err.Error(), "dpi: malformed dns at offset 12: compression pointer in question"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrMalformed) || errors.Is(err, ErrTruncated) {
+		t.Errorf("errors.Is() does not match ErrMalformed only")
+	}
+	var pe *ParseError
+	if !errors.As(truncated(ProtoTLS, 43, "session id"), &pe) || pe.Protocol != ProtoTLS || pe.Offset != 43 {
+		t.Errorf("errors.As() = %+v, want tls at offset 43", pe)
+	}
+}
@@ -0,0 +1,113 @@
+package dpi
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// FTP parsing safety limits
+const (
+	maxFTPLineLength = 512 // RFC 959 command lines are short
+	MaxFTPArgLength  = 255
+)
+
+// ftpCommands lists the RFC 959 commands and common extensions, so random
+// traffic on port 21 is not mistaken for FTP.
+var ftpCommands = map[string]bool{
+	"USER": true, "PASS": true, "ACCT": true, "CWD": true, "CDUP": true, "QUIT": true,
+	"PORT": true, "PASV": true, "EPRT": true, "EPSV": true, "TYPE": true, "STRU": true,
+	"MODE": true, "RETR": true, "STOR": true, "STOU": true, "APPE": true, "ALLO": true,
+	"REST": true, "RNFR": true, "RNTO": true, "ABOR": true, "DELE": true, "RMD": true,
+	"MKD": true, "PWD": true, "LIST": true, "NLST": true, "SITE": true, "SYST": true,
+	"STAT": true, "HELP": true, "NOOP": true, "FEAT": true, "OPTS": true, "AUTH": true,
+	"PBSZ": true, "PROT": true, "SIZE": true, "MDTM": true, "MLSD": true, "MLST": true,
+}
+
+// FTPCommand is a client command on the control channel.
+type FTPCommand struct {
+	Command string // Uppercase, e.g. "RETR"
+	Arg     string // Argument (file name, user name, ...), empty if none or unprintable
+}
+
+// FTPReply is a server reply on the control channel.
+type FTPReply struct {
+	Code int    // e.g. 230
+	Text string // First line text after the code
+}
+
+// ftpLine returns the first CRLF terminated line of payload, or the whole
+// (length limited) payload and false when it has no line terminator.
+func ftpLine(payload []byte) ([]byte, bool) {
+	if len(payload) > maxFTPLineLength {
+		payload = payload[:maxFTPLineLength]
+	}
+	end := bytes.Index(payload, []byte("\r\n"))
+	if end < 0 {
+		return payload, false
+	}
+	return payload[:end], true
+}
+
+// ftpText returns b as a string if it is printable and within the length
+// limit.
+func ftpText(b []byte) (string, bool) {
+	b = bytes.TrimSpace(b)
+	if len(b) > MaxFTPArgLength || !utf8.Valid(b) || containsControlChars(b) {
+		return "", false
+	}
+	return string(b), true
+}
+
+// ParseFTPCommand extracts the command from a client -> server control
+// channel payload. A known command with an unprintable argument or without
+// line terminator is returned without the argument as a partial parse.
+func ParseFTPCommand(payload []byte) (*FTPCommand, error) {
+	line, complete := ftpLine(payload)
+	verb, arg, _ := bytes.Cut(line, []byte(" "))
+	cmd := strings.ToUpper(string(verb))
+	if !ftpCommands[cmd] {
+		return nil, nil
+	}
+
+	c := &FTPCommand{Command: cmd}
+	if !complete {
+		return c, truncated(ProtoFTP, len(line), "command line")
+	}
+	text, ok := ftpText(arg)
+	if !ok {
+		return c, malformed(ProtoFTP, len(verb)+1, "unprintable argument")
+	}
+	c.Arg = text
+	return c, nil
+}
+
+// ParseFTPReply extracts the reply code from a server -> client control
+// channel payload. Only the first line of multi-line replies is read.
+func ParseFTPReply(payload []byte) (*FTPReply, error) {
+	line, complete := ftpLine(payload)
+	if len(line) < 3 {
+		return nil, nil
+	}
+
+	// Code: [1-5][0-5][0-9] followed by ' ' (last line) or '-' (multi-line)
+	if line[0] < '1' || line[0] > '5' || line[1] < '0' || line[1] > '5' || line[2] < '0' || line[2] > '9' {
+		return nil, nil
+	}
+	if len(line) > 3 && line[3] != ' ' && line[3] != '-' {
+		return nil, nil
+	}
+
+	r := &FTPReply{Code: int(line[0]-'0')*100 + int(line[1]-'0')*10 + int(line[2]-'0')}
+	if !complete {
+		return r, truncated(ProtoFTP, len(line), "reply line")
+	}
+	if len(line) > 4 {
+		text, ok := ftpText(line[4:])
+		if !ok {
+			return r, malformed(ProtoFTP, 4, "unprintable reply text")
+		}
+		r.Text = text
+	}
+	return r, nil
+}
@@ -0,0 +1,67 @@
+package dpi
+
+import "testing"
+
+func TestParseFTPCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantCmd string
+		wantArg string
+		want    string
+	}{
+		{"User", "USER alice\r\n", "USER", "alice", "ok"},
+		{"Retrieve", "RETR reports/q3 2024.xlsx\r\n", "RETR", "reports/q3 2024.xlsx", "ok"},
+		{"Lowercase", "stor backup.tar.gz\r\n", "STOR", "backup.tar.gz", "ok"},
+		{"No Argument", "PASV\r\n", "PASV", "", "ok"},
+		{"Control Chars In Argument", "STOR a\x01b\r\n", "STOR", "", "partial"},
+		{"Unknown Command", "HELO mail.example.com\r\n", "", "", "n/a"},
+		{"Missing CRLF", "USER alice", "USER", "", "partial"},
+		{"Binary", "\x16\x03\x01\x00\xa5\r\n", "", "", "n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFTPCommand([]byte(tt.payload))
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseFTPCommand() = %s (%v), want %s", o, err, tt.want)
+			}
+			if got != nil && (got.Command != tt.wantCmd || got.Arg != tt.wantArg) {
+				t.Errorf("ParseFTPCommand() = %+v, want %s %q", got, tt.wantCmd, tt.wantArg)
+			}
+		})
+	}
+}
+
+func TestParseFTPReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		wantCode int
+		wantText string
+		want     string
+	}{
+		{"Banner", "220 ProFTPD Server ready.\r\n", 220, "ProFTPD Server ready.", "ok"},
+		{"Login OK", "230 User alice logged in\r\n", 230, "User alice logged in", "ok"},
+		{"Multi Line", "211-Features:\r\n MDTM\r\n211 End\r\n", 211, "Features:", "ok"},
+		{"Code Only", "200\r\n", 200, "", "ok"},
+		{"Login Failed", "530 Login incorrect.\r\n", 530, "Login incorrect.", "ok"},
+		{"Missing CRLF", "331 Password req", 331, "", "partial"},
+		{"Control Chars In Text", "550 a\x00b\r\n", 550, "", "partial"},
+		{"Invalid Code", "999 Nope\r\n", 0, "", "n/a"},
+		{"Not A Reply", "USER alice\r\n", 0, "", "n/a"},
+		{"Digits Without Separator", "2301\r\n", 0, "", "n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFTPReply([]byte(tt.payload))
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseFTPReply() = %s (%v), want %s", o, err, tt.want)
+			}
+			if got != nil && (got.Code != tt.wantCode || got.Text != tt.wantText) {
+				t.Errorf("ParseFTPReply() = %+v, want %d %q", got, tt.wantCode, tt.wantText)
+			}
+		})
+	}
+}
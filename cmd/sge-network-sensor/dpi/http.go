@@ -32,21 +32,18 @@ type HTTPRequest struct {
 }
 
 // ParseHTTPRequest extracts HTTP details from payload if present.
-// Includes safety checks against malformed/malicious input.
-func ParseHTTPRequest(payload []byte) (*HTTPRequest, bool) {
+// Includes safety checks against malformed/malicious input. A request
+// whose Host header is invalid or cut off is returned without the host as
+// a partial parse.
+func ParseHTTPRequest(payload []byte) (*HTTPRequest, error) {
 	// Safety: limit payload size to prevent CPU exhaustion
 	if len(payload) == 0 {
-		return nil, false
+		return nil, nil
 	}
 	if len(payload) > MaxPayloadSize {
 		payload = payload[:MaxPayloadSize]
 	}
 
-	// Safety: check for null bytes (binary data, not HTTP)
-	if bytes.IndexByte(payload[:min(256, len(payload))], 0) != -1 {
-		return nil, false
-	}
-
 	// 1. Check method
 	var method string
 	for _, m := range httpMethods {
@@ -56,28 +53,36 @@ func ParseHTTPRequest(payload []byte) (*HTTPRequest, bool) {
 		}
 	}
 	if method == "" {
-		return nil, false
+		return nil, nil
+	}
+
+	// Safety: check for null bytes (binary data, not HTTP)
+	if i := bytes.IndexByte(payload[:min(256, len(payload))], 0); i != -1 {
+		return nil, malformed(ProtoHTTP, i, "null byte")
 	}
 
+	req := &HTTPRequest{Method: method}
+
 	// 2. Extract Host header
 	hostStart := bytes.Index(payload, headerHost)
-	var host string
-	if hostStart != -1 {
-		start := hostStart + len(headerHost)
-		end := bytes.IndexByte(payload[start:], '\r')
-		if end != -1 && end <= MaxHostLength {
-			hostBytes := payload[start : start+end]
-			// Validate: must be valid UTF-8, no control chars
-			if utf8.Valid(hostBytes) && !containsControlChars(hostBytes) {
-				host = string(hostBytes)
-			}
-		}
+	if hostStart == -1 {
+		return req, nil
 	}
-
-	return &HTTPRequest{
-		Method: method,
-		Host:   host,
-	}, true
+	start := hostStart + len(headerHost)
+	end := bytes.IndexByte(payload[start:], '\r')
+	switch {
+	case end == -1:
+		return req, truncated(ProtoHTTP, start, "host header")
+	case end > MaxHostLength:
+		return req, malformed(ProtoHTTP, start, "host header too long")
+	}
+	hostBytes := payload[start : start+end]
+	// Validate: must be valid UTF-8, no control chars
+	if !utf8.Valid(hostBytes) || containsControlChars(hostBytes) {
+		return req, malformed(ProtoHTTP, start, "invalid host header")
+	}
+	req.Host = string(hostBytes)
+	return req, nil
 }
 
 // containsControlChars checks for ASCII control characters (except HTAB)
@@ -10,50 +10,69 @@ func TestParseHTTPRequest(t *testing.T) {
 		name     string
 		payload  []byte
 		wantHost string
-		wantOK   bool
+		want     string
 	}{
 		{
 			name:     "Valid GET Request",
 			payload:  []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"),
 			wantHost: "example.com",
-			wantOK:   true,
+			want:     "ok",
 		},
 		{
 			name:     "Valid GET Request Long Header",
 			payload:  []byte("GET /api/v1/status HTTP/1.1\r\nUser-Agent: bot\r\nHost: sub.test.com\r\n"),
 			wantHost: "sub.test.com",
-			wantOK:   true,
+			want:     "ok",
 		},
 		{
 			name:    "Invalid Method",
 			payload: []byte("BOOM / HTTP/1.1\r\nHost: example.com\r\n"),
-			wantOK:  false,
+			want:    "n/a",
 		},
 		{
 			name:    "Missing Host Header",
 			payload: []byte("GET / HTTP/1.1\r\nUser-Agent: test\r\n"),
-			wantOK:  true, // Returns true but empty host
+			want:    "ok", // Empty host
 		},
 		{
 			name:    "Binary Data (Attack)",
 			payload: append([]byte("GET / HTTP/1.1\r\nHost: "), 0x00, 0x01, 0x02),
-			wantOK:  false,
+			want:    "malformed",
 		},
 		{
 			name:    "Oversized Payload (Partial check)",
 			payload: []byte("GET / " + strings.Repeat("A", MaxHostLength+10) + " HTTP/1.1\r\nHost: overflow.com\r\n"),
-			wantOK:  true, // It parses method, but might fail host if too far
+			want:    "ok", // Host is still within the inspected payload
+		},
+		{
+			name:    "Host Cut Off",
+			payload: []byte("GET / HTTP/1.1\r\nHost: exam"),
+			want:    "partial",
+		},
+		{
+			name:    "Host Too Long",
+			payload: []byte("GET / HTTP/1.1\r\nHost: " + strings.Repeat("a", MaxHostLength+1) + "\r\n"),
+			want:    "partial",
+		},
+		{
+			name:    "Control Chars In Host",
+			payload: []byte("POST /x HTTP/1.1\r\nHost: evil\x1b.com\r\n"),
+			want:    "partial",
+		},
+		{
+			name:    "Empty Payload",
+			payload: nil,
+			want:    "n/a",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, ok := ParseHTTPRequest(tt.payload)
-			if ok != tt.wantOK {
-				t.Errorf("ParseHTTPRequest() ok = %v, want %v", ok, tt.wantOK)
-				return
+			got, err := ParseHTTPRequest(tt.payload)
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseHTTPRequest() = %s (%v), want %s", o, err, tt.want)
 			}
-			if ok && tt.wantHost != "" && got.Host != tt.wantHost {
+			if got != nil && got.Host != tt.wantHost && tt.wantHost != "" {
 				t.Errorf("ParseHTTPRequest() Host = %v, want %v", got.Host, tt.wantHost)
 			}
 		})
@@ -0,0 +1,113 @@
+package dpi
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	tpktVersion       = 3
+	tpktHeaderLength  = 4
+	x224CRCode        = 0xE0 // Connection Request TPDU, upper nibble
+	x224CRFixedLength = 7    // LI, code, dst-ref, src-ref, class
+	rdpNegReqType     = 0x01
+	rdpNegReqLength   = 8
+	maxRDPCookie      = 256
+)
+
+// Protocols a client offers in RDP_NEG_REQ (MS-RDPBCGR 2.2.1.1.1).
+const (
+	RDPProtocolSSL      = 0x00000001
+	RDPProtocolHybrid   = 0x00000002 // CredSSP (NLA)
+	RDPProtocolRDSTLS   = 0x00000004
+	RDPProtocolHybridEx = 0x00000008
+)
+
+var (
+	rdpCookiePrefix = []byte("Cookie: ")
+	rdpMSTSHash     = []byte("mstshash=")
+	crlf            = []byte("\r\n")
+)
+
+// RDPConnectionRequest is the client's first RDP packet: a TPKT framed
+// X.224 Connection Request carrying an optional cookie and security
+// negotiation request.
+type RDPConnectionRequest struct {
+	Username           string // From "Cookie: mstshash=<user>", as typed by the client
+	RoutingToken       string // Load balancer cookie ("msts=..."), if sent instead
+	Negotiation        bool   // RDP_NEG_REQ present
+	RequestedProtocols uint32 // RDPProtocol* bits, 0 is standard RDP security
+}
+
+// Security returns the strongest security layer the client offered:
+// "nla" (CredSSP), "tls" or "rdp".
+func (r *RDPConnectionRequest) Security() string {
+	switch {
+	case r.RequestedProtocols&(RDPProtocolHybrid|RDPProtocolHybridEx) != 0:
+		return "nla"
+	case r.RequestedProtocols&(RDPProtocolSSL|RDPProtocolRDSTLS) != 0:
+		return "tls"
+	}
+	return "rdp"
+}
+
+// ParseRDPConnectionRequest extracts the cookie and negotiation request
+// from an X.224 Connection Request at the start of payload. A request cut
+// off after the X.224 header is returned as a partial parse.
+func ParseRDPConnectionRequest(payload []byte) (*RDPConnectionRequest, error) {
+	if len(payload) < tpktHeaderLength+2 || payload[0] != tpktVersion || payload[1] != 0 || payload[5]&0xF0 != x224CRCode {
+		return nil, nil
+	}
+
+	length := int(binary.BigEndian.Uint16(payload[2:4]))
+	if length < tpktHeaderLength+x224CRFixedLength || int(payload[4]) != length-tpktHeaderLength-1 {
+		return nil, malformed(ProtoRDP, 2, "TPKT and X.224 lengths disagree")
+	}
+	if len(payload) < tpktHeaderLength+x224CRFixedLength {
+		return nil, truncated(ProtoRDP, len(payload), "X.224 header")
+	}
+	cut := len(payload) < length
+	body := payload[tpktHeaderLength+x224CRFixedLength : min(length, len(payload))]
+	offset := tpktHeaderLength + x224CRFixedLength
+
+	req := &RDPConnectionRequest{}
+	if bytes.HasPrefix(body, rdpCookiePrefix) || (cut && bytes.HasPrefix(rdpCookiePrefix, body)) {
+		end := bytes.Index(body[:min(len(body), maxRDPCookie)], crlf)
+		if end < 0 {
+			if cut && len(body) < maxRDPCookie {
+				return req, truncated(ProtoRDP, offset+len(body), "cookie")
+			}
+			return req, malformed(ProtoRDP, offset, "unterminated cookie")
+		}
+		cookie := body[len(rdpCookiePrefix):end]
+		if containsControlChars(cookie) {
+			return req, malformed(ProtoRDP, offset, "control characters in cookie")
+		}
+		if user, ok := bytes.CutPrefix(cookie, rdpMSTSHash); ok {
+			req.Username = string(user)
+		} else {
+			req.RoutingToken = string(cookie)
+		}
+		body = body[end+len(crlf):]
+		offset += end + len(crlf)
+	}
+
+	if len(body) > 0 && body[0] == rdpNegReqType {
+		if len(body) < rdpNegReqLength {
+			if cut {
+				return req, truncated(ProtoRDP, offset+len(body), "negotiation request")
+			}
+			return req, malformed(ProtoRDP, offset, "short negotiation request")
+		}
+		if binary.LittleEndian.Uint16(body[2:4]) != rdpNegReqLength {
+			return req, malformed(ProtoRDP, offset+2, "negotiation request length")
+		}
+		req.Negotiation = true
+		req.RequestedProtocols = binary.LittleEndian.Uint32(body[4:8])
+	}
+
+	if cut {
+		return req, truncated(ProtoRDP, len(payload), "connection request")
+	}
+	return req, nil
+}
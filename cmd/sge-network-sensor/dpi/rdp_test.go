@@ -0,0 +1,62 @@
+package dpi
+
+import (
+	"testing"
+)
+
+// capturedRDPRequest is the X.224 Connection Request of an mstsc client
+// logging on as "eltons" (TPKT + X.224 CR + cookie + RDP_NEG_REQ).
+const capturedRDPRequest = "0300002c27e00000000000" +
+	"436f6f6b69653a206d737473686173683d656c746f6e730d0a" +
+	"0100080003000000"
+
+// rdpRequest frames userData as an X.224 Connection Request.
+func rdpRequest(userData string) []byte {
+	n := tpktHeaderLength + x224CRFixedLength + len(userData)
+	return append([]byte{3, 0, byte(n >> 8), byte(n), byte(n - 5), 0xE0, 0, 0, 0, 0, 0}, userData...)
+}
+
+func TestParseRDPConnectionRequest(t *testing.T) {
+	captured := mustHex(capturedRDPRequest)
+	negTLS := "\x01\x00\x08\x00\x01\x00\x00\x00"
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		wantUser  string
+		wantToken string
+		wantSec   string
+		want      string
+	}{
+		{"Captured mstsc", captured, "eltons", "", "nla", "ok"},
+		{"Routing Token", rdpRequest("Cookie: msts=3640205228.15629.0000\r\n" + negTLS), "", "msts=3640205228.15629.0000", "tls", "ok"},
+		{"Cookie Only", rdpRequest("Cookie: mstshash=CORP\\svc_backup\r\n"), "CORP\\svc_backup", "", "rdp", "ok"},
+		{"No Cookie No Negotiation", rdpRequest(""), "", "", "rdp", "ok"},
+		{"Cut In Cookie", captured[:25], "", "", "rdp", "partial"},
+		{"Cut In Negotiation", captured[:40], "eltons", "", "rdp", "partial"},
+		{"Only X.224 Code", captured[:6], "", "", "", "truncated"},
+		{"Length Mismatch", mustHex("0300002c20e00000000000"), "", "", "", "malformed"},
+		{"Unterminated Cookie", rdpRequest("Cookie: mstshash=eltons"), "", "", "rdp", "partial"},
+		{"Control Chars", rdpRequest("Cookie: mstshash=elt\x01ons\r\n"), "", "", "rdp", "partial"},
+		{"Bad Negotiation Length", rdpRequest("\x01\x00\x09\x00\x01\x00\x00\x00"), "", "", "rdp", "partial"},
+		{"TLS", mustHex("16030100a5010000a103"), "", "", "", "n/a"},
+		{"X.224 Data", mustHex("0300000c02f080"), "", "", "", "n/a"},
+		{"Empty", nil, "", "", "", "n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRDPConnectionRequest(tt.payload)
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseRDPConnectionRequest() = %s (%v), want %s", o, err, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			if got.Username != tt.wantUser || got.RoutingToken != tt.wantToken || got.Security() != tt.wantSec {
+				t.Errorf("ParseRDPConnectionRequest() = %+v (security %s), want user %q token %q security %s",
+					got, got.Security(), tt.wantUser, tt.wantToken, tt.wantSec)
+			}
+		})
+	}
+}
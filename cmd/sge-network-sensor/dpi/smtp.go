@@ -0,0 +1,301 @@
+package dpi
+
+import (
+	"bytes"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// SMTP parsing safety limits
+const (
+	maxSMTPLineLength  = 1000 // RFC 5321 line limit incl. CRLF
+	maxSMTPRecipients  = 100
+	maxSMTPAttachments = 20
+	MaxSMTPFieldLength = 255
+)
+
+// Mail phishing indicators reported in Mail.Indicators.
+const (
+	IndicatorExecutableAttachment = "executable_attachment"
+	IndicatorDoubleExtension      = "double_extension"
+	IndicatorFromMismatch         = "from_mismatch"
+)
+
+// executableExtensions are attachment types that run code when opened.
+var executableExtensions = map[string]bool{
+	".exe": true, ".scr": true, ".com": true, ".bat": true, ".cmd": true, ".pif": true,
+	".js": true, ".jse": true, ".vbs": true, ".vbe": true, ".wsf": true, ".hta": true,
+	".msi": true, ".ps1": true, ".jar": true, ".lnk": true, ".cpl": true, ".dll": true,
+	".iso": true, ".img": true,
+}
+
+// lureExtensions are document/media types used to disguise executables
+// ("invoice.pdf.exe").
+var lureExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true, ".ppt": true,
+	".pptx": true, ".txt": true, ".rtf": true, ".jpg": true, ".jpeg": true, ".png": true,
+	".gif": true, ".zip": true, ".rar": true, ".csv": true, ".html": true, ".htm": true,
+}
+
+// Mail is the metadata of one message sent over SMTP.
+type Mail struct {
+	MailFrom    string   `json:"mail_from,omitempty"`   // Envelope sender (MAIL FROM)
+	RcptTo      []string `json:"rcpt_to,omitempty"`     // Envelope recipients (RCPT TO)
+	HeaderFrom  string   `json:"header_from,omitempty"` // From: header address
+	Subject     string   `json:"subject,omitempty"`
+	Attachments []string `json:"attachments,omitempty"` // MIME file names
+	Indicators  []string `json:"indicators,omitempty"`  // Indicator* values
+}
+
+// SMTPSession follows the client side of one SMTP connection and
+// reassembles the lines of the envelope and DATA phase. It keeps only the
+// metadata, never message bodies. It is not safe for concurrent use.
+type SMTPSession struct {
+	partial []byte // Incomplete line carried over to the next segment
+	skip    bool   // Rest of an over-long line is being discarded
+
+	mail       *Mail // Current transaction, nil before MAIL FROM
+	inData     bool
+	inHeaders  bool
+	lastHeader string // Header name, for folded continuation lines
+	attachPart bool   // Inside a MIME part header block
+	closed     bool   // QUIT or STARTTLS seen, nothing more to parse
+}
+
+// Closed reports whether the session ended (QUIT) or switched to TLS.
+func (s *SMTPSession) Closed() bool {
+	return s.closed
+}
+
+// Feed parses a client -> server segment and returns the messages whose
+// DATA phase completed in it.
+func (s *SMTPSession) Feed(payload []byte) []*Mail {
+	var done []*Mail
+	for len(payload) > 0 && !s.closed {
+		end := bytes.IndexByte(payload, '\n')
+		if end < 0 {
+			if len(s.partial)+len(payload) > maxSMTPLineLength {
+				s.partial, s.skip = s.partial[:0], true
+			} else if !s.skip {
+				s.partial = append(s.partial, payload...)
+			}
+			return done
+		}
+
+		line := payload[:end]
+		payload = payload[end+1:]
+		if s.skip {
+			s.skip = false
+			continue
+		}
+		if len(s.partial) > 0 {
+			line = append(s.partial, line...)
+			s.partial = s.partial[:0]
+		}
+		if len(line) > maxSMTPLineLength {
+			continue
+		}
+		if m := s.line(bytes.TrimSuffix(line, []byte("\r"))); m != nil {
+			done = append(done, m)
+		}
+	}
+	return done
+}
+
+// line handles one line and returns the message it completed, if any.
+func (s *SMTPSession) line(line []byte) *Mail {
+	if s.inData {
+		return s.dataLine(line)
+	}
+	if !utf8.Valid(line) || containsControlChars(line) {
+		return nil
+	}
+
+	verb, arg, _ := strings.Cut(string(line), " ")
+	switch strings.ToUpper(verb) {
+	case "MAIL":
+		s.mail = &Mail{MailFrom: smtpPath(arg, "FROM:")}
+	case "RCPT":
+		if s.mail != nil && len(s.mail.RcptTo) < maxSMTPRecipients {
+			if rcpt := smtpPath(arg, "TO:"); rcpt != "" {
+				s.mail.RcptTo = append(s.mail.RcptTo, rcpt)
+			}
+		}
+	case "DATA":
+		if s.mail != nil {
+			s.inData, s.inHeaders, s.lastHeader, s.attachPart = true, true, "", false
+		}
+	case "RSET":
+		s.mail = nil
+	case "QUIT", "STARTTLS":
+		s.closed = true
+	}
+	return nil
+}
+
+func (s *SMTPSession) dataLine(line []byte) *Mail {
+	if len(line) == 1 && line[0] == '.' {
+		m := s.mail
+		s.mail, s.inData = nil, false
+		m.Indicators = mailIndicators(m)
+		return m
+	}
+	// Dot-stuffing (RFC 5321 4.5.2)
+	if len(line) > 1 && line[0] == '.' {
+		line = line[1:]
+	}
+	if !utf8.Valid(line) {
+		return nil
+	}
+	text := string(line)
+
+	// Empty line ends a header block (message or MIME part)
+	if strings.TrimSpace(text) == "" {
+		s.inHeaders, s.attachPart, s.lastHeader = false, false, ""
+		return nil
+	}
+
+	// Folded header continuation
+	if text[0] == ' ' || text[0] == '\t' {
+		if (s.inHeaders || s.attachPart) && s.lastHeader != "" {
+			s.header(s.lastHeader, text, true)
+		}
+		return nil
+	}
+
+	name, value, ok := strings.Cut(text, ":")
+	if !ok || strings.ContainsAny(name, " \t") {
+		if strings.HasPrefix(text, "--") {
+			s.attachPart = true // MIME boundary, a part header block follows
+		}
+		s.lastHeader = ""
+		return nil
+	}
+	if s.inHeaders || s.attachPart {
+		s.lastHeader = strings.ToLower(name)
+		s.header(s.lastHeader, value, false)
+	}
+	return nil
+}
+
+// header records the headers the metadata is taken from.
+func (s *SMTPSession) header(name, value string, continued bool) {
+	m := s.mail
+	switch name {
+	case "from":
+		if s.inHeaders && m.HeaderFrom == "" {
+			m.HeaderFrom = headerAddress(value)
+		}
+	case "subject":
+		if !s.inHeaders {
+			return
+		}
+		v := strings.TrimSpace(value)
+		if continued {
+			v = m.Subject + " " + v
+		}
+		m.Subject = truncate(v, MaxSMTPFieldLength)
+	case "content-type", "content-disposition":
+		if name := mimeFileName(value); name != "" && len(m.Attachments) < maxSMTPAttachments && !slices.Contains(m.Attachments, name) {
+			m.Attachments = append(m.Attachments, name)
+		}
+	}
+}
+
+// smtpPath extracts the address of "FROM:<a@b> SIZE=1" style arguments.
+func smtpPath(arg, prefix string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return ""
+	}
+	arg = strings.TrimSpace(arg[len(prefix):])
+	if strings.HasPrefix(arg, "<") {
+		if end := strings.IndexByte(arg, '>'); end > 0 {
+			return truncate(strings.ToLower(arg[1:end]), MaxSMTPFieldLength)
+		}
+		return ""
+	}
+	addr, _, _ := strings.Cut(arg, " ")
+	return truncate(strings.ToLower(addr), MaxSMTPFieldLength)
+}
+
+// headerAddress extracts the address of a From: header value
+// ("Alice <alice@example.com>" or "alice@example.com").
+func headerAddress(value string) string {
+	value = strings.TrimSpace(value)
+	if start := strings.LastIndexByte(value, '<'); start >= 0 {
+		if end := strings.IndexByte(value[start:], '>'); end > 0 {
+			value = value[start+1 : start+end]
+		}
+	}
+	return truncate(strings.ToLower(strings.TrimSpace(value)), MaxSMTPFieldLength)
+}
+
+// mimeFileName extracts filename= / name= from a Content-Type or
+// Content-Disposition value.
+func mimeFileName(value string) string {
+	for _, param := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key != "filename" && key != "name" {
+			continue
+		}
+		val = strings.Trim(strings.TrimSpace(val), `"`)
+		if val == "" || containsControlChars([]byte(val)) {
+			continue
+		}
+		return truncate(val, MaxSMTPFieldLength)
+	}
+	return ""
+}
+
+// mailIndicators applies the phishing heuristics to m.
+func mailIndicators(m *Mail) []string {
+	var out []string
+	for _, name := range m.Attachments {
+		lower := strings.ToLower(name)
+		ext := path.Ext(lower)
+		if !executableExtensions[ext] {
+			continue
+		}
+		if !slices.Contains(out, IndicatorExecutableAttachment) {
+			out = append(out, IndicatorExecutableAttachment)
+		}
+		if lureExtensions[path.Ext(strings.TrimSuffix(lower, ext))] && !slices.Contains(out, IndicatorDoubleExtension) {
+			out = append(out, IndicatorDoubleExtension)
+		}
+	}
+	if from, header := addressDomain(m.MailFrom), addressDomain(m.HeaderFrom); from != "" && header != "" && !domainsAligned(from, header) {
+		out = append(out, IndicatorFromMismatch)
+	}
+	return out
+}
+
+func addressDomain(addr string) string {
+	if at := strings.LastIndexByte(addr, '@'); at >= 0 {
+		return addr[at+1:]
+	}
+	return ""
+}
+
+// domainsAligned reports whether a and b are equal or one is a subdomain of
+// the other (bounces.example.com vs example.com).
+func domainsAligned(a, b string) bool {
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,119 @@
+package dpi
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// phishingSession is a client side SMTP session: a spoofed From header and
+// a disguised executable attachment, split across segments mid-line.
+var phishingSession = []string{
+	"EHLO mx.attacker.test\r\n",
+	"MAIL FROM:<billing@attacker.test> SIZE=2048\r\n",
+	"RCPT TO:<Alice@Corp.example>\r\nRCPT TO:<bob@corp.example>\r\n",
+	"DATA\r\n",
+	"From: \"Corp Billing\" <billing@corp.example>\r\nTo: alice@corp.example\r\nSubject: Overdue invoice\r\n\t#4711\r\n",
+	"MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=\"b1\"\r\n\r\n",
+	"--b1\r\nContent-Type: text/plain\r\n\r\nPlease see attached.\r\nFrom: not a header\r\n..hidden dot line\r\n",
+	"--b1\r\nContent-Type: application/octet-stream; name=\"invoice.pdf.exe\"\r\nContent-Disposition: attach",
+	"ment;\r\n filename=\"invoice.pdf.exe\"\r\n\r\nTVqQAAMAAAAEAAAA//8AALgAAAAAAAAA\r\n--b1--\r\n.\r\n",
+	"QUIT\r\n",
+}
+
+func TestSMTPSessionPhishing(t *testing.T) {
+	s := &SMTPSession{}
+	var mails []*Mail
+	for _, seg := range phishingSession {
+		mails = append(mails, s.Feed([]byte(seg))...)
+	}
+
+	if len(mails) != 1 {
+		t.Fatalf("Feed() = %d messages, want 1", len(mails))
+	}
+	m := mails[0]
+	if m.MailFrom != "billing@attacker.test" {
+		t.Errorf("MailFrom = %q, want billing@attacker.test", m.MailFrom)
+	}
+	if !slices.Equal(m.RcptTo, []string{"alice@corp.example", "bob@corp.example"}) {
+		t.Errorf("RcptTo = %v", m.RcptTo)
+	}
+	if m.HeaderFrom != "billing@corp.example" {
+		t.Errorf("HeaderFrom = %q, want billing@corp.example", m.HeaderFrom)
+	}
+	if m.Subject != "Overdue invoice #4711" {
+		t.Errorf("Subject = %q, want folded subject", m.Subject)
+	}
+	if !slices.Equal(m.Attachments, []string{"invoice.pdf.exe"}) {
+		t.Errorf("Attachments = %v, want [invoice.pdf.exe]", m.Attachments)
+	}
+	want := []string{IndicatorExecutableAttachment, IndicatorDoubleExtension, IndicatorFromMismatch}
+	if !slices.Equal(m.Indicators, want) {
+		t.Errorf("Indicators = %v, want %v", m.Indicators, want)
+	}
+	if !s.Closed() {
+		t.Error("Closed() = false after QUIT")
+	}
+}
+
+func TestSMTPSessionBenign(t *testing.T) {
+	s := &SMTPSession{}
+	session := "MAIL FROM:<bounces@mail.corp.example>\r\nRCPT TO:<alice@corp.example>\r\nDATA\r\n" +
+		"From: Reports <reports@corp.example>\r\nSubject: Weekly report\r\nContent-Type: application/pdf; name=report.pdf\r\n\r\n" +
+		"JVBERi0xLjQK\r\n.\r\n" +
+		// Second transaction on the same connection
+		"MAIL FROM:<a@corp.example>\r\nRCPT TO:<b@corp.example>\r\nDATA\r\nSubject: hi\r\n\r\nhello\r\n.\r\n"
+
+	mails := s.Feed([]byte(session))
+	if len(mails) != 2 {
+		t.Fatalf("Feed() = %d messages, want 2", len(mails))
+	}
+	if len(mails[0].Indicators) != 0 || !slices.Equal(mails[0].Attachments, []string{"report.pdf"}) {
+		t.Errorf("first message = %+v, want report.pdf without indicators", mails[0])
+	}
+	if mails[1].Subject != "hi" || mails[1].MailFrom != "a@corp.example" || len(mails[1].Attachments) != 0 {
+		t.Errorf("second message = %+v", mails[1])
+	}
+}
+
+func TestSMTPSessionLimits(t *testing.T) {
+	s := &SMTPSession{}
+	s.Feed([]byte("MAIL FROM:<a@corp.example>\r\n"))
+	// An over-long line split over segments is dropped, the next line still parses
+	s.Feed([]byte("RCPT TO:<" + strings.Repeat("x", maxSMTPLineLength)))
+	s.Feed([]byte("@corp.example>\r\nRCPT TO:<b@corp.example>\r\n"))
+	s.Feed([]byte("STARTTLS\r\n"))
+
+	if !s.Closed() {
+		t.Fatal("Closed() = false after STARTTLS")
+	}
+	if got := s.Feed([]byte("DATA\r\n.\r\n")); len(got) != 0 {
+		t.Errorf("Feed() after STARTTLS = %v, want nothing", got)
+	}
+	if !slices.Equal(s.mail.RcptTo, []string{"b@corp.example"}) {
+		t.Errorf("RcptTo = %v, want only b@corp.example", s.mail.RcptTo)
+	}
+}
+
+func TestMailIndicators(t *testing.T) {
+	tests := []struct {
+		name string
+		mail Mail
+		want []string
+	}{
+		{"Script Attachment", Mail{Attachments: []string{"update.JS"}}, []string{IndicatorExecutableAttachment}},
+		{"Archive", Mail{Attachments: []string{"photos.zip"}}, nil},
+		{"Double Extension", Mail{Attachments: []string{"scan.jpg.scr"}}, []string{IndicatorExecutableAttachment, IndicatorDoubleExtension}},
+		{"Aligned Subdomain", Mail{MailFrom: "bounce@mail.corp.example", HeaderFrom: "news@corp.example"}, nil},
+		{"Lookalike Domain", Mail{MailFrom: "it@corp-example.test", HeaderFrom: "it@corp.example"}, []string{IndicatorFromMismatch}},
+		{"Null Sender", Mail{MailFrom: "", HeaderFrom: "mailer-daemon@corp.example"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mailIndicators(&tt.mail); !slices.Equal(got, tt.want) {
+				t.Errorf("mailIndicators() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package dpi
+
+import (
+	"bytes"
+	"strings"
+)
+
+// maxSSHBannerLength is the RFC 4253 limit of the identification line,
+// CR LF included.
+const maxSSHBannerLength = 255
+
+var sshPrefix = []byte("SSH-")
+
+// SSHBanner is the identification string both sides send first:
+// "SSH-protoversion-softwareversion SP comments CR LF".
+type SSHBanner struct {
+	ProtoVersion string // "2.0", or "1.99" for servers also accepting 1.x
+	Software     string // e.g. "OpenSSH_9.6"
+	Comments     string
+}
+
+// ParseSSHBanner extracts the SSH identification string from the start of
+// payload. A banner without line terminator is returned as a partial parse
+// as long as the versions are complete.
+func ParseSSHBanner(payload []byte) (*SSHBanner, error) {
+	if !bytes.HasPrefix(payload, sshPrefix) {
+		return nil, nil
+	}
+
+	line := payload
+	if len(line) > maxSSHBannerLength {
+		line = line[:maxSSHBannerLength]
+	}
+	end := bytes.IndexByte(line, '\n')
+	complete := end >= 0
+	if complete {
+		line = bytes.TrimSuffix(line[:end], []byte("\r"))
+	}
+	if containsControlChars(line) {
+		return nil, malformed(ProtoSSH, 0, "control characters in banner")
+	}
+
+	proto, rest, ok := strings.Cut(string(line[len(sshPrefix):]), "-")
+	software, comments, _ := strings.Cut(rest, " ")
+	if !ok || proto == "" || software == "" {
+		if !complete && len(payload) < maxSSHBannerLength {
+			return nil, truncated(ProtoSSH, len(line), "identification string")
+		}
+		return nil, malformed(ProtoSSH, len(sshPrefix), "missing protocol or software version")
+	}
+
+	b := &SSHBanner{ProtoVersion: proto, Software: software, Comments: comments}
+	if !complete {
+		if len(payload) >= maxSSHBannerLength {
+			return b, malformed(ProtoSSH, maxSSHBannerLength, "identification string too long")
+		}
+		return b, truncated(ProtoSSH, len(line), "identification string")
+	}
+	return b, nil
+}
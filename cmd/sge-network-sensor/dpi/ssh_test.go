@@ -0,0 +1,41 @@
+package dpi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSSHBanner(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      string
+		wantProto    string
+		wantSoftware string
+		wantComments string
+		want         string
+	}{
+		{"OpenSSH", "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n", "2.0", "OpenSSH_9.6p1", "Ubuntu-3ubuntu13", "ok"},
+		{"No Comments", "SSH-2.0-dropbear_2022.83\r\n", "2.0", "dropbear_2022.83", "", "ok"},
+		{"Bare LF", "SSH-1.99-Cisco-1.25\n", "1.99", "Cisco-1.25", "", "ok"},
+		{"Followed By KEXINIT", "SSH-2.0-Go\r\n\x00\x00\x01\x14\x0a\x14", "2.0", "Go", "", "ok"},
+		{"Missing CRLF", "SSH-2.0-OpenSSH_9.6", "2.0", "OpenSSH_9.6", "", "partial"},
+		{"Cut In Version", "SSH-2", "", "", "", "truncated"},
+		{"No Software", "SSH-2.0-\r\n", "", "", "", "malformed"},
+		{"Control Chars", "SSH-2.0-Open\x01SSH\r\n", "", "", "", "malformed"},
+		{"Too Long", "SSH-2.0-" + strings.Repeat("a", 300), "2.0", strings.Repeat("a", 247), "", "partial"},
+		{"HTTP", "GET / HTTP/1.1\r\n", "", "", "", "n/a"},
+		{"Empty", "", "", "", "", "n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSSHBanner([]byte(tt.payload))
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseSSHBanner() = %s (%v), want %s", o, err, tt.want)
+			}
+			if got != nil && (got.ProtoVersion != tt.wantProto || got.Software != tt.wantSoftware || got.Comments != tt.wantComments) {
+				t.Errorf("ParseSSHBanner() = %+v, want %s %s %q", got, tt.wantProto, tt.wantSoftware, tt.wantComments)
+			}
+		})
+	}
+}
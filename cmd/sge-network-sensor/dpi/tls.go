@@ -2,6 +2,8 @@ package dpi
 
 import (
 	"encoding/binary"
+	"fmt"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -12,47 +14,115 @@ const (
 	MinTLSHelloSize   = 43    // Minimum valid ClientHello size
 )
 
+// TLS protocol versions as sent on the wire.
+const (
+	VersionSSL30 uint16 = 0x0300
+	VersionTLS10 uint16 = 0x0301
+	VersionTLS11 uint16 = 0x0302
+	VersionTLS12 uint16 = 0x0303
+	VersionTLS13 uint16 = 0x0304
+)
+
+var tlsVersionNames = map[uint16]string{
+	VersionSSL30: "SSL 3.0",
+	VersionTLS10: "TLS 1.0",
+	VersionTLS11: "TLS 1.1",
+	VersionTLS12: "TLS 1.2",
+	VersionTLS13: "TLS 1.3",
+}
+
+// TLSVersionName returns e.g. "TLS 1.2" for v, or its hex value if unknown.
+func TLSVersionName(v uint16) string {
+	if name, ok := tlsVersionNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
+
+// ParseTLSVersion parses "1.0".."1.3" (optionally prefixed with "TLS")
+// into a wire version.
+func ParseTLSVersion(s string) (uint16, error) {
+	v := strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "TLS"))
+	for version, name := range tlsVersionNames {
+		if strings.HasPrefix(name, "TLS ") && name[4:] == v {
+			return version, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown TLS version %q", s)
+}
+
 // TLSClientHello represents minimal extracted TLS information.
 type TLSClientHello struct {
-	ServerName string
-	Version    uint16
+	ServerName        string
+	Version           uint16   // Legacy client_version (TLS 1.2 for TLS 1.3 clients)
+	SupportedVersions []uint16 // supported_versions extension, GREASE values removed
 }
 
-// ParseTLSClientHello attempts to extract SNI from a TCP payload.
-// Includes safety bounds checking to prevent crashes from malformed data.
-func ParseTLSClientHello(payload []byte) (*TLSClientHello, bool) {
-	// Safety: limit payload size
-	if len(payload) < MinTLSHelloSize {
-		return nil, false
-	}
-	if len(payload) > MaxTLSPayloadSize {
-		payload = payload[:MaxTLSPayloadSize]
+// MaxVersion returns the highest version the client offers.
+func (h *TLSClientHello) MaxVersion() uint16 {
+	max := h.Version
+	for _, v := range h.SupportedVersions {
+		if v > max {
+			max = v
+		}
 	}
+	return max
+}
+
+// isGREASE reports whether v is a reserved GREASE value (RFC 8701).
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
 
+// ParseTLSClientHello attempts to extract SNI from a TCP payload.
+// Includes safety bounds checking to prevent crashes from malformed data.
+// A ClientHello without SNI extension is a successful parse with an empty
+// ServerName. Once the client version is read, failures return the hello
+// as a partial parse; a ClientHello spanning several segments (large key
+// shares) typically ends up there with ErrTruncated.
+func ParseTLSClientHello(payload []byte) (*TLSClientHello, error) {
 	// TLS Record Header
 	// Content Type: Handshake (22)
-	if payload[0] != 0x16 {
-		return nil, false
-	}
-
 	// Version: TLS 1.0 (0x0301), 1.1 (0x0302), 1.2 (0x0303), 1.3 (0x0303 or similar legacy)
 	// We just check major version 3
-	if payload[1] != 0x03 {
-		return nil, false
+	if len(payload) < 2 || payload[0] != 0x16 || payload[1] != 0x03 {
+		return nil, nil
 	}
 
 	// Handshake Msg Type: Client Hello (1)
 	// Skip Record Header (5 bytes) -> Handshake Header
+	if len(payload) < 6 {
+		return nil, truncated(ProtoTLS, len(payload), "record header")
+	}
 	if payload[5] != 0x01 {
-		return nil, false
+		return nil, nil
+	}
+
+	// Safety: limit payload size
+	if len(payload) < MinTLSHelloSize {
+		return nil, truncated(ProtoTLS, len(payload), "client hello")
+	}
+	if len(payload) > MaxTLSPayloadSize {
+		payload = payload[:MaxTLSPayloadSize]
+	}
+
+	// Running out of payload is truncation if the record claims more
+	// bytes than we have, malformed otherwise
+	recordEnd := 5 + int(binary.BigEndian.Uint16(payload[3:5]))
+	short := func(offset int, what string) error {
+		if recordEnd > len(payload) {
+			return truncated(ProtoTLS, offset, what)
+		}
+		return malformed(ProtoTLS, offset, what+" exceeds record")
 	}
 
 	// Skip Handshake Header (4 bytes: Type(1) + Length(3))
 	// Client Version (2 bytes) + Random (32 bytes)
+	hello := &TLSClientHello{Version: binary.BigEndian.Uint16(payload[9:11])}
 	offset := 5 + 4 + 2 + 32
 
 	if offset >= len(payload) {
-		return nil, false
+		return hello, short(offset, "session id length")
 	}
 
 	// Session ID Length (1 byte)
@@ -60,7 +130,7 @@ func ParseTLSClientHello(payload []byte) (*TLSClientHello, bool) {
 	offset += 1 + sessionIDLen
 
 	if offset+2 >= len(payload) {
-		return nil, false
+		return hello, short(offset, "session id")
 	}
 
 	// Cipher Suites Length (2 bytes)
@@ -68,24 +138,36 @@ func ParseTLSClientHello(payload []byte) (*TLSClientHello, bool) {
 	offset += 2 + cipherSuitesLen
 
 	if offset+1 >= len(payload) {
-		return nil, false
+		return hello, short(offset, "cipher suites")
 	}
 
 	// Compression Methods Length (1 byte)
 	compressionLen := int(payload[offset])
 	offset += 1 + compressionLen
 
-	if offset+2 >= len(payload) {
-		return nil, false
+	if offset > len(payload) {
+		return hello, short(offset, "compression methods")
+	}
+	if offset == len(payload) {
+		if recordEnd > len(payload) {
+			return hello, truncated(ProtoTLS, offset, "extensions")
+		}
+		return hello, nil // No extensions
+	}
+	if offset+2 > len(payload) {
+		return hello, short(offset, "extensions length")
 	}
 
 	// Extensions Length (2 bytes)
 	extensionsLen := int(binary.BigEndian.Uint16(payload[offset : offset+2]))
 	offset += 2
 
+	// Extensions cut off by the segment end are read as far as present
 	extensionsEnd := offset + extensionsLen
+	var err error
 	if extensionsEnd > len(payload) {
-		return nil, false
+		err = short(len(payload), "extensions")
+		extensionsEnd = len(payload)
 	}
 
 	// Iterating extensions
@@ -93,63 +175,72 @@ func ParseTLSClientHello(payload []byte) (*TLSClientHello, bool) {
 		extType := binary.BigEndian.Uint16(payload[offset : offset+2])
 		extLen := int(binary.BigEndian.Uint16(payload[offset+2 : offset+4]))
 		offset += 4
+		next := offset + extLen
 
 		// Extension Type 0 is Server Name
 		if extType == 0x0000 {
 			if offset+extLen > extensionsEnd {
-				return nil, false
+				return hello, short(offset, "server name extension")
 			}
 
-			// SNI List Length (2 bytes)
-			if extLen < 2 {
-				return nil, false
+			// SNI List Length (2 bytes) + Server Name Type (1 byte) + Server Name Length (2 bytes)
+			if extLen < 5 {
+				return hello, malformed(ProtoTLS, offset, "server name extension too short")
 			}
-			// listLen := int(binary.BigEndian.Uint16(payload[offset : offset+2]))
 			offset += 2 // skip list len
 
 			// Server Name Type (1 byte) - 0 is host_name
-			if offset >= extensionsEnd {
-				return nil, false
-			}
 			nameType := payload[offset]
 			offset++
 
 			if nameType != 0 {
-				return nil, false
+				return hello, malformed(ProtoTLS, offset-1, "unknown server name type")
 			}
 
 			// Server Name Length (2 bytes)
-			if offset+2 > extensionsEnd {
-				return nil, false
-			}
 			nameLen := int(binary.BigEndian.Uint16(payload[offset : offset+2]))
 			offset += 2
 
 			// Safety: validate name length
 			if nameLen == 0 || nameLen > MaxSNILength {
-				return nil, false
+				return hello, malformed(ProtoTLS, offset-2, "invalid server name length")
 			}
 
 			if offset+nameLen > extensionsEnd {
-				return nil, false
+				return hello, short(offset, "server name")
 			}
 
 			sniBytes := payload[offset : offset+nameLen]
 			// Safety: validate UTF-8 and no control characters
 			if !utf8.Valid(sniBytes) {
-				return nil, false
+				return hello, malformed(ProtoTLS, offset, "server name not UTF-8")
 			}
 			for _, b := range sniBytes {
 				if b < 32 || b == 127 {
-					return nil, false
+					return hello, malformed(ProtoTLS, offset, "control character in server name")
 				}
 			}
 
-			return &TLSClientHello{ServerName: string(sniBytes)}, true
+			hello.ServerName = string(sniBytes)
+			offset = next
+			continue
+		}
+
+		// Extension Type 43 is Supported Versions: 1 byte length, 2 bytes per version
+		if extType == 0x002b && next <= extensionsEnd && extLen >= 1 {
+			list := payload[offset+1 : offset+min(extLen, 1+int(payload[offset]))]
+			for k := 0; k+2 <= len(list); k += 2 {
+				if v := binary.BigEndian.Uint16(list[k:]); !isGREASE(v) {
+					hello.SupportedVersions = append(hello.SupportedVersions, v)
+				}
+			}
 		}
 
-		offset += extLen
+		offset = next
 	}
 
-	return nil, false
+	if hello.ServerName != "" {
+		return hello, nil // Everything after the SNI is a bonus
+	}
+	return hello, err
 }
@@ -1,58 +1,205 @@
 package dpi
 
 import (
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"strings"
 	"testing"
 )
 
+func u16(n int) []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(n))
+}
+
+// tlsExt encodes one extension.
+func tlsExt(typ int, data []byte) []byte {
+	return append(append(u16(typ), u16(len(data))...), data...)
+}
+
+// sniExt encodes a server_name extension with one host_name entry.
+func sniExt(name string) []byte {
+	entry := append(append([]byte{0}, u16(len(name))...), name...)
+	return tlsExt(0, append(u16(len(entry)), entry...))
+}
+
+// clientHello builds a TLS 1.2 ClientHello record carrying exts.
+func clientHello(exts ...[]byte) []byte {
+	var ext []byte
+	for _, e := range exts {
+		ext = append(ext, e...)
+	}
+	body := []byte{0x03, 0x03}
+	body = append(body, make([]byte, 32)...)    // Random
+	body = append(body, 0)                      // Session ID
+	body = append(body, 0x00, 0x02, 0x00, 0x2f) // Cipher suites
+	body = append(body, 0x01, 0x00)             // Compression
+	body = append(body, u16(len(ext))...)
+	body = append(body, ext...)
+
+	hs := append([]byte{0x01, 0, byte(len(body) >> 8), byte(len(body))}, body...)
+	return append(append([]byte{0x16, 0x03, 0x01}, u16(len(hs))...), hs...)
+}
+
 func TestParseTLSClientHello(t *testing.T) {
-	// Sample ClientHello hex dump (Valid Google.com SNI)
-	// Simplified construction for testing
-	// Record(16) + Ver(0301) + Len + Handshake(01) + Len + Ver + Random + SessID + CipherSuites + Compression + ExtLen + Ext(SNI)
-	validSNIHex := "16030100c8010000c40303" + // Record + Handshake Header
-		"0000000000000000000000000000000000000000000000000000000000000000" + // Random
-		"00" + // SessionID Len
-		"0002002f" + // Cipher Suites
-		"0100" // Compression
-		// Extensions would allow SNI, constructing full packet manually in hex is tedious and error prone without a builder.
-		// Instead, we will test the rejection logic primarily as constructing valid full binary TLS packets is complex.
-
-	_ = validSNIHex
+	keyShare := tlsExt(0x0033, make([]byte, 1400))
+	badCiphers := clientHello(sniExt("example.com"))
+	binary.BigEndian.PutUint16(badCiphers[44:46], 0xfff0)
 
 	tests := []struct {
-		name       string
-		payloadHex string
-		wantSNI    string
-		wantOK     bool
+		name    string
+		payload []byte
+		wantSNI string
+		want    string
+		wantErr error
 	}{
 		{
-			name:       "Short Payload",
-			payloadHex: "16030100",
-			wantOK:     false,
+			name:    "SNI",
+			payload: clientHello(tlsExt(0x000a, []byte{0, 2, 0, 0x1d}), sniExt("example.com")),
+			wantSNI: "example.com",
+			want:    "ok",
+		},
+		{
+			name:    "No SNI Extension",
+			payload: clientHello(tlsExt(0x0010, []byte{0, 3, 2, 'h', '2'})),
+			want:    "ok",
+		},
+		{
+			name:    "SNI Before Segment End",
+			payload: clientHello(sniExt("example.com"), keyShare)[:600],
+			wantSNI: "example.com",
+			want:    "ok",
+		},
+		{
+			name:    "SNI After Segment End",
+			payload: clientHello(keyShare, sniExt("example.com"))[:600],
+			want:    "partial",
+			wantErr: ErrTruncated,
+		},
+		{
+			name:    "Control Chars In SNI",
+			payload: clientHello(sniExt("evil\x07.com")),
+			want:    "partial",
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "Oversized SNI",
+			payload: clientHello(sniExt(strings.Repeat("a", MaxSNILength+1))),
+			want:    "partial",
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "Cipher Suites Exceed Record",
+			payload: badCiphers,
+			want:    "partial",
+			wantErr: ErrMalformed,
+		},
+		{
+			name:    "Short Client Hello",
+			payload: clientHello()[:20],
+			want:    "truncated",
+		},
+		{
+			name:    "Short Payload",
+			payload: []byte{0x16, 0x03, 0x01, 0x00},
+			want:    "truncated",
+		},
+		{
+			name:    "Invalid Record Type",
+			payload: mustHex("15030100c8010000c40303" + "0000000000000000000000000000000000000000000000000000000000000000"),
+			want:    "n/a", // Content Type not 0x16
 		},
 		{
-			name:       "Invalid Record Type",
-			payloadHex: "15030100c8010000c40303" + "0000000000000000000000000000000000000000000000000000000000000000",
-			wantOK:     false, // Content Type not 0x16
+			name:    "Invalid Handshake Type",
+			payload: mustHex("16030100c8020000c40303" + "0000000000000000000000000000000000000000000000000000000000000000"),
+			want:    "n/a", // Handshake type not 0x01 (ClientHello)
 		},
 		{
-			name:       "Invalid Handshake Type",
-			payloadHex: "16030100c8020000c40303" + "0000000000000000000000000000000000000000000000000000000000000000",
-			wantOK:     false, // Handshake type not 0x01 (ClientHello)
+			name:    "HTTP",
+			payload: []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+			want:    "n/a",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			payload, _ := hex.DecodeString(tt.payloadHex)
-			got, ok := ParseTLSClientHello(payload)
-			if ok != tt.wantOK {
-				t.Errorf("ParseTLSClientHello() ok = %v, want %v", ok, tt.wantOK)
+			got, err := ParseTLSClientHello(tt.payload)
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseTLSClientHello() = %s (%v), want %s", o, err, tt.want)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseTLSClientHello() error = %v, want %v", err, tt.wantErr)
+			}
+			if got == nil {
 				return
 			}
-			if ok && got.ServerName != tt.wantSNI {
+			if got.ServerName != tt.wantSNI {
 				t.Errorf("ParseTLSClientHello() SNI = %v, want %v", got.ServerName, tt.wantSNI)
 			}
+			if got.Version != 0x0303 {
+				t.Errorf("ParseTLSClientHello() Version = %#04x, want 0x0303", got.Version)
+			}
 		})
 	}
 }
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestTLSClientHelloVersion(t *testing.T) {
+	// GREASE (0x3a3a) is ignored, TLS 1.3 wins over the legacy 1.2 field
+	tls13 := tlsExt(0x002b, []byte{6, 0x3a, 0x3a, 0x03, 0x04, 0x03, 0x03})
+	tests := []struct {
+		name    string
+		payload []byte
+		want    uint16
+	}{
+		{"TLS 1.3 Supported Versions", clientHello(sniExt("example.com"), tls13), VersionTLS13},
+		{"Supported Versions Before SNI", clientHello(tls13, sniExt("example.com")), VersionTLS13},
+		{"Legacy Only", clientHello(sniExt("example.com")), VersionTLS12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTLSClientHello(tt.payload)
+			if err != nil || got == nil {
+				t.Fatalf("ParseTLSClientHello() = %v, %v", got, err)
+			}
+			if got.MaxVersion() != tt.want || got.ServerName != "example.com" {
+				t.Errorf("MaxVersion() = %s (SNI %q), want %s", TLSVersionName(got.MaxVersion()), got.ServerName, TLSVersionName(tt.want))
+			}
+		})
+	}
+
+	// A TLS 1.0 client (legacy field only)
+	old := clientHello(sniExt("example.com"))
+	old[10] = 0x01
+	if got, _ := ParseTLSClientHello(old); got == nil || got.MaxVersion() != VersionTLS10 {
+		t.Errorf("TLS 1.0 MaxVersion() = %v, want TLS 1.0", got)
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint16
+		wantErr bool
+	}{
+		{"1.0", VersionTLS10, false},
+		{"1.2", VersionTLS12, false},
+		{"TLS 1.3", VersionTLS13, false},
+		{"tls1.1", VersionTLS11, false},
+		{"3.0", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTLSVersion(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTLSVersion(%q) = %#04x, %v, want %#04x", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package dpi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+)
+
+// WinRM authentication schemes reported in WinRMRequest.Auth.
+const (
+	WinRMAuthBasic    = "basic"
+	WinRMAuthNTLM     = "ntlm"
+	WinRMAuthKerberos = "kerberos"
+	WinRMAuthCredSSP  = "credssp"
+)
+
+var (
+	winrmPrefix         = []byte("POST /wsman")
+	headerAuthorization = []byte("\r\nauthorization: ")
+	ntlmSignature       = []byte("NTLMSSP\x00")
+)
+
+// ntlmAuthenticate is the NTLM message type carrying the user name.
+const ntlmAuthenticate = 3
+
+// WinRMRequest is a WS-Management request over HTTP (ports 5985/5986).
+// Over HTTPS only the TLS handshake is visible.
+type WinRMRequest struct {
+	Auth     string // WinRMAuth* scheme when the request carries credentials
+	Username string // "DOMAIN\user" or "user", for Basic and NTLM only
+}
+
+// ParseWinRMRequest recognizes a request to the WS-Management endpoint and
+// extracts who is authenticating. NTLM negotiate messages, which precede
+// the credentials, leave Auth empty. A request whose Authorization header
+// is cut off or cannot be decoded is returned as a partial parse.
+func ParseWinRMRequest(payload []byte) (*WinRMRequest, error) {
+	if !bytes.HasPrefix(payload, winrmPrefix) {
+		return nil, nil
+	}
+	if len(payload) > MaxPayloadSize {
+		payload = payload[:MaxPayloadSize]
+	}
+
+	req := &WinRMRequest{}
+	headerEnd := bytes.Index(payload, []byte("\r\n\r\n"))
+	if headerEnd < 0 {
+		headerEnd = len(payload)
+	}
+	start := bytes.Index(bytes.ToLower(payload[:headerEnd]), headerAuthorization)
+	if start < 0 {
+		return req, nil
+	}
+	start += len(headerAuthorization)
+	end := bytes.Index(payload[start:], crlf)
+	if end < 0 {
+		return req, truncated(ProtoWinRM, start, "authorization header")
+	}
+	value := payload[start : start+end]
+
+	scheme, token, _ := bytes.Cut(value, []byte(" "))
+	switch string(bytes.ToLower(scheme)) {
+	case "basic":
+		raw, err := base64.StdEncoding.DecodeString(string(token))
+		if err != nil {
+			return req, malformed(ProtoWinRM, start, "invalid basic credentials")
+		}
+		user, _, _ := bytes.Cut(raw, []byte(":"))
+		if containsControlChars(user) {
+			return req, malformed(ProtoWinRM, start, "control characters in user name")
+		}
+		req.Auth, req.Username = WinRMAuthBasic, string(user)
+	case "negotiate", "ntlm":
+		raw, err := base64.StdEncoding.DecodeString(string(token))
+		if err != nil {
+			return req, malformed(ProtoWinRM, start, "invalid negotiate token")
+		}
+		if !bytes.HasPrefix(raw, ntlmSignature) {
+			// SPNEGO wrapping a Kerberos ticket; the principal is encrypted
+			req.Auth = WinRMAuthKerberos
+			return req, nil
+		}
+		user, ok, err := ntlmUser(raw)
+		if err != nil {
+			return req, malformed(ProtoWinRM, start, err.Error())
+		}
+		if ok {
+			req.Auth, req.Username = WinRMAuthNTLM, user
+		}
+	case "kerberos":
+		req.Auth = WinRMAuthKerberos
+	case "credssp":
+		req.Auth = WinRMAuthCredSSP
+	}
+	return req, nil
+}
+
+// ntlmUser returns "DOMAIN\user" from an NTLM AUTHENTICATE message; ok is
+// false for the other message types.
+func ntlmUser(msg []byte) (user string, ok bool, err error) {
+	if len(msg) < 12 || binary.LittleEndian.Uint32(msg[8:12]) != ntlmAuthenticate {
+		return "", false, nil
+	}
+	if len(msg) < 64 {
+		return "", false, errors.New("short NTLM authenticate message")
+	}
+	unicode := binary.LittleEndian.Uint32(msg[60:64])&1 != 0
+
+	field := func(at int) (string, error) {
+		n := int(binary.LittleEndian.Uint16(msg[at : at+2]))
+		off := int(binary.LittleEndian.Uint32(msg[at+4 : at+8]))
+		if off < 0 || off+n > len(msg) {
+			return "", errors.New("NTLM field out of bounds")
+		}
+		b := msg[off : off+n]
+		if !unicode {
+			return string(b), nil
+		}
+		if n%2 != 0 {
+			return "", errors.New("odd NTLM unicode field")
+		}
+		u := make([]uint16, n/2)
+		for i := range u {
+			u[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+		return string(utf16.Decode(u)), nil
+	}
+
+	domain, err := field(28)
+	if err != nil {
+		return "", false, err
+	}
+	name, err := field(36)
+	if err != nil {
+		return "", false, err
+	}
+	if containsControlChars([]byte(domain + name)) {
+		return "", false, errors.New("control characters in NTLM user")
+	}
+	if domain != "" {
+		name = domain + `\` + name
+	}
+	return name, true, nil
+}
@@ -0,0 +1,89 @@
+package dpi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+// ntlmAuthenticateMsg builds a minimal NTLM AUTHENTICATE message with
+// Unicode domain and user fields.
+func ntlmAuthenticateMsg(domain, user string) []byte {
+	enc := func(s string) []byte {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(s)) {
+			b = binary.LittleEndian.AppendUint16(b, u)
+		}
+		return b
+	}
+	d, u := enc(domain), enc(user)
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], ntlmAuthenticate)
+	put := func(at int, b []byte) {
+		binary.LittleEndian.PutUint16(msg[at:], uint16(len(b)))
+		binary.LittleEndian.PutUint16(msg[at+2:], uint16(len(b)))
+		binary.LittleEndian.PutUint32(msg[at+4:], uint32(len(msg)))
+		msg = append(msg, b...)
+	}
+	put(28, d)
+	put(36, u)
+	binary.LittleEndian.PutUint32(msg[60:], 1) // NTLMSSP_NEGOTIATE_UNICODE
+	return msg
+}
+
+func winrmRequest(auth string) []byte {
+	req := "POST /wsman HTTP/1.1\r\nHost: dc01.corp.local:5985\r\nContent-Type: application/soap+xml;charset=UTF-8\r\n"
+	if auth != "" {
+		req += "Authorization: " + auth + "\r\n"
+	}
+	return []byte(req + "Content-Length: 0\r\n\r\n")
+}
+
+func TestParseWinRMRequest(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString
+	negotiate := append(append([]byte{}, ntlmSignature...), 1, 0, 0, 0, 0x07, 0x82, 0x08, 0xa2)
+	short := append(append([]byte{}, ntlmSignature...), 3, 0, 0, 0)
+	basic := winrmRequest("Basic " + b64([]byte("administrator:x")))
+	cut := basic[:bytes.Index(basic, []byte("Authorization: "))+20]
+	outOfBounds := ntlmAuthenticateMsg("CORP", "alice")
+	binary.LittleEndian.PutUint32(outOfBounds[40:], 4096)
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		wantAuth string
+		wantUser string
+		want     string
+	}{
+		{"Basic", winrmRequest("Basic " + b64([]byte("administrator:P@ssw0rd"))), WinRMAuthBasic, "administrator", "ok"},
+		{"NTLM Authenticate", winrmRequest("Negotiate " + b64(ntlmAuthenticateMsg("CORP", "alice"))), WinRMAuthNTLM, `CORP\alice`, "ok"},
+		{"NTLM Without Domain", winrmRequest("NTLM " + b64(ntlmAuthenticateMsg("", "bob"))), WinRMAuthNTLM, "bob", "ok"},
+		{"NTLM Negotiate", winrmRequest("Negotiate " + b64(negotiate)), "", "", "ok"},
+		{"Kerberos", winrmRequest("Negotiate " + b64([]byte{0x60, 0x82, 0x05, 0x1a, 0x06, 0x06})), WinRMAuthKerberos, "", "ok"},
+		{"CredSSP", winrmRequest("CredSSP " + b64([]byte{0x30, 0x2f})), WinRMAuthCredSSP, "", "ok"},
+		{"Lowercase Header", []byte("POST /wsman HTTP/1.1\r\nauthorization: Basic " + b64([]byte("svc:x")) + "\r\n\r\n"), WinRMAuthBasic, "svc", "ok"},
+		{"Anonymous Identify", []byte("POST /wsman-anon/identify HTTP/1.1\r\nHost: dc01\r\n\r\n"), "", "", "ok"},
+		{"Cut In Authorization", cut, "", "", "partial"},
+		{"Bad Base64", winrmRequest("Basic !!!"), "", "", "partial"},
+		{"Short NTLM", winrmRequest("Negotiate " + b64(short)), "", "", "partial"},
+		{"NTLM Out Of Bounds", winrmRequest("Negotiate " + b64(outOfBounds)), "", "", "partial"},
+		{"Authorization In Body", []byte("POST /wsman HTTP/1.1\r\nHost: dc01\r\n\r\n\r\nAuthorization: Basic " + b64([]byte("x:y")) + "\r\n"), "", "", "ok"},
+		{"Other HTTP", []byte("POST /api/v1/events HTTP/1.1\r\nHost: x\r\n\r\n"), "", "", "n/a"},
+		{"Empty", nil, "", "", "n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWinRMRequest(tt.payload)
+			if o := outcome(got, err); o != tt.want {
+				t.Fatalf("ParseWinRMRequest() = %s (%v), want %s", o, err, tt.want)
+			}
+			if got != nil && (got.Auth != tt.wantAuth || got.Username != tt.wantUser) {
+				t.Errorf("ParseWinRMRequest() = %+v, want auth %q user %q", got, tt.wantAuth, tt.wantUser)
+			}
+		})
+	}
+}
@@ -11,19 +11,16 @@ import (
 
 // DBHandler manages database persistence.
 type DBHandler struct {
-	pg *database.PostgresClient
-	ch *database.ClickHouseClient
+	store database.EventStore
 }
 
-// NewDBHandler creates a new DB persistence handler.
-func NewDBHandler(pg *database.PostgresClient, ch *database.ClickHouseClient) *DBHandler {
-	return &DBHandler{
-		pg: pg,
-		ch: ch,
-	}
+// NewDBHandler creates a DB persistence handler writing flows to store
+// (e.g. a database.ClickHouseClient).
+func NewDBHandler(store database.EventStore) *DBHandler {
+	return &DBHandler{store: store}
 }
 
-// ProcessEvents consumes network events and writes them to ClickHouse in batches.
+// ProcessEvents consumes network events and writes them to the store in batches.
 func (h *DBHandler) ProcessEvents(ctx context.Context, envChan <-chan interface{}) {
 	batchSize := 1000
 	flushInterval := 2 * time.Second
@@ -44,7 +41,7 @@ func (h *DBHandler) ProcessEvents(ctx context.Context, envChan <-chan interface{
 				continue
 			}
 
-			// Map to ClickHouse schema structure (NetworkFlows)
+			// Map to the network_flows schema
 			flow := map[string]interface{}{
 				"id":          timestampToID(event.Timestamp), // optimize UUID gen?
 				"timestamp":   event.Timestamp,
@@ -81,8 +78,8 @@ func (h *DBHandler) flush(flows []map[string]interface{}) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := h.ch.InsertNetworkFlows(ctx, flows); err != nil {
-		log.Printf("[DB] ClickHouse insert failed: %v", err)
+	if err := h.store.InsertFlows(ctx, flows); err != nil {
+		log.Printf("[DB] Flow insert failed: %v", err)
 	}
 }
 
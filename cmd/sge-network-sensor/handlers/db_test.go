@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// fakeStore records inserted flow batches.
+type fakeStore struct {
+	mu      sync.Mutex
+	batches [][]map[string]interface{}
+}
+
+func (f *fakeStore) InsertEvents(ctx context.Context, events []*models.Event) error {
+	return nil
+}
+
+func (f *fakeStore) InsertFlows(ctx context.Context, flows []map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]map[string]interface{}(nil), flows...))
+	return nil
+}
+
+func (f *fakeStore) CountEvents(ctx context.Context, since time.Time) (uint64, error) {
+	return 0, nil
+}
+
+func TestDBHandlerWritesFlows(t *testing.T) {
+	store := &fakeStore{}
+	h := NewDBHandler(store)
+
+	events := make(chan interface{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.ProcessEvents(ctx, events)
+		close(done)
+	}()
+
+	now := time.Now()
+	events <- inspector.NetworkEvent{Timestamp: now, SrcIP: "10.0.0.5", SrcPort: 51000, DstIP: "203.0.113.10", DstPort: 443, Protocol: "TCP", PayloadSize: 517}
+	events <- "not a network event"
+	events <- inspector.NetworkEvent{Timestamp: now, SrcIP: "10.0.0.5", SrcPort: 40000, DstIP: "10.0.0.53", DstPort: 53, Protocol: "UDP", PayloadSize: 40}
+	cancel() // Flushes the buffered flows
+	<-done
+
+	if len(store.batches) != 1 || len(store.batches[0]) != 2 {
+		t.Fatalf("batches = %v, want one batch of 2 flows", store.batches)
+	}
+	first := store.batches[0][0]
+	if first["source_ip"] != "10.0.0.5" || first["dest_port"] != uint16(443) || first["protocol"] != "TCP" || first["bytes_sent"] != uint64(517) {
+		t.Errorf("flow = %v, want 10.0.0.5 -> :443/TCP, 517 bytes", first)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"sync/atomic"
+
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+// DetectionHandler runs the threat detector over the live event stream.
+type DetectionHandler struct {
+	det     *detector.Detector
+	threats atomic.Uint64
+}
+
+// NewDetectionHandler creates a handler feeding det.
+func NewDetectionHandler(det *detector.Detector) *DetectionHandler {
+	return &DetectionHandler{det: det}
+}
+
+// ProcessEvents analyzes the network events from in until ctx is done or
+// in is closed, writing each threat to out as a network.threat event
+// (*models.Event). Other values are ignored, so out may feed back into
+// the channel in is tapped from. It must run on a single goroutine, as
+// the detector's trackers require.
+func (h *DetectionHandler) ProcessEvents(ctx context.Context, in <-chan interface{}, out chan<- interface{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-in:
+			if !ok {
+				return
+			}
+			evt, ok := v.(inspector.NetworkEvent)
+			if !ok {
+				continue
+			}
+			for _, t := range h.det.Analyze(&evt) {
+				select {
+				case out <- t.Event():
+					h.threats.Add(1)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// Threats returns the number of threats raised so far.
+func (h *DetectionHandler) Threats() uint64 {
+	return h.threats.Load()
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/cmd/sge-network-sensor/output"
+	"sakin-go/pkg/models"
+)
+
+// memWriter collects encoded events.
+type memWriter struct {
+	mu    sync.Mutex
+	lines [][]byte
+}
+
+func (w *memWriter) Write(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, append([]byte(nil), data...))
+	return nil
+}
+
+func (w *memWriter) Close() error { return nil }
+
+// threats returns the network.threat events written so far.
+func (w *memWriter) threats(t *testing.T) []models.Event {
+	t.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var out []models.Event
+	for _, line := range w.lines {
+		var evt models.Event
+		if err := json.Unmarshal(line, &evt); err != nil {
+			t.Fatalf("invalid JSON %s: %v", line, err)
+		}
+		if evt.EventType == models.EventTypeNetworkThreat {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
+
+func TestDetectionHandlerLivePipeline(t *testing.T) {
+	cfg := detector.DefaultConfig()
+	cfg.RemoteAdminAttempts = 3
+	h := NewDetectionHandler(detector.NewDetector(cfg))
+
+	// Wired like main: the detector taps the manager and feeds threats back
+	siem := &memWriter{}
+	events := make(chan interface{}, 100)
+	detChan := make(chan interface{}, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.ProcessEvents(ctx, detChan, events)
+	done := make(chan struct{})
+	go func() {
+		output.NewManager(output.New("siem", nil, siem)).Run(ctx, events, detChan)
+		close(done)
+	}()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		events <- inspector.NetworkEvent{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			SrcIP:     "10.0.0.7", SrcPort: uint16(50000 + i),
+			DstIP: "10.0.0.20", DstPort: 3389,
+			Protocol: "TCP", AppProtocol: dpi.ProtoRDP, PayloadSize: 40, AdminUser: "admin",
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(siem.threats(t)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	got := siem.threats(t)
+	if len(got) != 1 {
+		t.Fatalf("threat events = %d, want 1", len(got))
+	}
+	evt := got[0]
+	if evt.SourceIP != "10.0.0.7" || evt.DestIP != "10.0.0.20" || evt.Severity != models.SeverityHigh || evt.Source != detector.EventSource {
+		t.Errorf("threat = %+v, want high from 10.0.0.7 to 10.0.0.20", evt)
+	}
+	if len(evt.Tags) != 2 || evt.Tags[0] != detector.TagThreat || evt.Tags[1] != string(detector.ThreatRemoteAdminBruteForce) {
+		t.Errorf("Tags = %v, want [threat remote_admin_brute_force]", evt.Tags)
+	}
+	if h.Threats() != 1 {
+		t.Errorf("Threats() = %d, want 1", h.Threats())
+	}
+}
@@ -0,0 +1,64 @@
+package inspector
+
+import "sakin-go/cmd/sge-network-sensor/dpi"
+
+// Application categories stored in NetworkEvent.AppCategory.
+const (
+	CategoryWeb          = "web"
+	CategoryEmail        = "email"
+	CategoryFileTransfer = "file-transfer"
+	CategoryAdmin        = "admin"
+	CategoryOther        = "other"
+)
+
+type portKey struct {
+	protocol string
+	port     uint16
+}
+
+// categoryPorts maps well-known service ports to their category.
+var categoryPorts = map[portKey]string{
+	// Web: HTTP, HTTPS, QUIC
+	{"TCP", 80}: CategoryWeb, {"TCP", 443}: CategoryWeb,
+	{"TCP", 8080}: CategoryWeb, {"TCP", 8443}: CategoryWeb,
+	{"UDP", 443}: CategoryWeb,
+
+	// Email: SMTP, submission, IMAP, POP3
+	{"TCP", 25}: CategoryEmail, {"TCP", 465}: CategoryEmail, {"TCP", 587}: CategoryEmail,
+	{"TCP", 143}: CategoryEmail, {"TCP", 993}: CategoryEmail,
+	{"TCP", 110}: CategoryEmail, {"TCP", 995}: CategoryEmail,
+
+	// File transfer: SMB, NetBIOS session, FTP (data, control, implicit TLS)
+	{"TCP", 445}: CategoryFileTransfer, {"TCP", 139}: CategoryFileTransfer,
+	{"TCP", 20}: CategoryFileTransfer, {"TCP", 21}: CategoryFileTransfer, {"TCP", 990}: CategoryFileTransfer,
+
+	// Admin: SSH, RDP, WinRM
+	{"TCP", 22}: CategoryAdmin, {"TCP", 3389}: CategoryAdmin, {"UDP", 3389}: CategoryAdmin,
+	{"TCP", 5985}: CategoryAdmin, {"TCP", 5986}: CategoryAdmin,
+}
+
+// Classify returns the application category of evt. DPI results win over
+// ports; otherwise the destination port is tried first and then the source
+// port, so server responses get the same category as requests.
+func Classify(evt *NetworkEvent) string {
+	switch evt.AppProtocol {
+	case dpi.ProtoSSH, dpi.ProtoRDP, dpi.ProtoWinRM: // WinRM also carries an HTTP Host
+		return CategoryAdmin
+	}
+	if evt.SNI != "" || evt.HTTPHost != "" {
+		return CategoryWeb
+	}
+	if c, ok := categoryPorts[portKey{evt.Protocol, evt.DstPort}]; ok {
+		return c
+	}
+	if c, ok := categoryPorts[portKey{evt.Protocol, evt.SrcPort}]; ok {
+		return c
+	}
+	return CategoryOther
+}
+
+// Category returns the event's application category, used by outputs for
+// category based routing.
+func (e NetworkEvent) Category() string {
+	return e.AppCategory
+}
@@ -0,0 +1,104 @@
+package inspector
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		evt  NetworkEvent
+		want string
+	}{
+		{"HTTP", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 80}, CategoryWeb},
+		{"HTTPS Response", NetworkEvent{Protocol: "TCP", SrcPort: 443, DstPort: 51000}, CategoryWeb},
+		{"QUIC", NetworkEvent{Protocol: "UDP", SrcPort: 51000, DstPort: 443}, CategoryWeb},
+		{"SNI On Odd Port", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 4443, SNI: "example.com"}, CategoryWeb},
+		{"SMTP Submission", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 587}, CategoryEmail},
+		{"IMAPS", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 993}, CategoryEmail},
+		{"POP3", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 110}, CategoryEmail},
+		{"SMB", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 445}, CategoryFileTransfer},
+		{"FTP Control", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 21}, CategoryFileTransfer},
+		{"SSH", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 22}, CategoryAdmin},
+		{"SSH Banner On 443", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 443, AppProtocol: "ssh"}, CategoryAdmin},
+		{"RDP", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 3389}, CategoryAdmin},
+		{"WinRM HTTPS", NetworkEvent{Protocol: "TCP", SrcPort: 51000, DstPort: 5986}, CategoryAdmin},
+		{"DNS", NetworkEvent{Protocol: "UDP", SrcPort: 51000, DstPort: 53}, CategoryOther},
+		{"SSH Port Over UDP", NetworkEvent{Protocol: "UDP", SrcPort: 51000, DstPort: 22}, CategoryOther},
+		{"ICMP", NetworkEvent{Protocol: "ICMPv4"}, CategoryOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(&tt.evt); got != tt.want {
+				t.Errorf("Classify() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSetsCategory(t *testing.T) {
+	evt, ok := NewDecoder().Decode(dnsFrame(t, []byte{10, 0, 0, 1}, "example.com"), time.Now())
+	if !ok || evt.AppCategory != CategoryOther {
+		t.Errorf("Decode() category = %q, want %q", evt.AppCategory, CategoryOther)
+	}
+}
+
+func TestDecodeAppProtocol(t *testing.T) {
+	client, server := net.IP{10, 0, 0, 5}, net.IP{203, 0, 113, 10}
+	tests := []struct {
+		name     string
+		port     uint16
+		payload  string
+		want     string
+		category string
+	}{
+		{"SSH On 443", 443, "SSH-2.0-OpenSSH_9.6\r\n", dpi.ProtoSSH, CategoryAdmin},
+		{"HTTP On 22", 22, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", dpi.ProtoHTTP, CategoryWeb},
+		{"Unknown", 443, "\x00\x01binary", "", CategoryWeb},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt, _ := NewDecoder().Decode(tcpFrame(t, client, server, 51000, tt.port, tt.payload), time.Now())
+			if evt.AppProtocol != tt.want || evt.AppCategory != tt.category {
+				t.Errorf("Decode() = %q/%q, want %q/%q", evt.AppProtocol, evt.AppCategory, tt.want, tt.category)
+			}
+		})
+	}
+}
+
+func TestDecodeRemoteAdmin(t *testing.T) {
+	client, server := net.IP{10, 0, 0, 5}, net.IP{10, 0, 0, 20}
+	rdp := "\x03\x00\x00\x2c\x27\xe0\x00\x00\x00\x00\x00Cookie: mstshash=eltons\r\n\x01\x00\x08\x00\x03\x00\x00\x00"
+	winrm := "POST /wsman HTTP/1.1\r\nHost: dc01:5985\r\nAuthorization: Basic YWRtaW46c2VjcmV0\r\n\r\n"
+
+	tests := []struct {
+		name      string
+		port      uint16
+		payload   string
+		proto     string
+		user      string
+		auth      string
+		cleartext bool
+	}{
+		{"RDP", 3389, rdp, dpi.ProtoRDP, "eltons", "nla", false},
+		{"RDP On 443", 443, rdp, dpi.ProtoRDP, "eltons", "nla", false},
+		{"WinRM Basic", 5985, winrm, dpi.ProtoWinRM, "admin", dpi.WinRMAuthBasic, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt, _ := NewDecoder().Decode(tcpFrame(t, client, server, 51000, tt.port, tt.payload), time.Now())
+			if evt.AppProtocol != tt.proto || evt.AppCategory != CategoryAdmin {
+				t.Errorf("Decode() = %q/%q, want %q/%q", evt.AppProtocol, evt.AppCategory, tt.proto, CategoryAdmin)
+			}
+			if evt.AdminUser != tt.user || evt.AdminAuth != tt.auth || evt.CleartextCredentials != tt.cleartext {
+				t.Errorf("Decode() user %q auth %q cleartext %v, want %q %q %v",
+					evt.AdminUser, evt.AdminAuth, evt.CleartextCredentials, tt.user, tt.auth, tt.cleartext)
+			}
+		})
+	}
+}
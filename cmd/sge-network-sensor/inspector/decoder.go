@@ -0,0 +1,210 @@
+package inspector
+
+import (
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+)
+
+// TCP flag bits stored in NetworkEvent.TCPFlags.
+const (
+	TCPFlagFIN uint8 = 1 << iota
+	TCPFlagSYN
+	TCPFlagRST
+	TCPFlagPSH
+	TCPFlagACK
+)
+
+// Decoder turns raw Ethernet frames into NetworkEvents.
+// It reuses its layer parsers and is not safe for concurrent use.
+type Decoder struct {
+	eth     layers.Ethernet
+	ip4     layers.IPv4
+	ip6     layers.IPv6
+	tcp     layers.TCP
+	udp     layers.UDP
+	payload gopacket.Payload
+
+	parser  *gopacket.DecodingLayerParser
+	decoded []gopacket.LayerType
+
+	// FTP enables FTP control channel parsing on port 21
+	FTP bool
+
+	// MACs records the source MAC address of each frame
+	MACs bool
+
+	// Errors, if set, counts DPI parse errors
+	Errors *ParseCounters
+
+	// FirstPacket, if set, skips DPI on connections that already emitted
+	// their event
+	FirstPacket *FirstPacketFilter
+}
+
+// NewDecoder creates a decoder for Ethernet link-type packets.
+func NewDecoder() *Decoder {
+	d := &Decoder{}
+	d.parser = gopacket.NewDecodingLayerParser(
+		layers.LayerTypeEthernet,
+		&d.eth, &d.ip4, &d.ip6, &d.tcp, &d.udp, &d.payload,
+	)
+	return d
+}
+
+// Decode parses a packet captured at ts. It returns false if the packet
+// carries no IP layer.
+func (d *Decoder) Decode(data []byte, ts time.Time) (NetworkEvent, bool) {
+	// Continue even if full decode fails, as long as we got some layers
+	_ = d.parser.DecodeLayers(data, &d.decoded)
+
+	evt := NetworkEvent{Timestamp: ts}
+	hasIP := false
+
+	for _, layerType := range d.decoded {
+		switch layerType {
+		case layers.LayerTypeEthernet:
+			if d.MACs {
+				evt.SrcMAC = d.eth.SrcMAC.String()
+			}
+		case layers.LayerTypeIPv4:
+			evt.SrcIP = d.ip4.SrcIP.String()
+			evt.DstIP = d.ip4.DstIP.String()
+			evt.Protocol = d.ip4.Protocol.String()
+			hasIP = true
+		case layers.LayerTypeIPv6:
+			evt.SrcIP = d.ip6.SrcIP.String()
+			evt.DstIP = d.ip6.DstIP.String()
+			evt.Protocol = d.ip6.NextHeader.String()
+			hasIP = true
+		case layers.LayerTypeTCP:
+			tcp := &d.tcp
+			evt.SrcPort = uint16(tcp.SrcPort)
+			evt.DstPort = uint16(tcp.DstPort)
+			evt.PayloadSize = len(tcp.Payload)
+			evt.TCPFlags = tcpFlags(tcp)
+			evt.TCPSeq = tcp.Seq
+
+			// DPI Checks
+			if len(tcp.Payload) > 0 && !d.skipDPI(&evt) {
+				if hello, err := dpi.ParseTLSClientHello(tcp.Payload); hello != nil || err != nil {
+					d.parsed(hello != nil, err)
+					if hello != nil {
+						evt.AppProtocol = dpi.ProtoTLS
+						evt.SNI = hello.ServerName
+						evt.TLSVersion = hello.MaxVersion()
+					}
+				} else if cr, err := dpi.ParseRDPConnectionRequest(tcp.Payload); cr != nil || err != nil {
+					d.parsed(cr != nil, err)
+					if cr != nil {
+						evt.AppProtocol = dpi.ProtoRDP
+						evt.AdminUser = cr.Username
+						evt.AdminAuth = cr.Security()
+					}
+				} else if wr, err := dpi.ParseWinRMRequest(tcp.Payload); wr != nil || err != nil {
+					d.parsed(wr != nil, err)
+					if wr != nil {
+						evt.AppProtocol = dpi.ProtoWinRM
+						evt.AdminUser = wr.Username
+						evt.AdminAuth = wr.Auth
+						evt.CleartextCredentials = wr.Auth == dpi.WinRMAuthBasic
+						if req, _ := dpi.ParseHTTPRequest(tcp.Payload); req != nil {
+							evt.HTTPHost = req.Host
+						}
+					}
+				} else if req, err := dpi.ParseHTTPRequest(tcp.Payload); req != nil || err != nil {
+					d.parsed(req != nil, err)
+					if req != nil {
+						evt.AppProtocol = dpi.ProtoHTTP
+						evt.HTTPHost = req.Host
+					}
+				} else if banner, err := dpi.ParseSSHBanner(tcp.Payload); banner != nil || err != nil {
+					d.parsed(banner != nil, err)
+					if banner != nil {
+						evt.AppProtocol = dpi.ProtoSSH
+					}
+				} else if d.FTP && (tcp.DstPort == ftpControlPort || tcp.SrcPort == ftpControlPort) {
+					ok, err := parseFTP(&evt, tcp)
+					d.parsed(ok, err)
+					if ok {
+						evt.AppProtocol = dpi.ProtoFTP
+					}
+				}
+			}
+		case layers.LayerTypeUDP:
+			evt.SrcPort = uint16(d.udp.SrcPort)
+			evt.DstPort = uint16(d.udp.DstPort)
+			evt.PayloadSize = len(d.udp.Payload)
+
+			if d.udp.DstPort == 53 && !d.skipDPI(&evt) {
+				q, err := dpi.ParseDNSQuery(d.udp.Payload)
+				d.parsed(q != nil, err)
+				if q != nil {
+					evt.AppProtocol = dpi.ProtoDNS
+					evt.DNSQuery = q.Name
+					evt.DNSType = q.Type
+				}
+			}
+		}
+	}
+
+	// If ports are 0 (e.g. ICMP), they stay 0 which is fine
+	if hasIP {
+		evt.AppCategory = Classify(&evt)
+	}
+	return evt, hasIP
+}
+
+// skipDPI reports whether evt's connection is past its first event.
+func (d *Decoder) skipDPI(evt *NetworkEvent) bool {
+	return d.FirstPacket != nil && d.FirstPacket.Seen(evt)
+}
+
+// parsed counts a DPI parser error, if any.
+func (d *Decoder) parsed(partial bool, err error) {
+	if err != nil && d.Errors != nil {
+		d.Errors.Record(partial, err)
+	}
+}
+
+// Payload returns the transport payload of the last decoded packet. It is
+// only valid until the next Decode call.
+func (d *Decoder) Payload() []byte {
+	for _, layerType := range d.decoded {
+		switch layerType {
+		case layers.LayerTypeTCP:
+			return d.tcp.Payload
+		case layers.LayerTypeUDP:
+			return d.udp.Payload
+		}
+	}
+	return nil
+}
+
+func tcpFlags(tcp *layers.TCP) uint8 {
+	var f uint8
+	if tcp.FIN {
+		f |= TCPFlagFIN
+	}
+	if tcp.SYN {
+		f |= TCPFlagSYN
+	}
+	if tcp.RST {
+		f |= TCPFlagRST
+	}
+	if tcp.PSH {
+		f |= TCPFlagPSH
+	}
+	if tcp.ACK {
+		f |= TCPFlagACK
+	}
+	return f
+}
+
+// IsConnectionAttempt reports whether the event is a TCP SYN without ACK.
+func (e *NetworkEvent) IsConnectionAttempt() bool {
+	return e.TCPFlags&TCPFlagSYN != 0 && e.TCPFlags&TCPFlagACK == 0
+}
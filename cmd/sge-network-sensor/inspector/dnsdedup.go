@@ -0,0 +1,92 @@
+package inspector
+
+import (
+	"sync"
+	"time"
+)
+
+// maxPendingDNS bounds the deduper; beyond it queries pass through as is.
+const maxPendingDNS = 100_000
+
+type dnsKey struct {
+	src   string
+	name  string
+	qtype string
+}
+
+type pendingDNS struct {
+	evt   NetworkEvent
+	first time.Time
+}
+
+// DNSDeduper collapses identical DNS queries (same source, name and type)
+// seen within a window into one event whose RepeatCount is the number of
+// queries it stands for. The event is held until its window closes.
+// It is safe for concurrent use.
+type DNSDeduper struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[dnsKey]*pendingDNS
+}
+
+// NewDNSDeduper creates a deduper collapsing queries within window.
+func NewDNSDeduper(window time.Duration) *DNSDeduper {
+	return &DNSDeduper{window: window, pending: make(map[dnsKey]*pendingDNS)}
+}
+
+// Add records a DNS query event. It returns an event to emit right away:
+// the previous collapsed event for the same key if its window has passed,
+// or evt itself if the deduper is full.
+func (d *DNSDeduper) Add(evt NetworkEvent) (NetworkEvent, bool) {
+	key := dnsKey{src: evt.SrcIP, name: evt.DNSQuery, qtype: evt.DNSType}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.pending[key]
+	if ok && evt.Timestamp.Sub(p.first) < d.window {
+		p.evt.RepeatCount++
+		return NetworkEvent{}, false
+	}
+
+	if !ok && len(d.pending) >= maxPendingDNS {
+		evt.RepeatCount = 1
+		return evt, true
+	}
+
+	evt.RepeatCount = 1
+	d.pending[key] = &pendingDNS{evt: evt, first: evt.Timestamp}
+	if ok {
+		return p.evt, true
+	}
+	return NetworkEvent{}, false
+}
+
+// Expire removes and returns the events whose window closed before now.
+func (d *DNSDeduper) Expire(now time.Time) []NetworkEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var out []NetworkEvent
+	for key, p := range d.pending {
+		if now.Sub(p.first) >= d.window {
+			out = append(out, p.evt)
+			delete(d.pending, key)
+		}
+	}
+	return out
+}
+
+// Flush removes and returns all held events.
+func (d *DNSDeduper) Flush() []NetworkEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make([]NetworkEvent, 0, len(d.pending))
+	for _, p := range d.pending {
+		out = append(out, p.evt)
+	}
+	d.pending = make(map[dnsKey]*pendingDNS)
+	return out
+}
@@ -0,0 +1,205 @@
+package inspector
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+)
+
+func dnsEvent(src, name, qtype string, ts time.Time) NetworkEvent {
+	return NetworkEvent{Timestamp: ts, SrcIP: src, DstIP: "10.0.0.53", DstPort: 53, Protocol: "UDP", DNSQuery: name, DNSType: qtype}
+}
+
+func TestDNSDeduperCollapsesRepeats(t *testing.T) {
+	d := NewDNSDeduper(time.Second)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for n := 0; n < 5; n++ {
+		if _, ok := d.Add(dnsEvent("10.0.0.1", "example.com", "A", start.Add(time.Duration(n)*100*time.Millisecond))); ok {
+			t.Fatalf("Add() #%d emitted inside the window", n)
+		}
+	}
+	// Distinct type, name and source are separate keys
+	d.Add(dnsEvent("10.0.0.1", "example.com", "AAAA", start))
+	d.Add(dnsEvent("10.0.0.1", "example.org", "A", start))
+	d.Add(dnsEvent("10.0.0.2", "example.com", "A", start))
+
+	if got := d.Expire(start.Add(500 * time.Millisecond)); len(got) != 0 {
+		t.Fatalf("Expire() before window end = %v, want none", got)
+	}
+
+	got := d.Expire(start.Add(time.Second))
+	if len(got) != 4 {
+		t.Fatalf("Expire() = %d events, want 4", len(got))
+	}
+	counts := make(map[dnsKey]int)
+	for _, evt := range got {
+		counts[dnsKey{evt.SrcIP, evt.DNSQuery, evt.DNSType}] = evt.RepeatCount
+	}
+	want := map[dnsKey]int{
+		{"10.0.0.1", "example.com", "A"}:    5,
+		{"10.0.0.1", "example.com", "AAAA"}: 1,
+		{"10.0.0.1", "example.org", "A"}:    1,
+		{"10.0.0.2", "example.com", "A"}:    1,
+	}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("%v repeat_count = %d, want %d", key, counts[key], n)
+		}
+	}
+}
+
+func TestDNSDeduperNewWindowReleasesPrevious(t *testing.T) {
+	d := NewDNSDeduper(time.Second)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	d.Add(dnsEvent("10.0.0.1", "example.com", "A", start))
+	d.Add(dnsEvent("10.0.0.1", "example.com", "A", start.Add(200*time.Millisecond)))
+
+	prev, ok := d.Add(dnsEvent("10.0.0.1", "example.com", "A", start.Add(1500*time.Millisecond)))
+	if !ok || prev.RepeatCount != 2 || !prev.Timestamp.Equal(start) {
+		t.Fatalf("Add() after window = %+v, %v, want first event with repeat_count 2", prev, ok)
+	}
+
+	rest := d.Flush()
+	if len(rest) != 1 || rest[0].RepeatCount != 1 {
+		t.Errorf("Flush() = %+v, want one event with repeat_count 1", rest)
+	}
+}
+
+// dnsFrame builds an Ethernet/IPv4/UDP DNS query for name.
+func dnsFrame(t *testing.T, src net.IP, name string) []byte {
+	t.Helper()
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: src, DstIP: net.IP{10, 0, 0, 53}}
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ip)
+	dns := &layers.DNS{ID: 1, RD: true, Questions: []layers.DNSQuestion{{Name: []byte(name), Type: layers.DNSTypeA, Class: layers.DNSClassIN}}}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, udp, dns); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspectorDNSDedup(t *testing.T) {
+	frames := [][]byte{dnsFrame(t, net.IP{10, 0, 0, 1}, "example.com"), dnsFrame(t, net.IP{10, 0, 0, 1}, "example.org")}
+	const repeats = 10
+
+	cfg := &config.AppConfig{Interface: "any", Workers: 1, QueueSize: 1024, DNSDedupWindow: time.Minute}
+	events := make(chan interface{}, 100)
+	insp := NewInspector(cfg, events)
+	insp.listInterfaces = func() ([]string, error) { return []string{"eth0"}, nil }
+	insp.openSource = func(string) (PacketSource, error) {
+		var seq [][]byte
+		for n := 0; n < repeats; n++ {
+			seq = append(seq, frames[0])
+		}
+		seq = append(seq, frames[1])
+		return &seqSource{frames: seq}, nil
+	}
+
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for insp.Stats().Packets < repeats+1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for insp.Stats().QueueDepth > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	insp.Stop() // Releases the held queries
+
+	got := make(map[string]int)
+	for len(events) > 0 {
+		evt := (<-events).(NetworkEvent)
+		got[evt.DNSQuery] = evt.RepeatCount
+	}
+	if len(got) != 2 || got["example.com"] != repeats || got["example.org"] != 1 {
+		t.Errorf("events = %v, want example.com:%d example.org:1", got, repeats)
+	}
+}
+
+// stampedFrame is a frame captured at ts.
+type stampedFrame struct {
+	data []byte
+	ts   time.Time
+}
+
+// stampedSource delivers frames sent on a channel with their own
+// timestamps, and read timeouts while none is sent.
+type stampedSource struct {
+	frames chan stampedFrame
+}
+
+func (s *stampedSource) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	select {
+	case f := <-s.frames:
+		return f.data, gopacket.CaptureInfo{Timestamp: f.ts}, nil
+	case <-time.After(5 * time.Millisecond):
+		return nil, gopacket.CaptureInfo{}, errors.New("timeout")
+	}
+}
+
+func (s *stampedSource) Close() {}
+
+func TestInspectorDNSDedupPacketTime(t *testing.T) {
+	src := &stampedSource{frames: make(chan stampedFrame)}
+	cfg := &config.AppConfig{Interface: "any", Workers: 1, QueueSize: 64, DNSDedupWindow: 200 * time.Millisecond}
+	events := make(chan interface{}, 100)
+	insp := NewInspector(cfg, events)
+	insp.listInterfaces = func() ([]string, error) { return []string{"eth0"}, nil }
+	insp.openSource = func(string) (PacketSource, error) { return src, nil }
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer insp.Stop()
+
+	// A capture from years ago: against the wall clock every window has closed
+	base := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	query := dnsFrame(t, net.IP{10, 0, 0, 1}, "example.com")
+	src.frames <- stampedFrame{query, base}
+	src.frames <- stampedFrame{query, base.Add(50 * time.Millisecond)}
+
+	time.Sleep(150 * time.Millisecond)
+	if n := len(events); n != 0 {
+		t.Fatalf("%d events before the window closed in packet time, want 0", n)
+	}
+
+	// A later packet closes the window
+	src.frames <- stampedFrame{dnsFrame(t, net.IP{10, 0, 0, 1}, "example.org"), base.Add(10 * time.Second)}
+	select {
+	case e := <-events:
+		if evt := e.(NetworkEvent); evt.DNSQuery != "example.com" || evt.RepeatCount != 2 {
+			t.Errorf("event = %s x%d, want example.com x2", evt.DNSQuery, evt.RepeatCount)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("example.com not released once packet time passed its window")
+	}
+}
+
+// seqSource replays frames once, then returns io.EOF.
+type seqSource struct {
+	frames [][]byte
+}
+
+func (s *seqSource) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	if len(s.frames) == 0 {
+		return nil, gopacket.CaptureInfo{}, io.EOF
+	}
+	f := s.frames[0]
+	s.frames = s.frames[1:]
+	return f, gopacket.CaptureInfo{Timestamp: time.Now()}, nil
+}
+
+func (s *seqSource) Close() {}
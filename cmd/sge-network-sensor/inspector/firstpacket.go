@@ -0,0 +1,93 @@
+package inspector
+
+import (
+	"sync"
+	"time"
+)
+
+// FirstPacketFilter implements first-packet-only DPI: each TCP/UDP
+// connection yields one event, from its first packet carrying payload
+// (which holds the SNI, HTTP Host or DNS query), and every other packet of
+// the connection is ignored. This trades per-packet visibility for CPU on
+// links where little more than the connection metadata is needed.
+// It is safe for concurrent use.
+type FirstPacketFilter struct {
+	idle     time.Duration // Connections idle this long may emit again
+	capacity int           // Max remembered connections, 0 means unlimited
+
+	mu   sync.Mutex
+	seen map[FlowKey]time.Time // Connection -> last packet
+}
+
+// NewFirstPacketFilter creates a filter remembering up to capacity
+// connections, forgetting those idle for longer than idle.
+func NewFirstPacketFilter(capacity int, idle time.Duration) *FirstPacketFilter {
+	return &FirstPacketFilter{idle: idle, capacity: capacity, seen: make(map[FlowKey]time.Time)}
+}
+
+// applies reports whether evt belongs to a connection the filter handles.
+// Other protocols (ICMP, ...) carry no ports and are passed through.
+func (f *FirstPacketFilter) applies(evt *NetworkEvent) bool {
+	return evt.Protocol == "TCP" || evt.Protocol == "UDP"
+}
+
+// Seen reports whether evt's connection has already emitted its event.
+// The decoder uses it to skip DPI on packets Admit will ignore anyway.
+func (f *FirstPacketFilter) Seen(evt *NetworkEvent) bool {
+	if !f.applies(evt) {
+		return false
+	}
+	key := KeyOf(evt)
+
+	f.mu.Lock()
+	_, ok := f.seen[key]
+	f.mu.Unlock()
+	return ok
+}
+
+// Admit reports whether evt is emitted: true for the first packet of a
+// connection carrying payload and for packets the filter does not handle,
+// false for everything else.
+func (f *FirstPacketFilter) Admit(evt *NetworkEvent) bool {
+	if !f.applies(evt) {
+		return true
+	}
+	key := KeyOf(evt)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.seen[key]; ok {
+		f.seen[key] = evt.Timestamp
+		return false
+	}
+	if evt.PayloadSize == 0 {
+		// Handshake and bare ACKs carry no metadata, wait for payload
+		return false
+	}
+	if f.capacity > 0 && len(f.seen) >= f.capacity {
+		// Better a repeated event per connection than unbounded state
+		f.seen = make(map[FlowKey]time.Time)
+	}
+	f.seen[key] = evt.Timestamp
+	return true
+}
+
+// Expire forgets connections idle since before now minus the idle timeout.
+func (f *FirstPacketFilter) Expire(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for key, last := range f.seen {
+		if now.Sub(last) > f.idle {
+			delete(f.seen, key)
+		}
+	}
+}
+
+// Len returns the number of remembered connections.
+func (f *FirstPacketFilter) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.seen)
+}
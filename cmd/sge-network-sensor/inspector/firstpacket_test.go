@@ -0,0 +1,141 @@
+package inspector
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+)
+
+// tlsHello builds a minimal TLS ClientHello record for sni.
+func tlsHello(sni string) string {
+	u16 := func(n int) []byte { return binary.BigEndian.AppendUint16(nil, uint16(n)) }
+	entry := append(append([]byte{0}, u16(len(sni))...), sni...)
+	list := append(u16(len(entry)), entry...)
+	ext := append(append(u16(0), u16(len(list))...), list...)
+
+	body := []byte{0x03, 0x03}
+	body = append(body, make([]byte, 32)...)    // Random
+	body = append(body, 0)                      // Session ID
+	body = append(body, 0x00, 0x02, 0x00, 0x2f) // Cipher suites
+	body = append(body, 0x01, 0x00)             // Compression
+	body = append(body, u16(len(ext))...)
+	body = append(body, ext...)
+
+	hs := append([]byte{0x01, 0, byte(len(body) >> 8), byte(len(body))}, body...)
+	return string(append(append([]byte{0x16, 0x03, 0x01}, u16(len(hs))...), hs...))
+}
+
+func TestFirstPacketFilterAdmit(t *testing.T) {
+	now := time.Now()
+	out := &NetworkEvent{Timestamp: now, Protocol: "TCP", SrcIP: "10.0.0.5", SrcPort: 51000, DstIP: "203.0.113.10", DstPort: 443}
+	reply := &NetworkEvent{Timestamp: now, Protocol: "TCP", SrcIP: out.DstIP, SrcPort: out.DstPort, DstIP: out.SrcIP, DstPort: out.SrcPort, PayloadSize: 1200}
+	icmp := &NetworkEvent{Timestamp: now, Protocol: "ICMPv4", SrcIP: "10.0.0.5", DstIP: "203.0.113.10"}
+
+	f := NewFirstPacketFilter(0, time.Minute)
+	if f.Admit(out) {
+		t.Error("Admit(handshake) = true, want false")
+	}
+	out.PayloadSize = 300
+	if !f.Admit(out) {
+		t.Error("Admit(first payload) = false, want true")
+	}
+	if f.Admit(out) || f.Admit(reply) {
+		t.Error("Admit(later packet) = true, want false")
+	}
+	if !f.Seen(reply) {
+		t.Error("Seen(reply) = false, want true")
+	}
+	if !f.Admit(icmp) || !f.Admit(icmp) {
+		t.Error("Admit(icmp) = false, want true")
+	}
+
+	f.Expire(now.Add(30 * time.Second))
+	if f.Len() != 1 {
+		t.Errorf("Len() after 30s = %d, want 1", f.Len())
+	}
+	f.Expire(now.Add(2 * time.Minute))
+	if f.Len() != 0 || !f.Admit(out) {
+		t.Errorf("after idle: Len() = %d, want 0 and the connection admitted again", f.Len())
+	}
+}
+
+func TestFirstPacketFilterCapacity(t *testing.T) {
+	f := NewFirstPacketFilter(2, time.Minute)
+	for port := uint16(1); port <= 3; port++ {
+		evt := &NetworkEvent{Protocol: "UDP", SrcIP: "10.0.0.5", SrcPort: port, DstIP: "10.0.0.53", DstPort: 53, PayloadSize: 40}
+		if !f.Admit(evt) {
+			t.Errorf("Admit(port %d) = false, want true", port)
+		}
+	}
+	if f.Len() > 2 {
+		t.Errorf("Len() = %d, want at most 2", f.Len())
+	}
+}
+
+func TestInspectorFirstPacketOnly(t *testing.T) {
+	client, web, tls := net.IP{10, 0, 0, 5}, net.IP{203, 0, 113, 10}, net.IP{203, 0, 113, 20}
+	frames := [][]byte{
+		tcpFrame(t, client, web, 51000, 80, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		tcpFrame(t, web, client, 80, 51000, "HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nhello"),
+		tcpFrame(t, client, web, 51000, 80, "GET /next HTTP/1.1\r\nHost: other.example\r\n\r\n"),
+		tcpFrame(t, client, tls, 51001, 443, tlsHello("secure.example")),
+		tcpFrame(t, tls, client, 443, 51001, "\x16\x03\x03server hello"),
+		tcpFrame(t, client, tls, 51001, 443, "\x17\x03\x03application data"),
+		tcpFrame(t, tls, client, 443, 51001, "\x17\x03\x03application data"),
+		dnsFrame(t, client, "example.org"),
+		dnsFrame(t, client, "example.org"),
+	}
+
+	cfg := &config.AppConfig{Interface: "any", Workers: 1, QueueSize: 1024, FirstPacketOnly: true, FlowTableSize: 100, FlowIdleTimeout: time.Minute}
+	events := make(chan interface{}, 100)
+	insp := NewInspector(cfg, events)
+	insp.listInterfaces = func() ([]string, error) { return []string{"eth0"}, nil }
+	insp.openSource = func(string) (PacketSource, error) { return &seqSource{frames: frames}, nil }
+	tap := make(chan interface{}, 100)
+	insp.Tap = tap
+
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for insp.Stats().Packets < uint64(len(frames)) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for insp.Stats().QueueDepth > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	insp.Stop()
+
+	got := make(map[uint16][]NetworkEvent)
+	for len(events) > 0 {
+		evt := (<-events).(NetworkEvent)
+		got[evt.DstPort] = append(got[evt.DstPort], evt)
+	}
+	if len(got) != 3 {
+		t.Fatalf("events for ports %v, want 80, 443 and 53", got)
+	}
+	for port, evts := range got {
+		if len(evts) != 1 {
+			t.Errorf("port %d: %d events, want 1", port, len(evts))
+		}
+	}
+	if host := got[80][0].HTTPHost; host != "example.com" {
+		t.Errorf("HTTPHost = %q, want example.com", host)
+	}
+	if sni := got[443][0].SNI; sni != "secure.example" {
+		t.Errorf("SNI = %q, want secure.example", sni)
+	}
+	if q := got[53][0].DNSQuery; q != "example.org" {
+		t.Errorf("DNSQuery = %q, want example.org", q)
+	}
+	if st := insp.Stats(); st.FirstOut != uint64(len(frames)-3) {
+		t.Errorf("Stats().FirstOut = %d, want %d", st.FirstOut, len(frames)-3)
+	}
+	// The tap sees the packets the filter skipped
+	if n := len(tap); n != len(frames) {
+		t.Errorf("tap got %d events, want all %d packets", n, len(frames))
+	}
+}
@@ -0,0 +1,202 @@
+package inspector
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// pressureLowMark is the fraction of capacity a table under pressure must
+// drain below before pressure is cleared (and can be signalled again).
+const pressureLowMark = 0.8
+
+// FlowKey identifies a bidirectional flow. Endpoints are ordered so both
+// directions of a connection map to the same key.
+type FlowKey struct {
+	Protocol string
+	AddrA    string
+	PortA    uint16
+	AddrB    string
+	PortB    uint16
+}
+
+// KeyOf returns the flow key of evt.
+func KeyOf(evt *NetworkEvent) FlowKey {
+	if evt.SrcIP < evt.DstIP || (evt.SrcIP == evt.DstIP && evt.SrcPort <= evt.DstPort) {
+		return FlowKey{evt.Protocol, evt.SrcIP, evt.SrcPort, evt.DstIP, evt.DstPort}
+	}
+	return FlowKey{evt.Protocol, evt.DstIP, evt.DstPort, evt.SrcIP, evt.SrcPort}
+}
+
+// Flow is the tracked state of one flow.
+type Flow struct {
+	Key       FlowKey
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Packets   uint64
+	Bytes     uint64
+	TCPFlags  uint8 // All TCPFlag* bits seen on the flow
+
+	// Embryonic is set while a TCP flow opened by a SYN has not completed
+	// its handshake (no ACK without SYN seen yet).
+	Embryonic bool
+}
+
+// FlowStats describes the flow table's occupancy and evictions.
+type FlowStats struct {
+	Active          int    // Flows currently tracked
+	Capacity        int    // Max tracked flows
+	HighWater       int    // Most flows tracked at once
+	Expired         uint64 // Flows removed after the idle timeout
+	ForcedEvictions uint64 // Live flows evicted early because the table was full
+	UnderPressure   bool   // Table is full and evicting live flows
+
+	Embryonic          int    // Half-open TCP flows currently tracked
+	EmbryonicEvictions uint64 // Half-open flows evicted to make room
+}
+
+// FlowTable tracks active flows up to a fixed capacity. When full, the
+// least recently seen half-open (embryonic) TCP flow is evicted to make
+// room, so a SYN flood with spoofed sources cannot push out established
+// flows. Only without embryonic flows is the least recently seen live flow
+// evicted early; OnPressure is called once when that starts. It is safe for
+// concurrent use.
+type FlowTable struct {
+	capacity int
+	idle     time.Duration
+
+	// OnPressure, if set, is called (outside the lock) when the table
+	// starts evicting live flows.
+	OnPressure func(FlowStats)
+
+	mu       sync.Mutex
+	flows    map[FlowKey]*list.Element // Values are *Flow, in lru or embryonic
+	lru      *list.List                // Established/non-TCP flows, front is most recently seen
+	half     *list.List                // Embryonic flows, front is most recently seen
+	stats    FlowStats
+	pressure bool
+}
+
+// NewFlowTable creates a table for up to capacity flows, expiring flows
+// idle for longer than idle.
+func NewFlowTable(capacity int, idle time.Duration) *FlowTable {
+	return &FlowTable{
+		capacity: capacity,
+		idle:     idle,
+		flows:    make(map[FlowKey]*list.Element),
+		lru:      list.New(),
+		half:     list.New(),
+		stats:    FlowStats{Capacity: capacity},
+	}
+}
+
+// Track accounts evt to its flow and returns a copy of the updated flow.
+func (t *FlowTable) Track(evt *NetworkEvent) Flow {
+	key := KeyOf(evt)
+
+	t.mu.Lock()
+	var notify *FlowStats
+
+	el, ok := t.flows[key]
+	if !ok {
+		if len(t.flows) >= t.capacity && t.evict() && !t.pressure {
+			t.pressure = true
+			s := t.snapshot()
+			notify = &s
+		}
+		f := &Flow{Key: key, FirstSeen: evt.Timestamp, Embryonic: evt.IsConnectionAttempt()}
+		if f.Embryonic {
+			el = t.half.PushFront(f)
+		} else {
+			el = t.lru.PushFront(f)
+		}
+		t.flows[key] = el
+		if n := len(t.flows); n > t.stats.HighWater {
+			t.stats.HighWater = n
+		}
+	}
+
+	f := el.Value.(*Flow)
+	switch {
+	case f.Embryonic && evt.TCPFlags&(TCPFlagACK|TCPFlagSYN) == TCPFlagACK:
+		// Handshake completed
+		t.half.Remove(el)
+		f.Embryonic = false
+		el = t.lru.PushFront(f)
+		t.flows[key] = el
+	case f.Embryonic:
+		t.half.MoveToFront(el)
+	case ok:
+		t.lru.MoveToFront(el)
+	}
+	f.LastSeen = evt.Timestamp
+	f.Packets++
+	f.Bytes += uint64(evt.PayloadSize)
+	f.TCPFlags |= evt.TCPFlags
+	out := *f
+	t.mu.Unlock()
+
+	if notify != nil && t.OnPressure != nil {
+		t.OnPressure(*notify)
+	}
+	return out
+}
+
+// evict makes room for one flow, preferring the oldest embryonic flow. It
+// reports whether a live flow had to be evicted.
+func (t *FlowTable) evict() bool {
+	if el := t.half.Back(); el != nil {
+		t.half.Remove(el)
+		delete(t.flows, el.Value.(*Flow).Key)
+		t.stats.EmbryonicEvictions++
+		return false
+	}
+	el := t.lru.Back()
+	if el == nil {
+		return false
+	}
+	t.lru.Remove(el)
+	delete(t.flows, el.Value.(*Flow).Key)
+	t.stats.ForcedEvictions++
+	return true
+}
+
+// Expire removes flows idle since before now-idle and returns them.
+func (t *FlowTable) Expire(now time.Time) []Flow {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []Flow
+	for _, l := range []*list.List{t.half, t.lru} {
+		for el := l.Back(); el != nil; el = l.Back() {
+			f := el.Value.(*Flow)
+			if now.Sub(f.LastSeen) < t.idle {
+				break
+			}
+			l.Remove(el)
+			delete(t.flows, f.Key)
+			t.stats.Expired++
+			out = append(out, *f)
+		}
+	}
+
+	if t.pressure && float64(len(t.flows)) < pressureLowMark*float64(t.capacity) {
+		t.pressure = false
+	}
+	return out
+}
+
+// Stats returns the current occupancy and eviction counters.
+func (t *FlowTable) Stats() FlowStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.snapshot()
+}
+
+func (t *FlowTable) snapshot() FlowStats {
+	s := t.stats
+	s.Active = len(t.flows)
+	s.Embryonic = t.half.Len()
+	s.UnderPressure = t.pressure
+	return s
+}
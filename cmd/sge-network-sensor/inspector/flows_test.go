@@ -0,0 +1,111 @@
+package inspector
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func flowEvent(src string, srcPort uint16, ts time.Time) *NetworkEvent {
+	return &NetworkEvent{Timestamp: ts, SrcIP: src, DstIP: "10.0.0.100", SrcPort: srcPort, DstPort: 443, Protocol: "TCP", PayloadSize: 100}
+}
+
+func TestFlowTableBidirectional(t *testing.T) {
+	ft := NewFlowTable(10, time.Minute)
+	now := time.Now()
+
+	out := flowEvent("10.0.0.1", 50000, now)
+	in := &NetworkEvent{Timestamp: now, SrcIP: "10.0.0.100", DstIP: "10.0.0.1", SrcPort: 443, DstPort: 50000, Protocol: "TCP", PayloadSize: 1400, TCPFlags: TCPFlagACK}
+	ft.Track(out)
+	f := ft.Track(in)
+
+	if f.Packets != 2 || f.Bytes != 1500 || f.TCPFlags != TCPFlagACK {
+		t.Errorf("Track() = %+v, want both directions in one flow", f)
+	}
+	if s := ft.Stats(); s.Active != 1 {
+		t.Errorf("Active = %d, want 1", s.Active)
+	}
+}
+
+func TestFlowTableOverflow(t *testing.T) {
+	const capacity = 10
+	ft := NewFlowTable(capacity, time.Minute)
+
+	var signals []FlowStats
+	ft.OnPressure = func(s FlowStats) { signals = append(signals, s) }
+
+	start := time.Now()
+	for n := 0; n < capacity+5; n++ {
+		ft.Track(flowEvent(fmt.Sprintf("10.0.1.%d", n), 50000, start.Add(time.Duration(n)*time.Millisecond)))
+	}
+
+	s := ft.Stats()
+	if s.Active != capacity || s.HighWater != capacity || s.ForcedEvictions != 5 || !s.UnderPressure {
+		t.Errorf("Stats() = %+v, want %d active/high-water, 5 forced evictions, under pressure", s, capacity)
+	}
+	if len(signals) != 1 || signals[0].ForcedEvictions != 1 {
+		t.Fatalf("pressure signals = %+v, want exactly one at the first eviction", signals)
+	}
+
+	// The oldest flows were evicted, the newest kept
+	if f := ft.Track(flowEvent("10.0.1.14", 50000, start.Add(time.Second))); f.Packets != 2 {
+		t.Errorf("newest flow packets = %d, want 2 (kept)", f.Packets)
+	}
+
+	// Draining below the low mark clears pressure and re-arms the signal
+	expired := ft.Expire(start.Add(time.Minute + 100*time.Millisecond))
+	if len(expired) != capacity-1 {
+		t.Errorf("Expire() = %d flows, want %d", len(expired), capacity-1)
+	}
+	if s := ft.Stats(); s.UnderPressure || s.Expired != capacity-1 || s.Active != 1 {
+		t.Errorf("Stats() after expiry = %+v", s)
+	}
+	for n := 0; n < capacity; n++ {
+		ft.Track(flowEvent(fmt.Sprintf("10.0.2.%d", n), 50000, start.Add(2*time.Minute)))
+	}
+	if len(signals) != 2 {
+		t.Errorf("pressure signals = %d, want 2 after re-arming", len(signals))
+	}
+}
+
+func TestFlowTableSYNFlood(t *testing.T) {
+	const capacity = 100
+	ft := NewFlowTable(capacity, time.Minute)
+	var signals int
+	ft.OnPressure = func(FlowStats) { signals++ }
+	start := time.Now()
+
+	// Established connections fill part of the table first
+	for n := 0; n < 10; n++ {
+		src := fmt.Sprintf("10.0.0.%d", n)
+		syn := flowEvent(src, 50000, start)
+		syn.TCPFlags = TCPFlagSYN
+		ack := flowEvent(src, 50000, start)
+		ack.TCPFlags = TCPFlagACK
+		ft.Track(syn)
+		ft.Track(ack)
+	}
+
+	// Spoofed SYNs, each from a new source
+	for n := 0; n < 10000; n++ {
+		evt := flowEvent(fmt.Sprintf("198.%d.%d.%d", n/65536, (n/256)%256, n%256), uint16(1024+n%60000), start.Add(time.Duration(n)*time.Microsecond))
+		evt.TCPFlags = TCPFlagSYN
+		ft.Track(evt)
+	}
+
+	s := ft.Stats()
+	if s.Active != capacity || s.HighWater != capacity {
+		t.Errorf("Stats() = %+v, want table bounded at %d", s, capacity)
+	}
+	if s.Embryonic != capacity-10 || s.EmbryonicEvictions != 10000-(capacity-10) {
+		t.Errorf("Stats() = %+v, want %d embryonic, %d embryonic evictions", s, capacity-10, 10000-(capacity-10))
+	}
+	if s.ForcedEvictions != 0 || signals != 0 {
+		t.Errorf("ForcedEvictions = %d, pressure signals = %d, want established flows kept", s.ForcedEvictions, signals)
+	}
+	for n := 0; n < 10; n++ {
+		if f := ft.Track(flowEvent(fmt.Sprintf("10.0.0.%d", n), 50000, start.Add(time.Second))); f.Packets != 3 || f.Embryonic {
+			t.Errorf("established flow %d = %+v, want kept with 3 packets", n, f)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package inspector
+
+import (
+	"sync"
+
+	"github.com/google/gopacket/layers"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+)
+
+// ftpControlPort is the FTP control channel port.
+const ftpControlPort = 21
+
+// ftpRedacted replaces PASS arguments so passwords never leave the sensor.
+const ftpRedacted = "********"
+
+// maxPendingFTP bounds the commands waiting for a reply. When reached the
+// pending set is reset, losing only the command/reply pairing.
+const maxPendingFTP = 10000
+
+// parseFTP fills the FTP fields of evt from a control channel segment:
+// commands on packets to port 21, replies on packets from it. It returns
+// whether a command or reply was parsed (possibly partially) and the
+// parser error.
+func parseFTP(evt *NetworkEvent, tcp *layers.TCP) (bool, error) {
+	switch {
+	case tcp.DstPort == ftpControlPort:
+		cmd, err := dpi.ParseFTPCommand(tcp.Payload)
+		if cmd == nil {
+			return false, err
+		}
+		evt.FTPCommand, evt.FTPArg = cmd.Command, cmd.Arg
+		if cmd.Command == "PASS" {
+			evt.FTPArg = ftpRedacted
+			evt.CleartextCredentials = true
+		}
+		return true, err
+	case tcp.SrcPort == ftpControlPort:
+		reply, err := dpi.ParseFTPReply(tcp.Payload)
+		if reply == nil {
+			return false, err
+		}
+		evt.FTPReply = reply.Code
+		return true, err
+	}
+	return false, nil
+}
+
+type ftpPending struct {
+	command string
+	arg     string
+}
+
+// FTPParser pairs FTP replies with the command they answer, so reply events
+// carry the command, its argument and the response code. Both directions of
+// a control connection must be tracked in capture order, as the inspector's
+// flow sharding guarantees. It is safe for concurrent use.
+type FTPParser struct {
+	mu      sync.Mutex
+	pending map[FlowKey]ftpPending // Last unanswered command per control connection
+}
+
+// NewFTPParser creates an FTP command/reply tracker.
+func NewFTPParser() *FTPParser {
+	return &FTPParser{pending: make(map[FlowKey]ftpPending)}
+}
+
+// Track records a command event or completes a reply event in place with
+// the pending command of the same connection. Replies without a pending
+// command (e.g. the 220 banner) are left as they are.
+func (p *FTPParser) Track(evt *NetworkEvent) {
+	key := KeyOf(evt)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case evt.FTPCommand != "":
+		if len(p.pending) >= maxPendingFTP {
+			p.pending = make(map[FlowKey]ftpPending)
+		}
+		p.pending[key] = ftpPending{command: evt.FTPCommand, arg: evt.FTPArg}
+	case evt.FTPReply != 0:
+		cmd, ok := p.pending[key]
+		if !ok {
+			return
+		}
+		// 1xx replies are preliminary, the final reply follows
+		if evt.FTPReply >= 200 {
+			delete(p.pending, key)
+		}
+		evt.FTPCommand, evt.FTPArg = cmd.command, cmd.arg
+		evt.CleartextCredentials = cmd.command == "PASS"
+	}
+}
@@ -0,0 +1,156 @@
+package inspector
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// tcpFrame builds an Ethernet/IPv4/TCP segment carrying payload.
+func tcpFrame(t *testing.T, src, dst net.IP, srcPort, dstPort uint16, payload string) []byte {
+	t.Helper()
+	return tcpSegment(t, src, dst, srcPort, dstPort, 0, false, payload)
+}
+
+// tcpSegment is tcpFrame with a sequence number and optionally FIN set.
+func tcpSegment(t *testing.T, src, dst net.IP, srcPort, dstPort uint16, seq uint32, fin bool, payload string) []byte {
+	t.Helper()
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: src, DstIP: dst}
+	tcp := &layers.TCP{SrcPort: layers.TCPPort(srcPort), DstPort: layers.TCPPort(dstPort), Seq: seq, ACK: true, PSH: true, FIN: fin, Window: 64240}
+	tcp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, tcp, gopacket.Payload(payload)); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFTPControlExchange(t *testing.T) {
+	client, server := net.IP{10, 0, 0, 5}, net.IP{10, 0, 0, 21}
+	const clientPort = 51515
+
+	// Control channel of a login and a download, as captured
+	exchange := []struct {
+		fromClient bool
+		payload    string
+		wantCmd    string
+		wantArg    string
+		wantReply  int
+		wantCreds  bool
+	}{
+		{false, "220 (vsFTPd 3.0.3)\r\n", "", "", 220, false},
+		{true, "USER alice\r\n", "USER", "alice", 0, false},
+		{false, "331 Please specify the password.\r\n", "USER", "alice", 331, false},
+		{true, "PASS s3cret!\r\n", "PASS", ftpRedacted, 0, true},
+		{false, "230 Login successful.\r\n", "PASS", ftpRedacted, 230, true},
+		{true, "PASV\r\n", "PASV", "", 0, false},
+		{false, "227 Entering Passive Mode (10,0,0,21,195,80).\r\n", "PASV", "", 227, false},
+		{true, "RETR payroll-2024.csv\r\n", "RETR", "payroll-2024.csv", 0, false},
+		{false, "150 Opening BINARY mode data connection for payroll-2024.csv (48213 bytes).\r\n", "RETR", "payroll-2024.csv", 150, false},
+		{false, "226 Transfer complete.\r\n", "RETR", "payroll-2024.csv", 226, false},
+		{true, "QUIT\r\n", "QUIT", "", 0, false},
+		{false, "221 Goodbye.\r\n", "QUIT", "", 221, false},
+	}
+
+	decoder := NewDecoder()
+	decoder.FTP = true
+	parser := NewFTPParser()
+
+	for n, step := range exchange {
+		var frame []byte
+		if step.fromClient {
+			frame = tcpFrame(t, client, server, clientPort, ftpControlPort, step.payload)
+		} else {
+			frame = tcpFrame(t, server, client, ftpControlPort, clientPort, step.payload)
+		}
+
+		evt, ok := decoder.Decode(frame, time.Now())
+		if !ok {
+			t.Fatalf("#%d Decode() failed", n)
+		}
+		parser.Track(&evt)
+
+		if evt.FTPCommand != step.wantCmd || evt.FTPArg != step.wantArg || evt.FTPReply != step.wantReply || evt.CleartextCredentials != step.wantCreds {
+			t.Errorf("#%d %q = command %q arg %q reply %d creds %v, want %q %q %d %v", n, step.payload,
+				evt.FTPCommand, evt.FTPArg, evt.FTPReply, evt.CleartextCredentials,
+				step.wantCmd, step.wantArg, step.wantReply, step.wantCreds)
+		}
+	}
+}
+
+func TestDecodeFTPDisabled(t *testing.T) {
+	frame := tcpFrame(t, net.IP{10, 0, 0, 5}, net.IP{10, 0, 0, 21}, 51515, ftpControlPort, "PASS s3cret!\r\n")
+	evt, ok := NewDecoder().Decode(frame, time.Now())
+	if !ok || evt.FTPCommand != "" || evt.FTPArg != "" || evt.CleartextCredentials {
+		t.Errorf("Decode() without FTP parsing = %+v, want no FTP fields", evt)
+	}
+}
+
+// TestInspectorFTPAcrossWorkers interleaves many control connections on
+// several workers; every reply must carry the command it answers.
+func TestInspectorFTPAcrossWorkers(t *testing.T) {
+	const conns = 50
+	server := net.IP{10, 0, 0, 21}
+	exchange := []struct {
+		fromClient bool
+		payload    string
+	}{
+		{true, "USER alice\r\n"},
+		{false, "331 Please specify the password.\r\n"},
+		{true, "PASS s3cret!\r\n"},
+		{false, "230 Login successful.\r\n"},
+		{true, "RETR payroll.csv\r\n"},
+		{false, "226 Transfer complete.\r\n"},
+	}
+	want := map[int]string{331: "USER", 230: "PASS", 226: "RETR"}
+
+	var frames [][]byte
+	for _, step := range exchange {
+		for c := 0; c < conns; c++ {
+			client, port := net.IP{10, 0, 1, byte(c)}, uint16(40000+c)
+			if step.fromClient {
+				frames = append(frames, tcpFrame(t, client, server, port, ftpControlPort, step.payload))
+			} else {
+				frames = append(frames, tcpFrame(t, server, client, ftpControlPort, port, step.payload))
+			}
+		}
+	}
+
+	cfg := &config.AppConfig{Interface: "any", Workers: 4, QueueSize: 4096, FTPParsing: true}
+	events := make(chan interface{}, len(frames))
+	insp := NewInspector(cfg, events)
+	insp.listInterfaces = func() ([]string, error) { return []string{"eth0"}, nil }
+	insp.openSource = func(string) (PacketSource, error) { return &seqSource{frames: frames}, nil }
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-insp.CaptureDone()
+	deadline := time.Now().Add(5 * time.Second)
+	for insp.Stats().Events < uint64(len(frames)) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	insp.Stop()
+
+	replies := 0
+	for len(events) > 0 {
+		evt := (<-events).(NetworkEvent)
+		if evt.FTPReply == 0 {
+			continue
+		}
+		replies++
+		if evt.FTPCommand != want[evt.FTPReply] {
+			t.Errorf("%s reply %d command = %q, want %q", evt.DstIP, evt.FTPReply, evt.FTPCommand, want[evt.FTPReply])
+		}
+	}
+	if replies != conns*len(want) {
+		t.Errorf("replies = %d, want %d", replies, conns*len(want))
+	}
+}
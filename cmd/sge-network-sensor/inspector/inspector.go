@@ -2,84 +2,372 @@ package inspector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/gopacket"
-	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcap"
 
 	"sakin-go/cmd/sge-network-sensor/config"
 	"sakin-go/cmd/sge-network-sensor/dpi"
 )
 
+// muxReadTimeout caps the read timeout of live handles when capture loops
+// read several interfaces (SENSOR_MAX_INTERFACES below the interface count).
+const muxReadTimeout = 10 * time.Millisecond
+
+// muxBurst bounds the packets read from one interface per turn.
+const muxBurst = 256
+
+// ErrNoInterfaces is returned by Start when no interface is left to capture
+// on after filtering and no replay file is configured.
+var ErrNoInterfaces = errors.New("no capture interfaces")
+
+// PacketSource is a capture handle (live pcap or a test double).
+// ReadPacketData returning io.EOF ends the capture loop.
+type PacketSource interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	Close()
+}
+
 // Inspector manages packet capture across interfaces.
+// Capture loops only read packets; decoding happens in a worker pool. Each
+// worker has its own FairQueue and packets are sharded by flow (see
+// flowShard), so one connection is always decoded by the same worker, in
+// capture order.
 type Inspector struct {
 	config    *config.AppConfig
 	eventChan chan<- interface{} // Channel to send detected events
 	wg        sync.WaitGroup
 	ctx       context.Context
 	cancel    context.CancelFunc
+
+	queues  []*FairQueue       // One per worker
+	dns     *DNSDeduper        // nil disables DNS dedup
+	flows   *FlowTable         // nil disables flow tracking
+	sampler *FlowSampler       // nil keeps every flow
+	ftp     *FTPParser         // nil disables FTP parsing
+	smtp    *SMTPParser        // nil disables SMTP parsing
+	first   *FirstPacketFilter // nil emits every packet
+
+	// Tap, if set before Start, receives every decoded event ahead of the
+	// first-packet filter, so a consumer that needs each packet (the
+	// threat detector) still sees them in first-packet DPI mode. A full
+	// tap drops the event.
+	Tap chan<- interface{}
+
+	// OnFlowPressure, if set before Start, is called when the flow table
+	// is full and starts evicting live flows.
+	OnFlowPressure func(FlowStats)
+
+	// Counters for Stats
+	interfaces atomic.Int32
+	packets    atomic.Uint64
+	events     atomic.Uint64
+	eventDrops atomic.Uint64
+	sampledOut atomic.Uint64
+	pausedOut  atomic.Uint64
+	firstOut   atomic.Uint64
+	parse      *ParseCounters
+
+	paused atomic.Bool
+	clock  packetClock // Expiry runs in packet time
+
+	captures    sync.WaitGroup
+	captureDone chan struct{} // Closed once every capture loop has returned
+	readTimeout time.Duration // Live handle read timeout
+
+	// Overridable for tests
+	listInterfaces func() ([]string, error)
+	openSource     func(iface string) (PacketSource, error)
+}
+
+// Stats is a point-in-time view of the capture pipeline counters.
+// Counters are cumulative since Start.
+type Stats struct {
+	Interfaces int    // Interfaces selected for capture
+	Packets    uint64 // Packets read from capture handles
+	Events     uint64 // Events handed to the event channel
+	QueueDrops uint64 // Packets dropped because an interface queue was full
+	EventDrops uint64 // Events dropped because the event channel was full
+	QueueDepth int    // Packets waiting for a worker
+	SampledOut uint64 // Packets skipped because their flow is not sampled
+	Paused     bool   // Capture is paused (see Pause)
+	PausedOut  uint64 // Packets read and discarded while paused
+	FirstOut   uint64 // Packets ignored in first-packet DPI mode
+
+	ParseErrors   map[string]uint64 // DPI parse errors per protocol (malformed or truncated)
+	PartialParses uint64            // Parse errors that still yielded partial metadata
+
+	Flows FlowStats // Flow table occupancy & evictions
 }
 
 // NetworkEvent represents a captured network event (simplified).
 type NetworkEvent struct {
-	Timestamp   time.Time
-	SrcIP       string
-	DstIP       string
-	SrcPort     uint16
-	DstPort     uint16
-	Protocol    string
-	PayloadSize int
-	SNI         string // HTTPS
-	HTTPHost    string // HTTP
+	Timestamp   time.Time `json:"timestamp"`
+	Interface   string    `json:"interface,omitempty"`
+	SrcMAC      string    `json:"src_mac,omitempty"` // Only with asset discovery
+	SrcIP       string    `json:"src_ip"`
+	DstIP       string    `json:"dst_ip"`
+	SrcPort     uint16    `json:"src_port,omitempty"`
+	DstPort     uint16    `json:"dst_port,omitempty"`
+	Protocol    string    `json:"protocol"`
+	AppCategory string    `json:"app_category,omitempty"` // Category* (web, email, ...)
+	AppProtocol string    `json:"app_protocol,omitempty"` // L7 protocol recognized from the payload (dpi.Proto*)
+	PayloadSize int       `json:"payload_size"`
+	TCPFlags    uint8     `json:"tcp_flags,omitempty"`   // TCPFlag* bits
+	TCPSeq      uint32    `json:"tcp_seq,omitempty"`     // Sequence number, for retransmission tracking
+	SNI         string    `json:"sni,omitempty"`         // HTTPS
+	TLSVersion  uint16    `json:"tls_version,omitempty"` // Highest version offered in the ClientHello (dpi.VersionTLS*)
+	HTTPHost    string    `json:"http_host,omitempty"`   // HTTP
+	DNSQuery    string    `json:"dns_query,omitempty"`   // DNS (queried name)
+	DNSType     string    `json:"dns_type,omitempty"`
+	RepeatCount int       `json:"repeat_count,omitempty"` // Identical DNS queries collapsed into this event
+
+	// FTP control channel (replies carry the command they answer)
+	FTPCommand           string `json:"ftp_command,omitempty"`
+	FTPArg               string `json:"ftp_arg,omitempty"` // File or user name, PASS is redacted
+	FTPReply             int    `json:"ftp_reply,omitempty"`
+	CleartextCredentials bool   `json:"cleartext_credentials,omitempty"`
+
+	// Remote administration logon attempts (RDP connection request, WinRM)
+	AdminUser string `json:"admin_user,omitempty"` // Offered user name (RDP mstshash cookie, WinRM Basic/NTLM)
+	AdminAuth string `json:"admin_auth,omitempty"` // RDP security offered (nla, tls, rdp) or WinRM scheme (dpi.WinRMAuth*)
+
+	// SMTP message metadata, set on the segment completing the DATA phase
+	Mail *dpi.Mail `json:"mail,omitempty"`
 }
 
 // NewInspector creates a new inspector instance.
 func NewInspector(cfg *config.AppConfig, eventChan chan<- interface{}) *Inspector {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Inspector{
+	i := &Inspector{
 		config:    cfg,
 		eventChan: eventChan,
 		ctx:       ctx,
 		cancel:    cancel,
+		parse:     NewParseCounters(),
+
+		captureDone: make(chan struct{}),
+	}
+	i.listInterfaces = listPcapInterfaces
+	i.openSource = i.openLive
+	if cfg.ReplayFile != "" {
+		i.openSource = i.openReplay
 	}
+	return i
 }
 
-// Start begins capturing on configured interfaces.
+// Start begins capturing on configured interfaces, or on the replay file
+// if one is configured. It fails with ErrNoInterfaces rather than idling
+// when there is nothing to capture on.
 func (i *Inspector) Start() error {
-	devices, err := pcap.FindAllDevs()
+	ifaces, err := i.selectInterfaces()
+	if err != nil {
+		return err
+	}
+
+	// Past the limit, each capture loop reads several interfaces in turn
+	loops := len(ifaces)
+	i.readTimeout = i.config.ReadTimeout
+	if limit := i.config.MaxCaptureInterfaces; limit > 0 && limit < len(ifaces) {
+		log.Printf("[Inspector] Capturing %d interfaces on %d capture routines", len(ifaces), limit)
+		loops = limit
+		if i.readTimeout <= 0 || i.readTimeout > muxReadTimeout {
+			i.readTimeout = muxReadTimeout
+		}
+	}
+
+	workers := i.config.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	queueSize := i.config.QueueSize
+	if queueSize <= 0 {
+		queueSize = 4096
+	}
+	i.queues = make([]*FairQueue, workers)
+	for w := range i.queues {
+		i.queues[w] = NewFairQueue(len(ifaces), queueSize)
+	}
+	i.interfaces.Store(int32(len(ifaces)))
+
+	if rate := i.config.FlowSampleRate; rate > 0 && rate < 1 {
+		i.sampler = NewFlowSampler(rate, uint64(i.config.FlowSampleSeed))
+		log.Printf("[Inspector] Sampling %.1f%% of flows", rate*100)
+	}
+
+	if size := i.config.FlowTableSize; size > 0 {
+		i.flows = NewFlowTable(size, i.config.FlowIdleTimeout)
+		i.flows.OnPressure = func(s FlowStats) {
+			log.Printf("[Inspector] Warning: flow table full (%d flows), evicting live flows", s.Capacity)
+			if i.OnFlowPressure != nil {
+				i.OnFlowPressure(s)
+			}
+		}
+		i.wg.Add(1)
+		go i.expireFlows()
+	}
+
+	if window := i.config.DNSDedupWindow; window > 0 {
+		i.dns = NewDNSDeduper(window)
+		i.wg.Add(1)
+		go i.expireDNS(window)
+	}
+
+	if i.config.FirstPacketOnly {
+		i.first = NewFirstPacketFilter(i.config.FlowTableSize, i.config.FlowIdleTimeout)
+		log.Println("[Inspector] First-packet DPI mode: one event per connection")
+		i.wg.Add(1)
+		go i.expireFirstPackets()
+	}
+
+	if i.config.FTPParsing {
+		i.ftp = NewFTPParser()
+	}
+	if i.config.SMTPParsing {
+		i.smtp = NewSMTPParser()
+	}
+
+	for _, q := range i.queues {
+		i.wg.Add(1)
+		go i.worker(q)
+	}
+
+	for l := 0; l < loops; l++ {
+		var members []captureIface
+		for idx := l; idx < len(ifaces); idx += loops {
+			members = append(members, captureIface{idx: idx, name: ifaces[idx]})
+		}
+		i.wg.Add(1)
+		i.captures.Add(1)
+		go i.captureLoop(members)
+	}
+	go func() {
+		i.captures.Wait()
+		close(i.captureDone)
+	}()
+
+	return nil
+}
+
+// selectInterfaces returns the capture sources: the replay file, or the
+// listed interfaces matching the config.
+func (i *Inspector) selectInterfaces() ([]string, error) {
+	if file := i.config.ReplayFile; file != "" {
+		// Fail here rather than in the capture loop, which only logs
+		src, err := i.openSource(file)
+		if err != nil {
+			return nil, fmt.Errorf("replay file: %w", err)
+		}
+		src.Close()
+		return []string{file}, nil
+	}
+
+	names, err := i.listInterfaces()
 	if err != nil {
-		return fmt.Errorf("failed to list interfaces: %w", err)
+		return nil, fmt.Errorf("failed to list interfaces: %w", err)
 	}
 
-	for _, device := range devices {
+	var ifaces []string
+	for _, name := range names {
 		// Filter interfaces logic
-		if i.shouldIgnoreInterface(device.Name) {
+		if i.shouldIgnoreInterface(name) {
 			continue
 		}
 
 		// Only capture on specific interface if config demands
 		if i.config.Interface != "all" && i.config.Interface != "any" {
-			if !strings.Contains(i.config.Interface, device.Name) {
+			if !strings.Contains(i.config.Interface, name) {
 				continue
 			}
 		}
+		ifaces = append(ifaces, name)
+	}
 
-		i.wg.Add(1)
-		go i.captureLoop(device.Name)
+	if len(ifaces) == 0 {
+		return nil, fmt.Errorf("%w: none of the %d listed interfaces match %q (loopback and docker interfaces are skipped)",
+			ErrNoInterfaces, len(names), i.config.Interface)
 	}
+	return ifaces, nil
+}
 
-	return nil
+// CaptureDone is closed once every capture source has ended, e.g. when the
+// replay file is fully read.
+func (i *Inspector) CaptureDone() <-chan struct{} {
+	return i.captureDone
+}
+
+// Stats returns the current pipeline counters. It is safe to call
+// concurrently with capture.
+func (i *Inspector) Stats() Stats {
+	s := Stats{
+		Interfaces: int(i.interfaces.Load()),
+		Packets:    i.packets.Load(),
+		Events:     i.events.Load(),
+		EventDrops: i.eventDrops.Load(),
+		SampledOut: i.sampledOut.Load(),
+		Paused:     i.paused.Load(),
+		PausedOut:  i.pausedOut.Load(),
+		FirstOut:   i.firstOut.Load(),
+	}
+	s.ParseErrors, s.PartialParses = i.parse.Snapshot()
+	for _, q := range i.queues {
+		for _, d := range q.Dropped() {
+			s.QueueDrops += d
+		}
+		s.QueueDepth += q.Len()
+	}
+	if i.flows != nil {
+		s.Flows = i.flows.Stats()
+	}
+	return s
+}
+
+// Pause stops forwarding captured packets to the workers, e.g. for
+// maintenance or load shedding. Capture handles stay open and keep being
+// read (so kernel buffers do not overflow), but packets are discarded.
+// Flow, DNS and parser state is kept for Resume.
+func (i *Inspector) Pause() {
+	if !i.paused.Swap(true) {
+		log.Println("[Inspector] Capture paused")
+	}
+}
+
+// Resume continues processing after Pause.
+func (i *Inspector) Resume() {
+	if i.paused.Swap(false) {
+		log.Println("[Inspector] Capture resumed")
+	}
+}
+
+// Paused reports whether capture is paused.
+func (i *Inspector) Paused() bool {
+	return i.paused.Load()
 }
 
 // Stop halts all capture routines.
 func (i *Inspector) Stop() {
 	i.cancel()
+	for _, q := range i.queues {
+		q.Close()
+	}
 	i.wg.Wait()
+
+	// Workers are done, release the DNS queries still held
+	if i.dns != nil {
+		for _, evt := range i.dns.Flush() {
+			i.emit(evt)
+		}
+	}
 }
 
 func (i *Inspector) shouldIgnoreInterface(name string) bool {
@@ -91,103 +379,285 @@ func (i *Inspector) shouldIgnoreInterface(name string) bool {
 	return false
 }
 
-func (i *Inspector) captureLoop(iface string) {
-	defer i.wg.Done()
-	log.Printf("[Inspector] Starting capture on %s", iface)
+func listPcapInterfaces() ([]string, error) {
+	devices, err := pcap.FindAllDevs()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(devices))
+	for idx, d := range devices {
+		names[idx] = d.Name
+	}
+	return names, nil
+}
 
+func (i *Inspector) openLive(iface string) (PacketSource, error) {
 	// Use configured timeout instead of BlockForever to prevent CPU spinning
-	handle, err := pcap.OpenLive(iface, i.config.SnapLen, i.config.PromiscuousMode, i.config.ReadTimeout)
+	handle, err := pcap.OpenLive(iface, i.config.SnapLen, i.config.PromiscuousMode, i.readTimeout)
 	if err != nil {
-		log.Printf("[Inspector] Error opening %s: %v", iface, err)
-		return
+		return nil, err
 	}
-	defer handle.Close()
 
 	// Note: SetBufferSize is not available in all gopacket/pcap versions
 	// Buffer tuning would require using pcap.InactiveHandle.SetBufferSize before activation
 	// For now, we rely on the timeout to prevent CPU spinning
 
-	if i.config.BPFFilter != "" {
-		if err := handle.SetBPFFilter(i.config.BPFFilter); err != nil {
+	filter, err := i.config.CaptureFilter(iface)
+	if err != nil {
+		handle.Close()
+		return nil, err
+	}
+	if filter != "" {
+		if err := handle.SetBPFFilter(filter); err != nil {
 			log.Printf("[Inspector] Failed to set BPF on %s: %v", iface, err)
 		}
 	}
+	return handle, nil
+}
+
+// captureIface is an interface read by a capture loop: its FairQueue
+// source index and name.
+type captureIface struct {
+	idx  int
+	name string
+}
+
+// captureHandle is an open capture source of a capture loop.
+type captureHandle struct {
+	captureIface
+	src PacketSource
+}
+
+// captureLoop reads the member interfaces in turn until every one has
+// ended or the inspector stops. A turn reads up to muxBurst packets and
+// ends early on a read timeout, so an idle interface costs the others at
+// most one read timeout per round.
+func (i *Inspector) captureLoop(members []captureIface) {
+	defer i.wg.Done()
+	defer i.captures.Done()
+
+	if i.config.PinThreads {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	var handles []captureHandle
+	defer func() {
+		for _, h := range handles {
+			h.src.Close()
+		}
+	}()
+	for _, m := range members {
+		log.Printf("[Inspector] Starting capture on %s", m.name)
+		src, err := i.openSource(m.name)
+		if err != nil {
+			log.Printf("[Inspector] Error opening %s: %v", m.name, err)
+			continue
+		}
+		handles = append(handles, captureHandle{captureIface: m, src: src})
+	}
+
+	for len(handles) > 0 {
+		for n := 0; n < len(handles); {
+			if i.ctx.Err() != nil {
+				return
+			}
+			if i.readBurst(handles[n]) {
+				n++
+				continue
+			}
+			log.Printf("[Inspector] Capture on %s ended", handles[n].name)
+			handles[n].src.Close()
+			handles = slices.Delete(handles, n, n+1)
+		}
+	}
+}
+
+// readBurst reads up to muxBurst packets from h. It returns false once the
+// source has ended.
+func (i *Inspector) readBurst(h captureHandle) bool {
+	for n := 0; n < muxBurst; n++ {
+		if i.ctx.Err() != nil {
+			return true
+		}
+		data, ci, err := h.src.ReadPacketData()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			return true // Read timeout, give the next interface its turn
+		}
+
+		i.packets.Add(1)
+		if i.paused.Load() {
+			i.pausedOut.Add(1)
+			continue
+		}
+
+		q := i.queues[flowShard(data, len(i.queues))]
+		p := packet{data: data, ts: ci.Timestamp, iface: h.name}
+		if i.config.ReplayFile != "" {
+			// A file can wait for the workers, nothing is lost by blocking
+			if !q.PushWait(h.idx, p) {
+				return true
+			}
+			continue
+		}
+		// Drops only this interface's packets if its queue is full
+		q.Push(h.idx, p)
+	}
+	return true
+}
+
+// worker decodes the packets queued in q and emits events.
+func (i *Inspector) worker(q *FairQueue) {
+	defer i.wg.Done()
+
+	if i.config.PinThreads {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	// Create the decoder once to reuse its layer parsers
+	decoder := NewDecoder()
+	decoder.FTP = i.ftp != nil
+	decoder.MACs = i.config.AssetDiscovery
+	decoder.Errors = i.parse
+	decoder.FirstPacket = i.first
+
+	for {
+		p, ok := q.Pop()
+		if !ok {
+			return
+		}
+
+		evt, hasIP := decoder.Decode(p.data, p.ts)
+		if !hasIP {
+			continue
+		}
+		evt.Interface = p.iface
+		i.clock.Observe(evt.Timestamp)
+
+		// Sample before any tracking, unsampled flows cost nothing further
+		if i.sampler != nil && !i.sampler.Keep(&evt) {
+			i.sampledOut.Add(1)
+			continue
+		}
+
+		if i.flows != nil {
+			i.flows.Track(&evt)
+		}
+
+		if i.Tap != nil {
+			select {
+			case i.Tap <- evt:
+			default:
+			}
+		}
+
+		// Flows are still accounted, the connection's other packets are not
+		if i.first != nil && !i.first.Admit(&evt) {
+			i.firstOut.Add(1)
+			continue
+		}
+
+		if i.ftp != nil && (evt.FTPCommand != "" || evt.FTPReply != 0) {
+			i.ftp.Track(&evt)
+		}
+
+		if i.smtp != nil && IsSMTP(&evt) {
+			mails := i.smtp.Feed(&evt, decoder.Payload())
+			for n, mail := range mails {
+				if n == 0 {
+					evt.Mail = mail
+					continue
+				}
+				extra := evt
+				extra.Mail = mail
+				i.emit(extra)
+			}
+		}
+
+		if i.dns != nil && evt.DNSQuery != "" {
+			if out, ok := i.dns.Add(evt); ok {
+				i.emit(out)
+			}
+			continue
+		}
+		i.emit(evt)
+	}
+}
 
-	// Create layer parsers once to reuse
-	var eth layers.Ethernet
-	var ip4 layers.IPv4
-	var ip6 layers.IPv6
-	var tcp layers.TCP
-	var udp layers.UDP
-	var payload gopacket.Payload
+// emit hands an event downstream without blocking the caller.
+func (i *Inspector) emit(evt NetworkEvent) {
+	select {
+	case i.eventChan <- evt:
+		i.events.Add(1)
+	default:
+		// Drop if channel full
+		i.eventDrops.Add(1)
+	}
+}
 
-	parser := gopacket.NewDecodingLayerParser(
-		layers.LayerTypeEthernet,
-		&eth, &ip4, &ip6, &tcp, &udp, &payload,
-	)
+// expireFlows drops idle flows from the flow table.
+func (i *Inspector) expireFlows() {
+	defer i.wg.Done()
 
-	decoded := []gopacket.LayerType{}
+	clock := clockReader{clock: &i.clock}
+	ticker := time.NewTicker(max(i.config.FlowIdleTimeout/4, time.Second))
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-i.ctx.Done():
 			return
-		default:
-			// Read packet
-			data, _, err := handle.ReadPacketData()
-			if err != nil {
-				continue
+		case wall := <-ticker.C:
+			if now, ok := clock.now(wall); ok {
+				i.flows.Expire(now)
 			}
+		}
+	}
+}
 
-			err = parser.DecodeLayers(data, &decoded)
-			if err != nil {
-				// Continue even if full decode fails, as long as we got some layers
-			}
+// expireFirstPackets forgets idle connections of the first-packet filter.
+func (i *Inspector) expireFirstPackets() {
+	defer i.wg.Done()
 
-			// Process Decoded Layers
-			evt := NetworkEvent{Timestamp: time.Now()}
-			hasIP := false
-
-			for _, layerType := range decoded {
-				switch layerType {
-				case layers.LayerTypeIPv4:
-					evt.SrcIP = ip4.SrcIP.String()
-					evt.DstIP = ip4.DstIP.String()
-					evt.Protocol = ip4.Protocol.String()
-					hasIP = true
-				case layers.LayerTypeIPv6:
-					evt.SrcIP = ip6.SrcIP.String()
-					evt.DstIP = ip6.DstIP.String()
-					evt.Protocol = ip6.NextHeader.String()
-					hasIP = true
-				case layers.LayerTypeTCP:
-					evt.SrcPort = uint16(tcp.SrcPort)
-					evt.DstPort = uint16(tcp.DstPort)
-					evt.PayloadSize = len(tcp.Payload)
-
-					// DPI Checks
-					if len(tcp.Payload) > 0 {
-						if sni, ok := dpi.ParseTLSClientHello(tcp.Payload); ok {
-							evt.SNI = sni.ServerName
-						} else if http, ok := dpi.ParseHTTPRequest(tcp.Payload); ok {
-							evt.HTTPHost = http.Host
-						}
-					}
-				case layers.LayerTypeUDP:
-					evt.SrcPort = uint16(udp.SrcPort)
-					evt.DstPort = uint16(udp.DstPort)
-					evt.PayloadSize = len(udp.Payload)
-				}
+	clock := clockReader{clock: &i.clock}
+	ticker := time.NewTicker(max(i.config.FlowIdleTimeout/4, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case wall := <-ticker.C:
+			if now, ok := clock.now(wall); ok {
+				i.first.Expire(now)
 			}
+		}
+	}
+}
 
-			if hasIP {
-				// If ports are 0 (e.g. ICMP), they stay 0 which is fine
-				// Non-blocking send to avoid stalling capture loop
-				select {
-				case i.eventChan <- evt:
-				default:
-					// Drop if channel full
-				}
+// expireDNS emits collapsed DNS queries once their window has closed.
+func (i *Inspector) expireDNS(window time.Duration) {
+	defer i.wg.Done()
+
+	clock := clockReader{clock: &i.clock}
+	ticker := time.NewTicker(max(window/2, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-i.ctx.Done():
+			return
+		case wall := <-ticker.C:
+			now, ok := clock.now(wall)
+			if !ok {
+				continue
+			}
+			for _, evt := range i.dns.Expire(now) {
+				i.emit(evt)
 			}
 		}
 	}
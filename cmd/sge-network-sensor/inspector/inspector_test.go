@@ -0,0 +1,438 @@
+package inspector
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+)
+
+// udpFrame builds a minimal Ethernet/IPv4/UDP frame.
+func udpFrame(t *testing.T) []byte {
+	t.Helper()
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.IP{10, 0, 0, 1}, DstIP: net.IP{10, 0, 0, 2}}
+	udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, udp, gopacket.Payload("query")); err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// liveSource delivers a frame on every read until closed, like a busy
+// live interface; with idle set it only ever times out, like a quiet one.
+type liveSource struct {
+	frame []byte
+	idle  bool
+	rec   *captureRecorder
+}
+
+func (s *liveSource) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	s.rec.enter()
+	defer s.rec.active.Add(-1)
+
+	time.Sleep(50 * time.Microsecond)
+	if s.idle {
+		return nil, gopacket.CaptureInfo{}, errors.New("timeout")
+	}
+	return s.frame, gopacket.CaptureInfo{Timestamp: time.Now()}, nil
+}
+
+func (s *liveSource) Close() {}
+
+// captureRecorder tracks how many reads are in progress at once.
+type captureRecorder struct {
+	active    atomic.Int32
+	maxActive atomic.Int32
+}
+
+func (r *captureRecorder) enter() {
+	n := r.active.Add(1)
+	for {
+		m := r.maxActive.Load()
+		if n <= m || r.maxActive.CompareAndSwap(m, n) {
+			return
+		}
+	}
+}
+
+func TestCaptureConcurrencyLimit(t *testing.T) {
+	ifaces := []string{"eth0", "eth1", "eth2", "eth3", "eth4", "eth5"}
+	const perIfaceWant = 50
+
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int32
+	}{
+		{"Limited To Two", 2, 2},
+		{"Unlimited", 0, int32(len(ifaces) + 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &captureRecorder{}
+			frame := udpFrame(t)
+			cfg := &config.AppConfig{Interface: "any", MaxCaptureInterfaces: tt.limit, Workers: 2, QueueSize: 1024}
+			events := make(chan interface{}, 1024)
+			insp := NewInspector(cfg, events)
+			insp.listInterfaces = func() ([]string, error) { return append(ifaces, "idle0"), nil }
+			insp.openSource = func(iface string) (PacketSource, error) {
+				return &liveSource{frame: frame, idle: iface == "idle0", rec: rec}, nil
+			}
+			if err := insp.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			// Every interface keeps being captured, the idle one included
+			perIface := make(map[string]int)
+			deadline := time.After(5 * time.Second)
+			for served := 0; served < len(ifaces); {
+				select {
+				case e := <-events:
+					iface := e.(NetworkEvent).Interface
+					if perIface[iface]++; perIface[iface] == perIfaceWant {
+						served++
+					}
+				case <-deadline:
+					t.Fatalf("events per interface = %v, want %d from each of %v", perIface, perIfaceWant, ifaces)
+				}
+			}
+			insp.Stop()
+
+			st := insp.Stats()
+			if st.Interfaces != len(ifaces)+1 || st.QueueDepth != 0 {
+				t.Errorf("Stats() = %+v, want %d interfaces and an empty queue", st, len(ifaces)+1)
+			}
+			// The frames carry a 5 byte payload to port 53, a truncated DNS header
+			if st.ParseErrors["dns"]+st.QueueDrops != st.Packets || st.PartialParses != 0 {
+				t.Errorf("Stats() parse errors = %v (%d partial), want one dns error per decoded packet", st.ParseErrors, st.PartialParses)
+			}
+
+			if got := rec.maxActive.Load(); got > tt.wantLimit {
+				t.Errorf("max concurrent reads = %d, want <= %d", got, tt.wantLimit)
+			}
+			if tt.limit == 0 && rec.maxActive.Load() < 2 {
+				t.Errorf("max concurrent reads = %d, want parallel capture", rec.maxActive.Load())
+			}
+		})
+	}
+}
+
+func TestFairQueueRoundRobin(t *testing.T) {
+	q := NewFairQueue(3, 100)
+
+	// A busy source 0 and two quiet ones
+	for n := 0; n < 50; n++ {
+		q.Push(0, packet{iface: "busy"})
+	}
+	q.Push(1, packet{iface: "quiet1"})
+	q.Push(2, packet{iface: "quiet2"})
+
+	var order []string
+	for n := 0; n < 3; n++ {
+		p, _ := q.Pop()
+		order = append(order, p.iface)
+	}
+	if order[0] != "busy" || order[1] != "quiet1" || order[2] != "quiet2" {
+		t.Errorf("Pop() order = %v, want busy, quiet1, quiet2", order)
+	}
+}
+
+func TestFairQueueDropsPerSource(t *testing.T) {
+	q := NewFairQueue(2, 2)
+
+	for n := 0; n < 5; n++ {
+		q.Push(0, packet{})
+	}
+	if !q.Push(1, packet{}) {
+		t.Error("Push() to quiet source rejected while busy source is full")
+	}
+
+	if d := q.Dropped(); d[0] != 3 || d[1] != 0 {
+		t.Errorf("Dropped() = %v, want [3 0]", d)
+	}
+	if n := q.Len(); n != 3 {
+		t.Errorf("Len() = %d, want 3", n)
+	}
+}
+
+func TestFairQueueCloseDrains(t *testing.T) {
+	q := NewFairQueue(1, 10)
+	q.Push(0, packet{iface: "a"})
+
+	var wg sync.WaitGroup
+	var got []string
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			p, ok := q.Pop()
+			if !ok {
+				return
+			}
+			got = append(got, p.iface)
+		}
+	}()
+
+	q.Close()
+	wg.Wait()
+	if len(got) != 1 {
+		t.Errorf("drained %v, want [a]", got)
+	}
+}
+
+func TestFairQueuePushWait(t *testing.T) {
+	q := NewFairQueue(1, 1)
+	q.Push(0, packet{iface: "a"})
+
+	pushed := make(chan bool)
+	go func() { pushed <- q.PushWait(0, packet{iface: "b"}) }()
+	select {
+	case <-pushed:
+		t.Fatal("PushWait() returned while the queue was full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if p, _ := q.Pop(); p.iface != "a" {
+		t.Errorf("Pop() = %q, want a", p.iface)
+	}
+	if ok := <-pushed; !ok {
+		t.Error("PushWait() = false after room was made")
+	}
+	if d := q.Dropped(); d[0] != 0 {
+		t.Errorf("Dropped() = %v, want [0]", d)
+	}
+
+	go func() { pushed <- q.PushWait(0, packet{iface: "c"}) }()
+	q.Close()
+	if ok := <-pushed; ok {
+		t.Error("PushWait() = true on a closed, full queue")
+	}
+}
+
+// chanSource delivers frames sent on a channel, like a live capture with a
+// read timeout. Closing the channel ends the capture.
+type chanSource struct {
+	frames chan []byte
+}
+
+func (s *chanSource) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	select {
+	case f, ok := <-s.frames:
+		if !ok {
+			return nil, gopacket.CaptureInfo{}, io.EOF
+		}
+		return f, gopacket.CaptureInfo{Timestamp: time.Now()}, nil
+	case <-time.After(5 * time.Millisecond):
+		return nil, gopacket.CaptureInfo{}, errors.New("timeout")
+	}
+}
+
+func (s *chanSource) Close() {}
+
+func TestPauseResume(t *testing.T) {
+	src := &chanSource{frames: make(chan []byte)}
+	events := make(chan interface{}, 100)
+	cfg := &config.AppConfig{Interface: "any", Workers: 1, QueueSize: 64, FlowTableSize: 100, FlowIdleTimeout: time.Hour}
+	insp := NewInspector(cfg, events)
+	insp.listInterfaces = func() ([]string, error) { return []string{"eth0"}, nil }
+	insp.openSource = func(string) (PacketSource, error) { return src, nil }
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer insp.Stop()
+
+	frame := tcpFrame(t, net.IP{10, 0, 0, 5}, net.IP{10, 0, 0, 80}, 50000, 80, "x")
+	receive := func(n int) {
+		t.Helper()
+		for k := 0; k < n; k++ {
+			select {
+			case <-events:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("received %d events, want %d", k, n)
+			}
+		}
+	}
+
+	for k := 0; k < 3; k++ {
+		src.frames <- frame
+	}
+	receive(3)
+
+	insp.Pause()
+	if !insp.Paused() || !insp.Stats().Paused {
+		t.Fatal("Paused() = false after Pause()")
+	}
+	for k := 0; k < 5; k++ {
+		src.frames <- frame
+	}
+	select {
+	case <-events:
+		t.Fatal("event produced while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if st := insp.Stats(); st.PausedOut != 5 || st.Events != 3 || st.Packets != 8 {
+		t.Errorf("Stats() = %d paused out, %d events, %d packets, want 5, 3, 8", st.PausedOut, st.Events, st.Packets)
+	}
+
+	insp.Resume()
+	src.frames <- frame
+	receive(1)
+	if insp.Paused() {
+		t.Error("Paused() = true after Resume()")
+	}
+
+	// The flow survived the pause and continued counting
+	evt, _ := NewDecoder().Decode(frame, time.Now())
+	insp.flows.mu.Lock()
+	el, ok := insp.flows.flows[KeyOf(&evt)]
+	var packets uint64
+	if ok {
+		packets = el.Value.(*Flow).Packets
+	}
+	insp.flows.mu.Unlock()
+	if !ok || packets != 4 {
+		t.Errorf("flow packets = %d (tracked %v), want 4", packets, ok)
+	}
+}
+
+func TestStartWithoutInterfaces(t *testing.T) {
+	tests := []struct {
+		name   string
+		iface  string
+		listed []string
+	}{
+		{"None Listed", "any", nil},
+		{"Only Ignored", "any", []string{"docker0", "Loopback Pseudo-Interface"}},
+		{"None Matching", "eth9", []string{"eth0", "eth1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insp := NewInspector(&config.AppConfig{Interface: tt.iface, Workers: 1}, make(chan interface{}, 1))
+			insp.listInterfaces = func() ([]string, error) { return tt.listed, nil }
+			insp.openSource = func(string) (PacketSource, error) {
+				t.Error("openSource called without interfaces")
+				return nil, io.EOF
+			}
+
+			err := insp.Start()
+			if !errors.Is(err, ErrNoInterfaces) {
+				t.Fatalf("Start() error = %v, want ErrNoInterfaces", err)
+			}
+			if !strings.Contains(err.Error(), tt.iface) {
+				t.Errorf("Start() error = %q, want it to name the configured interface %q", err, tt.iface)
+			}
+			if got := insp.Stats().Interfaces; got != 0 {
+				t.Errorf("Stats().Interfaces = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestReplayFile(t *testing.T) {
+	const file = "../analyzer/testdata/sample.pcap"
+	events := make(chan interface{}, 10000)
+	insp := NewInspector(&config.AppConfig{Interface: "any", ReplayFile: file, Workers: 2, QueueSize: 10000}, events)
+	insp.listInterfaces = func() ([]string, error) {
+		t.Error("listInterfaces called with a replay file")
+		return nil, nil
+	}
+
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	select {
+	case <-insp.CaptureDone():
+	case <-time.After(5 * time.Second):
+		t.Fatal("CaptureDone() not closed after the replay file was read")
+	}
+	insp.Stop()
+
+	if s := insp.Stats(); s.Interfaces != 1 || s.Packets == 0 {
+		t.Fatalf("Stats() = %+v, want one source with packets", s)
+	}
+	if len(events) == 0 {
+		t.Fatal("no events from the replay file")
+	}
+	if evt := (<-events).(NetworkEvent); evt.Interface != file {
+		t.Errorf("Interface = %q, want %q", evt.Interface, file)
+	}
+}
+
+func TestReplayFileWaitsForWorkers(t *testing.T) {
+	const file = "../analyzer/testdata/sample.pcap"
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	src, err := NewCaptureReader(f)
+	if err != nil {
+		t.Fatalf("NewCaptureReader() error = %v", err)
+	}
+	var want uint64
+	for {
+		if _, _, err := src.ReadPacketData(); err != nil {
+			break
+		}
+		want++
+	}
+
+	// A one-packet queue overflows at once if the replay drops
+	insp := NewInspector(&config.AppConfig{Interface: "any", ReplayFile: file, Workers: 1, QueueSize: 1}, make(chan interface{}, 10000))
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	select {
+	case <-insp.CaptureDone():
+	case <-time.After(5 * time.Second):
+		t.Fatal("CaptureDone() not closed after the replay file was read")
+	}
+	insp.Stop()
+
+	if s := insp.Stats(); s.Packets != want || s.QueueDrops != 0 {
+		t.Errorf("Stats() = %d packets, %d queue drops, want %d packets and no drops", s.Packets, s.QueueDrops, want)
+	}
+}
+
+func TestReplayFileMissing(t *testing.T) {
+	insp := NewInspector(&config.AppConfig{Interface: "any", ReplayFile: "testdata/missing.pcap", Workers: 1}, make(chan interface{}, 1))
+	if err := insp.Start(); err == nil || errors.Is(err, ErrNoInterfaces) {
+		t.Errorf("Start() error = %v, want a replay file error", err)
+	}
+}
+
+func TestFlowShardSymmetric(t *testing.T) {
+	a, b := net.IP{10, 0, 0, 5}, net.IP{10, 0, 0, 25}
+	seen := make(map[int]bool)
+	for port := uint16(40000); port < 40064; port++ {
+		out := flowShard(tcpFrame(t, a, b, port, 25, "EHLO"), 8)
+		back := flowShard(tcpFrame(t, b, a, 25, port, "250 OK"), 8)
+		if out != back {
+			t.Fatalf("port %d: shard %d one way, %d back", port, out, back)
+		}
+		seen[out] = true
+	}
+	if len(seen) < 4 {
+		t.Errorf("64 flows spread over %d of 8 workers", len(seen))
+	}
+	if got := flowShard([]byte{1, 2, 3}, 8); got != 0 {
+		t.Errorf("flowShard(runt) = %d, want 0", got)
+	}
+}
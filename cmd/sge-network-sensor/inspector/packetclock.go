@@ -0,0 +1,56 @@
+package inspector
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// packetClock is the latest packet timestamp seen by the workers. Flow,
+// first-packet and DNS state is stamped with packet timestamps, so it is
+// expired against this clock rather than the wall clock: a replayed
+// capture ages by its own timestamps, not by how fast it is read.
+type packetClock struct {
+	latest atomic.Int64 // UnixNano, 0 until the first packet
+}
+
+// Observe advances the clock to ts if ts is later. Safe for concurrent use.
+func (c *packetClock) Observe(ts time.Time) {
+	n := ts.UnixNano()
+	for {
+		cur := c.latest.Load()
+		if n <= cur || c.latest.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+// Latest returns the latest observed timestamp, false before any packet.
+func (c *packetClock) Latest() (time.Time, bool) {
+	n := c.latest.Load()
+	if n == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n), true
+}
+
+// clockReader reads a packetClock for one expiry loop. While no newer
+// packet arrives the time it reports keeps moving at wall speed, so
+// state on a link that went idle still expires.
+type clockReader struct {
+	clock *packetClock
+	last  time.Time // Latest packet time seen
+	at    time.Time // Wall time last changed
+}
+
+// now returns the packet time at wall time wall, false before any packet.
+func (r *clockReader) now(wall time.Time) (time.Time, bool) {
+	latest, ok := r.clock.Latest()
+	if !ok {
+		return time.Time{}, false
+	}
+	if !latest.Equal(r.last) {
+		r.last, r.at = latest, wall
+		return latest, true
+	}
+	return latest.Add(wall.Sub(r.at)), true
+}
@@ -0,0 +1,62 @@
+package inspector
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPacketClockKeepsLatest(t *testing.T) {
+	var c packetClock
+	if _, ok := c.Latest(); ok {
+		t.Fatal("Latest() ok before any packet")
+	}
+
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for n := 0; n < 1000; n++ {
+				c.Observe(base.Add(time.Duration(n*4+w) * time.Millisecond))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Workers race, an out of order packet does not move the clock back
+	c.Observe(base)
+	want := base.Add(3999 * time.Millisecond)
+	if got, _ := c.Latest(); !got.Equal(want) {
+		t.Errorf("Latest() = %v, want %v", got, want)
+	}
+}
+
+func TestClockReaderFollowsPackets(t *testing.T) {
+	var c packetClock
+	r := clockReader{clock: &c}
+	wall := time.Now()
+	if _, ok := r.now(wall); ok {
+		t.Fatal("now() ok before any packet")
+	}
+
+	// A replayed capture years in the past
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Observe(base)
+	if got, _ := r.now(wall); !got.Equal(base) {
+		t.Errorf("now() = %v, want the packet time %v", got, base)
+	}
+
+	c.Observe(base.Add(time.Minute))
+	wall = wall.Add(time.Second)
+	if got, _ := r.now(wall); !got.Equal(base.Add(time.Minute)) {
+		t.Errorf("now() = %v, want %v", got, base.Add(time.Minute))
+	}
+
+	// The link goes idle: time moves on at wall speed
+	wall = wall.Add(30 * time.Second)
+	if got, _ := r.now(wall); !got.Equal(base.Add(90 * time.Second)) {
+		t.Errorf("now() on an idle link = %v, want %v", got, base.Add(90*time.Second))
+	}
+}
@@ -0,0 +1,49 @@
+package inspector
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+)
+
+// ParseCounters counts DPI parse errors per protocol. It is safe for
+// concurrent use, so workers can share one.
+type ParseCounters struct {
+	errors  map[string]*atomic.Uint64 // Fixed set of dpi.Proto* keys
+	partial atomic.Uint64
+}
+
+// NewParseCounters creates zeroed counters for every DPI protocol.
+func NewParseCounters() *ParseCounters {
+	c := &ParseCounters{errors: make(map[string]*atomic.Uint64)}
+	for _, proto := range []string{dpi.ProtoTLS, dpi.ProtoHTTP, dpi.ProtoDNS, dpi.ProtoFTP} {
+		c.errors[proto] = new(atomic.Uint64)
+	}
+	return c
+}
+
+// Record counts err, a parser error; partial reports whether the parser
+// still returned a packet.
+func (c *ParseCounters) Record(partial bool, err error) {
+	var pe *dpi.ParseError
+	if !errors.As(err, &pe) {
+		return
+	}
+	if n, ok := c.errors[pe.Protocol]; ok {
+		n.Add(1)
+	}
+	if partial {
+		c.partial.Add(1)
+	}
+}
+
+// Snapshot returns the errors per protocol and the number of partial
+// parses among them.
+func (c *ParseCounters) Snapshot() (map[string]uint64, uint64) {
+	out := make(map[string]uint64, len(c.errors))
+	for proto, n := range c.errors {
+		out[proto] = n.Load()
+	}
+	return out, c.partial.Load()
+}
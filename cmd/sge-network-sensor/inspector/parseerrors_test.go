@@ -0,0 +1,56 @@
+package inspector
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDecoderParseErrors(t *testing.T) {
+	client, server := net.IP{10, 0, 0, 5}, net.IP{10, 0, 0, 80}
+	frames := []struct {
+		port    uint16
+		payload string
+	}{
+		{80, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"}, // ok
+		{80, "GET / HTTP/1.1\r\nHost: exam"},                // partial
+		{80, "GET / HTTP/1.1\r\n\x00\x01"},                  // malformed
+		{443, "\x16\x03\x01\x02\x00\x01"},                   // truncated ClientHello
+		{21, "RETR a\x01b\r\n"},                             // partial
+		{21, "EHLO mail.example.com\r\n"},                   // not FTP
+		{8080, "random bytes"},                              // not applicable
+	}
+
+	counters := NewParseCounters()
+	decoder := NewDecoder()
+	decoder.FTP = true
+	decoder.Errors = counters
+
+	var hosts []string
+	for _, f := range frames {
+		evt, ok := decoder.Decode(tcpFrame(t, client, server, 51000, f.port, f.payload), time.Now())
+		if !ok {
+			t.Fatalf("Decode(%q) ok = false", f.payload)
+		}
+		if evt.HTTPHost != "" {
+			hosts = append(hosts, evt.HTTPHost)
+		}
+		if f.port == 21 && f.payload[0] == 'R' && evt.FTPCommand != "RETR" {
+			t.Errorf("partial FTP command = %q, want RETR", evt.FTPCommand)
+		}
+	}
+
+	errs, partial := counters.Snapshot()
+	want := map[string]uint64{"http": 2, "tls": 1, "ftp": 1, "dns": 0}
+	for proto, n := range want {
+		if errs[proto] != n {
+			t.Errorf("%s errors = %d, want %d", proto, errs[proto], n)
+		}
+	}
+	if partial != 2 {
+		t.Errorf("partial parses = %d, want 2", partial)
+	}
+	if len(hosts) != 1 || hosts[0] != "example.com" {
+		t.Errorf("hosts = %v, want [example.com]", hosts)
+	}
+}
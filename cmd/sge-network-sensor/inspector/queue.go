@@ -0,0 +1,130 @@
+package inspector
+
+import (
+	"sync"
+	"time"
+)
+
+// packet is a raw frame waiting to be decoded by a worker.
+type packet struct {
+	data  []byte
+	ts    time.Time
+	iface string
+}
+
+// FairQueue holds one bounded queue per interface and hands packets to
+// workers round-robin, so a busy interface only overflows its own queue
+// and cannot starve the others.
+type FairQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // Signalled when a packet is queued
+	space   *sync.Cond // Signalled when a packet is taken
+	queues  [][]packet
+	cap     int
+	next    int
+	closed  bool
+	dropped []uint64
+}
+
+// NewFairQueue creates a queue for n sources holding up to capacity packets each.
+func NewFairQueue(n, capacity int) *FairQueue {
+	q := &FairQueue{
+		queues:  make([][]packet, n),
+		cap:     capacity,
+		dropped: make([]uint64, n),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	q.space = sync.NewCond(&q.mu)
+	return q
+}
+
+// Push enqueues p for source src. It returns false (and counts a drop)
+// if that source's queue is full or the queue is closed.
+func (q *FairQueue) Push(src int, p packet) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || len(q.queues[src]) >= q.cap {
+		q.dropped[src]++
+		return false
+	}
+	q.queues[src] = append(q.queues[src], p)
+	q.cond.Signal()
+	return true
+}
+
+// PushWait enqueues p for source src, waiting for room instead of
+// dropping when that source's queue is full. It is used for file sources,
+// which have no packets to lose by waiting. It returns false if the queue
+// is closed.
+func (q *FairQueue) PushWait(src int, p packet) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && len(q.queues[src]) >= q.cap {
+		q.space.Wait()
+	}
+	if q.closed {
+		return false
+	}
+	q.queues[src] = append(q.queues[src], p)
+	q.cond.Signal()
+	return true
+}
+
+// Pop blocks until a packet is available, taking from the sources in
+// rotation. It returns false once the queue is closed and drained.
+func (q *FairQueue) Pop() (packet, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		for i := 0; i < len(q.queues); i++ {
+			src := (q.next + i) % len(q.queues)
+			if len(q.queues[src]) == 0 {
+				continue
+			}
+
+			p := q.queues[src][0]
+			q.queues[src][0] = packet{}
+			q.queues[src] = q.queues[src][1:]
+			q.next = (src + 1) % len(q.queues)
+			q.space.Broadcast()
+			return p, true
+		}
+
+		if q.closed {
+			return packet{}, false
+		}
+		q.cond.Wait()
+	}
+}
+
+// Close wakes all workers and waiting pushers; remaining packets are
+// still delivered.
+func (q *FairQueue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+	q.space.Broadcast()
+}
+
+// Dropped returns the number of packets dropped per source.
+func (q *FairQueue) Dropped() []uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]uint64(nil), q.dropped...)
+}
+
+// Len returns the number of packets currently queued across all sources.
+func (q *FairQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, sq := range q.queues {
+		n += len(sq)
+	}
+	return n
+}
@@ -0,0 +1,67 @@
+package inspector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// pcapngMagic is the section header block type that starts every pcapng file.
+const pcapngMagic = 0x0A0D0D0A
+
+// NewCaptureReader reads a pcap or pcapng capture from r. Only Ethernet
+// captures are accepted, as those are the only frames the decoder handles.
+func NewCaptureReader(r io.Reader) (gopacket.PacketDataSource, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %w", err)
+	}
+
+	var src interface {
+		gopacket.PacketDataSource
+		LinkType() layers.LinkType
+	}
+	if binary.LittleEndian.Uint32(magic) == pcapngMagic {
+		src, err = pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions)
+	} else {
+		src, err = pcapgo.NewReader(br)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid capture file: %w", err)
+	}
+	if lt := src.LinkType(); lt != layers.LinkTypeEthernet {
+		return nil, fmt.Errorf("unsupported link type %s (only Ethernet captures are supported)", lt)
+	}
+	return src, nil
+}
+
+// fileSource reads packets from a pcap or pcapng file.
+type fileSource struct {
+	gopacket.PacketDataSource
+	f *os.File
+}
+
+func (s *fileSource) Close() { s.f.Close() }
+
+// openReplay opens the replay file. Capture filters are not applied: the
+// file is read without libpcap, as in `analyze`.
+func (i *Inspector) openReplay(path string) (PacketSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := NewCaptureReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileSource{PacketDataSource: src, f: f}, nil
+}
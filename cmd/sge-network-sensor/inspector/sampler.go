@@ -0,0 +1,67 @@
+package inspector
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// FlowSampler keeps a fixed fraction of flows and all packets of each kept
+// flow, so sampled traffic still contains complete flows. The decision is
+// a hash of the bidirectional 5-tuple: it needs no per-flow state, is the
+// same for both directions and for every worker and sensor sharing the seed.
+// It is safe for concurrent use.
+type FlowSampler struct {
+	ratio     float64
+	threshold uint64 // Flows hashing below this are kept
+	seed      uint64
+}
+
+// NewFlowSampler creates a sampler keeping ratio (0..1) of all flows.
+// Ratios >= 1 keep everything, <= 0 drop everything.
+func NewFlowSampler(ratio float64, seed uint64) *FlowSampler {
+	s := &FlowSampler{ratio: ratio, seed: seed}
+	switch {
+	case ratio >= 1:
+		s.threshold = math.MaxUint64
+	case ratio > 0:
+		s.threshold = uint64(ratio * math.MaxUint64)
+	}
+	return s
+}
+
+// Ratio returns the configured fraction of kept flows.
+func (s *FlowSampler) Ratio() float64 {
+	return s.ratio
+}
+
+// Keep reports whether the flow of evt is sampled.
+func (s *FlowSampler) Keep(evt *NetworkEvent) bool {
+	if s.threshold == math.MaxUint64 {
+		return true
+	}
+	return s.hash(KeyOf(evt)) < s.threshold
+}
+
+// hash is FNV-1a over the key, finished with a splitmix64 mix so the whole
+// 64-bit range is uniform (FNV alone is biased in its high bits for short,
+// similar inputs such as neighbouring ports).
+func (s *FlowSampler) hash(k FlowKey) uint64 {
+	h := fnv.New64a()
+	var buf [2]byte
+	h.Write([]byte(k.Protocol))
+	h.Write([]byte{0})
+	h.Write([]byte(k.AddrA))
+	buf[0], buf[1] = byte(k.PortA>>8), byte(k.PortA)
+	h.Write(buf[:])
+	h.Write([]byte(k.AddrB))
+	buf[0], buf[1] = byte(k.PortB>>8), byte(k.PortB)
+	h.Write(buf[:])
+
+	x := h.Sum64() ^ s.seed
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,60 @@
+package inspector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFlowSamplerKeepsWholeFlows(t *testing.T) {
+	s := NewFlowSampler(0.5, 0)
+	now := time.Now()
+
+	for n := 0; n < 200; n++ {
+		out := flowEvent(fmt.Sprintf("10.0.%d.%d", n/250, n%250), uint16(40000+n), now)
+		in := &NetworkEvent{SrcIP: out.DstIP, DstIP: out.SrcIP, SrcPort: out.DstPort, DstPort: out.SrcPort, Protocol: out.Protocol}
+		want := s.Keep(out)
+		for pkt := 0; pkt < 10; pkt++ {
+			if got := s.Keep(out); got != want {
+				t.Fatalf("flow %d packet %d: Keep() = %v, want %v", n, pkt, got, want)
+			}
+			if got := s.Keep(in); got != want {
+				t.Fatalf("flow %d reply %d: Keep() = %v, want %v", n, pkt, got, want)
+			}
+		}
+	}
+}
+
+func TestFlowSamplerRatio(t *testing.T) {
+	const flows = 20000
+	for _, ratio := range []float64{0, 0.01, 0.1, 0.25, 0.5, 0.9, 1} {
+		t.Run(fmt.Sprint(ratio), func(t *testing.T) {
+			s := NewFlowSampler(ratio, 7)
+			kept := 0
+			for n := 0; n < flows; n++ {
+				evt := flowEvent(fmt.Sprintf("10.%d.%d.%d", n/65536, (n/256)%256, n%256), uint16(1024+n%50000), time.Time{})
+				if s.Keep(evt) {
+					kept++
+				}
+			}
+			if got := float64(kept) / flows; math.Abs(got-ratio) > 0.01 {
+				t.Errorf("kept fraction = %.4f, want %.2f", got, ratio)
+			}
+		})
+	}
+}
+
+func TestFlowSamplerSeed(t *testing.T) {
+	a, b := NewFlowSampler(0.5, 1), NewFlowSampler(0.5, 2)
+	differ := 0
+	for n := 0; n < 1000; n++ {
+		evt := flowEvent("10.0.0.1", uint16(20000+n), time.Time{})
+		if a.Keep(evt) != b.Keep(evt) {
+			differ++
+		}
+	}
+	if differ == 0 {
+		t.Error("different seeds selected the same flows")
+	}
+}
@@ -0,0 +1,61 @@
+package inspector
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// flowShard returns the worker, out of n, that decodes frame. Both
+// directions of a flow map to the same worker, so the stateful parsers
+// (FTP, SMTP, first-packet DPI) see a connection's packets in capture
+// order. Frames other than Ethernet IPv4/IPv6 go to worker 0.
+func flowShard(frame []byte, n int) int {
+	if n <= 1 || len(frame) < 14 {
+		return 0
+	}
+
+	var proto byte
+	var addrA, addrB, l4 []byte
+	ip := frame[14:]
+	switch binary.BigEndian.Uint16(frame[12:14]) {
+	case 0x0800: // IPv4
+		if len(ip) < 20 {
+			return 0
+		}
+		ihl := int(ip[0]&0x0f) * 4
+		if ihl < 20 || len(ip) < ihl {
+			return 0
+		}
+		proto, addrA, addrB = ip[9], ip[12:16], ip[16:20]
+		// Only the first fragment carries the ports
+		if binary.BigEndian.Uint16(ip[6:8])&0x1fff == 0 {
+			l4 = ip[ihl:]
+		}
+	case 0x86DD: // IPv6, extension headers are not followed
+		if len(ip) < 40 {
+			return 0
+		}
+		proto, addrA, addrB, l4 = ip[6], ip[8:24], ip[24:40], ip[40:]
+	default:
+		return 0
+	}
+
+	var portA, portB []byte
+	if (proto == 6 || proto == 17) && len(l4) >= 4 {
+		portA, portB = l4[0:2], l4[2:4]
+	}
+	// Order the endpoints as KeyOf does, so both directions hash the same
+	if c := bytes.Compare(addrA, addrB); c > 0 || (c == 0 && bytes.Compare(portA, portB) > 0) {
+		addrA, addrB, portA, portB = addrB, addrA, portB, portA
+	}
+
+	// FNV-1a
+	h := uint32(2166136261)
+	for _, part := range [...][]byte{{proto}, addrA, portA, addrB, portB} {
+		for _, c := range part {
+			h ^= uint32(c)
+			h *= 16777619
+		}
+	}
+	return int(h % uint32(n))
+}
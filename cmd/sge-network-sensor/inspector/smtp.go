@@ -0,0 +1,82 @@
+package inspector
+
+import (
+	"sync"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+)
+
+// smtpPorts are the cleartext SMTP ports (relay and submission). Implicit
+// TLS on 465 cannot be parsed.
+var smtpPorts = map[uint16]bool{25: true, 587: true}
+
+// maxSMTPSessions bounds the tracked SMTP connections. When reached the
+// session set is reset, losing only messages in progress.
+const maxSMTPSessions = 10000
+
+// SMTPParser follows the client side of SMTP connections and attaches the
+// metadata of each sent message to the event of the segment completing it.
+// Segments must be fed in capture order per connection, as the inspector's
+// flow sharding guarantees; retransmitted bytes are skipped by sequence
+// number. It is safe for concurrent use.
+type SMTPParser struct {
+	mu       sync.Mutex
+	sessions map[FlowKey]*smtpConn
+}
+
+// smtpConn is one tracked SMTP connection.
+type smtpConn struct {
+	session dpi.SMTPSession
+	next    uint32 // Sequence number following the bytes fed so far
+}
+
+// NewSMTPParser creates an SMTP session tracker.
+func NewSMTPParser() *SMTPParser {
+	return &SMTPParser{sessions: make(map[FlowKey]*smtpConn)}
+}
+
+// IsSMTP reports whether evt is a client -> server SMTP segment.
+func IsSMTP(evt *NetworkEvent) bool {
+	return evt.Protocol == "TCP" && smtpPorts[evt.DstPort]
+}
+
+// Feed parses the payload of a client -> server SMTP segment and returns
+// the messages it completed.
+func (p *SMTPParser) Feed(evt *NetworkEvent, payload []byte) []*dpi.Mail {
+	key := KeyOf(evt)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.sessions[key]
+	if !ok {
+		if len(payload) == 0 {
+			return nil
+		}
+		if len(p.sessions) >= maxSMTPSessions {
+			p.sessions = make(map[FlowKey]*smtpConn)
+		}
+		c = &smtpConn{next: evt.TCPSeq}
+		p.sessions[key] = c
+	}
+
+	// Drop the part of a retransmitted segment that was already fed
+	end := evt.TCPSeq + uint32(len(payload))
+	if seen := int32(c.next - evt.TCPSeq); seen > 0 {
+		payload = payload[min(int(seen), len(payload)):]
+	}
+	if int32(end-c.next) > 0 {
+		c.next = end
+	}
+
+	// Closed sessions stay until the connection ends so TLS traffic after
+	// STARTTLS is ignored
+	var mails []*dpi.Mail
+	if len(payload) > 0 {
+		mails = c.session.Feed(payload)
+	}
+	if evt.TCPFlags&(TCPFlagFIN|TCPFlagRST) != 0 {
+		delete(p.sessions, key)
+	}
+	return mails
+}
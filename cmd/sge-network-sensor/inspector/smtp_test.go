@@ -0,0 +1,116 @@
+package inspector
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+	"sakin-go/cmd/sge-network-sensor/dpi"
+)
+
+func TestSMTPParserAttachesMail(t *testing.T) {
+	client, server := net.IP{10, 0, 0, 5}, net.IP{10, 0, 0, 25}
+	segments := []string{
+		"EHLO ws01\r\nMAIL FROM:<alice@corp.example>\r\nRCPT TO:<eve@evil.test>\r\nDATA\r\n",
+		"From: alice@corp.example\r\nSubject: Q3 numbers\r\nContent-Disposition: attachment; filename=q3.xlsx\r\n\r\n",
+		"UEsDBBQAAAAIAA==\r\n.\r\n",
+	}
+
+	decoder := NewDecoder()
+	parser := NewSMTPParser()
+	var got []*dpi.Mail
+	seq := uint32(1000)
+	for n, seg := range segments {
+		evt, ok := decoder.Decode(tcpSegment(t, client, server, 50025, 25, seq, false, seg), time.Now())
+		if !ok || !IsSMTP(&evt) {
+			t.Fatalf("Decode() = %+v, want an SMTP segment", evt)
+		}
+		got = append(got, parser.Feed(&evt, decoder.Payload())...)
+
+		// The headers are retransmitted, their lines must not be parsed twice
+		if n == 1 {
+			dup, _ := decoder.Decode(tcpSegment(t, client, server, 50025, 25, seq, false, seg), time.Now())
+			got = append(got, parser.Feed(&dup, decoder.Payload())...)
+		}
+		seq += uint32(len(seg))
+	}
+
+	if len(got) != 1 || got[0].Subject != "Q3 numbers" || got[0].RcptTo[0] != "eve@evil.test" || got[0].Attachments[0] != "q3.xlsx" {
+		t.Fatalf("Feed() = %+v, want the Q3 numbers message", got)
+	}
+
+	// Server replies are not SMTP client segments
+	reply, _ := decoder.Decode(tcpFrame(t, server, client, 25, 50025, "250 OK\r\n"), time.Now())
+	if IsSMTP(&reply) {
+		t.Error("IsSMTP() = true for a server reply")
+	}
+}
+
+// TestInspectorSMTPAcrossWorkers interleaves many SMTP connections, with
+// retransmits and a closing FIN, on several workers.
+func TestInspectorSMTPAcrossWorkers(t *testing.T) {
+	const conns = 50
+	server := net.IP{10, 0, 0, 25}
+	segments := []string{
+		"EHLO ws01\r\nMAIL FROM:<alice@corp.example>\r\nRCPT TO:<eve@evil.test>\r\nDATA\r\n",
+		"From: alice@corp.example\r\nSubject: Q3 numbers\r\n\r\n",
+		"body line\r\n",
+		".\r\n",
+	}
+
+	// Round-robin over the connections, one segment each, with unrelated
+	// traffic in between
+	var frames [][]byte
+	for n, seq := 0, uint32(1); n <= len(segments); n++ {
+		for c := 0; c < conns; c++ {
+			client := net.IP{10, 0, 1, byte(c)}
+			port := uint16(40000 + c)
+			if n == len(segments) {
+				frames = append(frames, tcpSegment(t, client, server, port, 25, seq, true, ""))
+				continue
+			}
+			frames = append(frames, tcpSegment(t, client, server, port, 25, seq, false, segments[n]))
+			if n == 1 {
+				frames = append(frames, tcpSegment(t, client, server, port, 25, seq, false, segments[n]))
+			}
+			frames = append(frames, tcpFrame(t, net.IP{10, 0, 2, byte(c)}, net.IP{10, 0, 0, 80}, 50000, 80, "x"))
+		}
+		if n < len(segments) {
+			seq += uint32(len(segments[n]))
+		}
+	}
+
+	cfg := &config.AppConfig{Interface: "any", Workers: 4, QueueSize: 4096, SMTPParsing: true}
+	events := make(chan interface{}, len(frames))
+	insp := NewInspector(cfg, events)
+	insp.listInterfaces = func() ([]string, error) { return []string{"eth0"}, nil }
+	insp.openSource = func(string) (PacketSource, error) { return &seqSource{frames: frames}, nil }
+	if err := insp.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	<-insp.CaptureDone()
+	deadline := time.Now().Add(5 * time.Second)
+	for insp.Stats().Events < uint64(len(frames)) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	insp.Stop()
+
+	mails := make(map[string]*dpi.Mail)
+	for len(events) > 0 {
+		if evt := (<-events).(NetworkEvent); evt.Mail != nil {
+			if mails[evt.SrcIP] != nil {
+				t.Errorf("%s: second mail %+v", evt.SrcIP, evt.Mail)
+			}
+			mails[evt.SrcIP] = evt.Mail
+		}
+	}
+	if len(mails) != conns {
+		t.Fatalf("mails from %d connections, want %d", len(mails), conns)
+	}
+	for src, m := range mails {
+		if m.Subject != "Q3 numbers" || m.MailFrom != "alice@corp.example" || len(m.RcptTo) != 1 {
+			t.Errorf("%s: mail = %+v, want the Q3 numbers message", src, m)
+		}
+	}
+}
@@ -2,23 +2,54 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"sakin-go/cmd/sge-network-sensor/admin"
+	"sakin-go/cmd/sge-network-sensor/assets"
 	"sakin-go/cmd/sge-network-sensor/config"
+	"sakin-go/cmd/sge-network-sensor/detector"
 	"sakin-go/cmd/sge-network-sensor/handlers"
 	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/cmd/sge-network-sensor/output"
+	"sakin-go/cmd/sge-network-sensor/telemetry"
 	"sakin-go/pkg/database"
 	"sakin-go/pkg/messaging"
+	"sakin-go/pkg/models"
 )
 
 func main() {
-	// 1. Config
+	// Offline mode: replay a capture file, no NATS/DB needed
+	if len(os.Args) > 1 && os.Args[1] == "analyze" {
+		os.Exit(runAnalyze(os.Args[2:]))
+	}
+
+	// 1. Config (flags override the environment)
 	cfg := config.LoadConfig()
+	flag.StringVar(&cfg.BPFFilter, "bpf", cfg.BPFFilter, "BPF filter, ANDed with the profiles")
+	flag.StringVar(&cfg.ReplayFile, "pcap", cfg.ReplayFile, "Replay this capture file through the pipeline instead of capturing live")
+	profiles := flag.String("bpf-profile", strings.Join(cfg.BPFProfiles, ","), "Comma separated BPF profiles: "+strings.Join(config.ProfileNames(), ", "))
+	flag.Parse()
+	cfg.BPFProfiles = strings.FieldsFunc(*profiles, func(r rune) bool { return r == ',' || r == ' ' })
+	filter, err := cfg.CaptureFilter("")
+	if err != nil {
+		log.Fatalf("[Main] Invalid capture filter: %v", err)
+	}
+	for iface, names := range cfg.InterfaceBPFProfiles {
+		if _, err := config.ExpandBPF(names, ""); err != nil {
+			log.Fatalf("[Main] Invalid BPF profile for %s: %v", iface, err)
+		}
+	}
 	log.Println("[Main] Starting SGE Network Sensor:", cfg.SensorName)
+	if filter != "" {
+		log.Printf("[Main] Capture filter: %s", filter)
+	}
 
 	// 2. Database Clients
 	// PostgreSQL (Optional if only network flows)
@@ -39,12 +70,17 @@ func main() {
 	}
 
 	// 3. NATS Client
+	retention, err := messaging.RetentionFromEnv()
+	if err != nil {
+		log.Fatalf("[Main] Invalid NATS retention config: %v", err)
+	}
 	natsConfig := &messaging.NatsConfig{
 		URL:           cfg.NatsURL,
 		Username:      cfg.NatsUser,
 		Password:      cfg.NatsPassword,
 		MaxReconnects: 5,
 		ReconnectWait: 2 * time.Second,
+		Retention:     retention,
 	}
 	nc, err := messaging.NewClient(natsConfig)
 	if err != nil {
@@ -60,28 +96,205 @@ func main() {
 	insp := inspector.NewInspector(cfg, eventChan)
 
 	// DB Handler (Consumer 1)
+	var taps []chan<- interface{}
 	if ch != nil {
-		dbHandler := handlers.NewDBHandler(nil, ch)
-		go dbHandler.ProcessEvents(context.Background(), eventChan)
+		dbChan := make(chan interface{}, 10000)
+		dbHandler := handlers.NewDBHandler(ch)
+		go dbHandler.ProcessEvents(context.Background(), dbChan)
+		taps = append(taps, dbChan)
+	}
+
+	// Fallback for events that fail to publish, replayed now that NATS is up
+	var fallback *output.FileProducer
+	if cfg.FallbackDir != "" {
+		key, err := output.ParseFallbackKey(cfg.FallbackKey)
+		if err != nil {
+			log.Fatalf("[Main] Invalid SENSOR_FALLBACK_KEY: %v", err)
+		}
+		if fallback, err = output.NewFileProducer(cfg.FallbackDir, key); err != nil {
+			log.Fatalf("[Main] Fallback init failed: %v", err)
+		}
+		n, err := fallback.Replay(func(subject string, data []byte) error {
+			_, err := nc.PublishSync(context.Background(), subject, data)
+			return err
+		})
+		if err != nil {
+			log.Printf("[Main] Fallback replay stopped after %d events: %v", n, err)
+		} else if n > 0 {
+			log.Printf("[Main] Replayed %d fallback events", n)
+		}
+	}
+
+	// Asset discovery (first appearance of internal IPs/MACs)
+	stopSnapshots := func() {}
+	snapDone := make(chan struct{})
+	if cfg.AssetDiscovery {
+		var shared assets.SeenSet
+		if cfg.RedisAddr != "" {
+			rc, err := database.NewRedisClient(&database.RedisConfig{Addr: cfg.RedisAddr, Password: cfg.RedisPassword, PoolSize: 10})
+			if err != nil {
+				log.Printf("[Main] Warning: Redis not connected, asset seen-set is local only: %v", err)
+			} else {
+				defer rc.Close()
+				shared = assets.NewRedisSeen(rc, cfg.AssetTTL)
+			}
+		}
+		subject := messaging.SafeSubject(messaging.TopicEventsRaw, string(models.SeverityLow), assets.Source)
+		local := assets.NewMemorySeen(cfg.AssetTTL, cfg.AssetMaxLocal)
+		if cfg.AssetSnapshotPath != "" && cfg.AssetSnapshotInterval > 0 {
+			if n, err := local.LoadFile(cfg.AssetSnapshotPath); err != nil {
+				log.Printf("[Main] Warning: asset snapshot not restored: %v", err)
+			} else if n > 0 {
+				log.Printf("[Main] Restored %d assets from %s", n, cfg.AssetSnapshotPath)
+			}
+			snapCtx, stop := context.WithCancel(context.Background())
+			stopSnapshots = func() {
+				stop()
+				<-snapDone
+			}
+			go func() {
+				local.RunSnapshots(snapCtx, cfg.AssetSnapshotPath, cfg.AssetSnapshotInterval)
+				close(snapDone)
+			}()
+		}
+		disc := assets.NewDiscoverer(cfg.SensorName, local, shared)
+		assetChan := make(chan interface{}, 10000)
+		go disc.Run(context.Background(), assetChan, output.NewNATSWriter(nc, subject).WithFallback(fallback))
+		taps = append(taps, assetChan)
+		log.Printf("[Main] Asset discovery enabled on %s", subject)
+	}
+
+	// Configured Outputs (Consumer 2..N, each with its own field projection)
+	outputs, err := output.Build(cfg.Outputs, nc, fallback)
+	if err != nil {
+		log.Fatalf("[Main] Invalid output config: %v", err)
+	}
+	for _, o := range outputs {
+		log.Printf("[Main] Output enabled: %s", o.Name)
 	}
+	routes, err := output.BuildRoutes(cfg.Routes, outputs, nc, fallback)
+	if err != nil {
+		log.Fatalf("[Main] Invalid route config: %v", err)
+	}
+	for _, r := range routes {
+		log.Printf("[Main] Route enabled: %s", r.Name)
+	}
+	outCtx, stopOutputs := context.WithCancel(context.Background())
+
+	// Threat detection (Consumer N+1): threats re-enter the pipeline as
+	// network.threat events, reaching the outputs and events.raw
+	var det *detector.Detector
+	if cfg.Detection {
+		det = detector.NewDetector(detector.DefaultConfig())
+		detChan := make(chan interface{}, 10000)
+		go handlers.NewDetectionHandler(det).ProcessEvents(outCtx, detChan, eventChan)
+		if cfg.FirstPacketOnly {
+			// Scans, SYN/RST storms and beacons show in the packets the
+			// first-packet filter skips, feed the detector ahead of it
+			insp.Tap = detChan
+		} else {
+			taps = append(taps, detChan)
+		}
+		routes = append(routes, output.ThreatRoutes(nc, fallback, outputs)...)
+		log.Println("[Main] Threat detection enabled")
+	}
+
+	outDone := make(chan struct{})
+	go func() {
+		output.NewManager(outputs...).WithRoutes(routes...).Run(outCtx, eventChan, taps...)
+		close(outDone)
+	}()
 
-	// NATS Publisher (Consumer 2 - Logic needed inside handler or separate)
-	// For now, simpler implementation: let's assume DBHandler handles flows
-	// and we might want a separate routine for alerting events.
+	// Self-telemetry (health reported as regular events)
+	telCtx, stopTelemetry := context.WithCancel(context.Background())
+	defer stopTelemetry()
+	var reporter *telemetry.Reporter
+	if cfg.TelemetryInterval > 0 {
+		subject, err := messaging.Subject(messaging.TopicSensorTelemetry, cfg.SensorName)
+		if err != nil {
+			log.Fatalf("[Main] Invalid SENSOR_NAME: %v", err)
+		}
+		reporter = telemetry.NewReporter(cfg.SensorName, insp.Stats, output.NewNATSWriter(nc, subject))
+		reporter.AddCircuit("nats", func() string { return nc.Connection().Status().String() })
+		insp.OnFlowPressure = func(s inspector.FlowStats) {
+			if err := reporter.ReportFlowPressure(s); err != nil {
+				log.Printf("[Main] Telemetry publish failed: %v", err)
+			}
+		}
+		log.Printf("[Main] Telemetry every %ds on %s", cfg.TelemetryInterval, subject)
+	}
 
 	// 5. Start Capture
 	if err := insp.Start(); err != nil {
+		if errors.Is(err, inspector.ErrNoInterfaces) {
+			log.Fatalf("[Main] %v; check SENSOR_INTERFACE and capture permissions, or set SENSOR_REPLAY_FILE (-pcap) to replay a capture", err)
+		}
 		log.Fatalf("[Main] Failed to start inspector: %v", err)
 	}
+	if cfg.ReplayFile != "" {
+		log.Printf("[Main] Replaying %s", cfg.ReplayFile)
+	}
+	if reporter != nil {
+		go reporter.Run(telCtx, time.Duration(cfg.TelemetryInterval)*time.Second)
+	}
+	if cfg.StatsLogInterval > 0 {
+		statsLog := telemetry.NewStatsLogger(func() telemetry.Counters {
+			c := telemetry.Counters{Inspector: insp.Stats(), Outputs: make(map[string]output.Stats, len(outputs))}
+			for _, o := range outputs {
+				c.Outputs[o.Name] = o.Stats()
+			}
+			for _, r := range routes {
+				for _, o := range r.Outputs() {
+					c.Outputs[o.Name] = o.Stats()
+				}
+			}
+			return c
+		})
+		go statsLog.Run(telCtx, time.Duration(cfg.StatsLogInterval)*time.Second)
+	}
+
+	// Admin API and SIGUSR1/SIGUSR2 (not on Windows) pause and resume capture
+	var adminSrv *admin.Server
+	if cfg.AdminAddr != "" {
+		adminSrv = admin.NewServer(insp)
+		if det != nil {
+			adminSrv.WithDetections(det)
+		}
+		go func() {
+			if err := adminSrv.Listen(cfg.AdminAddr); err != nil {
+				log.Printf("[Main] Admin API stopped: %v", err)
+			}
+		}()
+		log.Printf("[Main] Admin API listening on %s", cfg.AdminAddr)
+	}
+	handleCaptureSignals(insp)
 
 	// 6. Graceful Shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	<-sigChan
+	// A replay ends on its own once the file is read
+	var replayDone <-chan struct{}
+	if cfg.ReplayFile != "" {
+		replayDone = insp.CaptureDone()
+	}
+	select {
+	case <-sigChan:
+	case <-replayDone:
+		log.Println("[Main] Replay finished")
+	}
 	log.Println("[Main] Shutting down...")
 
+	if adminSrv != nil {
+		adminSrv.Shutdown()
+	}
+
+	stopTelemetry()
 	insp.Stop()
+	log.Printf("[Main] Final stats: %+v", insp.Stats())
 	// Drain channel logic here...
+	stopOutputs()
+	<-outDone // Outputs flushed and closed
+	stopSnapshots()
 	log.Println("[Main] Shutdown complete.")
 }
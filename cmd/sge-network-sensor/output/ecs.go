@@ -0,0 +1,215 @@
+package output
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+// ecsVersion is the ECS release the mapping follows.
+const ecsVersion = "8.11.0"
+
+// ecsSeverity maps severities to event.severity (numeric in ECS).
+var ecsSeverity = map[models.Severity]int{
+	models.SeverityInfo:     1,
+	models.SeverityLow:      2,
+	models.SeverityMedium:   3,
+	models.SeverityHigh:     4,
+	models.SeverityCritical: 5,
+}
+
+// ECSFormatter maps network and platform events to Elastic Common Schema
+// documents with nested field objects (source.ip is {"source":{"ip":..}}).
+// Fields without an ECS equivalent go under "sakin". Other event types are
+// encoded natively.
+type ECSFormatter struct{}
+
+func (ECSFormatter) Format(evt interface{}) ([]byte, error) {
+	switch e := evt.(type) {
+	case inspector.NetworkEvent:
+		return json.Marshal(ecsNetworkEvent(&e))
+	case *inspector.NetworkEvent:
+		return json.Marshal(ecsNetworkEvent(e))
+	case models.Event:
+		return json.Marshal(ecsEvent(&e))
+	case *models.Event:
+		return json.Marshal(ecsEvent(e))
+	}
+	return json.Marshal(evt)
+}
+
+// ecsDoc is an ECS document under construction.
+type ecsDoc map[string]interface{}
+
+// set stores v at the dotted path, creating the intermediate objects.
+// Zero values are skipped so documents only carry what was observed.
+func (d ecsDoc) set(path string, v interface{}) {
+	switch x := v.(type) {
+	case string:
+		if x == "" {
+			return
+		}
+	case int:
+		if x == 0 {
+			return
+		}
+	case uint16:
+		if x == 0 {
+			return
+		}
+	case []string:
+		if len(x) == 0 {
+			return
+		}
+	case nil:
+		return
+	}
+
+	parts := strings.Split(path, ".")
+	m := d
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(ecsDoc)
+		if !ok {
+			next = ecsDoc{}
+			m[p] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = v
+}
+
+func newECSDoc(ts time.Time) ecsDoc {
+	d := ecsDoc{"@timestamp": ts.UTC().Format(time.RFC3339Nano)}
+	d.set("ecs.version", ecsVersion)
+	return d
+}
+
+func ecsNetworkEvent(e *inspector.NetworkEvent) ecsDoc {
+	d := newECSDoc(e.Timestamp)
+	d.set("event.kind", "event")
+	d.set("event.category", []string{"network"})
+	d.set("event.type", []string{"connection"})
+	d.set("event.module", "sakin")
+	d.set("event.dataset", "sakin.network")
+	d.set("observer.type", "sensor")
+	d.set("observer.ingress.interface.name", e.Interface)
+
+	d.set("source.ip", e.SrcIP)
+	d.set("source.port", int(e.SrcPort))
+	d.set("source.mac", ecsMAC(e.SrcMAC))
+	d.set("source.bytes", e.PayloadSize)
+	d.set("destination.ip", e.DstIP)
+	d.set("destination.port", int(e.DstPort))
+
+	d.set("network.transport", strings.ToLower(e.Protocol))
+	d.set("network.type", ecsIPType(e.SrcIP))
+
+	switch {
+	case e.SNI != "" || e.TLSVersion != 0:
+		d.set("network.protocol", "tls")
+		d.set("tls.client.server_name", e.SNI)
+		if e.TLSVersion != 0 {
+			name := dpi.TLSVersionName(e.TLSVersion) // e.g. "TLS 1.2"
+			if proto, version, ok := strings.Cut(name, " "); ok {
+				d.set("tls.version_protocol", strings.ToLower(proto))
+				d.set("tls.version", version)
+			}
+		}
+	case e.AppProtocol == dpi.ProtoRDP || e.AppProtocol == dpi.ProtoWinRM:
+		d.set("network.protocol", e.AppProtocol)
+		d.set("user.name", e.AdminUser)
+		d.set("sakin.admin_auth", e.AdminAuth)
+	case e.HTTPHost != "":
+		d.set("network.protocol", "http")
+		host := e.HTTPHost
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		d.set("url.domain", host)
+	case e.DNSQuery != "":
+		d.set("network.protocol", "dns")
+		d.set("dns.type", "query")
+		d.set("dns.question.name", e.DNSQuery)
+		d.set("dns.question.type", e.DNSType)
+	case e.AppProtocol == dpi.ProtoSSH:
+		d.set("network.protocol", "ssh")
+	case e.FTPCommand != "" || e.FTPReply != 0:
+		d.set("network.protocol", "ftp")
+	case e.Mail != nil:
+		d.set("network.protocol", "smtp")
+	}
+
+	if m := e.Mail; m != nil {
+		from := m.HeaderFrom
+		if from == "" {
+			from = m.MailFrom
+		}
+		d.set("email.from.address", []string{from})
+		d.set("email.to.address", m.RcptTo)
+		d.set("email.subject", m.Subject)
+		if len(m.Attachments) > 0 {
+			files := make([]ecsDoc, len(m.Attachments))
+			for i, name := range m.Attachments {
+				files[i] = ecsDoc{"file": ecsDoc{"name": name}}
+			}
+			d.set("email.attachments", files)
+		}
+		d.set("sakin.mail.indicators", m.Indicators)
+	}
+
+	d.set("sakin.app_category", e.AppCategory)
+	d.set("sakin.tcp_flags", int(e.TCPFlags))
+	d.set("sakin.repeat_count", e.RepeatCount)
+	d.set("sakin.ftp.command", e.FTPCommand)
+	d.set("sakin.ftp.arg", e.FTPArg)
+	d.set("sakin.ftp.reply", e.FTPReply)
+	if e.CleartextCredentials {
+		d.set("sakin.cleartext_credentials", true)
+	}
+	return d
+}
+
+func ecsEvent(e *models.Event) ecsDoc {
+	d := newECSDoc(e.Timestamp)
+	d.set("event.id", e.ID)
+	d.set("event.kind", "event")
+	d.set("event.action", e.EventType)
+	d.set("event.provider", e.Source)
+	d.set("event.severity", ecsSeverity[e.Severity])
+	d.set("event.original", e.RawLog)
+	d.set("log.level", string(e.Severity))
+	d.set("message", e.Description)
+	d.set("source.ip", e.SourceIP)
+	d.set("destination.ip", e.DestIP)
+	d.set("tags", e.Tags)
+
+	d.set("sakin.status", string(e.Status))
+	if len(e.Metadata) > 0 {
+		d.set("sakin.metadata", e.Metadata)
+	}
+	if len(e.Enrichment) > 0 {
+		d.set("sakin.enrichment", e.Enrichment)
+	}
+	return d
+}
+
+// ecsMAC formats a MAC address the ECS way: uppercase, dash separated.
+func ecsMAC(mac string) string {
+	return strings.ToUpper(strings.ReplaceAll(mac, ":", "-"))
+}
+
+func ecsIPType(ip string) string {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case parsed.To4() != nil:
+		return "ipv4"
+	}
+	return "ipv6"
+}
@@ -0,0 +1,220 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/pkg/models"
+)
+
+// lookup follows a dotted path through nested JSON objects.
+func lookup(doc map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = doc
+	for _, p := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[p]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func decodeECS(t *testing.T, evt interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := ECSFormatter{}.Format(evt)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	for k := range doc {
+		if k != "@timestamp" && strings.Contains(k, ".") {
+			t.Errorf("top-level key %q is dotted, want nested objects", k)
+		}
+	}
+	return doc
+}
+
+func assertPaths(t *testing.T, doc map[string]interface{}, want map[string]interface{}) {
+	t.Helper()
+	for path, w := range want {
+		got, ok := lookup(doc, path)
+		if !ok {
+			t.Errorf("%s missing", path)
+			continue
+		}
+		if !jsonEqual(got, w) {
+			t.Errorf("%s = %v, want %v", path, got, w)
+		}
+	}
+}
+
+// jsonEqual compares a decoded value with an expected Go value.
+func jsonEqual(got, want interface{}) bool {
+	g, _ := json.Marshal(got)
+	w, _ := json.Marshal(want)
+	return string(g) == string(w)
+}
+
+func TestECSNetworkEvent(t *testing.T) {
+	evt := sampleEvent()
+	evt.Interface = "eth0"
+	evt.SrcMAC = "aa:bb:cc:00:11:22"
+	evt.TLSVersion = dpi.VersionTLS12
+
+	doc := decodeECS(t, evt)
+	assertPaths(t, doc, map[string]interface{}{
+		"@timestamp":                      "2024-01-01T12:00:00Z",
+		"ecs.version":                     ecsVersion,
+		"event.kind":                      "event",
+		"event.category":                  []string{"network"},
+		"observer.ingress.interface.name": "eth0",
+		"source.ip":                       "10.0.0.7",
+		"source.port":                     52000,
+		"source.mac":                      "AA-BB-CC-00-11-22",
+		"source.bytes":                    517,
+		"destination.ip":                  "203.0.113.10",
+		"destination.port":                443,
+		"network.transport":               "tcp",
+		"network.protocol":                "tls",
+		"network.type":                    "ipv4",
+		"tls.client.server_name":          "c2.example.net",
+		"tls.version":                     "1.2",
+		"tls.version_protocol":            "tls",
+	})
+	for _, absent := range []string{"dns", "url", "email", "src_ip", "sni"} {
+		if _, ok := doc[absent]; ok {
+			t.Errorf("unexpected field %q", absent)
+		}
+	}
+
+	// Pointers encode the same
+	if got := decodeECS(t, &evt); !jsonEqual(got, doc) {
+		t.Errorf("pointer = %v, want %v", got, doc)
+	}
+}
+
+func TestECSNetworkEventProtocols(t *testing.T) {
+	base := sampleEvent()
+	base.SNI = ""
+
+	dns := base
+	dns.Protocol, dns.DstPort, dns.DNSQuery, dns.DNSType = "UDP", 53, "example.org", "A"
+
+	http := base
+	http.DstPort, http.HTTPHost = 8080, "intranet.local:8080"
+
+	mail := base
+	mail.DstPort = 25
+	mail.Mail = &dpi.Mail{MailFrom: "a@example.org", RcptTo: []string{"b@example.net"}, Subject: "Invoice", Attachments: []string{"invoice.pdf.exe"}}
+
+	tests := []struct {
+		name string
+		evt  interface{}
+		want map[string]interface{}
+	}{
+		{"dns", dns, map[string]interface{}{
+			"network.transport": "udp",
+			"network.protocol":  "dns",
+			"dns.question.name": "example.org",
+			"dns.question.type": "A",
+		}},
+		{"http", http, map[string]interface{}{
+			"network.protocol": "http",
+			"url.domain":       "intranet.local",
+			"destination.port": 8080,
+		}},
+		{"smtp", mail, map[string]interface{}{
+			"network.protocol":   "smtp",
+			"email.from.address": []string{"a@example.org"},
+			"email.to.address":   []string{"b@example.net"},
+			"email.subject":      "Invoice",
+			"email.attachments":  []interface{}{map[string]interface{}{"file": map[string]interface{}{"name": "invoice.pdf.exe"}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertPaths(t, decodeECS(t, tt.evt), tt.want)
+		})
+	}
+}
+
+func TestECSEvent(t *testing.T) {
+	evt := &models.Event{
+		ID:          "evt-1",
+		Timestamp:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Source:      "network-sensor",
+		SourceIP:    "10.0.0.7",
+		DestIP:      "2001:db8::1",
+		EventType:   models.EventTypeAssetDiscovered,
+		Severity:    models.SeverityHigh,
+		Status:      models.EventStatusNew,
+		Description: "New asset discovered: 10.0.0.7",
+		Metadata:    map[string]interface{}{"asset_type": "ip"},
+		Tags:        []string{"asset"},
+	}
+
+	doc := decodeECS(t, evt)
+	assertPaths(t, doc, map[string]interface{}{
+		"event.id":       "evt-1",
+		"event.provider": "network-sensor",
+		"event.action":   models.EventTypeAssetDiscovered,
+		"event.severity": 4,
+		"log.level":      "high",
+		"message":        "New asset discovered: 10.0.0.7",
+		"source.ip":      "10.0.0.7",
+		"destination.ip": "2001:db8::1",
+		"tags":           []string{"asset"},
+		"sakin.metadata": map[string]interface{}{"asset_type": "ip"},
+		"sakin.status":   string(models.EventStatusNew),
+	})
+}
+
+func TestNewFormatter(t *testing.T) {
+	for _, name := range []string{"", FormatNative, FormatECS} {
+		if _, err := NewFormatter(name); err != nil {
+			t.Errorf("NewFormatter(%q) error = %v", name, err)
+		}
+	}
+	if _, err := NewFormatter("cef"); err == nil {
+		t.Error("NewFormatter(cef) error = nil, want unknown format")
+	}
+}
+
+func TestBuildFormat(t *testing.T) {
+	path := t.TempDir() + "/events.jsonl"
+	outs, err := Build([]config.OutputConfig{{Name: "siem", Type: "file", Format: FormatECS, Target: path, Fields: []string{"source", "network"}}}, nil, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	defer outs[0].Close()
+
+	data, err := outs[0].Encode(sampleEvent())
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if len(doc) != 2 {
+		t.Errorf("fields = %v, want source and network only", doc)
+	}
+	if got, _ := lookup(doc, "network.protocol"); got != "tls" {
+		t.Errorf("network.protocol = %v, want tls", got)
+	}
+
+	_, err = Build([]config.OutputConfig{{Name: "siem", Type: "file", Format: "cef", Target: path}}, nil, nil)
+	if err == nil {
+		t.Error("Build() with unknown format error = nil")
+	}
+}
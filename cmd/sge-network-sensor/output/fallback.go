@@ -0,0 +1,244 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fallback file name suffixes. Encrypted files hold one base64 line of
+// nonce+ciphertext per record instead of plaintext JSON.
+const (
+	fallbackPlainExt     = ".jsonl"
+	fallbackEncryptedExt = ".jsonl.enc"
+)
+
+// maxFallbackLine bounds a single record line read back during replay.
+const maxFallbackLine = 4 * 1024 * 1024
+
+// ErrFallbackKeyRequired is returned when replaying encrypted files without
+// a key.
+var ErrFallbackKeyRequired = errors.New("encrypted fallback file requires a key")
+
+// fallbackRecord is one event that could not be published.
+type fallbackRecord struct {
+	Subject string `json:"subject"`
+	Data    []byte `json:"data"`
+}
+
+// FileProducer stores events that could not be published as daily files in
+// dir (<dir>/fallback-YYYYMMDD.jsonl) and publishes them again on Replay.
+// With a key every record is sealed with AES-GCM, so the raw events are not
+// readable on the sensor disk. It is safe for concurrent use.
+type FileProducer struct {
+	dir  string
+	aead cipher.AEAD // nil writes plaintext
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// NewFileProducer creates a fallback producer writing to dir. key must be
+// empty (plaintext) or an AES-128/192/256 key.
+func NewFileProducer(dir string, key []byte) (*FileProducer, error) {
+	p := &FileProducer{dir: dir, now: time.Now}
+	if len(key) > 0 {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fallback key: %w", err)
+		}
+		if p.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create fallback dir: %w", err)
+	}
+	return p, nil
+}
+
+// ParseFallbackKey decodes a hex or base64 encoded AES key. An empty string
+// returns a nil key (encryption disabled).
+func ParseFallbackKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		if key, err = base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, errors.New("fallback key must be hex or base64")
+		}
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("fallback key must be 16, 24 or 32 bytes, got %d", len(key))
+}
+
+// Encrypted reports whether records are sealed with AES-GCM.
+func (p *FileProducer) Encrypted() bool {
+	return p.aead != nil
+}
+
+// Path returns today's fallback file path.
+func (p *FileProducer) Path() string {
+	ext := fallbackPlainExt
+	if p.aead != nil {
+		ext = fallbackEncryptedExt
+	}
+	return filepath.Join(p.dir, "fallback-"+p.now().UTC().Format("20060102")+ext)
+}
+
+// Write appends the event destined for subject to today's file.
+func (p *FileProducer) Write(subject string, data []byte) error {
+	line, err := json.Marshal(fallbackRecord{Subject: subject, Data: data})
+	if err != nil {
+		return err
+	}
+	if p.aead != nil {
+		if line, err = p.seal(line); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.Path(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open fallback file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write fallback file: %w", err)
+	}
+	return f.Close()
+}
+
+// Replay publishes the records of all fallback files, oldest first, and
+// deletes each file once all of its records were published. Delivery is
+// at-least-once: a file failing halfway is kept and replayed in full on
+// the next call. It returns the number of records published.
+func (p *FileProducer) Replay(publish func(subject string, data []byte) error) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	files, err := p.files()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, path := range files {
+		n, err := p.replayFile(path, publish)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if err := os.Remove(path); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// files lists the fallback files in dir in chronological order.
+func (p *FileProducer) files() ([]string, error) {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, "fallback-") {
+			continue
+		}
+		if strings.HasSuffix(name, fallbackPlainExt) || strings.HasSuffix(name, fallbackEncryptedExt) {
+			files = append(files, filepath.Join(p.dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (p *FileProducer) replayFile(path string, publish func(subject string, data []byte) error) (int, error) {
+	encrypted := strings.HasSuffix(path, fallbackEncryptedExt)
+	if encrypted && p.aead == nil {
+		return 0, ErrFallbackKeyRequired
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxFallbackLine)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if encrypted {
+			if line, err = p.open(line); err != nil {
+				return n, err
+			}
+		}
+		var rec fallbackRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return n, fmt.Errorf("invalid record: %w", err)
+		}
+		if err := publish(rec.Subject, rec.Data); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// seal encrypts a record line as base64(nonce || ciphertext).
+func (p *FileProducer) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize(), p.aead.NonceSize()+len(plain)+p.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := p.aead.Seal(nonce, nonce, plain, nil)
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(sealed)))
+	base64.StdEncoding.Encode(out, sealed)
+	return out, nil
+}
+
+// open reverses seal.
+func (p *FileProducer) open(line []byte) ([]byte, error) {
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+	n, err := base64.StdEncoding.Decode(sealed, line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted record: %w", err)
+	}
+	sealed = sealed[:n]
+	if len(sealed) < p.aead.NonceSize() {
+		return nil, errors.New("invalid encrypted record: too short")
+	}
+	nonce, ciphertext := sealed[:p.aead.NonceSize()], sealed[p.aead.NonceSize():]
+	plain, err := p.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt record: %w", err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,160 @@
+package output
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type published struct {
+	subject string
+	data    string
+}
+
+func replayAll(t *testing.T, p *FileProducer) []published {
+	t.Helper()
+	var got []published
+	n, err := p.Replay(func(subject string, data []byte) error {
+		got = append(got, published{subject, string(data)})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if n != len(got) {
+		t.Errorf("Replay() = %d, want %d", n, len(got))
+	}
+	return got
+}
+
+func TestFileProducerEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{0x42}, 32)
+	p, err := NewFileProducer(dir, key)
+	if err != nil {
+		t.Fatalf("NewFileProducer() error = %v", err)
+	}
+
+	events := []published{
+		{"events.raw.info.sensor", `{"src_ip":"10.0.0.7","sni":"secret.example.com"}`},
+		{"events.raw.info.sensor", `{"src_ip":"10.0.0.8","http_host":"intranet.local"}`},
+	}
+	for _, e := range events {
+		if err := p.Write(e.subject, []byte(e.data)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	raw, err := os.ReadFile(p.Path())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if filepath.Ext(p.Path()) != ".enc" {
+		t.Errorf("Path() = %s, want .enc suffix", p.Path())
+	}
+	for _, plain := range []string{"secret.example.com", "intranet.local", "10.0.0.7", "events.raw"} {
+		if bytes.Contains(raw, []byte(plain)) {
+			t.Errorf("fallback file contains plaintext %q", plain)
+		}
+	}
+
+	// A second producer with the same key (e.g. after a restart) replays
+	p2, err := NewFileProducer(dir, key)
+	if err != nil {
+		t.Fatalf("NewFileProducer() error = %v", err)
+	}
+	got := replayAll(t, p2)
+	if len(got) != len(events) {
+		t.Fatalf("replayed %d events, want %d", len(got), len(events))
+	}
+	for i := range events {
+		if got[i] != events[i] {
+			t.Errorf("replayed[%d] = %+v, want %+v", i, got[i], events[i])
+		}
+	}
+	if _, err := os.Stat(p.Path()); !os.IsNotExist(err) {
+		t.Errorf("fallback file not removed after replay: %v", err)
+	}
+}
+
+func TestFileProducerReplayErrors(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{0x42}, 16)
+	enc, err := NewFileProducer(dir, key)
+	if err != nil {
+		t.Fatalf("NewFileProducer() error = %v", err)
+	}
+	if err := enc.Write("events.raw.info.sensor", []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	t.Run("missing key", func(t *testing.T) {
+		plain, _ := NewFileProducer(dir, nil)
+		if _, err := plain.Replay(func(string, []byte) error { return nil }); !errors.Is(err, ErrFallbackKeyRequired) {
+			t.Errorf("Replay() error = %v, want %v", err, ErrFallbackKeyRequired)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, _ := NewFileProducer(dir, bytes.Repeat([]byte{0x24}, 16))
+		if _, err := other.Replay(func(string, []byte) error { return nil }); err == nil {
+			t.Error("Replay() with wrong key succeeded")
+		}
+	})
+
+	t.Run("publish failure keeps file", func(t *testing.T) {
+		if _, err := enc.Replay(func(string, []byte) error { return errors.New("nats down") }); err == nil {
+			t.Error("Replay() error = nil, want publish error")
+		}
+		if _, err := os.Stat(enc.Path()); err != nil {
+			t.Errorf("fallback file removed after failed replay: %v", err)
+		}
+		if got := replayAll(t, enc); len(got) != 1 {
+			t.Errorf("replayed %d events, want 1", len(got))
+		}
+	})
+}
+
+func TestFileProducerPlaintext(t *testing.T) {
+	p, err := NewFileProducer(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewFileProducer() error = %v", err)
+	}
+	if err := p.Write("events.raw.info.sensor", []byte(`{"sni":"a.example"}`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got := replayAll(t, p)
+	if len(got) != 1 || got[0].data != `{"sni":"a.example"}` {
+		t.Errorf("replayed = %+v", got)
+	}
+}
+
+func TestParseFallbackKey(t *testing.T) {
+	key32 := bytes.Repeat([]byte{1}, 32)
+	tests := []struct {
+		name    string
+		in      string
+		wantLen int
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"hex", hex.EncodeToString(key32), 32, false},
+		{"base64", "AQEBAQEBAQEBAQEBAQEBAQ==", 16, false},
+		{"short", "0102", 0, true},
+		{"garbage", "not a key!", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseFallbackKey(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFallbackKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(key) != tt.wantLen {
+				t.Errorf("ParseFallbackKey() len = %d, want %d", len(key), tt.wantLen)
+			}
+		})
+	}
+}
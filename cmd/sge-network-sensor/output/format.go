@@ -0,0 +1,35 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Output formats (SENSOR_OUTPUT_<NAME>_FORMAT).
+const (
+	FormatNative = "native" // Sensor JSON schema
+	FormatECS    = "ecs"    // Elastic Common Schema
+)
+
+// Formatter serializes an event for an output.
+type Formatter interface {
+	Format(evt interface{}) ([]byte, error)
+}
+
+// NativeFormatter encodes events as they are.
+type NativeFormatter struct{}
+
+func (NativeFormatter) Format(evt interface{}) ([]byte, error) {
+	return json.Marshal(evt)
+}
+
+// NewFormatter returns the formatter registered as name ("" is native).
+func NewFormatter(name string) (Formatter, error) {
+	switch name {
+	case "", FormatNative:
+		return NativeFormatter{}, nil
+	case FormatECS:
+		return ECSFormatter{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", name)
+}
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// flushInterval is how often buffered writers are flushed.
+const flushInterval = time.Second
+
+// flusher is implemented by writers that buffer (e.g. FileWriter).
+type flusher interface {
+	Flush() error
+}
+
+// Manager fans events out to all configured outputs, or to the outputs of
+// the routes they match.
+type Manager struct {
+	outputs []*Output
+	routes  []*Route
+	owned   []*Output // Every output, for flushing and closing
+}
+
+// NewManager creates a manager for the given outputs.
+func NewManager(outputs ...*Output) *Manager {
+	return &Manager{outputs: outputs, owned: append([]*Output(nil), outputs...)}
+}
+
+// WithRoutes sets the edge routing rules. An event matching one or more
+// routes goes to the union of their outputs only; other events go to the
+// default outputs.
+func (m *Manager) WithRoutes(routes ...*Route) *Manager {
+	m.routes = routes
+	seen := make(map[*Output]bool, len(m.owned))
+	for _, o := range m.owned {
+		seen[o] = true
+	}
+	for _, r := range routes {
+		for _, o := range r.outputs {
+			if !seen[o] {
+				seen[o] = true
+				m.owned = append(m.owned, o)
+			}
+		}
+	}
+	return m
+}
+
+// targets returns the outputs evt is delivered to.
+func (m *Manager) targets(evt interface{}) []*Output {
+	var targets []*Output
+	var seen map[*Output]bool
+	for _, r := range m.routes {
+		if !r.Matches(evt) {
+			continue
+		}
+		if seen == nil {
+			seen = make(map[*Output]bool)
+		}
+		for _, o := range r.outputs {
+			if !seen[o] {
+				seen[o] = true
+				targets = append(targets, o)
+			}
+		}
+	}
+	if seen == nil {
+		return m.outputs
+	}
+	return targets
+}
+
+// Run delivers every event from in to all outputs until ctx is done or in
+// is closed, then closes the outputs. Taps receive every event unmodified
+// (e.g. the ClickHouse handler); a full tap drops the event.
+func (m *Manager) Run(ctx context.Context, in <-chan interface{}, taps ...chan<- interface{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	defer m.close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case evt, ok := <-in:
+			if !ok {
+				return
+			}
+			for _, tap := range taps {
+				select {
+				case tap <- evt:
+				default:
+				}
+			}
+			for _, o := range m.targets(evt) {
+				if err := o.Write(evt); err != nil {
+					log.Printf("[Output] %s write failed: %v", o.Name, err)
+				}
+			}
+
+		case <-ticker.C:
+			m.flush()
+		}
+	}
+}
+
+func (m *Manager) flush() {
+	for _, o := range m.owned {
+		if f, ok := o.writer.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				log.Printf("[Output] %s flush failed: %v", o.Name, err)
+			}
+		}
+	}
+}
+
+func (m *Manager) close() {
+	for _, o := range m.owned {
+		if err := o.Close(); err != nil {
+			log.Printf("[Output] %s close failed: %v", o.Name, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+	"sakin-go/pkg/messaging"
+)
+
+// Writer delivers encoded events to a destination.
+type Writer interface {
+	Write(data []byte) error
+	Close() error
+}
+
+// categorized is implemented by events carrying an application category
+// (see inspector.NetworkEvent).
+type categorized interface {
+	Category() string
+}
+
+// Output is a named destination (e.g. "siem", "archive") with its own
+// field projection and optional category routing.
+type Output struct {
+	Name       string
+	fields     map[string]bool // Projected JSON fields, nil keeps everything
+	categories map[string]bool // Routed app categories, nil accepts all
+	format     Formatter
+	writer     Writer
+
+	written atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// Stats counts the events an output delivered or failed to deliver.
+type Stats struct {
+	Written uint64
+	Failed  uint64
+}
+
+// New creates an output. An empty fields list keeps all event fields.
+func New(name string, fields []string, w Writer) *Output {
+	o := &Output{Name: name, format: NativeFormatter{}, writer: w}
+	if len(fields) > 0 {
+		o.fields = make(map[string]bool, len(fields))
+		for _, f := range fields {
+			o.fields[f] = true
+		}
+	}
+	return o
+}
+
+// RouteCategories restricts the output to events in the given application
+// categories. Events without a category are always delivered.
+func (o *Output) RouteCategories(categories []string) *Output {
+	if len(categories) == 0 {
+		o.categories = nil
+		return o
+	}
+	o.categories = make(map[string]bool, len(categories))
+	for _, c := range categories {
+		o.categories[c] = true
+	}
+	return o
+}
+
+// WithFormatter sets how events are serialized (native by default).
+func (o *Output) WithFormatter(f Formatter) *Output {
+	o.format = f
+	return o
+}
+
+// Accepts reports whether evt is routed to this output.
+func (o *Output) Accepts(evt interface{}) bool {
+	if o.categories == nil {
+		return true
+	}
+	c, ok := evt.(categorized)
+	return !ok || o.categories[c.Category()]
+}
+
+// Encode serializes evt with the output's formatter, keeping only the
+// projected top-level fields.
+func (o *Output) Encode(evt interface{}) ([]byte, error) {
+	data, err := o.format.Format(evt)
+	if err != nil {
+		return nil, err
+	}
+	if o.fields == nil {
+		return data, nil
+	}
+	return project(data, o.fields)
+}
+
+// Write encodes and delivers a single event, skipping events not routed
+// to this output.
+func (o *Output) Write(evt interface{}) error {
+	if !o.Accepts(evt) {
+		return nil
+	}
+	data, err := o.Encode(evt)
+	if err != nil {
+		o.failed.Add(1)
+		return fmt.Errorf("encode: %w", err)
+	}
+	if err := o.writer.Write(data); err != nil {
+		o.failed.Add(1)
+		return err
+	}
+	o.written.Add(1)
+	return nil
+}
+
+// Stats returns the cumulative delivery counters. It is safe to call
+// concurrently with Write.
+func (o *Output) Stats() Stats {
+	return Stats{Written: o.written.Load(), Failed: o.failed.Load()}
+}
+
+// Close releases the underlying writer.
+func (o *Output) Close() error {
+	return o.writer.Close()
+}
+
+// project drops all top-level fields of a JSON object not in fields.
+// Values are passed through as raw JSON, so nothing is re-encoded.
+func project(data []byte, fields map[string]bool) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for k := range doc {
+		if !fields[k] {
+			delete(doc, k)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// Build creates the outputs described in the sensor config. NATS outputs
+// spill to fallback (may be nil) when publishing fails.
+func Build(cfgs []config.OutputConfig, nc *messaging.Client, fallback *FileProducer) ([]*Output, error) {
+	var outputs []*Output
+	for _, c := range cfgs {
+		format, err := NewFormatter(c.Format)
+		if err != nil {
+			return nil, fmt.Errorf("output %s: %w", c.Name, err)
+		}
+		var w Writer
+		switch c.Type {
+		case "nats":
+			if nc == nil {
+				return nil, fmt.Errorf("output %s: nats client not available", c.Name)
+			}
+			w = NewNATSWriter(nc, c.Target).WithFallback(fallback)
+		case "file":
+			fw, err := NewFileWriter(c.Target)
+			if err != nil {
+				return nil, fmt.Errorf("output %s: %w", c.Name, err)
+			}
+			w = fw
+		default:
+			return nil, fmt.Errorf("output %s: unknown type %q", c.Name, c.Type)
+		}
+		outputs = append(outputs, New(c.Name, c.Fields, w).RouteCategories(c.Categories).WithFormatter(format))
+	}
+	return outputs, nil
+}
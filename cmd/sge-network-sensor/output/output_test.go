@@ -0,0 +1,161 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+type memWriter struct {
+	mu    sync.Mutex
+	lines [][]byte
+}
+
+func (w *memWriter) Write(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, data)
+	return nil
+}
+
+func (w *memWriter) Close() error { return nil }
+
+func (w *memWriter) keys(t *testing.T, i int) []string {
+	t.Helper()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.lines[i], &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", w.lines[i], err)
+	}
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sampleEvent() inspector.NetworkEvent {
+	return inspector.NetworkEvent{
+		Timestamp:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		SrcIP:       "10.0.0.7",
+		DstIP:       "203.0.113.10",
+		SrcPort:     52000,
+		DstPort:     443,
+		Protocol:    "TCP",
+		PayloadSize: 517,
+		TCPFlags:    inspector.TCPFlagACK | inspector.TCPFlagPSH,
+		SNI:         "c2.example.net",
+	}
+}
+
+func TestOutputProjection(t *testing.T) {
+	allFields := []string{"dst_ip", "dst_port", "payload_size", "protocol", "sni", "src_ip", "src_port", "tcp_flags", "timestamp"}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   []string
+	}{
+		{"siem", []string{"timestamp", "src_ip", "dst_ip", "dst_port", "sni"}, []string{"dst_ip", "dst_port", "sni", "src_ip", "timestamp"}},
+		{"archive", nil, allFields},
+		{"unknown field ignored", []string{"src_ip", "no_such_field"}, []string{"src_ip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &memWriter{}
+			evt := sampleEvent()
+			if err := New(tt.name, tt.fields, w).Write(evt); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			got := w.keys(t, 0)
+			if len(got) != len(tt.want) {
+				t.Fatalf("fields = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("fields = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestProjectionKeepsValues(t *testing.T) {
+	w := &memWriter{}
+	if err := New("siem", []string{"dst_port", "sni"}, w).Write(sampleEvent()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got, want := string(w.lines[0]), `{"dst_port":443,"sni":"c2.example.net"}`; got != want {
+		t.Errorf("Encode() = %s, want %s", got, want)
+	}
+}
+
+func TestManagerFanOut(t *testing.T) {
+	siem, archive := &memWriter{}, &memWriter{}
+	m := NewManager(
+		New("siem", []string{"src_ip"}, siem),
+		New("archive", nil, archive),
+	)
+
+	in := make(chan interface{}, 3)
+	tap := make(chan interface{}, 3)
+	for i := 0; i < 3; i++ {
+		in <- sampleEvent()
+	}
+	close(in)
+
+	m.Run(context.Background(), in, tap)
+
+	if len(siem.lines) != 3 || len(archive.lines) != 3 || len(tap) != 3 {
+		t.Errorf("delivered siem=%d archive=%d tap=%d, want 3 each", len(siem.lines), len(archive.lines), len(tap))
+	}
+	if got := siem.keys(t, 2); len(got) != 1 || got[0] != "src_ip" {
+		t.Errorf("siem fields = %v, want [src_ip]", got)
+	}
+	if got := archive.keys(t, 2); len(got) != 9 {
+		t.Errorf("archive fields = %v, want all 9", got)
+	}
+}
+
+func TestOutputCategoryRouting(t *testing.T) {
+	web := sampleEvent()
+	web.AppCategory = inspector.CategoryWeb
+	admin := sampleEvent()
+	admin.DstPort, admin.AppCategory = 22, inspector.CategoryAdmin
+
+	tests := []struct {
+		name       string
+		categories []string
+		want       int
+	}{
+		{"All Categories", nil, 3},
+		{"Admin Only", []string{inspector.CategoryAdmin}, 2}, // admin + uncategorized event
+		{"No Match", []string{inspector.CategoryEmail}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &memWriter{}
+			o := New(tt.name, nil, w).RouteCategories(tt.categories)
+			for _, evt := range []interface{}{web, admin, map[string]string{"kind": "telemetry"}} {
+				if err := o.Write(evt); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if len(w.lines) != tt.want {
+				t.Errorf("delivered %d events, want %d", len(w.lines), tt.want)
+			}
+		})
+	}
+}
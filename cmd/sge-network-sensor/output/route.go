@@ -0,0 +1,152 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/messaging"
+	"sakin-go/pkg/models"
+)
+
+// Route sends the events it matches to its own outputs instead of the
+// default ones. Each criterion left empty matches everything; a criterion
+// matches when any of its values does. Network events are matched by
+// protocol; severities and tags match threat events (see ThreatRoutes)
+// and other models.Event values.
+type Route struct {
+	Name       string
+	protocols  map[string]bool // Transport or L7 protocol (tcp, dns, ...)
+	severities map[string]bool // models.Severity
+	tags       map[string]bool // Event tags, detector.ThreatType for threats
+	outputs    []*Output
+}
+
+// NewRoute creates a route matching the given criteria (case-insensitive).
+func NewRoute(name string, protocols, severities, tags []string) *Route {
+	return &Route{
+		Name:       name,
+		protocols:  lowerSet(protocols),
+		severities: lowerSet(severities),
+		tags:       lowerSet(tags),
+	}
+}
+
+// To sets the outputs matched events are delivered to.
+func (r *Route) To(outputs ...*Output) *Route {
+	r.outputs = outputs
+	return r
+}
+
+// Outputs returns the route's destinations.
+func (r *Route) Outputs() []*Output {
+	return r.outputs
+}
+
+// Matches reports whether evt satisfies all of the route's criteria.
+func (r *Route) Matches(evt interface{}) bool {
+	protocols, severity, tags := routeFields(evt)
+	return anyIn(r.protocols, protocols) && anyIn(r.severities, []string{severity}) && anyIn(r.tags, tags)
+}
+
+// routeFields extracts what routes match on from the event types the
+// sensor emits.
+func routeFields(evt interface{}) (protocols []string, severity string, tags []string) {
+	switch e := evt.(type) {
+	case inspector.NetworkEvent:
+		return []string{e.Protocol, e.AppProtocol}, "", nil
+	case *inspector.NetworkEvent:
+		return []string{e.Protocol, e.AppProtocol}, "", nil
+	case models.Event:
+		return nil, string(e.Severity), e.Tags
+	case *models.Event:
+		return nil, string(e.Severity), e.Tags
+	case detector.Threat:
+		return nil, string(e.Severity), []string{string(e.Type)}
+	case *detector.Threat:
+		return nil, string(e.Severity), []string{string(e.Type)}
+	}
+	return nil, "", nil
+}
+
+// anyIn reports whether set is nil (no criterion) or holds any of values.
+func anyIn(set map[string]bool, values []string) bool {
+	if set == nil {
+		return true
+	}
+	for _, v := range values {
+		if set[strings.ToLower(v)] {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// BuildRoutes creates the routes described in the sensor config. Routes
+// reference outputs by name and/or publish to their own NATS subject,
+// spilling to fallback (may be nil) when publishing fails.
+func BuildRoutes(cfgs []config.RouteConfig, outputs []*Output, nc *messaging.Client, fallback *FileProducer) ([]*Route, error) {
+	byName := make(map[string]*Output, len(outputs))
+	for _, o := range outputs {
+		byName[o.Name] = o
+	}
+
+	var routes []*Route
+	for _, c := range cfgs {
+		if len(c.Protocols) > 0 && (len(c.Severities) > 0 || len(c.Tags) > 0) {
+			// Network events carry protocols only, threat events severity and tags only
+			return nil, fmt.Errorf("route %s: protocols cannot be combined with severities or tags, no event has both", c.Name)
+		}
+		var targets []*Output
+		for _, name := range c.Outputs {
+			o, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("route %s: unknown output %q", c.Name, name)
+			}
+			targets = append(targets, o)
+		}
+		if c.Subject != "" {
+			if nc == nil {
+				return nil, fmt.Errorf("route %s: nats client not available", c.Name)
+			}
+			targets = append(targets, New("route:"+c.Name, nil, NewNATSWriter(nc, c.Subject).WithFallback(fallback)))
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("route %s: no output or subject", c.Name)
+		}
+		routes = append(routes, NewRoute(c.Name, c.Protocols, c.Severities, c.Tags).To(targets...))
+	}
+	return routes, nil
+}
+
+// threatSeverities are the severities ThreatRoutes publish by.
+var threatSeverities = []models.Severity{models.SeverityInfo, models.SeverityLow, models.SeverityMedium, models.SeverityHigh, models.SeverityCritical}
+
+// ThreatRoutes deliver threat events (tagged detector.TagThreat) to the
+// default outputs and publish them on events.raw.<severity>.<source>, where
+// enrichment and correlation consume them, spilling to fallback (may be
+// nil) when publishing fails. Add them after the configured routes.
+func ThreatRoutes(nc *messaging.Client, fallback *FileProducer, defaults []*Output) []*Route {
+	routes := make([]*Route, 0, len(threatSeverities))
+	for _, sev := range threatSeverities {
+		name := "threats-" + string(sev)
+		subject := messaging.SafeSubject(messaging.TopicEventsRaw, string(sev), detector.EventSource)
+		pub := New("route:"+name, nil, NewNATSWriter(nc, subject).WithFallback(fallback))
+		targets := append(append([]*Output(nil), defaults...), pub)
+		routes = append(routes, NewRoute(name, nil, []string{string(sev)}, []string{detector.TagThreat}).To(targets...))
+	}
+	return routes
+}
@@ -0,0 +1,180 @@
+package output
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/config"
+	"sakin-go/cmd/sge-network-sensor/detector"
+	"sakin-go/cmd/sge-network-sensor/dpi"
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/messaging"
+	"sakin-go/pkg/models"
+)
+
+func dnsEvent() inspector.NetworkEvent {
+	evt := sampleEvent()
+	evt.Protocol, evt.DstPort, evt.AppProtocol = "UDP", 53, dpi.ProtoDNS
+	evt.SNI, evt.DNSQuery, evt.DNSType = "", "c2.example.net", "A"
+	return evt
+}
+
+func threatEvent() *detector.Threat {
+	return &detector.Threat{Type: detector.ThreatBeaconing, Severity: models.SeverityHigh, SrcIP: "10.0.0.7", DstIP: "203.0.113.10"}
+}
+
+func TestRouteMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		route *Route
+		evt   interface{}
+		want  bool
+	}{
+		{"App Protocol", NewRoute("dns", []string{"dns"}, nil, nil), dnsEvent(), true},
+		{"Transport Protocol", NewRoute("udp", []string{"udp"}, nil, nil), dnsEvent(), true},
+		{"Other Protocol", NewRoute("dns", []string{"dns"}, nil, nil), sampleEvent(), false},
+		{"Severity", NewRoute("alerts", nil, []string{"high", "critical"}, nil), threatEvent(), true},
+		{"Low Severity", NewRoute("alerts", nil, []string{"high"}, nil), &models.Event{Severity: models.SeverityLow}, false},
+		{"Network Event Has No Severity", NewRoute("alerts", nil, []string{"high"}, nil), dnsEvent(), false},
+		{"Tag", NewRoute("intel", nil, nil, []string{"Malicious_IP"}), models.Event{Tags: []string{"auth", "malicious_ip"}}, true},
+		{"Threat Type Tag", NewRoute("beacons", nil, nil, []string{"beaconing"}), threatEvent(), true},
+		{"All Criteria", NewRoute("both", nil, []string{"high"}, []string{"port_scan"}), threatEvent(), false},
+		{"No Criteria", NewRoute("all", nil, nil, nil), map[string]string{"kind": "telemetry"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.Matches(tt.evt); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRoutes(t *testing.T) {
+	siem := New("siem", nil, &memWriter{})
+	cfgs := []config.RouteConfig{
+		{Name: "dns", Protocols: []string{"dns"}, Subject: "events.raw.info.dns"},
+		{Name: "alerts", Severities: []string{"high", "critical"}, Outputs: []string{"siem"}, Subject: "alerts.sensor"},
+	}
+	routes, err := BuildRoutes(cfgs, []*Output{siem}, &messaging.Client{}, nil)
+	if err != nil {
+		t.Fatalf("BuildRoutes() error = %v", err)
+	}
+
+	subjects := func(evt interface{}) (subjects []string) {
+		for _, r := range routes {
+			if !r.Matches(evt) {
+				continue
+			}
+			for _, o := range r.Outputs() {
+				if w, ok := o.writer.(*NATSWriter); ok {
+					subjects = append(subjects, w.subject)
+				} else {
+					subjects = append(subjects, o.Name)
+				}
+			}
+		}
+		return
+	}
+	if got := subjects(dnsEvent()); len(got) != 1 || got[0] != "events.raw.info.dns" {
+		t.Errorf("DNS event routed to %v, want [events.raw.info.dns]", got)
+	}
+	if got := subjects(threatEvent()); len(got) != 2 || got[0] != "siem" || got[1] != "alerts.sensor" {
+		t.Errorf("threat routed to %v, want [siem alerts.sensor]", got)
+	}
+
+	for _, bad := range []config.RouteConfig{
+		{Name: "typo", Outputs: []string{"sime"}},
+		{Name: "nowhere", Protocols: []string{"dns"}},
+		{Name: "never", Protocols: []string{"dns"}, Severities: []string{"high"}, Outputs: []string{"siem"}},
+	} {
+		if _, err := BuildRoutes([]config.RouteConfig{bad}, []*Output{siem}, &messaging.Client{}, nil); err == nil {
+			t.Errorf("BuildRoutes(%s) expected error", bad.Name)
+		}
+	}
+}
+
+func TestManagerRoutes(t *testing.T) {
+	def, dns, alerts := &memWriter{}, &memWriter{}, &memWriter{}
+	defOut := New("siem", nil, def)
+	m := NewManager(defOut).WithRoutes(
+		NewRoute("dns", []string{"dns"}, nil, nil).To(New("route:dns", nil, dns)),
+		NewRoute("alerts", nil, []string{"high", "critical"}, nil).To(New("route:alerts", nil, alerts), defOut),
+	)
+
+	in := make(chan interface{}, 3)
+	in <- dnsEvent()
+	in <- threatEvent()
+	in <- sampleEvent()
+	close(in)
+	m.Run(context.Background(), in)
+
+	if len(dns.lines) != 1 || len(alerts.lines) != 1 || len(def.lines) != 2 {
+		t.Fatalf("delivered dns=%d alerts=%d default=%d, want 1, 1, 2", len(dns.lines), len(alerts.lines), len(def.lines))
+	}
+	if got := dns.keys(t, 0); !contains(got, "dns_query") {
+		t.Errorf("dns route fields = %v, want the DNS event", got)
+	}
+	if got := alerts.keys(t, 0); !contains(got, "type") {
+		t.Errorf("alerts route fields = %v, want the threat", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestThreatRoutes(t *testing.T) {
+	siem := New("siem", nil, &memWriter{})
+	routes := ThreatRoutes(&messaging.Client{}, nil, []*Output{siem})
+
+	m := NewManager(siem).WithRoutes(routes...)
+	threat := detector.Threat{Type: detector.ThreatSYNFlood, Severity: models.SeverityCritical, SrcIP: "10.0.0.7"}.Event()
+
+	var got []string
+	for _, o := range m.targets(threat) {
+		if w, ok := o.writer.(*NATSWriter); ok {
+			got = append(got, w.subject)
+		} else {
+			got = append(got, o.Name)
+		}
+	}
+	if len(got) != 2 || got[0] != "siem" || got[1] != "events.raw.critical.network-sensor" {
+		t.Errorf("threat routed to %v, want [siem events.raw.critical.network-sensor]", got)
+	}
+	if got := m.targets(dnsEvent()); len(got) != 1 || got[0] != siem {
+		t.Errorf("network event routed to %d outputs, want the default one", len(got))
+	}
+}
+
+func TestRouteSeverityOnLiveEvents(t *testing.T) {
+	cfg := detector.DefaultConfig()
+	cfg.RemoteAdminAttempts = 2
+	d := detector.NewDetector(cfg)
+	route := NewRoute("alerts", nil, []string{"high"}, []string{string(detector.ThreatRemoteAdminBruteForce)})
+
+	// The network events themselves never match, the threat raised from them does
+	var threats []detector.Threat
+	for i := 0; i < 2; i++ {
+		evt := sampleEvent()
+		evt.DstIP, evt.DstPort, evt.AppProtocol, evt.PayloadSize = "10.0.0.20", 3389, dpi.ProtoRDP, 40
+		evt.Timestamp = evt.Timestamp.Add(time.Duration(i) * time.Second)
+		if route.Matches(evt) {
+			t.Errorf("Matches(network event %d) = true, want false", i)
+		}
+		threats = append(threats, d.Analyze(&evt)...)
+	}
+	if len(threats) != 1 {
+		t.Fatalf("threats = %v, want one brute force", threats)
+	}
+	if !route.Matches(threats[0].Event()) {
+		t.Errorf("Matches(%s event) = false, want true", threats[0].Type)
+	}
+}
@@ -0,0 +1,161 @@
+package output
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"sakin-go/pkg/messaging"
+)
+
+// DefaultAckTimeout is how long NATSWriter waits for a JetStream ack before
+// spooling the event to its fallback.
+const DefaultAckTimeout = 5 * time.Second
+
+// maxPendingAcks bounds the events awaiting an ack; Write blocks beyond it.
+const maxPendingAcks = 4096
+
+// asyncPublisher is the part of messaging.Client NATSWriter needs.
+type asyncPublisher interface {
+	PublishAsync(ctx context.Context, subject string, data []byte, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error)
+}
+
+// pendingAck is a published event awaiting its JetStream ack.
+type pendingAck struct {
+	data   []byte
+	future jetstream.PubAckFuture
+	sent   time.Time
+}
+
+// NATSWriter publishes each event to a fixed JetStream subject.
+type NATSWriter struct {
+	client     asyncPublisher
+	subject    string
+	fallback   *FileProducer // nil drops events that fail to publish
+	ackTimeout time.Duration
+
+	pending   chan pendingAck // Awaiting acks, only with a fallback
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewNATSWriter creates a writer publishing to subject.
+func NewNATSWriter(client *messaging.Client, subject string) *NATSWriter {
+	return &NATSWriter{client: client, subject: subject, ackTimeout: DefaultAckTimeout}
+}
+
+// WithFallback stores events that fail to publish in fp for later replay:
+// those rejected right away as well as those whose ack reports an error or
+// does not arrive within the ack timeout.
+func (w *NATSWriter) WithFallback(fp *FileProducer) *NATSWriter {
+	if fp == nil || w.fallback != nil {
+		return w
+	}
+	w.fallback = fp
+	w.pending = make(chan pendingAck, maxPendingAcks)
+	w.done = make(chan struct{})
+	go w.watchAcks()
+	return w
+}
+
+func (w *NATSWriter) Write(data []byte) error {
+	future, err := w.client.PublishAsync(context.Background(), w.subject, data)
+	if w.fallback == nil {
+		return err
+	}
+	if err != nil {
+		return w.spool(data, err)
+	}
+	w.pending <- pendingAck{data: data, future: future, sent: time.Now()}
+	return nil
+}
+
+// watchAcks spools events whose ack fails or times out. Acks arrive
+// roughly in publish order, so the pending events are awaited in turn.
+func (w *NATSWriter) watchAcks() {
+	defer close(w.done)
+
+	timer := time.NewTimer(w.ackTimeout)
+	defer timer.Stop()
+	for p := range w.pending {
+		timer.Reset(w.ackTimeout - time.Since(p.sent))
+
+		var err error
+		select {
+		case <-p.future.Ok():
+		case err = <-p.future.Err():
+		case <-timer.C:
+			err = fmt.Errorf("no ack within %s", w.ackTimeout)
+		}
+		if err != nil {
+			if err := w.spool(p.data, err); err != nil {
+				log.Printf("[Output] Dropped event for %s: %v", w.subject, err)
+			}
+		}
+	}
+}
+
+// spool stores an event that failed to publish with cause in the fallback.
+func (w *NATSWriter) spool(data []byte, cause error) error {
+	if err := w.fallback.Write(w.subject, data); err != nil {
+		return fmt.Errorf("publish: %v, fallback: %w", cause, err)
+	}
+	return nil
+}
+
+// Close waits for the pending acks, spooling the events not acked. The NATS
+// client itself is owned by main.
+func (w *NATSWriter) Close() error {
+	if w.pending != nil {
+		w.closeOnce.Do(func() { close(w.pending) })
+		<-w.done
+	}
+	return nil
+}
+
+// FileWriter appends events as JSON lines to a file.
+type FileWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+}
+
+// NewFileWriter opens (or creates) path for appending.
+func NewFileWriter(path string) (*FileWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &FileWriter{file: f, buf: bufio.NewWriterSize(f, 64*1024)}, nil
+}
+
+func (w *FileWriter) Write(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.buf.Write(data); err != nil {
+		return err
+	}
+	return w.buf.WriteByte('\n')
+}
+
+// Flush writes buffered lines to disk.
+func (w *FileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Flush()
+}
+
+func (w *FileWriter) Close() error {
+	if err := w.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
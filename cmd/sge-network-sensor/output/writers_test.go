@@ -0,0 +1,70 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// fakeFuture resolves the way the test tells it to.
+type fakeFuture struct {
+	ok  chan *jetstream.PubAck
+	err chan error
+}
+
+func (f *fakeFuture) Ok() <-chan *jetstream.PubAck { return f.ok }
+func (f *fakeFuture) Err() <-chan error            { return f.err }
+func (f *fakeFuture) Msg() *nats.Msg               { return nil }
+
+// fakePublisher answers each publish by the outcome registered for its payload.
+type fakePublisher struct {
+	outcomes map[string]string // "ack", "nack", "lost" or "reject"
+}
+
+func (p *fakePublisher) PublishAsync(ctx context.Context, subject string, data []byte, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	f := &fakeFuture{ok: make(chan *jetstream.PubAck, 1), err: make(chan error, 1)}
+	switch p.outcomes[string(data)] {
+	case "ack":
+		f.ok <- &jetstream.PubAck{Stream: "EVENTS"}
+	case "nack":
+		f.err <- errors.New("stream full")
+	case "reject":
+		return nil, nats.ErrConnectionClosed
+	}
+	return f, nil // "lost" never resolves
+}
+
+func TestNATSWriterSpoolsUnackedEvents(t *testing.T) {
+	fp, err := NewFileProducer(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewFileProducer() error = %v", err)
+	}
+	pub := &fakePublisher{outcomes: map[string]string{"a": "ack", "b": "nack", "c": "lost", "d": "reject"}}
+	w := &NATSWriter{client: pub, subject: "events.raw.low.network-sensor", ackTimeout: 50 * time.Millisecond}
+	w.WithFallback(fp)
+
+	for _, data := range []string{"a", "b", "c", "d"} {
+		if err := w.Write([]byte(data)); err != nil {
+			t.Errorf("Write(%s) error = %v, want spooled", data, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	spooled := map[string]bool{}
+	for _, p := range replayAll(t, fp) {
+		if p.subject != w.subject {
+			t.Errorf("spooled subject = %s, want %s", p.subject, w.subject)
+		}
+		spooled[p.data] = true
+	}
+	want := map[string]bool{"b": true, "c": true, "d": true}
+	if len(spooled) != len(want) || !spooled["b"] || !spooled["c"] || !spooled["d"] {
+		t.Errorf("spooled = %v, want the nacked, timed out and rejected events %v", spooled, want)
+	}
+}
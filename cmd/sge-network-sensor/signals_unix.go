@@ -0,0 +1,26 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+)
+
+// handleCaptureSignals pauses capture on SIGUSR1 and resumes it on SIGUSR2.
+func handleCaptureSignals(insp *inspector.Inspector) {
+	ctlChan := make(chan os.Signal, 1)
+	signal.Notify(ctlChan, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range ctlChan {
+			if sig == syscall.SIGUSR1 {
+				insp.Pause()
+			} else {
+				insp.Resume()
+			}
+		}
+	}()
+}
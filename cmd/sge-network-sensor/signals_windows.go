@@ -0,0 +1,9 @@
+//go:build windows
+
+package main
+
+import "sakin-go/cmd/sge-network-sensor/inspector"
+
+// handleCaptureSignals is a no-op: Windows has no SIGUSR1/SIGUSR2, use the
+// admin API to pause and resume capture.
+func handleCaptureSignals(insp *inspector.Inspector) {}
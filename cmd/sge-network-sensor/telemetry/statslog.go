@@ -0,0 +1,140 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/cmd/sge-network-sensor/output"
+)
+
+// Counters is a snapshot of the cumulative sensor counters.
+type Counters struct {
+	Inspector inspector.Stats
+	Outputs   map[string]output.Stats // Keyed by output name
+}
+
+// OutputRates is the activity of one output over an interval.
+type OutputRates struct {
+	Written uint64
+	Failed  uint64
+	PerSec  float64 // Written events per second
+}
+
+// Rates is the activity between two Counters snapshots.
+type Rates struct {
+	Elapsed       time.Duration
+	Packets       uint64
+	PacketsPerSec float64
+	Events        uint64
+	EventsPerSec  float64
+	QueueDrops    uint64
+	EventDrops    uint64
+	SampledOut    uint64
+	ParseErrors   uint64
+	FlowsActive   int // Current, not a delta
+	Outputs       map[string]OutputRates
+}
+
+// delta returns cur - prev, or 0 if the counter went backwards (restart).
+func delta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+func perSec(n uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / elapsed.Seconds()
+}
+
+// Delta computes the activity between prev and cur, taken elapsed apart.
+func Delta(prev, cur Counters, elapsed time.Duration) Rates {
+	p, c := prev.Inspector, cur.Inspector
+	r := Rates{
+		Elapsed:     elapsed,
+		Packets:     delta(p.Packets, c.Packets),
+		Events:      delta(p.Events, c.Events),
+		QueueDrops:  delta(p.QueueDrops, c.QueueDrops),
+		EventDrops:  delta(p.EventDrops, c.EventDrops),
+		SampledOut:  delta(p.SampledOut, c.SampledOut),
+		FlowsActive: c.Flows.Active,
+		Outputs:     make(map[string]OutputRates, len(cur.Outputs)),
+	}
+	r.PacketsPerSec = perSec(r.Packets, elapsed)
+	r.EventsPerSec = perSec(r.Events, elapsed)
+	for proto, n := range c.ParseErrors {
+		r.ParseErrors += delta(p.ParseErrors[proto], n)
+	}
+	for name, o := range cur.Outputs {
+		was := prev.Outputs[name]
+		written := delta(was.Written, o.Written)
+		r.Outputs[name] = OutputRates{Written: written, Failed: delta(was.Failed, o.Failed), PerSec: perSec(written, elapsed)}
+	}
+	return r
+}
+
+func (r Rates) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d packets (%.0f/s), %d events (%.0f/s), drops queue=%d events=%d, sampled out %d, parse errors %d, %d flows",
+		r.Elapsed.Round(time.Second), r.Packets, r.PacketsPerSec, r.Events, r.EventsPerSec,
+		r.QueueDrops, r.EventDrops, r.SampledOut, r.ParseErrors, r.FlowsActive)
+
+	names := make([]string, 0, len(r.Outputs))
+	for name := range r.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o := r.Outputs[name]
+		fmt.Fprintf(&sb, "; %s %d written (%.0f/s) %d failed", name, o.Written, o.PerSec, o.Failed)
+	}
+	return sb.String()
+}
+
+// StatsLogger periodically logs the sensor activity since the previous
+// log line, so progress is visible during long runs.
+type StatsLogger struct {
+	collect func() Counters
+	last    Counters
+	lastAt  time.Time
+
+	now func() time.Time // Overridable for tests
+}
+
+// NewStatsLogger creates a logger reading counters from collect.
+func NewStatsLogger(collect func() Counters) *StatsLogger {
+	l := &StatsLogger{collect: collect, now: time.Now}
+	l.last, l.lastAt = collect(), l.now()
+	return l
+}
+
+// Next returns the activity since the previous call (or creation).
+func (l *StatsLogger) Next() Rates {
+	now, cur := l.now(), l.collect()
+	r := Delta(l.last, cur, now.Sub(l.lastAt))
+	l.last, l.lastAt = cur, now
+	return r
+}
+
+// Run logs the activity every interval until ctx is done.
+func (l *StatsLogger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Printf("[Stats] %s", l.Next())
+		}
+	}
+}
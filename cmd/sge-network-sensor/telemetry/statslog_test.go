@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/cmd/sge-network-sensor/output"
+)
+
+func TestDelta(t *testing.T) {
+	prev := Counters{
+		Inspector: inspector.Stats{Packets: 1000, Events: 800, QueueDrops: 5, ParseErrors: map[string]uint64{"tls": 2, "dns": 1}},
+		Outputs:   map[string]output.Stats{"siem": {Written: 800, Failed: 1}},
+	}
+	cur := Counters{
+		Inspector: inspector.Stats{
+			Packets: 31000, Events: 15800, QueueDrops: 5, EventDrops: 40, SampledOut: 100,
+			ParseErrors: map[string]uint64{"tls": 7, "dns": 1, "http": 3},
+			Flows:       inspector.FlowStats{Active: 250},
+		},
+		Outputs: map[string]output.Stats{"siem": {Written: 15800, Failed: 4}, "archive": {Written: 600}},
+	}
+
+	r := Delta(prev, cur, 30*time.Second)
+
+	if r.Packets != 30000 || r.PacketsPerSec != 1000 {
+		t.Errorf("packets = %d (%.1f/s), want 30000 (1000/s)", r.Packets, r.PacketsPerSec)
+	}
+	if r.Events != 15000 || r.EventsPerSec != 500 {
+		t.Errorf("events = %d (%.1f/s), want 15000 (500/s)", r.Events, r.EventsPerSec)
+	}
+	if r.QueueDrops != 0 || r.EventDrops != 40 || r.SampledOut != 100 {
+		t.Errorf("drops = %d/%d, sampled out %d, want 0/40, 100", r.QueueDrops, r.EventDrops, r.SampledOut)
+	}
+	if r.ParseErrors != 8 {
+		t.Errorf("ParseErrors = %d, want 8", r.ParseErrors)
+	}
+	if r.FlowsActive != 250 {
+		t.Errorf("FlowsActive = %d, want 250", r.FlowsActive)
+	}
+	if got, want := r.Outputs["siem"], (OutputRates{Written: 15000, Failed: 3, PerSec: 500}); got != want {
+		t.Errorf("siem = %+v, want %+v", got, want)
+	}
+	if got, want := r.Outputs["archive"], (OutputRates{Written: 600, PerSec: 20}); got != want {
+		t.Errorf("archive = %+v, want %+v", got, want)
+	}
+
+	line := r.String()
+	for _, want := range []string{"30s:", "30000 packets (1000/s)", "15000 events (500/s)", "archive 600 written (20/s)", "siem 15000 written (500/s) 3 failed"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("String() = %q, missing %q", line, want)
+		}
+	}
+	if strings.Index(line, "archive") > strings.Index(line, "siem") {
+		t.Errorf("String() = %q, want outputs sorted by name", line)
+	}
+}
+
+func TestDeltaCounterReset(t *testing.T) {
+	prev := Counters{Inspector: inspector.Stats{Packets: 5000}}
+	cur := Counters{Inspector: inspector.Stats{Packets: 100}}
+	if r := Delta(prev, cur, 10*time.Second); r.Packets != 0 || r.PacketsPerSec != 0 {
+		t.Errorf("after reset packets = %d (%.1f/s), want 0", r.Packets, r.PacketsPerSec)
+	}
+	if r := Delta(Counters{}, cur, 0); r.PacketsPerSec != 0 {
+		t.Errorf("zero interval PacketsPerSec = %.1f, want 0", r.PacketsPerSec)
+	}
+}
+
+func TestStatsLoggerNext(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cur := Counters{Inspector: inspector.Stats{Packets: 100}}
+
+	l := NewStatsLogger(func() Counters { return cur })
+	l.now = func() time.Time { return clock }
+	l.lastAt = clock
+
+	for _, step := range []struct {
+		packets uint64
+		wantPPS float64
+	}{{2100, 200}, {2600, 50}} {
+		clock = clock.Add(10 * time.Second)
+		cur.Inspector.Packets = step.packets
+		if r := l.Next(); r.PacketsPerSec != step.wantPPS || r.Elapsed != 10*time.Second {
+			t.Errorf("Next() = %.1f/s over %s, want %.1f/s over 10s", r.PacketsPerSec, r.Elapsed, step.wantPPS)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+	"sakin-go/pkg/utils"
+)
+
+// Source is the event source of sensor telemetry events.
+const Source = "network-sensor"
+
+// Writer delivers encoded telemetry events (e.g. an output.NATSWriter on
+// system.sensors.<sensor_name>).
+type Writer interface {
+	Write(data []byte) error
+}
+
+// Reporter periodically turns inspector stats into sensor telemetry events
+// so the backend can monitor a sensor fleet like any other event stream.
+type Reporter struct {
+	sensor string
+	stats  func() inspector.Stats
+	writer Writer
+
+	mu       sync.Mutex
+	circuits map[string]func() string
+	started  time.Time
+	last     inspector.Stats
+	lastAt   time.Time
+
+	now func() time.Time // Overridable for tests
+}
+
+// NewReporter creates a reporter for sensor reading from stats.
+func NewReporter(sensor string, stats func() inspector.Stats, w Writer) *Reporter {
+	r := &Reporter{
+		sensor:   sensor,
+		stats:    stats,
+		writer:   w,
+		circuits: make(map[string]func() string),
+		now:      time.Now,
+	}
+	r.started = r.now()
+	r.lastAt = r.started
+	return r
+}
+
+// AddCircuit registers a downstream dependency whose state (e.g. a circuit
+// breaker or connection status) is included in every report.
+func (r *Reporter) AddCircuit(name string, state func() string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.circuits[name] = state
+}
+
+// Run emits a report every interval until ctx is done.
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Report(); err != nil {
+				log.Printf("[Telemetry] Publish failed: %v", err)
+			}
+		}
+	}
+}
+
+// Report builds one telemetry event and writes it.
+func (r *Reporter) Report() error {
+	data, err := json.Marshal(r.Snapshot())
+	if err != nil {
+		return err
+	}
+	return r.writer.Write(data)
+}
+
+// Snapshot builds a telemetry event from the current stats. Rates and
+// deltas cover the time since the previous snapshot.
+func (r *Reporter) Snapshot() *models.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	cur := r.stats()
+	elapsed := now.Sub(r.lastAt).Seconds()
+
+	newDrops := (cur.QueueDrops - r.last.QueueDrops) + (cur.EventDrops - r.last.EventDrops)
+	var pps float64
+	if elapsed > 0 {
+		pps = float64(cur.Packets-r.last.Packets) / elapsed
+	}
+
+	circuits := make(map[string]interface{}, len(r.circuits))
+	for name, state := range r.circuits {
+		circuits[name] = state()
+	}
+
+	newEvictions := cur.Flows.ForcedEvictions - r.last.Flows.ForcedEvictions
+
+	severity := models.SeverityInfo
+	if newDrops > 0 {
+		// Dropping packets means the sensor is losing visibility
+		severity = models.SeverityLow
+	}
+	if cur.Flows.UnderPressure || newEvictions > 0 {
+		// Live flows are being forgotten, flow based detections degrade
+		severity = models.SeverityMedium
+	}
+
+	r.last, r.lastAt = cur, now
+
+	return &models.Event{
+		ID:          utils.GenerateID(),
+		Timestamp:   now.UTC(),
+		Source:      Source,
+		EventType:   models.EventTypeSensorTelemetry,
+		Severity:    severity,
+		Status:      models.EventStatusNew,
+		Description: "Sensor health report: " + r.sensor,
+		Metadata: map[string]interface{}{
+			"sensor":         r.sensor,
+			"uptime_seconds": int64(now.Sub(r.started).Seconds()),
+			"interfaces":     cur.Interfaces,
+			"packets":        cur.Packets,
+			"pps":            pps,
+			"events":         cur.Events,
+			"queue_depth":    cur.QueueDepth,
+			"queue_drops":    cur.QueueDrops,
+			"event_drops":    cur.EventDrops,
+			"drops_interval": newDrops,
+			"sampled_out":    cur.SampledOut,
+			"parse_errors":   cur.ParseErrors,
+			"partial_parses": cur.PartialParses,
+			"circuits":       circuits,
+
+			"flows_active":             cur.Flows.Active,
+			"flows_high_water":         cur.Flows.HighWater,
+			"flows_capacity":           cur.Flows.Capacity,
+			"flows_expired":            cur.Flows.Expired,
+			"flow_evictions":           cur.Flows.ForcedEvictions,
+			"flow_evictions_interval":  newEvictions,
+			"flow_cache_pressure":      cur.Flows.UnderPressure,
+			"flows_embryonic":          cur.Flows.Embryonic,
+			"flow_embryonic_evictions": cur.Flows.EmbryonicEvictions,
+		},
+	}
+}
+
+// ReportFlowPressure immediately writes a warning event when the flow
+// table starts evicting live flows (see inspector.FlowTable.OnPressure),
+// instead of waiting for the next periodic report.
+func (r *Reporter) ReportFlowPressure(s inspector.FlowStats) error {
+	evt := &models.Event{
+		ID:          utils.GenerateID(),
+		Timestamp:   r.now().UTC(),
+		Source:      Source,
+		EventType:   models.EventTypeSensorTelemetry,
+		Severity:    models.SeverityMedium,
+		Status:      models.EventStatusNew,
+		Description: "Sensor flow table under pressure: " + r.sensor,
+		Metadata: map[string]interface{}{
+			"sensor":              r.sensor,
+			"flow_cache_pressure": true,
+			"flows_active":        s.Active,
+			"flows_capacity":      s.Capacity,
+			"flow_evictions":      s.ForcedEvictions,
+		},
+	}
+
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	return r.writer.Write(data)
+}
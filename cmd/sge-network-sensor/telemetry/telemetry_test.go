@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"sakin-go/cmd/sge-network-sensor/inspector"
+	"sakin-go/pkg/models"
+)
+
+type recordWriter struct {
+	mu     sync.Mutex
+	events []models.Event
+}
+
+func (w *recordWriter) Write(data []byte) error {
+	var evt models.Event
+	if err := json.Unmarshal(data, &evt); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.events = append(w.events, evt)
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *recordWriter) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.events)
+}
+
+func TestSnapshot(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stats := inspector.Stats{Interfaces: 2}
+
+	r := NewReporter("sensor-a", func() inspector.Stats { return stats }, &recordWriter{})
+	r.now = func() time.Time { return clock }
+	r.started, r.lastAt = clock, clock
+	r.AddCircuit("nats", func() string { return "CONNECTED" })
+
+	clock = clock.Add(10 * time.Second)
+	stats.Packets, stats.Events, stats.QueueDepth = 5000, 4000, 12
+	first := r.Snapshot()
+
+	if first.EventType != models.EventTypeSensorTelemetry || first.Source != Source {
+		t.Fatalf("type/source = %s/%s, want %s/%s", first.EventType, first.Source, models.EventTypeSensorTelemetry, Source)
+	}
+	if first.Severity != models.SeverityInfo {
+		t.Errorf("Severity = %s, want info", first.Severity)
+	}
+	m := first.Metadata
+	if m["sensor"] != "sensor-a" || m["pps"] != 500.0 || m["queue_depth"] != 12 || m["interfaces"] != 2 {
+		t.Errorf("Metadata = %v", m)
+	}
+	if c := m["circuits"].(map[string]interface{}); c["nats"] != "CONNECTED" {
+		t.Errorf("circuits = %v, want nats=CONNECTED", c)
+	}
+
+	// Drops since the last report raise severity; pps covers the interval only
+	clock = clock.Add(5 * time.Second)
+	stats.Packets, stats.QueueDrops, stats.EventDrops = 6000, 3, 4
+	second := r.Snapshot()
+
+	if second.Severity != models.SeverityLow {
+		t.Errorf("Severity = %s, want low", second.Severity)
+	}
+	m = second.Metadata
+	if m["pps"] != 200.0 || m["drops_interval"] != uint64(7) || m["uptime_seconds"] != int64(15) {
+		t.Errorf("Metadata = %v", m)
+	}
+}
+
+func TestRunEmitsPeriodically(t *testing.T) {
+	w := &recordWriter{}
+	r := NewReporter("sensor-a", func() inspector.Stats {
+		return inspector.Stats{Interfaces: 1, Packets: 42}
+	}, w)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for w.count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if w.count() < 3 {
+		t.Fatalf("reports = %d, want at least 3", w.count())
+	}
+	for _, evt := range w.events {
+		if evt.ID == "" || evt.EventType != models.EventTypeSensorTelemetry {
+			t.Errorf("event = %+v", evt)
+		}
+		for _, key := range []string{"sensor", "pps", "packets", "queue_depth", "queue_drops", "event_drops", "circuits"} {
+			if _, ok := evt.Metadata[key]; !ok {
+				t.Errorf("Metadata missing %q: %v", key, evt.Metadata)
+			}
+		}
+	}
+}
+
+func TestFlowPressure(t *testing.T) {
+	stats := inspector.Stats{Flows: inspector.FlowStats{Active: 10, Capacity: 10, HighWater: 10}}
+	w := &recordWriter{}
+	r := NewReporter("sensor-a", func() inspector.Stats { return stats }, w)
+
+	if evt := r.Snapshot(); evt.Severity != models.SeverityInfo || evt.Metadata["flow_cache_pressure"] != false {
+		t.Fatalf("Snapshot() without pressure = %s %v", evt.Severity, evt.Metadata)
+	}
+
+	stats.Flows.ForcedEvictions, stats.Flows.UnderPressure = 3, true
+	evt := r.Snapshot()
+	if evt.Severity != models.SeverityMedium || evt.Metadata["flow_evictions_interval"] != uint64(3) || evt.Metadata["flows_high_water"] != 10 {
+		t.Errorf("Snapshot() under pressure = %s %v", evt.Severity, evt.Metadata)
+	}
+
+	if err := r.ReportFlowPressure(stats.Flows); err != nil {
+		t.Fatalf("ReportFlowPressure() error = %v", err)
+	}
+	got := w.events[0]
+	if got.Severity != models.SeverityMedium || got.Metadata["flow_cache_pressure"] != true || got.Metadata["flow_evictions"] != 3.0 {
+		t.Errorf("pressure event = %s %v", got.Severity, got.Metadata)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"sakin-go/pkg/database"
 )
@@ -15,29 +16,29 @@ type DashboardStats struct {
 }
 
 type DashboardService struct {
-	ch *database.ClickHouseClient
-	pg *database.PostgresClient
+	events database.EventStore
+	pg     *database.PostgresClient
 }
 
-func NewDashboardService(ch *database.ClickHouseClient, pg *database.PostgresClient) *DashboardService {
-	return &DashboardService{ch: ch, pg: pg}
+func NewDashboardService(events database.EventStore, pg *database.PostgresClient) *DashboardService {
+	return &DashboardService{events: events, pg: pg}
 }
 
 func (s *DashboardService) GetOverview(ctx context.Context) (*DashboardStats, error) {
 	stats := &DashboardStats{}
 
-	// 1. ClickHouse Stats (Events)
-	// count() from events
-	row := s.ch.Conn().QueryRow(ctx, "SELECT count() FROM events")
-	if err := row.Scan(&stats.TotalEvents); err != nil {
-		return nil, fmt.Errorf("ch query failed: %w", err)
+	// 1. Event store stats
+	total, err := s.events.CountEvents(ctx, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("event count failed: %w", err)
 	}
+	stats.TotalEvents = total
 
-	// count() last hour
-	row = s.ch.Conn().QueryRow(ctx, "SELECT count() FROM events WHERE timestamp > now() - INTERVAL 1 HOUR")
-	if err := row.Scan(&stats.EventsLastHour); err != nil {
+	lastHour, err := s.events.CountEvents(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
 		return nil, err
 	}
+	stats.EventsLastHour = lastHour
 
 	// 2. Postgres Stats (Alerts)
 	// SELECT count(*) FROM alerts WHERE status = 'new'
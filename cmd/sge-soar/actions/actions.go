@@ -2,18 +2,34 @@ package actions
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/nats-io/nats.go/jetstream"
+
 	"sakin-go/pkg/messaging"
+	"sakin-go/pkg/models"
+	"sakin-go/pkg/utils"
 )
 
+// Publisher sends commands to agents; *messaging.Client implements it.
+type Publisher interface {
+	PublishAsync(ctx context.Context, subject string, data []byte, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error)
+}
+
 // ExecutionContext holds data available to an action (e.g. the Alert).
 type ExecutionContext struct {
-	AlertID    string
-	TargetIP   string
-	NatsClient *messaging.Client
+	ExecutionID string
+	AlertID     string
+	AgentID     string // Agent receiving commands, from the alert or playbook
+	TargetIP    string
+	NatsClient  Publisher // nil skips publishing
+
+	// TrackCommand registers a command dispatched to agentID so its result
+	// can be correlated with this execution. Must be called before publishing.
+	TrackCommand func(commandID, agentID string)
 }
 
 // Action interface
@@ -36,19 +52,39 @@ type BlockIPAction struct{}
 func (a *BlockIPAction) Name() string { return "block_ip" }
 
 func (a *BlockIPAction) Execute(ctx context.Context, execCtx *ExecutionContext, params map[string]interface{}) error {
-	log.Printf("[SOAR] Executing BlockIP on %s (Alert: %s)", execCtx.TargetIP, execCtx.AlertID)
+	// The step may name the agent controlling the firewall, otherwise the
+	// command goes to the execution's agent.
+	agentID := execCtx.AgentID
+	if id, ok := params["agent_id"].(string); ok && id != "" {
+		agentID = id
+	}
+	if agentID == "" {
+		return fmt.Errorf("no target agent: set agent_id on the step, playbook or alert")
+	}
 
-	// In real system: Send command to Firewall Agent or API
-	// Simulation: Publish a command to NATS
-	// Subject: commands.<agent_id> (We assume we know which agent controls the firewall, or broadcast)
-	// For MVP: Just log success
+	log.Printf("[SOAR] Executing BlockIP on %s via agent %s (Alert: %s)", execCtx.TargetIP, agentID, execCtx.AlertID)
 
 	// Command payload
-	cmd := fmt.Sprintf(`{"action": "firewall_block", "ip": "%s"}`, execCtx.TargetIP)
+	cmd := &models.Command{
+		ID:       utils.GenerateID(),
+		Action:   "firewall_block",
+		Params:   map[string]interface{}{"ip": execCtx.TargetIP},
+		AlertID:  execCtx.AlertID,
+		IssuedAt: utils.NowUTC(),
+	}
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to encode command: %w", err)
+	}
 
 	if execCtx.NatsClient != nil {
-		subject := messaging.TopicCommands + "firewall-agent" // simplified
-		execCtx.NatsClient.PublishAsync(ctx, subject, []byte(cmd))
+		if execCtx.TrackCommand != nil {
+			execCtx.TrackCommand(cmd.ID, agentID)
+		}
+		subject := messaging.SafeSubject(messaging.TopicCommands, agentID)
+		if _, err := execCtx.NatsClient.PublishAsync(ctx, subject, data); err != nil {
+			return fmt.Errorf("failed to publish command: %w", err)
+		}
 	}
 
 	time.Sleep(100 * time.Millisecond) // Simulate work
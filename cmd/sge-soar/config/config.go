@@ -2,12 +2,25 @@ package config
 
 import (
 	"os"
+	"strconv"
 )
 
 type Config struct {
 	NatsURL      string
 	NatsUser     string
 	NatsPassword string
+
+	// Postgres (playbook execution status)
+	PostgresHost string
+	PostgresPort int
+	PostgresUser string
+	PostgresPass string
+	PostgresDB   string
+
+	// CommandTimeout is how long agents have to report a command result (seconds)
+	CommandTimeout int
+	// DefaultAgentID receives commands when neither playbook nor alert names an agent
+	DefaultAgentID string
 }
 
 func LoadConfig() *Config {
@@ -15,6 +28,15 @@ func LoadConfig() *Config {
 		NatsURL:      getEnv("NATS_URL", "nats://localhost:4222"),
 		NatsUser:     getEnv("NATS_USER", "admin"),
 		NatsPassword: getEnv("NATS_PASSWORD", "sakin123"),
+
+		PostgresHost: getEnv("POSTGRES_ADDR", "localhost"),
+		PostgresPort: 5432,
+		PostgresUser: getEnv("POSTGRES_USER", "postgres"),
+		PostgresPass: getEnv("POSTGRES_PASSWORD", "sakin123"),
+		PostgresDB:   getEnv("POSTGRES_DB", "sge_db"),
+
+		CommandTimeout: getEnvInt("SOAR_COMMAND_TIMEOUT", 300),
+		DefaultAgentID: getEnv("SOAR_DEFAULT_AGENT_ID", "firewall-agent"),
 	}
 }
 
@@ -24,3 +46,12 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if val, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(val); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
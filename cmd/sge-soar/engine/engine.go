@@ -2,11 +2,15 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"sakin-go/cmd/sge-soar/actions"
 	"sakin-go/pkg/messaging"
 	"sakin-go/pkg/models"
+	"sakin-go/pkg/utils"
 )
 
 // Playbook definition
@@ -14,6 +18,7 @@ type Playbook struct {
 	ID      string
 	Name    string
 	Trigger string // e.g., "Critical Severity", "RuleID=xyz"
+	AgentID string // Agent receiving commands unless the alert names one
 	Steps   []PlaybookStep
 }
 
@@ -22,20 +27,82 @@ type PlaybookStep struct {
 	Params     map[string]interface{}
 }
 
+// ExecutionStore persists playbook execution status (e.g. Postgres).
+type ExecutionStore interface {
+	SavePlaybookExecution(ctx context.Context, exec *models.PlaybookExecution) error
+}
+
+// DefaultCommandTimeout is how long an agent has to report a command result.
+const DefaultCommandTimeout = 5 * time.Minute
+
+// DefaultAgentID receives commands of playbooks that name no agent, unless
+// the alert does.
+const DefaultAgentID = "firewall-agent"
+
+// execution tracks a running playbook until all agent commands report back.
+type execution struct {
+	record    models.PlaybookExecution
+	pending   map[string]bool // Command IDs awaiting a result
+	stepsDone bool
+}
+
+// command is a dispatched agent command awaiting its result.
+type command struct {
+	execID  string
+	agentID string // Only this agent's result is accepted
+	issued  time.Time
+}
+
 // Engine executes playbooks.
 type Engine struct {
-	playbooks  []*Playbook
-	natsClient *messaging.Client
+	playbooks      []*Playbook
+	natsClient     actions.Publisher
+	store          ExecutionStore
+	commandTimeout time.Duration
+	defaultAgent   string
+	now            func() time.Time
+
+	mu         sync.Mutex
+	executions map[string]*execution // Execution ID -> state
+	commands   map[string]command    // Command ID -> issuing execution
 }
 
-func NewEngine(nc *messaging.Client) *Engine {
+// NewEngine creates an engine. store may be nil, in which case execution
+// status is only tracked in memory.
+func NewEngine(nc *messaging.Client, store ExecutionStore) *Engine {
 	e := &Engine{
-		natsClient: nc,
+		store:          store,
+		commandTimeout: DefaultCommandTimeout,
+		defaultAgent:   DefaultAgentID,
+		now:            time.Now,
+		executions:     make(map[string]*execution),
+		commands:       make(map[string]command),
+	}
+	if nc != nil {
+		e.natsClient = nc
 	}
 	e.loadDummyPlaybooks()
 	return e
 }
 
+// WithDefaultAgent sets the agent receiving commands of playbooks that name
+// no agent, unless the alert does.
+func (e *Engine) WithDefaultAgent(agentID string) *Engine {
+	if agentID != "" {
+		e.defaultAgent = agentID
+	}
+	return e
+}
+
+// WithCommandTimeout sets how long commands may go unanswered before their
+// execution fails (see ExpireCommands).
+func (e *Engine) WithCommandTimeout(d time.Duration) *Engine {
+	if d > 0 {
+		e.commandTimeout = d
+	}
+	return e
+}
+
 func (e *Engine) loadDummyPlaybooks() {
 	// Demo Playbook: Auto-Block Critical Threats
 	e.playbooks = append(e.playbooks, &Playbook{
@@ -83,10 +150,25 @@ func (e *Engine) runPlaybook(ctx context.Context, pb *Playbook, alert *models.Al
 	// Simulating target IP extraction:
 	targetIP := "1.2.3.4" // Placeholder
 
+	exec := e.startExecution(ctx, pb, alert)
+
+	agentID := pb.AgentID
+	if agentID == "" {
+		agentID = e.defaultAgent
+	}
+	if id, ok := alert.Metadata["agent_id"].(string); ok && id != "" {
+		agentID = id
+	}
+
 	execCtx := &actions.ExecutionContext{
-		AlertID:    alert.ID,
-		TargetIP:   targetIP,
-		NatsClient: e.natsClient,
+		ExecutionID: exec.record.ID,
+		AlertID:     alert.ID,
+		AgentID:     agentID,
+		TargetIP:    targetIP,
+		NatsClient:  e.natsClient,
+		TrackCommand: func(commandID, agentID string) {
+			e.trackCommand(exec.record.ID, commandID, agentID)
+		},
 	}
 
 	for _, step := range pb.Steps {
@@ -98,7 +180,177 @@ func (e *Engine) runPlaybook(ctx context.Context, pb *Playbook, alert *models.Al
 
 		if err := action.Execute(ctx, execCtx, step.Params); err != nil {
 			log.Printf("[SOAR] Action Failed: %v", err)
-			break // Stop playbook on failure?
+			e.finishSteps(ctx, exec.record.ID, fmt.Errorf("%s: %w", step.ActionName, err))
+			return // Stop playbook on failure
+		}
+	}
+
+	e.finishSteps(ctx, exec.record.ID, nil)
+}
+
+// HandleCommandResult correlates an agent result with the execution that
+// issued the command and updates its status. It returns false for results
+// that do not belong to a tracked execution or come from another agent than
+// the command was sent to.
+func (e *Engine) HandleCommandResult(ctx context.Context, res *models.CommandResult) bool {
+	e.mu.Lock()
+	cmd, ok := e.commands[res.CommandID]
+	if !ok {
+		e.mu.Unlock()
+		return false
+	}
+	if res.AgentID != cmd.agentID {
+		e.mu.Unlock()
+		log.Printf("[SOAR] Rejecting result for command %s from agent %s, it was sent to %s", res.CommandID, res.AgentID, cmd.agentID)
+		return false
+	}
+	delete(e.commands, res.CommandID)
+
+	exec := e.executions[cmd.execID]
+	delete(exec.pending, res.CommandID)
+
+	var snapshot *models.PlaybookExecution
+	if !res.Success {
+		log.Printf("[SOAR] Command %s failed on agent %s: %s", res.CommandID, res.AgentID, res.Detail)
+		snapshot = e.finishLocked(exec, models.ExecutionStatusFailed,
+			fmt.Sprintf("agent %s: %s", res.AgentID, res.Detail))
+	} else if exec.stepsDone && len(exec.pending) == 0 {
+		snapshot = e.finishLocked(exec, models.ExecutionStatusSuccess, "")
+	}
+	e.mu.Unlock()
+
+	if snapshot != nil {
+		e.save(ctx, snapshot)
+	}
+	return true
+}
+
+func (e *Engine) startExecution(ctx context.Context, pb *Playbook, alert *models.Alert) *execution {
+	exec := &execution{
+		record: models.PlaybookExecution{
+			ID:         utils.GenerateID(),
+			PlaybookID: pb.ID,
+			AlertID:    alert.ID,
+			Status:     models.ExecutionStatusRunning,
+			StartedAt:  utils.NowUTC(),
+		},
+		pending: make(map[string]bool),
+	}
+
+	e.mu.Lock()
+	e.executions[exec.record.ID] = exec
+	snapshot := exec.record
+	e.mu.Unlock()
+
+	e.save(ctx, &snapshot)
+	return exec
+}
+
+func (e *Engine) trackCommand(execID, commandID, agentID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if exec, ok := e.executions[execID]; ok {
+		exec.pending[commandID] = true
+		e.commands[commandID] = command{execID: execID, agentID: agentID, issued: e.now()}
+	}
+}
+
+// ExpireCommands fails the executions of commands unanswered for longer
+// than the command timeout and forgets them, so agents that never report
+// back do not grow the engine's state. It returns the number of
+// executions failed.
+func (e *Engine) ExpireCommands(ctx context.Context) int {
+	cutoff := e.now().Add(-e.commandTimeout)
+
+	e.mu.Lock()
+	var snapshots []*models.PlaybookExecution
+	for id, cmd := range e.commands {
+		if !cmd.issued.Before(cutoff) {
+			continue
 		}
+		exec, ok := e.executions[cmd.execID]
+		if !ok {
+			delete(e.commands, id)
+			continue
+		}
+		log.Printf("[SOAR] Command %s timed out after %s", id, e.commandTimeout)
+		snapshots = append(snapshots, e.finishLocked(exec, models.ExecutionStatusFailed,
+			fmt.Sprintf("command %s: no result within %s", id, e.commandTimeout)))
+	}
+	e.mu.Unlock()
+
+	for _, snapshot := range snapshots {
+		e.save(ctx, snapshot)
+	}
+	return len(snapshots)
+}
+
+// Run expires unanswered commands until ctx is done.
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(e.commandTimeout/10, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.ExpireCommands(ctx)
+		}
+	}
+}
+
+// finishSteps marks all playbook steps as dispatched. The execution completes
+// now if it failed or has no outstanding commands, otherwise on the last result.
+func (e *Engine) finishSteps(ctx context.Context, execID string, stepErr error) {
+	e.mu.Lock()
+	exec, ok := e.executions[execID]
+	if !ok {
+		e.mu.Unlock()
+		return
+	}
+	exec.stepsDone = true
+
+	var snapshot *models.PlaybookExecution
+	if stepErr != nil {
+		snapshot = e.finishLocked(exec, models.ExecutionStatusFailed, stepErr.Error())
+	} else if len(exec.pending) == 0 {
+		snapshot = e.finishLocked(exec, models.ExecutionStatusSuccess, "")
+	}
+	e.mu.Unlock()
+
+	if snapshot != nil {
+		e.save(ctx, snapshot)
+	}
+}
+
+// finishLocked sets the final status and forgets the execution along with
+// any unanswered commands. Caller must hold e.mu.
+func (e *Engine) finishLocked(exec *execution, status models.ExecutionStatus, detail string) *models.PlaybookExecution {
+	now := utils.NowUTC()
+	exec.record.Status = status
+	exec.record.Detail = detail
+	exec.record.FinishedAt = &now
+
+	for id := range exec.pending {
+		delete(e.commands, id)
+	}
+	exec.pending = nil
+	delete(e.executions, exec.record.ID)
+
+	log.Printf("[SOAR] Execution %s (%s) finished: %s", exec.record.ID, exec.record.PlaybookID, status)
+	snapshot := exec.record
+	return &snapshot
+}
+
+func (e *Engine) save(ctx context.Context, exec *models.PlaybookExecution) {
+	if e.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := e.store.SavePlaybookExecution(ctx, exec); err != nil {
+		log.Printf("[SOAR] Failed to persist execution %s: %v", exec.ID, err)
 	}
 }
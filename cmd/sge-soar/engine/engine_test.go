@@ -0,0 +1,298 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"sakin-go/cmd/sge-soar/actions"
+	"sakin-go/pkg/models"
+)
+
+type memoryStore struct {
+	mu    sync.Mutex
+	execs map[string]models.PlaybookExecution
+}
+
+func (s *memoryStore) SavePlaybookExecution(ctx context.Context, exec *models.PlaybookExecution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.execs[exec.ID] = *exec
+	return nil
+}
+
+// only returns the single stored execution.
+func (s *memoryStore) only(t *testing.T) models.PlaybookExecution {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.execs) != 1 {
+		t.Fatalf("stored %d executions, want 1", len(s.execs))
+	}
+	for _, e := range s.execs {
+		return e
+	}
+	return models.PlaybookExecution{}
+}
+
+// dispatchAction simulates an action that sends a command to an agent.
+type dispatchAction struct{}
+
+func (a *dispatchAction) Name() string { return "test_dispatch" }
+
+func (a *dispatchAction) Execute(ctx context.Context, execCtx *actions.ExecutionContext, params map[string]interface{}) error {
+	execCtx.TrackCommand(params["id"].(string), params["agent"].(string))
+	return nil
+}
+
+// agentAction records the agent commands would be sent to.
+type agentAction struct{ got *string }
+
+func (a agentAction) Name() string { return "test_agent" }
+
+func (a agentAction) Execute(ctx context.Context, execCtx *actions.ExecutionContext, params map[string]interface{}) error {
+	*a.got = execCtx.AgentID
+	return nil
+}
+
+type failAction struct{}
+
+func (failAction) Name() string { return "test_fail" }
+
+func (failAction) Execute(ctx context.Context, execCtx *actions.ExecutionContext, params map[string]interface{}) error {
+	return errors.New("api down")
+}
+
+func newTestEngine(steps ...PlaybookStep) (*Engine, *memoryStore) {
+	actions.Register(&dispatchAction{})
+	actions.Register(failAction{})
+
+	store := &memoryStore{execs: make(map[string]models.PlaybookExecution)}
+	e := NewEngine(nil, store)
+	e.playbooks = []*Playbook{{ID: "pb-test", Trigger: "critical", Steps: steps}}
+	return e, store
+}
+
+func dispatch(id, agent string) PlaybookStep {
+	return PlaybookStep{ActionName: "test_dispatch", Params: map[string]interface{}{"id": id, "agent": agent}}
+}
+
+func TestCommandResultTracking(t *testing.T) {
+	tests := []struct {
+		name       string
+		steps      []PlaybookStep
+		results    []models.CommandResult
+		wantStatus models.ExecutionStatus
+		wantDetail string
+	}{
+		{
+			name:       "No Commands",
+			steps:      []PlaybookStep{{ActionName: "slack_notify"}},
+			wantStatus: models.ExecutionStatusSuccess,
+		},
+		{
+			name:       "Awaiting Result",
+			steps:      []PlaybookStep{dispatch("c1", "a1")},
+			wantStatus: models.ExecutionStatusRunning,
+		},
+		{
+			name:  "All Succeeded",
+			steps: []PlaybookStep{dispatch("c1", "a1"), dispatch("c2", "a2")},
+			results: []models.CommandResult{
+				{CommandID: "c1", AgentID: "a1", Success: true},
+				{CommandID: "c2", AgentID: "a2", Success: true},
+			},
+			wantStatus: models.ExecutionStatusSuccess,
+		},
+		{
+			name:  "Partial Results",
+			steps: []PlaybookStep{dispatch("c1", "a1"), dispatch("c2", "a2")},
+			results: []models.CommandResult{
+				{CommandID: "c2", AgentID: "a2", Success: true},
+			},
+			wantStatus: models.ExecutionStatusRunning,
+		},
+		{
+			name:  "Agent Failure",
+			steps: []PlaybookStep{dispatch("c1", "a1"), dispatch("c2", "a2")},
+			results: []models.CommandResult{
+				{CommandID: "c1", AgentID: "a1", Success: false, Detail: "iptables: permission denied"},
+			},
+			wantStatus: models.ExecutionStatusFailed,
+			wantDetail: "agent a1: iptables: permission denied",
+		},
+		{
+			name:       "Step Failure",
+			steps:      []PlaybookStep{dispatch("c1", "a1"), {ActionName: "test_fail"}},
+			wantStatus: models.ExecutionStatusFailed,
+			wantDetail: "test_fail: api down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, store := newTestEngine(tt.steps...)
+			e.Execute(context.Background(), &models.Alert{ID: "alert-1", Severity: models.SeverityCritical})
+
+			for _, res := range tt.results {
+				if !e.HandleCommandResult(context.Background(), &res) {
+					t.Errorf("HandleCommandResult(%s) = false, want true", res.CommandID)
+				}
+			}
+
+			got := store.only(t)
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", got.Status, tt.wantStatus)
+			}
+			if got.Detail != tt.wantDetail {
+				t.Errorf("Detail = %q, want %q", got.Detail, tt.wantDetail)
+			}
+			if got.PlaybookID != "pb-test" || got.AlertID != "alert-1" {
+				t.Errorf("execution = %+v, want pb-test/alert-1", got)
+			}
+			if (got.FinishedAt != nil) != (tt.wantStatus != models.ExecutionStatusRunning) {
+				t.Errorf("FinishedAt = %v for status %v", got.FinishedAt, got.Status)
+			}
+		})
+	}
+}
+
+func TestHandleCommandResultUnknown(t *testing.T) {
+	e, _ := newTestEngine(dispatch("c1", "a1"))
+	e.Execute(context.Background(), &models.Alert{ID: "alert-1", Severity: models.SeverityCritical})
+
+	if e.HandleCommandResult(context.Background(), &models.CommandResult{CommandID: "nope", Success: true}) {
+		t.Error("HandleCommandResult(unknown) = true, want false")
+	}
+
+	// A duplicate result after completion is ignored
+	res := &models.CommandResult{CommandID: "c1", AgentID: "a1", Success: true}
+	if !e.HandleCommandResult(context.Background(), res) {
+		t.Fatal("HandleCommandResult(c1) = false, want true")
+	}
+	if e.HandleCommandResult(context.Background(), res) {
+		t.Error("HandleCommandResult(c1) twice = true, want false")
+	}
+}
+
+func TestExpireCommands(t *testing.T) {
+	e, store := newTestEngine(dispatch("c1", "a1"), dispatch("c2", "a2"))
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+	e.WithCommandTimeout(time.Minute)
+	e.Execute(context.Background(), &models.Alert{ID: "alert-1", Severity: models.SeverityCritical})
+
+	now = now.Add(59 * time.Second)
+	if n := e.ExpireCommands(context.Background()); n != 0 {
+		t.Fatalf("ExpireCommands() before timeout = %d, want 0", n)
+	}
+
+	now = now.Add(2 * time.Second)
+	if n := e.ExpireCommands(context.Background()); n != 1 {
+		t.Fatalf("ExpireCommands() = %d, want 1", n)
+	}
+	if got := store.only(t); got.Status != models.ExecutionStatusFailed || got.FinishedAt == nil {
+		t.Errorf("execution = %+v, want failed and finished", got)
+	}
+	if len(e.executions) != 0 || len(e.commands) != 0 {
+		t.Errorf("tracked %d executions and %d commands, want none", len(e.executions), len(e.commands))
+	}
+
+	// A late result is ignored
+	if e.HandleCommandResult(context.Background(), &models.CommandResult{CommandID: "c1", AgentID: "a1", Success: true}) {
+		t.Error("HandleCommandResult() after timeout = true, want false")
+	}
+}
+
+func TestCommandAgentTarget(t *testing.T) {
+	var got string
+	actions.Register(agentAction{got: &got})
+
+	tests := []struct {
+		name     string
+		playbook string
+		alert    map[string]interface{}
+		want     string
+	}{
+		{"Playbook Agent", "fw-01", nil, "fw-01"},
+		{"Alert Agent", "fw-01", map[string]interface{}{"agent_id": "web-07"}, "web-07"},
+		{"Default Agent", "", nil, DefaultAgentID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			e, _ := newTestEngine(PlaybookStep{ActionName: "test_agent"})
+			e.playbooks[0].AgentID = tt.playbook
+			e.Execute(context.Background(), &models.Alert{ID: "alert-1", Severity: models.SeverityCritical, Metadata: tt.alert})
+			if got != tt.want {
+				t.Errorf("AgentID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockIPRequiresAgent(t *testing.T) {
+	err := (&actions.BlockIPAction{}).Execute(context.Background(), &actions.ExecutionContext{TargetIP: "1.2.3.4"}, nil)
+	if err == nil {
+		t.Error("Execute() without target agent error = nil")
+	}
+	if err := (&actions.BlockIPAction{}).Execute(context.Background(), &actions.ExecutionContext{TargetIP: "1.2.3.4"}, map[string]interface{}{"agent_id": "fw-01"}); err != nil {
+		t.Errorf("Execute() with step agent error = %v", err)
+	}
+}
+
+// fakePublisher records published commands.
+type fakePublisher struct {
+	mu       sync.Mutex
+	subjects []string
+	commands []models.Command
+}
+
+func (p *fakePublisher) PublishAsync(ctx context.Context, subject string, data []byte, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	var cmd models.Command
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subjects = append(p.subjects, subject)
+	p.commands = append(p.commands, cmd)
+	return nil, nil
+}
+
+func TestDemoPlaybookBlocksViaDefaultAgent(t *testing.T) {
+	store := &memoryStore{execs: make(map[string]models.PlaybookExecution)}
+	pub := &fakePublisher{}
+	e := NewEngine(nil, store).WithDefaultAgent("fw-01")
+	e.natsClient = pub
+
+	e.Execute(context.Background(), &models.Alert{ID: "alert-1", Severity: models.SeverityCritical})
+
+	if len(pub.commands) != 1 || pub.subjects[0] != "commands.fw-01" {
+		t.Fatalf("published %v, want one command on commands.fw-01", pub.subjects)
+	}
+	cmd := pub.commands[0]
+	if cmd.Action != "firewall_block" || cmd.AlertID != "alert-1" {
+		t.Errorf("command = %+v, want firewall_block for alert-1", cmd)
+	}
+	if got := store.only(t); got.PlaybookID != "pb-001" || got.Status != models.ExecutionStatusRunning {
+		t.Fatalf("execution = %+v, want pb-001 awaiting the agent", got)
+	}
+
+	// Only the agent the command was sent to may answer it
+	if e.HandleCommandResult(context.Background(), &models.CommandResult{CommandID: cmd.ID, AgentID: "web-07", Success: true}) {
+		t.Error("HandleCommandResult() from another agent = true, want false")
+	}
+	if !e.HandleCommandResult(context.Background(), &models.CommandResult{CommandID: cmd.ID, AgentID: "fw-01", Success: true}) {
+		t.Fatal("HandleCommandResult() from fw-01 = false, want true")
+	}
+	if got := store.only(t); got.Status != models.ExecutionStatusSuccess {
+		t.Errorf("Status = %v, want %v", got.Status, models.ExecutionStatusSuccess)
+	}
+}
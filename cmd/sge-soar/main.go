@@ -13,6 +13,7 @@ import (
 
 	"sakin-go/cmd/sge-soar/config"
 	"sakin-go/cmd/sge-soar/engine"
+	"sakin-go/pkg/database"
 	"sakin-go/pkg/messaging"
 	"sakin-go/pkg/models"
 )
@@ -22,26 +23,70 @@ func main() {
 	log.Println("[SOAR] Starting SGE Automation Response...")
 
 	// 1. NATS
+	retention, err := messaging.RetentionFromEnv()
+	if err != nil {
+		log.Fatalf("[SOAR] Invalid NATS retention config: %v", err)
+	}
+	slo, err := messaging.SLOFromEnv()
+	if err != nil {
+		log.Fatalf("[SOAR] Invalid consumer SLO config: %v", err)
+	}
 	nc, err := messaging.NewClient(&messaging.NatsConfig{
 		URL:           cfg.NatsURL,
 		Username:      cfg.NatsUser,
 		Password:      cfg.NatsPassword,
 		ReconnectWait: 2 * time.Second,
+		Retention:     retention,
+		SLO:           slo,
 	})
 	if err != nil {
 		log.Fatalf("[SOAR] NATS Error: %v", err)
 	}
 	defer nc.Close()
+	if slo.HealthAddr != "" {
+		go func() {
+			if err := nc.ServeHealth(slo.HealthAddr); err != nil {
+				log.Printf("[SOAR] Health endpoint stopped: %v", err)
+			}
+		}()
+		log.Printf("[SOAR] Health endpoint (consumer SLO) on %s/health", slo.HealthAddr)
+	}
 
-	// 2. Engine
-	eng := engine.NewEngine(nc)
+	// 2. Postgres (execution status, optional)
+	var store engine.ExecutionStore
+	pg, err := database.NewPostgresClient(&database.PostgresConfig{
+		Host:     cfg.PostgresHost,
+		Port:     cfg.PostgresPort,
+		Username: cfg.PostgresUser,
+		Password: cfg.PostgresPass,
+		Database: cfg.PostgresDB,
+		SSLMode:  "disable",
+	})
+	if err != nil {
+		log.Printf("[SOAR] Warning: Postgres unavailable, execution status will not be persisted: %v", err)
+	} else {
+		defer pg.Close()
+		if err := pg.InitializeSchema(context.Background()); err != nil {
+			log.Printf("[SOAR] Warning: Postgres schema init failed: %v", err)
+		}
+		store = pg
+	}
 
-	// 3. Consume Alerts
+	// 3. Engine
+	eng := engine.NewEngine(nc, store).
+		WithCommandTimeout(time.Duration(cfg.CommandTimeout) * time.Second).
+		WithDefaultAgent(cfg.DefaultAgentID)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go eng.Run(ctx)
+
+	// 4. Consume Alerts
 	_, err = nc.QueueSubscribe(context.Background(), messaging.StreamAlerts, messaging.TopicAlerts, messaging.ConsumerSOAR, func(msg jetstream.Msg) {
 		msg.Ack()
 
 		var alert models.Alert
 		if err := json.Unmarshal(msg.Data(), &alert); err != nil {
+			messaging.MarkFailed(msg)
 			return
 		}
 
@@ -54,6 +99,25 @@ func main() {
 		log.Fatalf("[SOAR] Subscribe failed: %v", err)
 	}
 
+	// 5. Consume Agent Command Results
+	_, err = nc.QueueSubscribe(context.Background(), messaging.StreamCommands, messaging.TopicCommandResults, messaging.ConsumerSOARResults, func(msg jetstream.Msg) {
+		msg.Ack()
+
+		var res models.CommandResult
+		if err := json.Unmarshal(msg.Data(), &res); err != nil {
+			messaging.MarkFailed(msg)
+			return
+		}
+
+		if !eng.HandleCommandResult(context.Background(), &res) {
+			log.Printf("[SOAR] Ignoring result for unknown command %s", res.CommandID)
+		}
+	})
+
+	if err != nil {
+		log.Fatalf("[SOAR] Result subscribe failed: %v", err)
+	}
+
 	// Wait
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen, devre açıkken çağrı yapılmadan döndürülür.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State, circuit breaker durumunu temsil eder.
+type State int
+
+const (
+	StateClosed   State = iota // Normal çalışma, çağrılar geçer
+	StateOpen                  // Hata eşiği aşıldı, çağrılar hemen reddedilir
+	StateHalfOpen              // Bekleme süresi doldu, tek deneme çağrısına izin verilir
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker, art arda hata veren bir bağımlılığa (ClickHouse, NATS vb.)
+// yapılan çağrıları geçici olarak keser ve hızlıca hata döndürür.
+type CircuitBreaker struct {
+	failureThreshold int
+	resetTimeout     time.Duration
+
+	// OnStateChange, durum değiştiğinde (kilit dışında) çağrılır. Opsiyonel.
+	OnStateChange func(from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool // Half-open durumunda deneme çağrısı sürüyor
+	now      func() time.Time
+}
+
+// NewCircuitBreaker, failureThreshold art arda hatadan sonra açılan ve
+// resetTimeout sonra tekrar deneyen bir circuit breaker oluşturur.
+func NewCircuitBreaker(failureThreshold int, resetTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		resetTimeout:     resetTimeout,
+		now:              time.Now,
+	}
+}
+
+// Execute, devre izin veriyorsa fn'i çalıştırır ve sonucunu kaydeder.
+// Devre açıksa fn çağrılmadan ErrCircuitOpen döner.
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	if !cb.allow() {
+		return ErrCircuitOpen
+	}
+	err := fn()
+	cb.record(err == nil)
+	return err
+}
+
+// State, güncel durumu döndürür.
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.mu.Lock()
+	from := cb.state
+
+	switch cb.state {
+	case StateOpen:
+		if cb.now().Sub(cb.openedAt) < cb.resetTimeout {
+			cb.mu.Unlock()
+			return false
+		}
+		cb.state = StateHalfOpen
+		cb.trial = true
+	case StateHalfOpen:
+		// Deneme çağrısı bitene kadar diğerleri reddedilir
+		if cb.trial {
+			cb.mu.Unlock()
+			return false
+		}
+		cb.trial = true
+	}
+
+	to := cb.state
+	cb.mu.Unlock()
+	cb.notify(from, to)
+	return true
+}
+
+func (cb *CircuitBreaker) record(success bool) {
+	cb.mu.Lock()
+	from := cb.state
+	cb.trial = false
+
+	if success {
+		cb.failures = 0
+		cb.state = StateClosed
+	} else {
+		cb.failures++
+		if cb.state == StateHalfOpen || cb.failures >= cb.failureThreshold {
+			cb.state = StateOpen
+			cb.openedAt = cb.now()
+		}
+	}
+
+	to := cb.state
+	cb.mu.Unlock()
+	cb.notify(from, to)
+}
+
+func (cb *CircuitBreaker) notify(from, to State) {
+	if from != to && cb.OnStateChange != nil {
+		cb.OnStateChange(from, to)
+	}
+}
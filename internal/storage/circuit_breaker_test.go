@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("clickhouse down")
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cb := NewCircuitBreaker(3, 30*time.Second)
+	cb.now = func() time.Time { return now }
+
+	var transitions []string
+	cb.OnStateChange = func(from, to State) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	}
+
+	calls := 0
+	fail := func() error { calls++; return errDown }
+	ok := func() error { calls++; return nil }
+
+	// 1. Opens after 3 consecutive failures
+	for i := 0; i < 3; i++ {
+		if err := cb.Execute(fail); !errors.Is(err, errDown) {
+			t.Fatalf("Execute() #%d error = %v, want %v", i, err, errDown)
+		}
+	}
+	if cb.State() != StateOpen {
+		t.Fatalf("State() = %v, want open", cb.State())
+	}
+
+	// 2. Fast-fails while open without calling fn
+	if err := cb.Execute(ok); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Execute() while open error = %v, want ErrCircuitOpen", err)
+	}
+	if calls != 3 {
+		t.Errorf("fn called %d times, want 3", calls)
+	}
+
+	// 3. Failed trial after timeout re-opens
+	now = now.Add(31 * time.Second)
+	if err := cb.Execute(fail); !errors.Is(err, errDown) {
+		t.Errorf("trial Execute() error = %v, want %v", err, errDown)
+	}
+	if cb.State() != StateOpen {
+		t.Errorf("State() after failed trial = %v, want open", cb.State())
+	}
+
+	// 4. Successful trial closes again
+	now = now.Add(31 * time.Second)
+	if err := cb.Execute(ok); err != nil {
+		t.Errorf("trial Execute() error = %v", err)
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("State() after recovery = %v, want closed", cb.State())
+	}
+
+	want := "closed->open,open->half-open,half-open->open,open->half-open,half-open->closed"
+	if got := strings.Join(transitions, ","); got != want {
+		t.Errorf("transitions = %s, want %s", got, want)
+	}
+}
+
+func TestCircuitBreakerResetsOnSuccess(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Minute)
+
+	cb.Execute(func() error { return errDown })
+	cb.Execute(func() error { return nil })
+	cb.Execute(func() error { return errDown })
+
+	if cb.State() != StateClosed {
+		t.Errorf("State() = %v, want closed (failures not consecutive)", cb.State())
+	}
+}
+
+func TestDeadLetterSpill(t *testing.T) {
+	dir := t.TempDir()
+	dl, err := NewDeadLetter(filepath.Join(dir, "dlq"), "events")
+	if err != nil {
+		t.Fatalf("NewDeadLetter() error = %v", err)
+	}
+	dl.now = func() time.Time { return time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC) }
+
+	if err := dl.Spill([]interface{}{map[string]int{"a": 1}, map[string]int{"b": 2}}); err != nil {
+		t.Fatalf("Spill() error = %v", err)
+	}
+	if err := dl.Spill([]interface{}{map[string]int{"c": 3}}); err != nil {
+		t.Fatalf("Spill() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "dlq", "events-20240305.jsonl"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if got, want := string(data), "{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n"; got != want {
+		t.Errorf("spill file = %q, want %q", got, want)
+	}
+}
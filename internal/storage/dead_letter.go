@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DeadLetter, yazılamayan kayıtları daha sonra tekrar işlenebilmeleri için
+// diske JSON Lines olarak döker. Dosyalar günlük olarak ayrılır:
+// <dir>/<name>-YYYYMMDD.jsonl
+type DeadLetter struct {
+	dir  string
+	name string
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// NewDeadLetter, dir dizininde name önekli dosyalara yazan bir DeadLetter oluşturur.
+func NewDeadLetter(dir, name string) (*DeadLetter, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter dir: %w", err)
+	}
+	return &DeadLetter{dir: dir, name: name, now: time.Now}, nil
+}
+
+// Spill, verilen kayıtları JSON satırları olarak dosyaya ekler.
+func (d *DeadLetter) Spill(records []interface{}) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.OpenFile(d.Path(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode dead-letter record: %w", err)
+		}
+	}
+	return w.Flush()
+}
+
+// Path, bugünkü dead-letter dosyasının yolunu döndürür.
+func (d *DeadLetter) Path() string {
+	return filepath.Join(d.dir, fmt.Sprintf("%s-%s.jsonl", d.name, d.now().UTC().Format("20060102")))
+}
@@ -131,8 +131,8 @@ func (c *ClickHouseClient) InsertEvents(ctx context.Context, events []*models.Ev
 	return batch.Send()
 }
 
-// InsertNetworkFlows, NetworkFlow batch'ini ClickHouse'a yazar.
-func (c *ClickHouseClient) InsertNetworkFlows(ctx context.Context, flows []map[string]interface{}) error {
+// InsertFlows, NetworkFlow batch'ini ClickHouse'a yazar.
+func (c *ClickHouseClient) InsertFlows(ctx context.Context, flows []map[string]interface{}) error {
 	batch, err := c.conn.PrepareBatch(ctx, "INSERT INTO network_flows")
 	if err != nil {
 		return fmt.Errorf("prepare batch failed: %w", err)
@@ -164,6 +164,19 @@ func (c *ClickHouseClient) InsertNetworkFlows(ctx context.Context, flows []map[s
 	return batch.Send()
 }
 
+// CountEvents, since'den sonraki event sayısını döndürür (sıfır zaman tümü).
+func (c *ClickHouseClient) CountEvents(ctx context.Context, since time.Time) (uint64, error) {
+	var n uint64
+	row := c.conn.QueryRow(ctx, "SELECT count() FROM events")
+	if !since.IsZero() {
+		row = c.conn.QueryRow(ctx, "SELECT count() FROM events WHERE timestamp > ?", since)
+	}
+	if err := row.Scan(&n); err != nil {
+		return 0, fmt.Errorf("count events failed: %w", err)
+	}
+	return n, nil
+}
+
 // Query, genel amaçlı sorgu çalıştırır.
 func (c *ClickHouseClient) Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error) {
 	return c.conn.Query(ctx, query, args...)
@@ -174,60 +187,14 @@ func (c *ClickHouseClient) Exec(ctx context.Context, query string, args ...inter
 	return c.conn.Exec(ctx, query, args...)
 }
 
-// InitializeSchema, gerekli ClickHouse tablolarını oluşturur.
+// InitializeSchema, gerekli ClickHouse tablolarını oluşturur ve mevcut
+// tabloların kolonlarını beklenen şemaya göre doğrular (bkz. MigrateSchema).
 func (c *ClickHouseClient) InitializeSchema(ctx context.Context) error {
-	// Events tablosu
-	eventsSchema := `
-	CREATE TABLE IF NOT EXISTS events (
-		id String,
-		timestamp DateTime64(3),
-		source String,
-		source_ip String,
-		dest_ip String,
-		event_type String,
-		severity String,
-		description String,
-		raw_log String,
-		metadata String
-	) ENGINE = MergeTree()
-	PARTITION BY toYYYYMMDD(timestamp)
-	ORDER BY (timestamp, source_ip, event_type)
-	TTL timestamp + INTERVAL 90 DAY
-	SETTINGS index_granularity = 8192
-	`
-
-	if err := c.Exec(ctx, eventsSchema); err != nil {
-		return fmt.Errorf("failed to create events table: %w", err)
-	}
-
-	// Network Flows tablosu
-	flowsSchema := `
-	CREATE TABLE IF NOT EXISTS network_flows (
-		id String,
-		timestamp DateTime64(3),
-		source_ip String,
-		source_port UInt16,
-		dest_ip String,
-		dest_port UInt16,
-		protocol String,
-		l7_protocol String,
-		bytes_sent UInt64,
-		bytes_received UInt64,
-		packets_sent UInt32,
-		packets_received UInt32,
-		duration UInt32,
-		flags String,
-		suspicious UInt8
-	) ENGINE = MergeTree()
-	PARTITION BY toYYYYMMDD(timestamp)
-	ORDER BY (timestamp, source_ip, dest_ip)
-	TTL timestamp + INTERVAL 90 DAY
-	SETTINGS index_granularity = 8192
-	`
-
-	if err := c.Exec(ctx, flowsSchema); err != nil {
-		return fmt.Errorf("failed to create network_flows table: %w", err)
+	for _, t := range clickHouseTables {
+		if err := c.Exec(ctx, t.createStatement()); err != nil {
+			return fmt.Errorf("failed to create %s table: %w", t.Name, err)
+		}
 	}
 
-	return nil
+	return c.MigrateSchema(ctx)
 }
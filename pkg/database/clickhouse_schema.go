@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ColumnDef, bir ClickHouse kolonunun adını ve tipini tanımlar.
+type ColumnDef struct {
+	Name string
+	Type string
+}
+
+// tableDef, beklenen bir tablonun kolonlarını ve engine ayarlarını içerir.
+type tableDef struct {
+	Name    string
+	Columns []ColumnDef
+	Engine  string
+}
+
+// clickHouseTables, servislerin yazdığı tabloların beklenen şemasıdır.
+// Yeni kolonlar buraya eklenir; mevcut tablolara MigrateSchema ile eklenir.
+var clickHouseTables = []tableDef{
+	{
+		Name: "events",
+		Columns: []ColumnDef{
+			{"id", "String"},
+			{"timestamp", "DateTime64(3)"},
+			{"source", "String"},
+			{"source_ip", "String"},
+			{"dest_ip", "String"},
+			{"event_type", "String"},
+			{"severity", "String"},
+			{"description", "String"},
+			{"raw_log", "String"},
+			{"metadata", "String"},
+		},
+		Engine: `ENGINE = MergeTree()
+	PARTITION BY toYYYYMMDD(timestamp)
+	ORDER BY (timestamp, source_ip, event_type)
+	TTL timestamp + INTERVAL 90 DAY
+	SETTINGS index_granularity = 8192`,
+	},
+	{
+		Name: "network_flows",
+		Columns: []ColumnDef{
+			{"id", "String"},
+			{"timestamp", "DateTime64(3)"},
+			{"source_ip", "String"},
+			{"source_port", "UInt16"},
+			{"dest_ip", "String"},
+			{"dest_port", "UInt16"},
+			{"protocol", "String"},
+			{"l7_protocol", "String"},
+			{"bytes_sent", "UInt64"},
+			{"bytes_received", "UInt64"},
+			{"packets_sent", "UInt32"},
+			{"packets_received", "UInt32"},
+			{"duration", "UInt32"},
+			{"flags", "String"},
+			{"suspicious", "UInt8"},
+		},
+		Engine: `ENGINE = MergeTree()
+	PARTITION BY toYYYYMMDD(timestamp)
+	ORDER BY (timestamp, source_ip, dest_ip)
+	TTL timestamp + INTERVAL 90 DAY
+	SETTINGS index_granularity = 8192`,
+	},
+}
+
+func (t tableDef) createStatement() string {
+	cols := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		cols[i] = "\t\t" + col.Name + " " + col.Type
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n\t) %s", t.Name, strings.Join(cols, ",\n"), t.Engine)
+}
+
+// ColumnMismatch, beklenenden farklı tipte bir kolonu tanımlar.
+type ColumnMismatch struct {
+	Column   string
+	Expected string
+	Actual   string
+}
+
+// SchemaDiff, bir tablonun beklenen şemadan farkıdır.
+// Fazla kolonlar sorun sayılmaz, yazma işlemlerini etkilemez.
+type SchemaDiff struct {
+	Table      string
+	Missing    []ColumnDef
+	Mismatched []ColumnMismatch
+}
+
+// Empty, tablonun beklenen şemayla uyumlu olup olmadığını döndürür.
+func (d SchemaDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Mismatched) == 0
+}
+
+// diffColumns, beklenen kolonları system.columns'dan okunan kolonlarla karşılaştırır.
+func diffColumns(t tableDef, actual map[string]string) SchemaDiff {
+	diff := SchemaDiff{Table: t.Name}
+	for _, col := range t.Columns {
+		typ, ok := actual[col.Name]
+		if !ok {
+			diff.Missing = append(diff.Missing, col)
+			continue
+		}
+		if normalizeType(typ) != normalizeType(col.Type) {
+			diff.Mismatched = append(diff.Mismatched, ColumnMismatch{Column: col.Name, Expected: col.Type, Actual: typ})
+		}
+	}
+	return diff
+}
+
+func normalizeType(t string) string {
+	return strings.ReplaceAll(t, " ", "")
+}
+
+// migrationStatements, eksik kolonlar için idempotent ALTER komutlarını üretir.
+func migrationStatements(diff SchemaDiff) []string {
+	stmts := make([]string, 0, len(diff.Missing))
+	for _, col := range diff.Missing {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", diff.Table, col.Name, col.Type))
+	}
+	return stmts
+}
+
+// mismatchError, tip uyuşmazlıklarını tek bir hata mesajında toplar.
+func mismatchError(diffs []SchemaDiff) error {
+	var parts []string
+	for _, d := range diffs {
+		for _, m := range d.Mismatched {
+			parts = append(parts, fmt.Sprintf("%s.%s is %s, expected %s", d.Table, m.Column, m.Actual, m.Expected))
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("clickhouse schema drift: %s", strings.Join(parts, "; "))
+}
+
+// tableColumns, bir tablonun mevcut kolonlarını system.columns'dan okur.
+func (c *ClickHouseClient) tableColumns(ctx context.Context, table string) (map[string]string, error) {
+	rows, err := c.Query(ctx, "SELECT name, type FROM system.columns WHERE database = currentDatabase() AND table = ?", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	cols := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, err
+		}
+		cols[name] = typ
+	}
+	return cols, rows.Err()
+}
+
+// VerifySchema, tabloları beklenen şemayla karşılaştırır ve farkları döndürür.
+func (c *ClickHouseClient) VerifySchema(ctx context.Context) ([]SchemaDiff, error) {
+	var diffs []SchemaDiff
+	for _, t := range clickHouseTables {
+		actual, err := c.tableColumns(ctx, t.Name)
+		if err != nil {
+			return nil, err
+		}
+		if d := diffColumns(t, actual); !d.Empty() {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs, nil
+}
+
+// MigrateSchema, eksik kolonları ALTER TABLE ADD COLUMN ile ekler.
+// Tipi farklı kolonlar otomatik düzeltilemez; bu durumda hangi kolonların
+// uyuşmadığını belirten bir hata döner.
+func (c *ClickHouseClient) MigrateSchema(ctx context.Context) error {
+	diffs, err := c.VerifySchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range diffs {
+		for _, stmt := range migrationStatements(d) {
+			log.Printf("[ClickHouse] Schema migration: %s", stmt)
+			if err := c.Exec(ctx, stmt); err != nil {
+				return fmt.Errorf("schema migration failed (%s): %w", stmt, err)
+			}
+		}
+	}
+
+	return mismatchError(diffs)
+}
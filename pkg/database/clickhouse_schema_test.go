@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDiffColumns(t *testing.T) {
+	table := tableDef{
+		Name: "events",
+		Columns: []ColumnDef{
+			{"id", "String"},
+			{"timestamp", "DateTime64(3)"},
+			{"severity", "String"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		actual       map[string]string
+		wantMissing  []string
+		wantMismatch []string
+	}{
+		{
+			name:   "Up To Date",
+			actual: map[string]string{"id": "String", "timestamp": "DateTime64(3)", "severity": "String"},
+		},
+		{
+			name:   "Extra Column Ignored",
+			actual: map[string]string{"id": "String", "timestamp": "DateTime64(3)", "severity": "String", "legacy": "String"},
+		},
+		{
+			name:        "Missing Column",
+			actual:      map[string]string{"id": "String", "timestamp": "DateTime64(3)"},
+			wantMissing: []string{"severity"},
+		},
+		{
+			name:         "Type Mismatch",
+			actual:       map[string]string{"id": "String", "timestamp": "DateTime", "severity": "String"},
+			wantMismatch: []string{"timestamp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diffColumns(table, tt.actual)
+
+			var missing, mismatch []string
+			for _, c := range d.Missing {
+				missing = append(missing, c.Name)
+			}
+			for _, m := range d.Mismatched {
+				mismatch = append(mismatch, m.Column)
+			}
+
+			if strings.Join(missing, ",") != strings.Join(tt.wantMissing, ",") {
+				t.Errorf("Missing = %v, want %v", missing, tt.wantMissing)
+			}
+			if strings.Join(mismatch, ",") != strings.Join(tt.wantMismatch, ",") {
+				t.Errorf("Mismatched = %v, want %v", mismatch, tt.wantMismatch)
+			}
+			if d.Empty() != (len(tt.wantMissing)+len(tt.wantMismatch) == 0) {
+				t.Errorf("Empty() = %v", d.Empty())
+			}
+		})
+	}
+}
+
+func TestMigrationStatements(t *testing.T) {
+	d := SchemaDiff{
+		Table:   "network_flows",
+		Missing: []ColumnDef{{"l7_protocol", "String"}, {"suspicious", "UInt8"}},
+	}
+
+	got := migrationStatements(d)
+	want := []string{
+		"ALTER TABLE network_flows ADD COLUMN IF NOT EXISTS l7_protocol String",
+		"ALTER TABLE network_flows ADD COLUMN IF NOT EXISTS suspicious UInt8",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("migrationStatements() = %v, want %v", got, want)
+	}
+}
+
+func TestMismatchError(t *testing.T) {
+	err := mismatchError([]SchemaDiff{
+		{Table: "events", Missing: []ColumnDef{{"metadata", "String"}}},
+		{Table: "network_flows", Mismatched: []ColumnMismatch{{Column: "dest_port", Expected: "UInt16", Actual: "String"}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "network_flows.dest_port is String, expected UInt16") {
+		t.Errorf("mismatchError() = %v, want dest_port mismatch", err)
+	}
+
+	if err := mismatchError([]SchemaDiff{{Table: "events", Missing: []ColumnDef{{"metadata", "String"}}}}); err != nil {
+		t.Errorf("mismatchError() with only missing columns = %v, want nil", err)
+	}
+}
+
+func TestCreateStatementMatchesColumns(t *testing.T) {
+	for _, table := range clickHouseTables {
+		stmt := table.createStatement()
+		for _, col := range table.Columns {
+			if !strings.Contains(stmt, col.Name+" "+col.Type) {
+				t.Errorf("%s create statement lacks %s %s", table.Name, col.Name, col.Type)
+			}
+		}
+	}
+}
+
+// TestMigrateSchemaClickHouse runs against a real ClickHouse when
+// CLICKHOUSE_TEST_ADDR (host) is set, e.g. a throwaway docker container.
+func TestMigrateSchemaClickHouse(t *testing.T) {
+	host := os.Getenv("CLICKHOUSE_TEST_ADDR")
+	if host == "" {
+		t.Skip("CLICKHOUSE_TEST_ADDR not set")
+	}
+
+	c, err := NewClickHouseClient(&ClickHouseConfig{Host: host, Port: 9000, Database: "default", Username: "default"})
+	if err != nil {
+		t.Fatalf("NewClickHouseClient() error = %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Exec(ctx, "DROP TABLE IF EXISTS events"); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	// Older events table without the metadata column
+	old := `CREATE TABLE events (id String, timestamp DateTime64(3), source String, source_ip String,
+		dest_ip String, event_type String, severity String, description String, raw_log String)
+		ENGINE = MergeTree() ORDER BY timestamp`
+	if err := c.Exec(ctx, old); err != nil {
+		t.Fatalf("create old table: %v", err)
+	}
+
+	diffs, err := c.VerifySchema(ctx)
+	if err != nil {
+		t.Fatalf("VerifySchema() error = %v", err)
+	}
+	found := false
+	for _, d := range diffs {
+		if d.Table == "events" && len(d.Missing) == 1 && d.Missing[0].Name == "metadata" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("VerifySchema() = %+v, want events.metadata missing", diffs)
+	}
+
+	if err := c.InitializeSchema(ctx); err != nil {
+		t.Fatalf("InitializeSchema() error = %v", err)
+	}
+	if diffs, err := c.VerifySchema(ctx); err != nil || len(diffs) != 0 {
+		t.Errorf("VerifySchema() after migration = %+v, %v; want no drift", diffs, err)
+	}
+}
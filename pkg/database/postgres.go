@@ -7,6 +7,8 @@ import (
 	"time"
 
 	_ "github.com/lib/pq"
+
+	"sakin-go/pkg/models"
 )
 
 // PostgresConfig, PostgreSQL bağlantı ayarlarını içerir.
@@ -127,12 +129,16 @@ func (p *PostgresClient) InitializeSchema(ctx context.Context) error {
 		location VARCHAR(255),
 		tags TEXT[] DEFAULT '{}',
 		status VARCHAR(50) DEFAULT 'active',
+		criticality VARCHAR(20) NOT NULL DEFAULT 'normal', -- low, normal, high, critical
 		last_seen TIMESTAMPTZ,
 		metadata JSONB DEFAULT '{}',
 		created_at TIMESTAMPTZ DEFAULT NOW(),
 		updated_at TIMESTAMPTZ DEFAULT NOW()
 	);
 
+	-- Önceki şemayla oluşturulmuş tablolar için
+	ALTER TABLE assets ADD COLUMN IF NOT EXISTS criticality VARCHAR(20) NOT NULL DEFAULT 'normal';
+
 	-- Rules tablosu
 	CREATE TABLE IF NOT EXISTS rules (
 		id SERIAL PRIMARY KEY,
@@ -180,6 +186,17 @@ func (p *PostgresClient) InitializeSchema(ctx context.Context) error {
 		updated_at TIMESTAMPTZ DEFAULT NOW()
 	);
 
+	-- Playbook Executions tablosu (SOAR çalıştırma durumu)
+	CREATE TABLE IF NOT EXISTS playbook_executions (
+		id VARCHAR(64) PRIMARY KEY,
+		playbook_id VARCHAR(100) NOT NULL,
+		alert_id VARCHAR(100),
+		status VARCHAR(50) NOT NULL,
+		detail TEXT,
+		started_at TIMESTAMPTZ DEFAULT NOW(),
+		finished_at TIMESTAMPTZ
+	);
+
 	-- Audit Logs tablosu (değiştirilemez)
 	CREATE TABLE IF NOT EXISTS audit_logs (
 		id SERIAL PRIMARY KEY,
@@ -213,6 +230,7 @@ func (p *PostgresClient) InitializeSchema(ctx context.Context) error {
 	CREATE INDEX IF NOT EXISTS idx_assets_ip_address ON assets(ip_address);
 	CREATE INDEX IF NOT EXISTS idx_assets_status ON assets(status);
 	CREATE INDEX IF NOT EXISTS idx_rules_enabled ON rules(enabled);
+	CREATE INDEX IF NOT EXISTS idx_playbook_executions_status ON playbook_executions(status);
 	CREATE INDEX IF NOT EXISTS idx_audit_logs_timestamp ON audit_logs(timestamp DESC);
 	CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs(user_id);
 
@@ -230,6 +248,63 @@ func (p *PostgresClient) InitializeSchema(ctx context.Context) error {
 	return nil
 }
 
+// AssetCriticality, IP adresi olan aktif varlıkların kritiklik
+// seviyesini IP'ye göre döndürür.
+func (p *PostgresClient) AssetCriticality(ctx context.Context) (map[string]string, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT host(ip_address), criticality FROM assets WHERE ip_address IS NOT NULL AND status = 'active'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]string)
+	for rows.Next() {
+		var ip, criticality string
+		if err := rows.Scan(&ip, &criticality); err != nil {
+			return nil, err
+		}
+		out[ip] = criticality
+	}
+	return out, rows.Err()
+}
+
+// Assets, IP adresi olan aktif varlıkları IP'ye göre döndürür.
+func (p *PostgresClient) Assets(ctx context.Context) (map[string]models.Asset, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, name, host(ip_address), criticality FROM assets WHERE ip_address IS NOT NULL AND status = 'active'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]models.Asset)
+	for rows.Next() {
+		var a models.Asset
+		if err := rows.Scan(&a.ID, &a.Name, &a.IPAddress, &a.Criticality); err != nil {
+			return nil, err
+		}
+		out[a.IPAddress] = a
+	}
+	return out, rows.Err()
+}
+
+// SavePlaybookExecution, playbook çalıştırma kaydını ekler veya günceller.
+func (p *PostgresClient) SavePlaybookExecution(ctx context.Context, exec *models.PlaybookExecution) error {
+	query := `
+	INSERT INTO playbook_executions (id, playbook_id, alert_id, status, detail, started_at, finished_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (id) DO UPDATE SET
+		status = EXCLUDED.status,
+		detail = EXCLUDED.detail,
+		finished_at = EXCLUDED.finished_at`
+
+	_, err := p.db.ExecContext(ctx, query,
+		exec.ID, exec.PlaybookID, exec.AlertID, string(exec.Status), exec.Detail, exec.StartedAt, exec.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save playbook execution: %w", err)
+	}
+	return nil
+}
+
 // Health, database sağlık durumunu döndürür.
 func (p *PostgresClient) Health(ctx context.Context) (map[string]string, error) {
 	var version string
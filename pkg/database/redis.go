@@ -74,6 +74,12 @@ func (r *RedisClient) Set(ctx context.Context, key string, value interface{}, tt
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
+// SetIfAbsent, key yoksa değeri TTL ile saklar. Key'i bu çağrının
+// oluşturup oluşturmadığını döndürür (SET NX).
+func (r *RedisClient) SetIfAbsent(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, ttl).Result()
+}
+
 // Get, key'e karşılık gelen değeri getirir.
 func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
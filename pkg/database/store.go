@@ -0,0 +1,22 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"sakin-go/pkg/models"
+)
+
+// EventStore, event ve akışların yazıldığı depolama arka ucudur. Sink'ler
+// bu arayüze bağlıdır; ClickHouseClient varsayılan uygulamadır, başka bir
+// kolon tabanlı depo veya TimescaleDB aynı arayüzü uygulayarak kullanılabilir.
+type EventStore interface {
+	// InsertEvents, Event batch'ini yazar.
+	InsertEvents(ctx context.Context, events []*models.Event) error
+	// InsertFlows, network_flows şemasındaki akış batch'ini yazar.
+	InsertFlows(ctx context.Context, flows []map[string]interface{}) error
+	// CountEvents, since'den sonraki event sayısını döndürür (sıfır zaman tümü).
+	CountEvents(ctx context.Context, since time.Time) (uint64, error)
+}
+
+var _ EventStore = (*ClickHouseClient)(nil)
@@ -0,0 +1,88 @@
+package messaging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Batch message headers.
+// Publishers that pack several payloads into one NATS message set these so
+// consumers can unpack them; messages without them are treated as single payloads.
+const (
+	HeaderContentEncoding = "Content-Encoding"
+	HeaderBatchCount      = "Sge-Batch-Count"
+
+	EncodingGzip = "gzip"
+)
+
+// EncodeBatch packs JSON payloads into a single JSON array message,
+// optionally gzip compressed. The returned header describes the encoding.
+// Batches are always JSON; see Codec for single messages.
+func EncodeBatch(items [][]byte, compress bool) (nats.Header, []byte, error) {
+	raw := make([]json.RawMessage, len(items))
+	for i, item := range items {
+		raw[i] = item
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("batch marshal failed: %w", err)
+	}
+
+	h := nats.Header{}
+	h.Set(HeaderBatchCount, strconv.Itoa(len(items)))
+
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, nil, fmt.Errorf("batch compress failed: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, nil, fmt.Errorf("batch compress failed: %w", err)
+		}
+		data = buf.Bytes()
+		h.Set(HeaderContentEncoding, EncodingGzip)
+	}
+
+	return h, data, nil
+}
+
+// DecodeBatch reverses EncodeBatch and returns the individual payloads.
+// Messages published without batch headers are returned as a single payload,
+// so consumers work with both batching and non-batching publishers.
+func DecodeBatch(h nats.Header, data []byte) ([][]byte, error) {
+	if h.Get(HeaderContentEncoding) == EncodingGzip {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("batch decompress failed: %w", err)
+		}
+		defer zr.Close()
+
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("batch decompress failed: %w", err)
+		}
+	}
+
+	if h.Get(HeaderBatchCount) == "" {
+		return [][]byte{data}, nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("batch unmarshal failed: %w", err)
+	}
+
+	items := make([][]byte, len(raw))
+	for i, r := range raw {
+		items[i] = r
+	}
+	return items, nil
+}
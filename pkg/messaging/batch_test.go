@@ -0,0 +1,61 @@
+package messaging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeBatch(t *testing.T) {
+	items := [][]byte{
+		[]byte(`{"id":"1","source":"agent"}`),
+		[]byte(`{"id":"2","source":"agent"}`),
+	}
+
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{name: "Plain Batch", compress: false},
+		{name: "Gzip Batch", compress: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, data, err := EncodeBatch(items, tt.compress)
+			if err != nil {
+				t.Fatalf("EncodeBatch() error = %v", err)
+			}
+
+			got, err := DecodeBatch(h, data)
+			if err != nil {
+				t.Fatalf("DecodeBatch() error = %v", err)
+			}
+			if len(got) != len(items) {
+				t.Fatalf("DecodeBatch() items = %d, want %d", len(got), len(items))
+			}
+			for i := range items {
+				if !bytes.Equal(got[i], items[i]) {
+					t.Errorf("item %d = %s, want %s", i, got[i], items[i])
+				}
+			}
+		})
+	}
+
+	t.Run("Unbatched Message", func(t *testing.T) {
+		raw := []byte(`{"id":"3"}`)
+		got, err := DecodeBatch(nil, raw)
+		if err != nil {
+			t.Fatalf("DecodeBatch() error = %v", err)
+		}
+		if len(got) != 1 || !bytes.Equal(got[0], raw) {
+			t.Errorf("DecodeBatch() = %q, want single passthrough payload", got)
+		}
+	})
+
+	t.Run("Corrupt Gzip", func(t *testing.T) {
+		h, _, _ := EncodeBatch(items, true)
+		if _, err := DecodeBatch(h, []byte("not gzip")); err == nil {
+			t.Error("DecodeBatch() expected error for corrupt gzip payload")
+		}
+	})
+}
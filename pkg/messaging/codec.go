@@ -0,0 +1,115 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"sakin-go/pkg/settings"
+)
+
+// HeaderCodec names the codec a message payload was encoded with. Messages
+// without it are JSON, so consumers keep reading publishers that predate
+// codecs and a fleet can switch codecs one publisher at a time.
+const HeaderCodec = "Sge-Codec"
+
+// Codec names accepted by CodecByName and NATS_CODEC.
+const (
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+)
+
+// Codec serializes message payloads.
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON is the default codec, readable by every consumer.
+	JSON Codec = jsonCodec{}
+	// Msgpack is smaller and cheaper to encode than JSON. It uses the json
+	// struct tags, so field names match the JSON form. Numbers inside
+	// interface{} values (e.g. Event.Metadata) decode as int64, uint64 or
+	// float64 instead of JSON's float64, and timestamps decode in the local
+	// time zone (same instant).
+	Msgpack Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return CodecJSON }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return CodecMsgpack }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+	dec.Reset(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	dec.UseLooseInterfaceDecoding(true)
+	return dec.Decode(v)
+}
+
+// CodecByName returns the codec called name; empty means JSON.
+func CodecByName(name string) (Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", CodecJSON:
+		return JSON, nil
+	case CodecMsgpack:
+		return Msgpack, nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q (want %s or %s)", name, CodecJSON, CodecMsgpack)
+	}
+}
+
+// CodecFromEnv returns the codec publishers should use, from NATS_CODEC
+// (default json). Consumers do not need it: they follow HeaderCodec.
+func CodecFromEnv() (Codec, error) {
+	l, err := settings.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	codec, err := CodecByName(l.String("NATS_CODEC", CodecJSON))
+	if err != nil {
+		return nil, fmt.Errorf("NATS_CODEC: %w", err)
+	}
+	return codec, l.Err()
+}
+
+// MessageCodec returns the codec a received message was encoded with.
+func MessageCodec(h nats.Header) (Codec, error) {
+	return CodecByName(h.Get(HeaderCodec))
+}
+
+// EncodeMessage marshals v with codec and returns the header announcing it.
+func EncodeMessage(codec Codec, v interface{}) (nats.Header, []byte, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s marshal failed: %w", codec.Name(), err)
+	}
+	h := nats.Header{}
+	h.Set(HeaderCodec, codec.Name())
+	return h, data, nil
+}
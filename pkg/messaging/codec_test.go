@@ -0,0 +1,219 @@
+package messaging
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"sakin-go/pkg/models"
+)
+
+// sampleEvent resembles an enriched agent event as it travels through NATS.
+func sampleEvent() *models.Event {
+	return &models.Event{
+		ID:          "evt-01HZX3K8",
+		Timestamp:   time.Date(2026, 3, 14, 9, 26, 53, 589793000, time.UTC),
+		Source:      "windows",
+		SourceIP:    "10.1.42.17",
+		DestIP:      "203.0.113.80",
+		EventType:   "auth.login",
+		Severity:    models.SeverityHigh,
+		Status:      models.EventStatusNew,
+		Description: "Failed logon for user administrator from 10.1.42.17",
+		RawLog:      `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event"><System><EventID>4625</EventID><Computer>DC01.corp.local</Computer></System><EventData><Data Name="TargetUserName">administrator</Data><Data Name="IpAddress">10.1.42.17</Data><Data Name="LogonType">3</Data></EventData></Event>`,
+		Metadata: map[string]interface{}{
+			"host":       "DC01.corp.local",
+			"event_id":   4625,
+			"logon_type": 3,
+			"user":       "administrator",
+			"success":    false,
+			"processes":  []string{"lsass.exe", "winlogon.exe"},
+		},
+		Tags: []string{"auth", "windows", "malicious_ip"},
+		Enrichment: map[string]interface{}{
+			"src_geo_country":     "Turkey",
+			"src_geo_city":        "Istanbul",
+			"src_geo_iso":         "TR",
+			"threat_intel_score":  87,
+			"threat_intel_source": "abuseipdb",
+		},
+	}
+}
+
+// number normalizes decoded numbers, whose Go type depends on the codec.
+func number(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	}
+	return -1
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSON, Msgpack} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			want := sampleEvent()
+			h, data, err := EncodeMessage(codec, want)
+			if err != nil {
+				t.Fatalf("EncodeMessage() error = %v", err)
+			}
+			if got := h.Get(HeaderCodec); got != codec.Name() {
+				t.Errorf("header %s = %q, want %q", HeaderCodec, got, codec.Name())
+			}
+
+			dec, err := MessageCodec(h)
+			if err != nil {
+				t.Fatalf("MessageCodec() error = %v", err)
+			}
+			var got models.Event
+			if err := dec.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if !got.Timestamp.Equal(want.Timestamp) {
+				t.Errorf("Timestamp = %v, want %v", got.Timestamp, want.Timestamp)
+			}
+			if got.ID != want.ID || got.Source != want.Source || got.SourceIP != want.SourceIP ||
+				got.DestIP != want.DestIP || got.EventType != want.EventType || got.Severity != want.Severity ||
+				got.Status != want.Status || got.Description != want.Description || got.RawLog != want.RawLog {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, *want)
+			}
+			if !reflect.DeepEqual(got.Tags, want.Tags) {
+				t.Errorf("Tags = %v, want %v", got.Tags, want.Tags)
+			}
+			if got.Metadata["host"] != "DC01.corp.local" || got.Metadata["success"] != false {
+				t.Errorf("Metadata = %v", got.Metadata)
+			}
+			if n := number(got.Metadata["event_id"]); n != 4625 {
+				t.Errorf("Metadata[event_id] = %v, want 4625", got.Metadata["event_id"])
+			}
+			if procs, _ := got.Metadata["processes"].([]interface{}); len(procs) != 2 || procs[0] != "lsass.exe" {
+				t.Errorf("Metadata[processes] = %#v", got.Metadata["processes"])
+			}
+			if got.Enrichment["src_geo_iso"] != "TR" || number(got.Enrichment["threat_intel_score"]) != 87 {
+				t.Errorf("Enrichment = %v", got.Enrichment)
+			}
+		})
+	}
+}
+
+func TestCodecOmitEmpty(t *testing.T) {
+	// Both codecs honor the json tags' omitempty, so the field sets match
+	for _, codec := range []Codec{JSON, Msgpack} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			data, err := codec.Marshal(&models.Event{ID: "1"})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var fields map[string]interface{}
+			if err := codec.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if _, ok := fields["id"]; !ok {
+				t.Errorf("fields = %v, want json field names", fields)
+			}
+			if _, ok := fields["tags"]; ok {
+				t.Errorf("fields = %v, want empty tags omitted", fields)
+			}
+		})
+	}
+}
+
+func TestMessageCodec(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  nats.Header
+		want    string
+		wantErr bool
+	}{
+		{name: "No Header", header: nil, want: CodecJSON},
+		{name: "JSON", header: nats.Header{HeaderCodec: []string{"json"}}, want: CodecJSON},
+		{name: "Msgpack", header: nats.Header{HeaderCodec: []string{"msgpack"}}, want: CodecMsgpack},
+		{name: "Case Insensitive", header: nats.Header{HeaderCodec: []string{"MsgPack"}}, want: CodecMsgpack},
+		{name: "Unknown", header: nats.Header{HeaderCodec: []string{"protobuf"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MessageCodec(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MessageCodec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name() != tt.want {
+				t.Errorf("MessageCodec() = %s, want %s", got.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCodecFromEnv(t *testing.T) {
+	t.Setenv("NATS_CODEC", "msgpack")
+	if c, err := CodecFromEnv(); err != nil || c != Msgpack {
+		t.Errorf("CodecFromEnv() = %v, %v, want msgpack", c, err)
+	}
+
+	t.Setenv("NATS_CODEC", "xml")
+	if _, err := CodecFromEnv(); err == nil {
+		t.Error("CodecFromEnv() expected error for unknown codec")
+	}
+}
+
+func TestMixedCodecs(t *testing.T) {
+	// A consumer reads legacy JSON (no header) and msgpack messages alike
+	legacy, _ := JSON.Marshal(sampleEvent())
+	h, packed, _ := EncodeMessage(Msgpack, sampleEvent())
+
+	msgs := []*nats.Msg{{Data: legacy}, {Header: h, Data: packed}}
+	for i, msg := range msgs {
+		payloads, err := DecodeBatch(msg.Header, msg.Data)
+		if err != nil {
+			t.Fatalf("message %d: DecodeBatch() error = %v", i, err)
+		}
+		codec, err := MessageCodec(msg.Header)
+		if err != nil {
+			t.Fatalf("message %d: MessageCodec() error = %v", i, err)
+		}
+		var evt models.Event
+		if err := codec.Unmarshal(payloads[0], &evt); err != nil || evt.ID != "evt-01HZX3K8" {
+			t.Errorf("message %d: Unmarshal() = %q, %v", i, evt.ID, err)
+		}
+	}
+}
+
+// BenchmarkCodecs compares payload size and encode/decode cost on a
+// realistic event: go test -bench Codec ./pkg/messaging
+func BenchmarkCodecs(b *testing.B) {
+	evt := sampleEvent()
+	for _, codec := range []Codec{JSON, Msgpack} {
+		data, err := codec.Marshal(evt)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(codec.Name()+"/Marshal", func(b *testing.B) {
+			b.ReportAllocs()
+			b.ReportMetric(float64(len(data)), "bytes/msg")
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Marshal(evt); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(codec.Name()+"/Unmarshal", func(b *testing.B) {
+			b.ReportAllocs()
+			b.ReportMetric(float64(len(data)), "bytes/msg")
+			for i := 0; i < b.N; i++ {
+				var out models.Event
+				if err := codec.Unmarshal(data, &out); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
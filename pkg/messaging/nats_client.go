@@ -3,6 +3,7 @@ package messaging
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -18,12 +19,21 @@ type NatsConfig struct {
 	MaxReconnects int
 	// ReconnectWait sets the time to wait between reconnect attempts
 	ReconnectWait time.Duration
+	// Retention sets stream buffer ages and per-message TTLs (nil = DefaultRetention)
+	Retention *Retention
+	// SLO sets the consumers' processing objective (nil = DefaultSLO)
+	SLO *SLOConfig
 }
 
 // Client wraps the NATS connection and JetStream context.
 type Client struct {
-	nc *nats.Conn
-	js jetstream.JetStream
+	nc        *nats.Conn
+	js        jetstream.JetStream
+	retention *Retention
+
+	slo      *SLOConfig
+	sloMu    sync.Mutex
+	monitors map[string]*SLOMonitor // Consumer name -> SLO accounting
 }
 
 // NewClient creates a new optimized NATS client with JetStream support.
@@ -55,9 +65,21 @@ func NewClient(config *NatsConfig) (*Client, error) {
 		return nil, fmt.Errorf("jetstream init failed: %w", err)
 	}
 
+	retention := config.Retention
+	if retention == nil {
+		retention = DefaultRetention()
+	}
+
+	slo := config.SLO
+	if slo == nil {
+		slo = DefaultSLO()
+	}
+
 	return &Client{
-		nc: nc,
-		js: js,
+		nc:        nc,
+		js:        js,
+		retention: retention,
+		slo:       slo,
 	}, nil
 }
 
@@ -80,21 +102,48 @@ func (c *Client) JetStream() jetstream.JetStream {
 
 // PublishAsync publishes a message asynchronously to JetStream.
 // This is non-blocking and highly performant. A future is returned to check status if needed.
-func (c *Client) PublishAsync(ctx context.Context, subject string, data []byte) (jetstream.PubAckFuture, error) {
+func (c *Client) PublishAsync(ctx context.Context, subject string, data []byte, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
 	// PublishAsync is the key for high throughput.
 	// It doesn't wait for the server to acknowledge receipt.
-	return c.js.PublishAsync(subject, data)
+	return c.js.PublishAsync(subject, data, c.withTTL(subject, opts)...)
+}
+
+// PublishMsgAsync is PublishAsync for a message with headers, e.g. one
+// built with EncodeMessage.
+func (c *Client) PublishMsgAsync(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	return c.js.PublishMsgAsync(msg, c.withTTL(msg.Subject, opts)...)
+}
+
+// PublishMsgSync is PublishSync for a message with headers.
+func (c *Client) PublishMsgSync(ctx context.Context, msg *nats.Msg) (*jetstream.PubAck, error) {
+	return c.js.PublishMsg(ctx, msg, c.withTTL(msg.Subject, nil)...)
 }
 
 // PublishSync publishes a message synchronously.
 // Use this only when delivery guarantee is critical before proceeding.
 func (c *Client) PublishSync(ctx context.Context, subject string, data []byte) (*jetstream.PubAck, error) {
-	return c.js.Publish(ctx, subject, data)
+	return c.js.Publish(ctx, subject, data, c.withTTL(subject, nil)...)
+}
+
+// withTTL adds the configured per-message TTL for subject, if any.
+func (c *Client) withTTL(subject string, opts []jetstream.PublishOpt) []jetstream.PublishOpt {
+	if ttl := c.retention.TTL(subject); ttl > 0 {
+		return append(opts, jetstream.WithMsgTTL(ttl))
+	}
+	return opts
 }
 
 // Subscribe is a wrapper for simple Pull Consumer (worker pattern).
 // It creates a Durable Consumer with FilterSubject and DeliverGroup (Queue).
 func (c *Client) QueueSubscribe(ctx context.Context, stream, subject, queueGroup string, handler func(msg jetstream.Msg)) (jetstream.ConsumeContext, error) {
+	return c.QueueSubscribeN(ctx, stream, subject, queueGroup, 1, handler)
+}
+
+// QueueSubscribeN is QueueSubscribe with n concurrent pull subscribers on
+// the same durable consumer. Each subscriber calls handler from its own
+// goroutine, so handler must be safe for concurrent use. Handler latency
+// and failures are accounted in the consumer's SLO (see SLOStatus).
+func (c *Client) QueueSubscribeN(ctx context.Context, stream, subject, queueGroup string, n int, handler func(msg jetstream.Msg)) (jetstream.ConsumeContext, error) {
 	// 1. Create/Update Consumer
 	// Name must he unique for the queue group
 	consumerName := queueGroup
@@ -113,44 +162,57 @@ func (c *Client) QueueSubscribe(ctx context.Context, stream, subject, queueGroup
 	}
 
 	// 2. Consume (Pull)
-	// This starts a goroutine that pulls messages and calls handler
-	cc, err := cons.Consume(handler)
-	if err != nil {
-		return nil, fmt.Errorf("consume failed: %w", err)
+	// Each subscriber starts a goroutine that pulls messages and calls handler
+	return consumeN(cons, n, instrument(c.sloMonitor(consumerName), handler))
+}
+
+// streamConfigs returns the JetStream stream definitions with buffer ages
+// taken from r.
+func streamConfigs(r *Retention) []jetstream.StreamConfig {
+	streams := []jetstream.StreamConfig{
+		{
+			Name:        StreamEvents,
+			Description: "SGE Security Events Stream",
+			Retention:   jetstream.WorkQueuePolicy, // Using WorkQueue for processing
+			Storage:     jetstream.FileStorage,
+			Replicas:    1, // Increase for HA
+		},
+		{
+			Name:        StreamAlerts,
+			Description: "SGE Generated Alerts",
+			Retention:   jetstream.LimitsPolicy, // Keep alerts available for history
+			Storage:     jetstream.FileStorage,
+		},
+		{
+			// Service logs & sensor telemetry
+			Name:        StreamSystem,
+			Description: "SGE Internal System Logs & Telemetry",
+			Retention:   jetstream.LimitsPolicy,
+			Storage:     jetstream.FileStorage,
+		},
+		{
+			// SOAR -> Agent commands and Agent -> SOAR results
+			Name:        StreamCommands,
+			Description: "SGE Agent Commands & Results",
+			Retention:   jetstream.LimitsPolicy,
+			Storage:     jetstream.FileStorage,
+		},
 	}
 
-	return cc, nil
+	for i := range streams {
+		streams[i].Subjects = streamSubjects[streams[i].Name]
+		r.applyTo(&streams[i])
+	}
+	return streams
 }
 
 // InitializeStreams creates the necessary JetStream streams if they don't exist.
 // Configured for high performance (File storage, defined retention).
 func (c *Client) InitializeStreams(ctx context.Context) error {
-	// Events Stream
-	_, err := c.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
-		Name:        StreamEvents,
-		Description: "SGE Security Events Stream",
-		Subjects:    []string{"events.>"},
-		Retention:   jetstream.WorkQueuePolicy, // Using WorkQueue for processing
-		Storage:     jetstream.FileStorage,
-		Replicas:    1,              // Increase for HA
-		MaxAge:      24 * time.Hour, // Keep events for 24h in stream buffer
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create events stream: %w", err)
+	for _, cfg := range streamConfigs(c.retention) {
+		if _, err := c.js.CreateOrUpdateStream(ctx, cfg); err != nil {
+			return fmt.Errorf("failed to create stream %s: %w", cfg.Name, err)
+		}
 	}
-
-	// Alerts Stream
-	_, err = c.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
-		Name:        StreamAlerts,
-		Description: "SGE Generated Alerts",
-		Subjects:    []string{"alerts.>"},
-		Retention:   jetstream.LimitsPolicy, // Keep alerts available for history
-		Storage:     jetstream.FileStorage,
-		MaxAge:      7 * 24 * time.Hour,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create alerts stream: %w", err)
-	}
-
 	return nil
 }
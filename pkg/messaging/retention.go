@@ -0,0 +1,232 @@
+package messaging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"sakin-go/pkg/models"
+	"sakin-go/pkg/settings"
+)
+
+// Retention controls how long messages stay in the JetStream buffer.
+// This is independent of storage retention (ClickHouse TTL): it only limits
+// how long unconsumed or historical messages are kept in the streams.
+//
+// Streams holds the default MaxAge per stream. Subjects and Severity are
+// per-message overrides applied as a Nats-TTL header on publish; a severity
+// override wins over a subject override. Streams with overrides get
+// AllowMsgTTL and a MaxAge raised to the longest override, since MaxAge caps
+// every message in the stream; their messages without an override carry the
+// stream default as TTL instead.
+type Retention struct {
+	Streams  map[string]time.Duration          // Stream name -> default MaxAge
+	Subjects map[string]time.Duration          // Subject pattern ("events.raw.>") -> TTL
+	Severity map[models.Severity]time.Duration // Event/alert severity -> TTL
+}
+
+// DefaultRetention returns the built-in stream buffer ages without overrides.
+func DefaultRetention() *Retention {
+	return &Retention{
+		Streams: map[string]time.Duration{
+			StreamEvents:   24 * time.Hour,
+			StreamAlerts:   7 * 24 * time.Hour,
+			StreamSystem:   24 * time.Hour,
+			StreamCommands: 24 * time.Hour,
+		},
+	}
+}
+
+// RetentionFromEnv loads the retention settings:
+//
+//	NATS_RETENTION_EVENTS / _ALERTS / _SYSTEM / _COMMANDS  stream MaxAge ("24h")
+//	NATS_RETENTION_SUBJECTS  per-subject TTLs ("events.raw.>=6h,system.sensors.>=1h")
+//	NATS_RETENTION_SEVERITY  per-severity TTLs ("critical=72h,low=2h")
+//
+// Every publishing service must use the same values as the service creating
+// the streams, otherwise TTL headers are rejected by streams without AllowMsgTTL.
+func RetentionFromEnv() (*Retention, error) {
+	l, err := settings.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	r := DefaultRetention()
+	for stream, key := range map[string]string{
+		StreamEvents:   "NATS_RETENTION_EVENTS",
+		StreamAlerts:   "NATS_RETENTION_ALERTS",
+		StreamSystem:   "NATS_RETENTION_SYSTEM",
+		StreamCommands: "NATS_RETENTION_COMMANDS",
+	} {
+		r.Streams[stream] = l.Duration(key, r.Streams[stream], time.Second)
+	}
+
+	subjects, err := parseTTLs(l.String("NATS_RETENTION_SUBJECTS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("NATS_RETENTION_SUBJECTS: %w", err)
+	}
+	for pattern := range subjects {
+		if streamOf(pattern) == "" {
+			return nil, fmt.Errorf("NATS_RETENTION_SUBJECTS: %q is not covered by any stream", pattern)
+		}
+	}
+	r.Subjects = subjects
+
+	severities, err := parseTTLs(l.String("NATS_RETENTION_SEVERITY", ""))
+	if err != nil {
+		return nil, fmt.Errorf("NATS_RETENTION_SEVERITY: %w", err)
+	}
+	if len(severities) > 0 {
+		r.Severity = make(map[models.Severity]time.Duration, len(severities))
+	}
+	for sev, ttl := range severities {
+		switch s := models.Severity(sev); s {
+		case models.SeverityInfo, models.SeverityLow, models.SeverityMedium, models.SeverityHigh, models.SeverityCritical:
+			r.Severity[s] = ttl
+		default:
+			return nil, fmt.Errorf("NATS_RETENTION_SEVERITY: unknown severity %q", sev)
+		}
+	}
+
+	return r, l.Err()
+}
+
+// parseTTLs parses "key=duration,key=duration". TTLs must be at least 1s,
+// the smallest per-message TTL JetStream accepts.
+func parseTTLs(spec string) (map[string]time.Duration, error) {
+	out := map[string]time.Duration{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid entry %q: want key=duration", item)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(val))
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry %q: %w", item, err)
+		}
+		if ttl < time.Second {
+			return nil, fmt.Errorf("invalid entry %q: TTL must be at least 1s", item)
+		}
+		out[strings.TrimSpace(key)] = ttl
+	}
+	return out, nil
+}
+
+// streamSubjects lists the subjects captured by each stream.
+var streamSubjects = map[string][]string{
+	StreamEvents:   {"events.>"},
+	StreamAlerts:   {"alerts.>"},
+	StreamSystem:   {"system.>"},
+	StreamCommands: {"commands.>", "results.>"},
+}
+
+// streamOf returns the stream capturing subject (or subject pattern).
+func streamOf(subject string) string {
+	root, _, _ := strings.Cut(subject, ".")
+	for stream, subjects := range streamSubjects {
+		for _, s := range subjects {
+			if strings.TrimSuffix(s, ".>") == root {
+				return stream
+			}
+		}
+	}
+	return ""
+}
+
+// subjectSeverity extracts the severity token of event and alert subjects
+// (events.<stage>.<severity>.<source>, alerts.<severity>.<rule_id>).
+func subjectSeverity(subject string) models.Severity {
+	tokens := strings.Split(subject, ".")
+	switch {
+	case tokens[0] == "events" && len(tokens) > 2:
+		return models.Severity(tokens[2])
+	case tokens[0] == "alerts" && len(tokens) > 1:
+		return models.Severity(tokens[1])
+	}
+	return ""
+}
+
+// matchSubject reports whether subject matches pattern, which may use the
+// '*' and '>' wildcards.
+func matchSubject(pattern, subject string) bool {
+	pt, st := strings.Split(pattern, "."), strings.Split(subject, ".")
+	for i, p := range pt {
+		if p == ">" {
+			return len(st) > i
+		}
+		if i >= len(st) || (p != "*" && p != st[i]) {
+			return false
+		}
+	}
+	return len(pt) == len(st)
+}
+
+// TTL returns the per-message TTL for subject, or 0 if the stream MaxAge
+// applies. On streams with overrides, other subjects get the stream default.
+func (r *Retention) TTL(subject string) time.Duration {
+	if r == nil {
+		return 0
+	}
+	if ttl, ok := r.Severity[subjectSeverity(subject)]; ok {
+		return ttl
+	}
+
+	// Most specific (longest) matching pattern wins
+	var best string
+	var ttl time.Duration
+	for pattern, d := range r.Subjects {
+		if matchSubject(pattern, subject) && (len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best)) {
+			best, ttl = pattern, d
+		}
+	}
+	if ttl > 0 {
+		return ttl
+	}
+
+	// The stream MaxAge is raised to its longest override
+	stream := streamOf(subject)
+	if _, ok := r.overrides(stream); ok {
+		return r.Streams[stream]
+	}
+	return 0
+}
+
+// overrides returns the longest override applying to stream and whether any does.
+func (r *Retention) overrides(stream string) (time.Duration, bool) {
+	var max time.Duration
+	found := false
+	for pattern, ttl := range r.Subjects {
+		if streamOf(pattern) == stream {
+			found = true
+			if ttl > max {
+				max = ttl
+			}
+		}
+	}
+	if stream == StreamEvents || stream == StreamAlerts {
+		for _, ttl := range r.Severity {
+			found = true
+			if ttl > max {
+				max = ttl
+			}
+		}
+	}
+	return max, found
+}
+
+// applyTo sets MaxAge and AllowMsgTTL on cfg according to the retention.
+func (r *Retention) applyTo(cfg *jetstream.StreamConfig) {
+	cfg.MaxAge = r.Streams[cfg.Name]
+	if max, ok := r.overrides(cfg.Name); ok {
+		cfg.AllowMsgTTL = true
+		if max > cfg.MaxAge {
+			cfg.MaxAge = max
+		}
+	}
+}
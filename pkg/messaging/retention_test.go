@@ -0,0 +1,138 @@
+package messaging
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func streamByName(t *testing.T, streams []jetstream.StreamConfig, name string) jetstream.StreamConfig {
+	t.Helper()
+	for _, s := range streams {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("stream %s not created", name)
+	return jetstream.StreamConfig{}
+}
+
+func TestStreamConfigsDefault(t *testing.T) {
+	streams := streamConfigs(DefaultRetention())
+	if len(streams) != 4 {
+		t.Fatalf("streamConfigs() = %d streams, want 4", len(streams))
+	}
+
+	tests := []struct {
+		stream string
+		maxAge time.Duration
+	}{
+		{StreamEvents, 24 * time.Hour},
+		{StreamAlerts, 7 * 24 * time.Hour},
+		{StreamSystem, 24 * time.Hour},
+		{StreamCommands, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stream, func(t *testing.T) {
+			s := streamByName(t, streams, tt.stream)
+			if s.MaxAge != tt.maxAge || s.AllowMsgTTL {
+				t.Errorf("MaxAge = %s, AllowMsgTTL = %v, want %s, false", s.MaxAge, s.AllowMsgTTL, tt.maxAge)
+			}
+		})
+	}
+}
+
+func TestRetentionFromEnv(t *testing.T) {
+	t.Setenv("NATS_RETENTION_EVENTS", "12h")
+	t.Setenv("NATS_RETENTION_ALERTS", "48h")
+	t.Setenv("NATS_RETENTION_SUBJECTS", "events.raw.>=6h, system.sensors.>=1h")
+	t.Setenv("NATS_RETENTION_SEVERITY", "critical=72h,info=2h")
+
+	r, err := RetentionFromEnv()
+	if err != nil {
+		t.Fatalf("RetentionFromEnv() error = %v", err)
+	}
+	streams := streamConfigs(r)
+
+	events := streamByName(t, streams, StreamEvents)
+	if !events.AllowMsgTTL || events.MaxAge != 72*time.Hour {
+		t.Errorf("events stream MaxAge = %s, AllowMsgTTL = %v, want 72h (longest override), true", events.MaxAge, events.AllowMsgTTL)
+	}
+	alerts := streamByName(t, streams, StreamAlerts)
+	if !alerts.AllowMsgTTL || alerts.MaxAge != 72*time.Hour {
+		t.Errorf("alerts stream MaxAge = %s, AllowMsgTTL = %v, want 72h, true", alerts.MaxAge, alerts.AllowMsgTTL)
+	}
+	system := streamByName(t, streams, StreamSystem)
+	if !system.AllowMsgTTL || system.MaxAge != 24*time.Hour {
+		t.Errorf("system stream MaxAge = %s, AllowMsgTTL = %v, want 24h, true", system.MaxAge, system.AllowMsgTTL)
+	}
+	commands := streamByName(t, streams, StreamCommands)
+	if commands.AllowMsgTTL || commands.MaxAge != 24*time.Hour {
+		t.Errorf("commands stream MaxAge = %s, AllowMsgTTL = %v, want 24h, false", commands.MaxAge, commands.AllowMsgTTL)
+	}
+
+	ttls := []struct {
+		subject string
+		want    time.Duration
+	}{
+		{"events.raw.critical.agent", 72 * time.Hour},
+		{"events.raw.medium.agent", 6 * time.Hour},
+		{"events.enriched.info.agent", 2 * time.Hour},
+		{"events.enriched.medium.agent", 12 * time.Hour},
+		{"alerts.critical.R1", 72 * time.Hour},
+		{"alerts.high.R1", 48 * time.Hour},
+		{"system.sensors.sensor-01", time.Hour},
+		{"system.agents.agent-1", 24 * time.Hour},
+		{"commands.agent-1", 0},
+	}
+	for _, tt := range ttls {
+		if got := r.TTL(tt.subject); got != tt.want {
+			t.Errorf("TTL(%s) = %s, want %s", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestRetentionFromEnvErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr string
+	}{
+		{"Bad Stream Age", "NATS_RETENTION_EVENTS", "forever", "is not a duration"},
+		{"Bad Entry", "NATS_RETENTION_SUBJECTS", "events.raw.>", "want key=duration"},
+		{"Unknown Stream", "NATS_RETENTION_SUBJECTS", "logs.>=1h", "not covered by any stream"},
+		{"TTL Too Short", "NATS_RETENTION_SUBJECTS", "events.raw.>=500ms", "at least 1s"},
+		{"Unknown Severity", "NATS_RETENTION_SEVERITY", "urgent=1h", "unknown severity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := RetentionFromEnv(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RetentionFromEnv() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchSubject(t *testing.T) {
+	tests := []struct {
+		pattern, subject string
+		want             bool
+	}{
+		{"events.raw.>", "events.raw.info.agent", true},
+		{"events.raw.>", "events.raw", false},
+		{"events.*.high.*", "events.enriched.high.agent", true},
+		{"events.*.high.*", "events.enriched.low.agent", false},
+		{"alerts.high.R1", "alerts.high.R1", true},
+		{"alerts.high", "alerts.high.R1", false},
+	}
+	for _, tt := range tests {
+		if got := matchSubject(tt.pattern, tt.subject); got != tt.want {
+			t.Errorf("matchSubject(%s, %s) = %v, want %v", tt.pattern, tt.subject, got, tt.want)
+		}
+	}
+}
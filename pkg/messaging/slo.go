@@ -0,0 +1,293 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"sakin-go/pkg/settings"
+)
+
+// SLOConfig is the processing objective of every consumer of a client: a
+// message meets it when its handler succeeds within Latency.
+type SLOConfig struct {
+	Latency    time.Duration // Per-message processing target
+	Objective  float64       // Share of messages (0..1) that must meet Latency
+	Window     time.Duration // Rolling window compliance is computed over
+	HealthAddr string        // Address ServeHealth listens on, empty disables
+}
+
+// DefaultSLO returns the objective used when none is configured.
+func DefaultSLO() *SLOConfig {
+	return &SLOConfig{Latency: 100 * time.Millisecond, Objective: 0.99, Window: 5 * time.Minute}
+}
+
+// SLOFromEnv loads the consumer SLO:
+//
+//	CONSUMER_SLO_LATENCY    per-message target ("100ms")
+//	CONSUMER_SLO_OBJECTIVE  percent of messages meeting it ("99", "99.9")
+//	CONSUMER_SLO_WINDOW     rolling window ("5m")
+//	CONSUMER_HEALTH_ADDR    health endpoint address (":9102"), empty disables
+func SLOFromEnv() (*SLOConfig, error) {
+	l, err := settings.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultSLO()
+	cfg.Latency = l.Duration("CONSUMER_SLO_LATENCY", cfg.Latency, time.Microsecond)
+	cfg.Window = l.Duration("CONSUMER_SLO_WINDOW", cfg.Window, time.Second)
+	cfg.HealthAddr = l.String("CONSUMER_HEALTH_ADDR", "")
+	if s := l.String("CONSUMER_SLO_OBJECTIVE", ""); s != "" {
+		pct, err := strconv.ParseFloat(s, 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			return nil, fmt.Errorf("CONSUMER_SLO_OBJECTIVE: want a percentage in (0, 100], got %q", s)
+		}
+		cfg.Objective = pct / 100
+	}
+	return cfg, l.Err()
+}
+
+// sloBuckets is the number of slices the rolling window is divided into.
+const sloBuckets = 60
+
+type sloBucket struct {
+	slot      int64 // Window slice this bucket holds, older slices are stale
+	processed uint64
+	errors    uint64
+	good      uint64 // Succeeded within the latency target
+	latency   time.Duration
+	max       time.Duration
+}
+
+// SLOMonitor accounts per-message processing latency and errors of one
+// consumer over a rolling window. It is safe for concurrent use.
+type SLOMonitor struct {
+	consumer string
+	cfg      SLOConfig
+	width    time.Duration // Duration of one bucket
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets [sloBuckets]sloBucket
+	total   sloBucket // Since start, slot unused
+}
+
+// NewSLOMonitor creates a monitor for consumer.
+func NewSLOMonitor(consumer string, cfg SLOConfig) *SLOMonitor {
+	width := cfg.Window / sloBuckets
+	if width <= 0 {
+		width = time.Second
+	}
+	return &SLOMonitor{consumer: consumer, cfg: cfg, width: width, now: time.Now}
+}
+
+// Observe records one processed message.
+func (m *SLOMonitor) Observe(latency time.Duration, failed bool) {
+	slot := m.now().UnixNano() / int64(m.width)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := &m.buckets[slot%sloBuckets]
+	if b.slot != slot {
+		*b = sloBucket{slot: slot}
+	}
+	for _, b := range []*sloBucket{b, &m.total} {
+		b.processed++
+		b.latency += latency
+		b.max = max(b.max, latency)
+		if failed {
+			b.errors++
+		} else if latency <= m.cfg.Latency {
+			b.good++
+		}
+	}
+}
+
+// SLOStatus is a consumer's SLO compliance over the rolling window.
+type SLOStatus struct {
+	Consumer     string  `json:"consumer"`
+	Window       string  `json:"window"`
+	LatencyMS    float64 `json:"latency_target_ms"`
+	Objective    float64 `json:"objective"`
+	Processed    uint64  `json:"processed"`
+	Errors       uint64  `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	Compliance   float64 `json:"compliance"` // Share succeeded within the latency target
+	AvgLatencyMS float64 `json:"avg_latency_ms"`
+	MaxLatencyMS float64 `json:"max_latency_ms"`
+	Healthy      bool    `json:"healthy"` // Compliance meets the objective (or nothing processed)
+
+	TotalProcessed uint64 `json:"total_processed"` // Since start
+	TotalErrors    uint64 `json:"total_errors"`
+}
+
+// Status returns the compliance over the last window.
+func (m *SLOMonitor) Status() SLOStatus {
+	oldest := m.now().UnixNano()/int64(m.width) - sloBuckets + 1
+
+	m.mu.Lock()
+	var w sloBucket
+	for _, b := range m.buckets {
+		if b.slot < oldest {
+			continue
+		}
+		w.processed += b.processed
+		w.errors += b.errors
+		w.good += b.good
+		w.latency += b.latency
+		w.max = max(w.max, b.max)
+	}
+	total := m.total
+	m.mu.Unlock()
+
+	s := SLOStatus{
+		Consumer:       m.consumer,
+		Window:         m.cfg.Window.String(),
+		LatencyMS:      ms(m.cfg.Latency),
+		Objective:      m.cfg.Objective,
+		Processed:      w.processed,
+		Errors:         w.errors,
+		Compliance:     1,
+		MaxLatencyMS:   ms(w.max),
+		Healthy:        true,
+		TotalProcessed: total.processed,
+		TotalErrors:    total.errors,
+	}
+	if w.processed > 0 {
+		s.ErrorRate = float64(w.errors) / float64(w.processed)
+		s.Compliance = float64(w.good) / float64(w.processed)
+		s.AvgLatencyMS = ms(w.latency / time.Duration(w.processed))
+		s.Healthy = s.Compliance >= m.cfg.Objective
+	}
+	return s
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// trackedMsg notes whether the handler rejected the message.
+type trackedMsg struct {
+	jetstream.Msg
+	failed bool
+}
+
+func (m *trackedMsg) Nak() error {
+	m.failed = true
+	return m.Msg.Nak()
+}
+
+func (m *trackedMsg) NakWithDelay(delay time.Duration) error {
+	m.failed = true
+	return m.Msg.NakWithDelay(delay)
+}
+
+func (m *trackedMsg) Term() error {
+	m.failed = true
+	return m.Msg.Term()
+}
+
+func (m *trackedMsg) TermWithReason(reason string) error {
+	m.failed = true
+	return m.Msg.TermWithReason(reason)
+}
+
+// MarkFailed counts msg as a processing error in the consumer's SLO.
+// Messages a handler Naks or Terms are counted already; use it for
+// failures after the message was acked.
+func MarkFailed(msg jetstream.Msg) {
+	if m, ok := msg.(*trackedMsg); ok {
+		m.failed = true
+	}
+}
+
+// instrument wraps handler so every message is observed by m.
+func instrument(m *SLOMonitor, handler func(msg jetstream.Msg)) func(msg jetstream.Msg) {
+	return func(msg jetstream.Msg) {
+		tracked := &trackedMsg{Msg: msg}
+		start := m.now()
+		handler(tracked)
+		m.Observe(m.now().Sub(start), tracked.failed)
+	}
+}
+
+// sloMonitor returns the monitor of consumer, creating it on first use.
+func (c *Client) sloMonitor(consumer string) *SLOMonitor {
+	c.sloMu.Lock()
+	defer c.sloMu.Unlock()
+
+	if m, ok := c.monitors[consumer]; ok {
+		return m
+	}
+	if c.monitors == nil {
+		c.monitors = make(map[string]*SLOMonitor)
+	}
+	cfg := c.slo
+	if cfg == nil {
+		cfg = DefaultSLO()
+	}
+	m := NewSLOMonitor(consumer, *cfg)
+	c.monitors[consumer] = m
+	return m
+}
+
+// SLOStatus returns the SLO compliance of every consumer, sorted by name.
+func (c *Client) SLOStatus() []SLOStatus {
+	c.sloMu.Lock()
+	monitors := make([]*SLOMonitor, 0, len(c.monitors))
+	for _, m := range c.monitors {
+		monitors = append(monitors, m)
+	}
+	c.sloMu.Unlock()
+
+	statuses := make([]SLOStatus, 0, len(monitors))
+	for _, m := range monitors {
+		statuses = append(statuses, m.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Consumer < statuses[j].Consumer })
+	return statuses
+}
+
+// HealthHandler serves the NATS connection state and consumer SLOs as
+// JSON, with status 503 when disconnected or any consumer misses its SLO.
+func (c *Client) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := struct {
+			Status    string      `json:"status"`
+			NATS      string      `json:"nats"`
+			Consumers []SLOStatus `json:"consumers"`
+		}{Status: "ok", NATS: "UNKNOWN", Consumers: c.SLOStatus()}
+
+		code := http.StatusOK
+		if c.nc != nil {
+			resp.NATS = c.nc.Status().String()
+			if !c.nc.IsConnected() {
+				resp.Status, code = "unavailable", http.StatusServiceUnavailable
+			}
+		}
+		for _, s := range resp.Consumers {
+			if !s.Healthy && code == http.StatusOK {
+				resp.Status, code = "degraded", http.StatusServiceUnavailable
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// ServeHealth serves HealthHandler on /health at addr until it fails.
+func (c *Client) ServeHealth(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/health", c.HealthHandler())
+	return http.ListenAndServe(addr, mux)
+}
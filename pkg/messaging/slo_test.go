@@ -0,0 +1,168 @@
+package messaging
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ackMsg accepts acks and naks without a server.
+type ackMsg struct{ jetstream.Msg }
+
+func (ackMsg) Ack() error { return nil }
+func (ackMsg) Nak() error { return nil }
+
+// fakeClock is advanced by the simulated handlers.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestMonitor(clock *fakeClock) *SLOMonitor {
+	m := NewSLOMonitor(ConsumerEnrichment, SLOConfig{Latency: 100 * time.Millisecond, Objective: 0.9, Window: time.Minute})
+	m.now = clock.now
+	return m
+}
+
+// simulate runs one message through an instrumented handler taking d and
+// failing as fail says.
+func simulate(m *SLOMonitor, clock *fakeClock, d time.Duration, fail string) {
+	instrument(m, func(msg jetstream.Msg) {
+		msg.Ack()
+		clock.t = clock.t.Add(d)
+		switch fail {
+		case "nak":
+			msg.Nak()
+		case "mark":
+			MarkFailed(msg)
+		}
+	})(ackMsg{})
+}
+
+func TestSLOMonitorAccounting(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)}
+	m := newTestMonitor(clock)
+
+	for i := 0; i < 7; i++ {
+		simulate(m, clock, 20*time.Millisecond, "")
+	}
+	simulate(m, clock, 250*time.Millisecond, "")
+	simulate(m, clock, 250*time.Millisecond, "")
+	simulate(m, clock, 10*time.Millisecond, "nak")
+
+	s := m.Status()
+	if s.Processed != 10 || s.Errors != 1 {
+		t.Errorf("Processed, Errors = %d, %d, want 10, 1", s.Processed, s.Errors)
+	}
+	if s.ErrorRate != 0.1 {
+		t.Errorf("ErrorRate = %v, want 0.1", s.ErrorRate)
+	}
+	// Slow and failed messages both miss the objective
+	if s.Compliance != 0.7 {
+		t.Errorf("Compliance = %v, want 0.7", s.Compliance)
+	}
+	if s.AvgLatencyMS != 65 || s.MaxLatencyMS != 250 {
+		t.Errorf("AvgLatencyMS, MaxLatencyMS = %v, %v, want 65, 250", s.AvgLatencyMS, s.MaxLatencyMS)
+	}
+	if s.Healthy {
+		t.Error("Healthy = true, want false below the 90% objective")
+	}
+}
+
+func TestSLOMonitorMarkFailed(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)}
+	m := newTestMonitor(clock)
+
+	for i := 0; i < 19; i++ {
+		simulate(m, clock, time.Millisecond, "")
+	}
+	simulate(m, clock, time.Millisecond, "mark")
+
+	if s := m.Status(); s.Errors != 1 || s.Compliance != 0.95 || !s.Healthy {
+		t.Errorf("Status() = %+v, want 1 error, 0.95 compliance, healthy", s)
+	}
+}
+
+func TestSLOMonitorWindow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)}
+	m := newTestMonitor(clock)
+
+	for i := 0; i < 5; i++ {
+		simulate(m, clock, 500*time.Millisecond, "nak")
+	}
+	clock.t = clock.t.Add(30 * time.Second)
+	for i := 0; i < 5; i++ {
+		simulate(m, clock, time.Millisecond, "")
+	}
+	if s := m.Status(); s.Processed != 10 || s.Compliance != 0.5 {
+		t.Errorf("within window: Status() = %+v, want 10 processed, 0.5 compliance", s)
+	}
+
+	// The failures age out first, then everything
+	clock.t = clock.t.Add(45 * time.Second)
+	if s := m.Status(); s.Processed != 5 || s.Errors != 0 || !s.Healthy {
+		t.Errorf("after failures expired: Status() = %+v, want 5 processed, healthy", s)
+	}
+	clock.t = clock.t.Add(time.Hour)
+	s := m.Status()
+	if s.Processed != 0 || s.Compliance != 1 || !s.Healthy {
+		t.Errorf("idle: Status() = %+v, want nothing processed, healthy", s)
+	}
+	if s.TotalProcessed != 10 || s.TotalErrors != 5 {
+		t.Errorf("TotalProcessed, TotalErrors = %d, %d, want 10, 5", s.TotalProcessed, s.TotalErrors)
+	}
+}
+
+func TestSLOFromEnv(t *testing.T) {
+	t.Setenv("CONSUMER_SLO_LATENCY", "250ms")
+	t.Setenv("CONSUMER_SLO_OBJECTIVE", "99.9")
+	t.Setenv("CONSUMER_SLO_WINDOW", "1m")
+	cfg, err := SLOFromEnv()
+	if err != nil {
+		t.Fatalf("SLOFromEnv() error = %v", err)
+	}
+	if cfg.Latency != 250*time.Millisecond || math.Abs(cfg.Objective-0.999) > 1e-9 || cfg.Window != time.Minute {
+		t.Errorf("SLOFromEnv() = %+v", cfg)
+	}
+
+	for _, bad := range []string{"0", "101", "most"} {
+		t.Setenv("CONSUMER_SLO_OBJECTIVE", bad)
+		if _, err := SLOFromEnv(); err == nil {
+			t.Errorf("SLOFromEnv() expected error for objective %q", bad)
+		}
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	c := &Client{slo: &SLOConfig{Latency: 100 * time.Millisecond, Objective: 0.99, Window: time.Minute}}
+	m := c.sloMonitor(ConsumerCorrelation)
+	if c.sloMonitor(ConsumerCorrelation) != m {
+		t.Fatal("sloMonitor() created a second monitor for the same consumer")
+	}
+
+	get := func() (int, []SLOStatus) {
+		rec := httptest.NewRecorder()
+		c.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var body struct {
+			Consumers []SLOStatus `json:"consumers"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid JSON %s: %v", rec.Body, err)
+		}
+		return rec.Code, body.Consumers
+	}
+
+	m.Observe(10*time.Millisecond, false)
+	if code, consumers := get(); code != http.StatusOK || len(consumers) != 1 || consumers[0].Consumer != ConsumerCorrelation {
+		t.Errorf("healthy: status %d, consumers %+v", code, consumers)
+	}
+
+	m.Observe(10*time.Millisecond, true)
+	if code, consumers := get(); code != http.StatusServiceUnavailable || consumers[0].Errors != 1 {
+		t.Errorf("degraded: status %d, consumers %+v, want 503", code, consumers)
+	}
+}
@@ -0,0 +1,77 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidToken is returned for subject tokens that are empty or contain
+// separators, wildcards or whitespace.
+var ErrInvalidToken = errors.New("invalid subject token")
+
+// tokenReplacement replaces characters a subject token cannot contain.
+const tokenReplacement = '_'
+
+func invalidTokenRune(r rune) bool {
+	return r == '.' || r == '*' || r == '>' || unicode.IsSpace(r) || unicode.IsControl(r)
+}
+
+// ValidateToken checks that tok can be used as a single subject token.
+func ValidateToken(tok string) error {
+	if tok == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidToken)
+	}
+	if i := strings.IndexFunc(tok, invalidTokenRune); i >= 0 {
+		return fmt.Errorf("%w: %q contains %q", ErrInvalidToken, tok, tok[i])
+	}
+	return nil
+}
+
+// SanitizeToken turns tok into a valid subject token by replacing separators,
+// wildcards and whitespace with '_'. Empty tokens become "_".
+func SanitizeToken(tok string) string {
+	if tok == "" {
+		return string(tokenReplacement)
+	}
+	return strings.Map(func(r rune) rune {
+		if invalidTokenRune(r) {
+			return tokenReplacement
+		}
+		return r
+	}, tok)
+}
+
+// topicPrefix returns the literal part of a topic constant, e.g.
+// "events.raw." for "events.raw.>".
+func topicPrefix(topic string) string {
+	topic = strings.TrimSuffix(topic, ">")
+	if !strings.HasSuffix(topic, ".") {
+		topic += "."
+	}
+	return topic
+}
+
+// Subject builds a concrete subject under topic (one of the Topic* constants)
+// from tokens, e.g. Subject(TopicAlerts, "high", "R1") = "alerts.high.R1".
+// Tokens are validated and an ErrInvalidToken is returned for the first bad one.
+func Subject(topic string, tokens ...string) (string, error) {
+	for _, tok := range tokens {
+		if err := ValidateToken(tok); err != nil {
+			return "", err
+		}
+	}
+	return topicPrefix(topic) + strings.Join(tokens, "."), nil
+}
+
+// SafeSubject is like Subject but sanitizes tokens instead of rejecting them.
+// Use it for tokens taken from event data (sources, rule IDs), where dropping
+// the message is worse than a rewritten subject.
+func SafeSubject(topic string, tokens ...string) string {
+	clean := make([]string, len(tokens))
+	for i, tok := range tokens {
+		clean[i] = SanitizeToken(tok)
+	}
+	return topicPrefix(topic) + strings.Join(clean, ".")
+}
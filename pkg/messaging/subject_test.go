@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		topic   string
+		tokens  []string
+		want    string
+		wantErr bool
+	}{
+		{"Alert", TopicAlerts, []string{"high", "R1"}, "alerts.high.R1", false},
+		{"Raw Event", TopicEventsRaw, []string{"info", "agent"}, "events.raw.info.agent", false},
+		{"Command", TopicCommands, []string{"agent-web-01"}, "commands.agent-web-01", false},
+		{"Topic Without Wildcard", "results", []string{"agent-1"}, "results.agent-1", false},
+		{"Rule ID With Dot", TopicAlerts, []string{"high", "brute.force"}, "", true},
+		{"Space", TopicAlerts, []string{"high", "brute force"}, "", true},
+		{"Wildcard", TopicEventsRaw, []string{"info", "*"}, "", true},
+		{"Full Wildcard", TopicEventsRaw, []string{"info", ">"}, "", true},
+		{"Empty", TopicEventsRaw, []string{"", "agent"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Subject(tt.topic, tt.tokens...)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Subject() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Subject() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Subject() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeSubject(t *testing.T) {
+	tests := []struct {
+		name   string
+		topic  string
+		tokens []string
+		want   string
+	}{
+		{"Valid", TopicAlerts, []string{"high", "R1"}, "alerts.high.R1"},
+		{"Rule ID With Dot", TopicAlerts, []string{"high", "brute.force"}, "alerts.high.brute_force"},
+		{"Hostname Source", TopicEventsEnriched, []string{"low", "web01.corp.local"}, "events.enriched.low.web01_corp_local"},
+		{"Wildcards And Spaces", TopicEventsRaw, []string{"info", "a *>\tb"}, "events.raw.info.a____b"},
+		{"Empty Source", TopicEventsRaw, []string{"info", ""}, "events.raw.info._"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SafeSubject(tt.topic, tt.tokens...)
+			if got != tt.want {
+				t.Errorf("SafeSubject() = %s, want %s", got, tt.want)
+			}
+			if _, err := Subject(tt.topic, strings.Split(strings.TrimPrefix(got, topicPrefix(tt.topic)), ".")...); err != nil {
+				t.Errorf("SafeSubject() = %s is not a valid subject: %v", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package messaging
+
+import (
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// puller is the part of jetstream.Consumer the subscribers use.
+type puller interface {
+	Consume(handler jetstream.MessageHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error)
+}
+
+// consumeN starts n pull subscribers on cons. The server hands each message
+// of the durable consumer to exactly one of them.
+func consumeN(cons puller, n int, handler func(msg jetstream.Msg)) (jetstream.ConsumeContext, error) {
+	n = max(n, 1)
+	group := &subscriberGroup{subs: make([]jetstream.ConsumeContext, 0, n), closed: make(chan struct{})}
+	for i := 0; i < n; i++ {
+		cc, err := cons.Consume(handler)
+		if err != nil {
+			group.Stop()
+			return nil, fmt.Errorf("consume failed (subscriber %d/%d): %w", i+1, n, err)
+		}
+		group.subs = append(group.subs, cc)
+	}
+	go group.wait()
+	return group, nil
+}
+
+// subscriberGroup controls several pull subscribers as one ConsumeContext.
+type subscriberGroup struct {
+	subs   []jetstream.ConsumeContext
+	closed chan struct{}
+}
+
+// Stop stops all subscribers, discarding buffered messages.
+func (g *subscriberGroup) Stop() {
+	for _, cc := range g.subs {
+		cc.Stop()
+	}
+}
+
+// Drain stops all subscribers after their buffered messages are handled.
+func (g *subscriberGroup) Drain() {
+	for _, cc := range g.subs {
+		cc.Drain()
+	}
+}
+
+// Closed is closed once every subscriber has stopped.
+func (g *subscriberGroup) Closed() <-chan struct{} {
+	return g.closed
+}
+
+func (g *subscriberGroup) wait() {
+	for _, cc := range g.subs {
+		<-cc.Closed()
+	}
+	close(g.closed)
+}
@@ -0,0 +1,125 @@
+package messaging
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// fakeMsg carries only a payload.
+type fakeMsg struct {
+	jetstream.Msg
+	data []byte
+}
+
+func (m *fakeMsg) Data() []byte { return m.data }
+
+// fakeConsumer mimics a durable pull consumer: every message of the
+// stream is delivered to exactly one of the subscribers pulling from it.
+type fakeConsumer struct {
+	msgs chan jetstream.Msg
+	fail int // Consume call (1-based) that fails, 0 for none
+	n    int
+}
+
+type fakeConsumeContext struct {
+	stop   chan struct{}
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (c *fakeConsumeContext) Stop()                   { c.once.Do(func() { close(c.stop) }) }
+func (c *fakeConsumeContext) Drain()                  { c.Stop() }
+func (c *fakeConsumeContext) Closed() <-chan struct{} { return c.closed }
+
+func (f *fakeConsumer) Consume(handler jetstream.MessageHandler, _ ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error) {
+	f.n++
+	if f.n == f.fail {
+		return nil, errors.New("consumer deleted")
+	}
+	cc := &fakeConsumeContext{stop: make(chan struct{}), closed: make(chan struct{})}
+	go func() {
+		defer close(cc.closed)
+		for {
+			select {
+			case <-cc.stop:
+				return
+			case msg := <-f.msgs:
+				handler(msg)
+			}
+		}
+	}()
+	return cc, nil
+}
+
+func TestConsumeNSharesLoad(t *testing.T) {
+	const subscribers, published = 4, 2000
+	cons := &fakeConsumer{msgs: make(chan jetstream.Msg)}
+
+	var (
+		mu        sync.Mutex
+		seen      = make(map[string]int)
+		active    int
+		maxActive int
+		done      sync.WaitGroup
+	)
+	done.Add(published)
+	cc, err := consumeN(cons, subscribers, func(msg jetstream.Msg) {
+		mu.Lock()
+		seen[string(msg.Data())]++
+		active++
+		maxActive = max(maxActive, active)
+		mu.Unlock()
+
+		time.Sleep(100 * time.Microsecond) // Simulated work
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		done.Done()
+	})
+	if err != nil {
+		t.Fatalf("consumeN() error = %v", err)
+	}
+	if cons.n != subscribers {
+		t.Errorf("Consume() called %d times, want %d", cons.n, subscribers)
+	}
+
+	for i := 0; i < published; i++ {
+		cons.msgs <- &fakeMsg{data: []byte(strconv.Itoa(i))}
+	}
+	done.Wait()
+	cc.Stop()
+
+	select {
+	case <-cc.Closed():
+	case <-time.After(time.Second):
+		t.Fatal("Closed() not closed after Stop()")
+	}
+
+	if len(seen) != published {
+		t.Errorf("processed %d distinct messages, want %d", len(seen), published)
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("message %s processed %d times, want 1", id, n)
+		}
+	}
+	if maxActive < 2 {
+		t.Errorf("max concurrent handlers = %d, want the load shared by several subscribers", maxActive)
+	}
+}
+
+func TestConsumeNFailure(t *testing.T) {
+	cons := &fakeConsumer{msgs: make(chan jetstream.Msg), fail: 3}
+	if _, err := consumeN(cons, 4, func(jetstream.Msg) {}); err == nil {
+		t.Fatal("consumeN() error = nil, want failure of subscriber 3")
+	}
+	if cons.n != 3 {
+		t.Errorf("Consume() called %d times, want 3 (stop at first failure)", cons.n)
+	}
+}
@@ -2,6 +2,7 @@ package messaging
 
 // Topic constants define the subject names for NATS JetStream.
 // Using constants avoids memory allocation for topic strings during runtime.
+// Build concrete subjects with Subject or SafeSubject rather than concatenation.
 const (
 	// EventsRaw is the topic for raw events coming from agents/ingest.
 	// Subject: events.raw.<severity>.<source>
@@ -19,9 +20,17 @@ const (
 	// Subject: system.logs.<service>.<level>
 	TopicSystemLogs = "system.logs.>"
 
+	// SensorTelemetry is the topic network sensors report their own health on.
+	// Subject: system.sensors.<sensor_name>
+	TopicSensorTelemetry = "system.sensors.>"
+
 	// Commands is the topic for sending commands to agents.
 	// Subject: commands.<agent_id>
 	TopicCommands = "commands.>"
+
+	// CommandResults is the topic agents report command outcomes on.
+	// Subject: results.<agent_id>
+	TopicCommandResults = "results.>"
 )
 
 // Stream names
@@ -38,4 +47,5 @@ const (
 	ConsumerCorrelation = "SGE_CORRELATION_ENGINE"
 	ConsumerArchival    = "SGE_ARCHIVAL_WORKER"
 	ConsumerSOAR        = "SGE_SOAR_EXECUTOR"
+	ConsumerSOARResults = "SGE_SOAR_RESULTS"
 )
@@ -30,6 +30,14 @@ const (
 	AlertStatusClosed        AlertStatus = "closed"
 )
 
+// Servislerin kendi ürettiği olay tipleri (Event.EventType).
+const (
+	EventTypeSystemLog       = "system.log"
+	EventTypeSensorTelemetry = "sensor.telemetry" // Sensörün kendi sağlık/istatistik olayı
+	EventTypeAssetDiscovered = "asset.discovered" // Trafikte ilk kez görülen iç ağ varlığı (IP/MAC)
+	EventTypeNetworkThreat   = "network.threat"   // Ağ sensörü dedektörünün ürettiği tehdit (port tarama, beaconing, ...)
+)
+
 // Event, sistemdeki tüm olayların temel veri yapısıdır.
 type Event struct {
 	ID          string                 `json:"id" db:"id"`
@@ -76,7 +84,49 @@ type Rule struct {
 
 // Asset, izlenen varlıkları temsil eder.
 type Asset struct {
-	ID        string `json:"id" db:"id"`
-	Name      string `json:"name" db:"name"`
-	IPAddress string `json:"ip_address" db:"ip_address"`
+	ID          string `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	IPAddress   string `json:"ip_address" db:"ip_address"`
+	Criticality string `json:"criticality" db:"criticality"` // low, normal, high, critical
+}
+
+// --- Active Response ---
+
+type ExecutionStatus string
+
+const (
+	ExecutionStatusRunning ExecutionStatus = "running" // Steps executing or awaiting agent results
+	ExecutionStatusSuccess ExecutionStatus = "success"
+	ExecutionStatusFailed  ExecutionStatus = "failed"
+)
+
+// Command, SOAR tarafından ajana gönderilen aksiyon talebidir.
+// Subject: commands.<agent_id>
+type Command struct {
+	ID       string                 `json:"id"`
+	Action   string                 `json:"action"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	AlertID  string                 `json:"alert_id,omitempty"`
+	IssuedAt time.Time              `json:"issued_at"`
+}
+
+// CommandResult, ajanın bir komutu çalıştırdıktan sonra gönderdiği sonuçtur.
+// Subject: results.<agent_id>
+type CommandResult struct {
+	CommandID string    `json:"command_id"`
+	AgentID   string    `json:"agent_id"`
+	Success   bool      `json:"success"`
+	Detail    string    `json:"detail,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PlaybookExecution, bir playbook çalıştırmasının durumunu temsil eder.
+type PlaybookExecution struct {
+	ID         string          `json:"id" db:"id"`
+	PlaybookID string          `json:"playbook_id" db:"playbook_id"`
+	AlertID    string          `json:"alert_id" db:"alert_id"`
+	Status     ExecutionStatus `json:"status" db:"status"`
+	Detail     string          `json:"detail" db:"detail"`
+	StartedAt  time.Time       `json:"started_at" db:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty" db:"finished_at"`
 }
@@ -0,0 +1,180 @@
+// Package settings loads typed service configuration from environment
+// variables and an optional .env style file, collecting every validation
+// error instead of silently falling back to defaults.
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// FileEnv names the variable pointing at an optional config file.
+const FileEnv = "SGE_CONFIG_FILE"
+
+// Address is a validated host:port pair.
+type Address struct {
+	Host string
+	Port int
+}
+
+func (a Address) String() string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+}
+
+// ParseAddress parses "host:port" (IPv6 as "[::1]:port"). A bare host gets
+// defaultPort; a defaultPort of 0 makes the port mandatory.
+func ParseAddress(s string, defaultPort int) (Address, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Address{}, errors.New("empty address")
+	}
+
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		// No port given, only allowed with a default
+		var addrErr *net.AddrError
+		if !errors.As(err, &addrErr) || addrErr.Err != "missing port in address" || defaultPort == 0 {
+			return Address{}, fmt.Errorf("invalid address %q: want host:port", s)
+		}
+		host, portStr = strings.Trim(s, "[]"), strconv.Itoa(defaultPort)
+	}
+	if host == "" {
+		return Address{}, fmt.Errorf("invalid address %q: missing host", s)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return Address{}, fmt.Errorf("invalid address %q: port must be 1-65535", s)
+	}
+	return Address{Host: host, Port: port}, nil
+}
+
+// Loader reads typed values. Environment variables take precedence over
+// the file. Invalid values are recorded and reported together by Err.
+type Loader struct {
+	file map[string]string
+	errs []error
+}
+
+// NewLoader creates a loader backed by the environment and, if path is
+// not empty, the KEY=VALUE file at path.
+func NewLoader(path string) (*Loader, error) {
+	l := &Loader{file: map[string]string{}}
+	if path == "" {
+		return l, nil
+	}
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	l.file = values
+	return l, nil
+}
+
+// FromEnv creates a loader using the file named by SGE_CONFIG_FILE, if set.
+func FromEnv() (*Loader, error) {
+	return NewLoader(os.Getenv(FileEnv))
+}
+
+func (l *Loader) lookup(key string) (string, bool) {
+	if val, ok := os.LookupEnv(key); ok {
+		return val, true
+	}
+	val, ok := l.file[key]
+	return val, ok
+}
+
+func (l *Loader) fail(key, format string, args ...interface{}) {
+	l.errs = append(l.errs, fmt.Errorf("%s: "+format, append([]interface{}{key}, args...)...))
+}
+
+// String returns the value of key or def when unset.
+func (l *Loader) String(key, def string) string {
+	if val, ok := l.lookup(key); ok {
+		return val
+	}
+	return def
+}
+
+// Required returns the value of key, recording an error if it is unset or empty.
+func (l *Loader) Required(key string) string {
+	val, ok := l.lookup(key)
+	if !ok || strings.TrimSpace(val) == "" {
+		l.fail(key, "required but not set")
+		return ""
+	}
+	return val
+}
+
+// Int returns key as an integer of at least min, or def when unset.
+func (l *Loader) Int(key string, def, min int) int {
+	val, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		l.fail(key, "%q is not a number", val)
+		return def
+	}
+	if i < min {
+		l.fail(key, "%d is below the minimum %d", i, min)
+		return def
+	}
+	return i
+}
+
+// Bool returns key as a boolean ("true", "1", "false", "0", ...), or def when unset.
+func (l *Loader) Bool(key string, def bool) bool {
+	val, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		l.fail(key, "%q is not a boolean", val)
+		return def
+	}
+	return b
+}
+
+// Duration returns key as a duration ("90s", "24h") of at least min, or def when unset.
+func (l *Loader) Duration(key string, def, min time.Duration) time.Duration {
+	val, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(val))
+	if err != nil {
+		l.fail(key, "%q is not a duration", val)
+		return def
+	}
+	if d < min {
+		l.fail(key, "%s is below the minimum %s", d, min)
+		return def
+	}
+	return d
+}
+
+// Address returns key (or def when unset) parsed as host:port, see ParseAddress.
+func (l *Loader) Address(key, def string, defaultPort int) Address {
+	val := l.String(key, def)
+	addr, err := ParseAddress(val, defaultPort)
+	if err != nil {
+		l.fail(key, "%v", err)
+	}
+	return addr
+}
+
+// Err returns all errors recorded so far, or nil.
+func (l *Loader) Err() error {
+	return errors.Join(l.errs...)
+}
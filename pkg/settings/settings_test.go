@@ -0,0 +1,128 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		defaultPort int
+		want        Address
+		wantErr     string
+	}{
+		{name: "Host And Port", in: "db.local:9000", want: Address{"db.local", 9000}},
+		{name: "Default Port", in: "db.local", defaultPort: 5432, want: Address{"db.local", 5432}},
+		{name: "IPv6", in: "[::1]:6379", want: Address{"::1", 6379}},
+		{name: "IPv6 Default Port", in: "[::1]", defaultPort: 6379, want: Address{"::1", 6379}},
+		{name: "Port Required", in: "db.local", wantErr: "want host:port"},
+		{name: "Non Numeric Port", in: "db.local:abc", wantErr: "port must be 1-65535"},
+		{name: "Port Out Of Range", in: "db.local:70000", wantErr: "port must be 1-65535"},
+		{name: "Missing Host", in: ":9000", wantErr: "missing host"},
+		{name: "Doubled Port", in: "localhost:9000:9000", defaultPort: 9000, wantErr: "want host:port"},
+		{name: "Empty", in: " ", defaultPort: 9000, wantErr: "empty address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAddress(tt.in, tt.defaultPort)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseAddress() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseAddress() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sge.env")
+	content := "SETTINGS_TEST_FILE_ONLY=from-file\nSETTINGS_TEST_OVERRIDE=from-file\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SETTINGS_TEST_OVERRIDE", "from-env")
+	t.Setenv("SETTINGS_TEST_INT", "42")
+	t.Setenv("SETTINGS_TEST_ADDR", "cache:7000")
+	t.Setenv("SETTINGS_TEST_DURATION", "6h")
+
+	l, err := NewLoader(path)
+	if err != nil {
+		t.Fatalf("NewLoader() error = %v", err)
+	}
+
+	if got := l.String("SETTINGS_TEST_FILE_ONLY", "def"); got != "from-file" {
+		t.Errorf("file value = %q, want from-file", got)
+	}
+	if got := l.String("SETTINGS_TEST_OVERRIDE", "def"); got != "from-env" {
+		t.Errorf("env override = %q, want from-env", got)
+	}
+	if got := l.String("SETTINGS_TEST_UNSET", "def"); got != "def" {
+		t.Errorf("default = %q, want def", got)
+	}
+	if got := l.Int("SETTINGS_TEST_INT", 1, 0); got != 42 {
+		t.Errorf("Int() = %d, want 42", got)
+	}
+	if got := l.Duration("SETTINGS_TEST_DURATION", time.Hour, time.Second); got != 6*time.Hour {
+		t.Errorf("Duration() = %s, want 6h", got)
+	}
+	if got := l.Address("SETTINGS_TEST_ADDR", "localhost:1", 0); got != (Address{"cache", 7000}) {
+		t.Errorf("Address() = %v, want cache:7000", got)
+	}
+	if err := l.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestLoaderCollectsErrors(t *testing.T) {
+	t.Setenv("SETTINGS_TEST_INT", "many")
+	t.Setenv("SETTINGS_TEST_SMALL", "0")
+	t.Setenv("SETTINGS_TEST_BOOL", "maybe")
+	t.Setenv("SETTINGS_TEST_ADDR", "cache:port")
+	t.Setenv("SETTINGS_TEST_EMPTY", "")
+	t.Setenv("SETTINGS_TEST_DURATION", "soon")
+
+	l, _ := NewLoader("")
+	if got := l.Int("SETTINGS_TEST_INT", 7, 0); got != 7 {
+		t.Errorf("Int() on invalid value = %d, want default 7", got)
+	}
+	l.Int("SETTINGS_TEST_SMALL", 5, 1)
+	l.Bool("SETTINGS_TEST_BOOL", false)
+	l.Address("SETTINGS_TEST_ADDR", "localhost:1", 0)
+	l.Required("SETTINGS_TEST_EMPTY")
+	l.Duration("SETTINGS_TEST_DURATION", time.Hour, 0)
+	l.Required("SETTINGS_TEST_MISSING")
+
+	err := l.Err()
+	if err == nil {
+		t.Fatal("Err() = nil, want errors")
+	}
+	for _, want := range []string{
+		`SETTINGS_TEST_INT: "many" is not a number`,
+		"SETTINGS_TEST_SMALL: 0 is below the minimum 1",
+		`SETTINGS_TEST_BOOL: "maybe" is not a boolean`,
+		"SETTINGS_TEST_ADDR: invalid address",
+		"SETTINGS_TEST_EMPTY: required but not set",
+		"SETTINGS_TEST_MISSING: required but not set",
+		`SETTINGS_TEST_DURATION: "soon" is not a duration`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Err() = %v, missing %q", err, want)
+		}
+	}
+}
+
+func TestNewLoaderMissingFile(t *testing.T) {
+	if _, err := NewLoader(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("NewLoader() with missing file error = nil, want error")
+	}
+}